@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminToken は管理用エンドポイントの認証に使用するトークン（空の場合は管理用エンドポイントを無効化）
+var adminToken string
+
+// SetAdminToken は管理用エンドポイントの認証トークンをセットします
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// AdminAuthMiddleware は管理用エンドポイントへのアクセスを Bearer トークンで保護するミドルウェア
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理用エンドポイントは無効です"})
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証に失敗しました"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CloseSessionsForTenant は指定したテナントのすべてのセッションを終了し、終了したセッション数を返します
+// 接続を閉じることで各セッションの受信ループが認識の停止とクリーンアップを行います
+func CloseSessionsForTenant(tenantID string) int {
+	if tenantID == "" {
+		return 0
+	}
+
+	activeSessionsMutex.RLock()
+	var sessions []*StreamingSession
+	for _, session := range activeSessions {
+		if session.TenantID == tenantID {
+			sessions = append(sessions, session)
+		}
+	}
+	activeSessionsMutex.RUnlock()
+
+	for _, session := range sessions {
+		log.Printf("Closing session for tenant: tenantID=%s, sessionID=%s", tenantID, session.ID)
+		if session.CancelFunc != nil {
+			session.CancelFunc()
+		}
+		if session.WSConnection != nil {
+			session.WSConnection.Close()
+		}
+	}
+	return len(sessions)
+}
+
+// CloseTenantSessionsHandler は指定したテナントのすべてのセッションを終了するハンドラー
+func CloseTenantSessionsHandler(c *gin.Context) {
+	tenantID := c.Param("tenantId")
+	closed := CloseSessionsForTenant(tenantID)
+	log.Printf("Closed %d sessions for tenant %s", closed, tenantID)
+	c.JSON(http.StatusOK, gin.H{"tenantId": tenantID, "closedSessions": closed})
+}
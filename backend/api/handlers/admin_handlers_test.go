@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestCloseSessionsForTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenant     string
+		wantClosed []string
+	}{
+		{name: "only the tenant's sessions are closed", tenant: "tenant-a", wantClosed: []string{"a-1", "a-2"}},
+		{name: "other tenant", tenant: "tenant-b", wantClosed: []string{"b-1"}},
+		{name: "unknown tenant", tenant: "tenant-x"},
+		{name: "empty tenant closes nothing", tenant: ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			server := newTestRouter(t)
+			setup := StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"}
+			clients := map[string]*websocket.Conn{}
+			for id, tenant := range map[string]string{"a-1": "tenant-a", "a-2": "tenant-a", "b-1": "tenant-b", "none": ""} {
+				// 前のサブテストのセッションと区別するため、セッションIDにはサブテストの番号を付ける
+				clients[id] = startStreamingSession(t, server, fmt.Sprintf("%s-%d?tenantId=%s", id, i, tenant), setup)
+				service.waitForConn(t)
+			}
+
+			if got := CloseSessionsForTenant(tt.tenant); got != len(tt.wantClosed) {
+				t.Errorf("CloseSessionsForTenant(%q) = %d, want %d", tt.tenant, got, len(tt.wantClosed))
+			}
+
+			closed := map[string]bool{}
+			for _, id := range tt.wantClosed {
+				closed[id] = true
+				clients[id].SetReadDeadline(time.Now().Add(5 * time.Second))
+				if _, _, err := clients[id].ReadMessage(); err == nil {
+					t.Errorf("session %s is still open", id)
+				}
+			}
+			waitForSessions(t, func(sessions map[string]*StreamingSession) bool {
+				for id := range clients {
+					if _, ok := sessions[fmt.Sprintf("%s-%d", id, i)]; ok == closed[id] {
+						return false
+					}
+				}
+				return true
+			})
+
+			// 他のテナントのセッションは動作を続ける
+			for id, client := range clients {
+				if closed[id] {
+					continue
+				}
+				if err := client.WriteJSON(map[string]string{"type": "init"}); err != nil {
+					t.Fatalf("session %s: %v", id, err)
+				}
+				if message := readMessage(t, client); message["type"] != "init_response" {
+					t.Errorf("session %s replied %v, want init_response", id, message)
+				}
+			}
+		})
+	}
+}
+
+// waitForSessions はアクティブなセッションが条件を満たすまで待ちます
+func waitForSessions(t *testing.T, condition func(map[string]*StreamingSession) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		activeSessionsMutex.RLock()
+		ok := condition(activeSessions)
+		activeSessionsMutex.RUnlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the active sessions")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "disabled without a token", token: "", authorization: "Bearer anything", wantStatus: http.StatusForbidden},
+		{name: "missing authorization", token: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := adminToken
+			SetAdminToken(tt.token)
+			t.Cleanup(func() { adminToken = previous })
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/admin/tenants/:tenantId/sessions/close", AdminAuthMiddleware(), CloseTenantSessionsHandler)
+			request := httptest.NewRequest(http.MethodPost, "/admin/tenants/tenant-a/sessions/close", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/google/uuid"
+)
+
+// streamingFallbackAfter はバッチ処理に切り替えるまでのストリーミング接続の連続失敗回数（0は無効）
+var streamingFallbackAfter int
+
+// SetStreamingFallbackAfter はストリーミング接続が指定回数続けて失敗した場合に
+// 音声をまとめて認識・翻訳するバッチ処理へ切り替えるよう設定します（0の場合は切り替えない）
+func SetStreamingFallbackAfter(failures int) {
+	if failures < 0 {
+		failures = 0
+	}
+	streamingFallbackAfter = failures
+}
+
+// recognizeShortAudio はバッチ処理で音声を認識する関数（テストで差し替え可能）
+var recognizeShortAudio = gospeech.RecognizeShortAudio
+
+// batchFallbackSegment はバッチ処理で1回に認識する音声の長さ
+const batchFallbackSegment = 10 * time.Second
+
+// batchFallbackQueueSize は処理待ちにできる音声区間の数（超えた場合は音声の受信を待たせる）
+const batchFallbackQueueSize = 8
+
+// batchFallback はストリーミング接続の失敗回数と、バッチ処理中の音声バッファを管理します
+type batchFallback struct {
+	mu           sync.Mutex
+	failures     int
+	active       bool
+	stopped      bool
+	buffer       []byte
+	segmentBytes int
+	// threshold はバッチ処理に切り替えるまでの連続失敗回数（0は無効）
+	threshold int
+
+	// 音声区間はキューに追加した順に1つのゴルーチンで処理し、結果が音声の順に送信されるようにする
+	process  func(audio []byte)
+	queue    chan []byte
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newBatchFallback は threshold 回の失敗でバッチ処理に切り替え、
+// bytesPerSecond の音声を batchFallbackSegment ごとに区切って process で処理するバッファを作成します
+func newBatchFallback(bytesPerSecond, threshold int, process func(audio []byte)) *batchFallback {
+	return &batchFallback{
+		segmentBytes: int(int64(bytesPerSecond) * int64(batchFallbackSegment) / int64(time.Second)),
+		threshold:    threshold,
+		process:      process,
+		queue:        make(chan []byte, batchFallbackQueueSize),
+		done:         make(chan struct{}),
+	}
+}
+
+// recordFailure は接続失敗を記録し、バッチ処理に切り替えた場合は true を返します
+// 切り替えた時点で音声区間を処理するゴルーチンを起動します
+func (f *batchFallback) recordFailure() (activated bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.threshold == 0 || f.active || f.stopped {
+		return false
+	}
+	f.failures++
+	if f.failures >= f.threshold {
+		f.active = true
+		go f.run()
+		return true
+	}
+	return false
+}
+
+// run はキューの音声区間を追加された順に処理します
+func (f *batchFallback) run() {
+	defer close(f.done)
+	for audio := range f.queue {
+		f.process(audio)
+	}
+}
+
+// enqueue は音声区間を処理のキューに追加します
+// enqueue と stop はクライアントからの受信ループ（同じゴルーチン）から呼び出します
+func (f *batchFallback) enqueue(audio []byte) {
+	if len(audio) == 0 {
+		return
+	}
+	f.queue <- audio
+}
+
+// stop はキューを閉じ、追加済みの音声区間の処理が終わるまで待ちます
+func (f *batchFallback) stop() {
+	f.mu.Lock()
+	f.stopped = true
+	started := f.active
+	f.mu.Unlock()
+	if !started {
+		return
+	}
+	f.stopOnce.Do(func() { close(f.queue) })
+	<-f.done
+}
+
+// isActive はバッチ処理中かどうかを返します
+func (f *batchFallback) isActive() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+// add は音声をバッファに追加し、1区間分たまった場合はその音声を返します
+func (f *batchFallback) add(data []byte) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buffer = append(f.buffer, data...)
+	if len(f.buffer) < f.segmentBytes {
+		return nil
+	}
+	segment := f.buffer
+	f.buffer = nil
+	return segment
+}
+
+// take はバッファに残っている音声を取り出します
+func (f *batchFallback) take() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	segment := f.buffer
+	f.buffer = nil
+	return segment
+}
+
+// translateBatchSegment は音声区間をREST APIで認識し、翻訳先言語ごとに翻訳して確定結果としてクライアントに送信します
+func translateBatchSegment(ctx context.Context, config *gospeech.SpeechTranslationConfig, format *gospeech.AudioStreamFormat,
+	sourceLanguage string, targetLanguages []string, normalize OutputNormalization, audio []byte, writer *sessionWriter, viewers *viewerSet) {
+	if len(audio) == 0 {
+		return
+	}
+	if translationProvider == nil {
+		log.Printf("Batch translation skipped: %v", errTranslationNotConfigured)
+		return
+	}
+
+	release, err := acquireUpstream(ctx)
+	if err != nil {
+		log.Printf("Batch recognition skipped: %v", err)
+		return
+	}
+	text, err := recognizeShortAudio(ctx, config.SpeechConfig, format, audio)
+	release()
+	if err != nil {
+		log.Printf("Batch recognition failed: %v", err)
+		return
+	}
+	if text == "" {
+		log.Printf("[DEBUG] No speech recognized in batch segment: %d bytes", len(audio))
+		return
+	}
+
+	// Translator はリージョンなしの言語コードを使用する
+	fromLanguage := strings.SplitN(sourceLanguage, "-", 2)[0]
+	items := make([]batchItem, len(targetLanguages))
+	for i, targetLanguage := range targetLanguages {
+		items[i] = batchItem{Text: text, SourceLanguage: fromLanguage, TargetLanguage: targetLanguage}
+	}
+	// 翻訳先言語ごとに並行して翻訳し、遅い言語が他の言語の送信を妨げないようにする
+	results, err := translateItems(ctx, translationProvider, items)
+	if err != nil {
+		log.Printf("Batch translation canceled: %v", err)
+		return
+	}
+
+	segmentID := uuid.New().String()
+	for i, targetLanguage := range targetLanguages {
+		output, err := results[i].Output, results[i].Err
+		if err != nil {
+			log.Printf("Batch translation failed: targetLanguage=%s, error=%v", targetLanguage, err)
+			continue
+		}
+
+		response := StreamingTranslationResponse{
+			SourceLanguage: sourceLanguage,
+			TargetLanguage: targetLanguage,
+			TranslatedText: normalize.apply(output.TranslatedText),
+			OriginalText:   text,
+			IsFinal:        true,
+			SegmentID:      segmentID,
+			Revision:       1,
+			Reason:         gospeech.ResultReasonTranslatedSpeech.String(),
+		}
+		log.Printf("Sending batch translation result: %+v", response)
+		writer.send(response)
+		viewers.publishFinal(response)
+	}
+}
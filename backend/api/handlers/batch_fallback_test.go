@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gorilla/websocket"
+)
+
+// useUnreachableSpeechService は WebSocket セッションの認識器が接続できない URL に接続するよう差し替えます
+func useUnreachableSpeechService(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http") + "/speech/universal/v2"
+	server.Close()
+
+	previousKey, previousRegion, previousConfig := speechSubscriptionKey, speechRegion, newTranslationConfig
+	SetSpeechCredentials("test-key", "japaneast")
+	newTranslationConfig = func() (*gospeech.SpeechTranslationConfig, error) {
+		return gospeech.SpeechTranslationConfigFromEndpoint(endpoint, "test-key")
+	}
+	t.Cleanup(func() {
+		SetSpeechCredentials(previousKey, previousRegion)
+		newTranslationConfig = previousConfig
+	})
+}
+
+// readUntilClosed はハンドラーが接続を閉じるまでに受信した JSON メッセージを返します
+func readUntilClosed(t *testing.T, client *websocket.Conn) []map[string]interface{} {
+	t.Helper()
+	var messages []map[string]interface{}
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var message map[string]interface{}
+		if err := client.ReadJSON(&message); err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Fatal("timed out waiting for the handler to close the connection")
+			}
+			return messages
+		}
+		messages = append(messages, message)
+	}
+}
+
+func TestWebSocketHandlerBatchFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		threshold     int
+		recognized    string
+		recognizeErr  error
+		wantFallback  bool
+		wantRecognize bool
+		wantResult    bool
+	}{
+		{name: "fallback yields a translation", threshold: 1, recognized: "こんにちは", wantFallback: true, wantRecognize: true, wantResult: true},
+		{name: "fallback after repeated failures", threshold: 3, recognized: "こんにちは", wantFallback: true, wantRecognize: true, wantResult: true},
+		{name: "fallback without speech", threshold: 1, recognized: "", wantFallback: true, wantRecognize: true},
+		{name: "fallback recognition error", threshold: 1, recognizeErr: errors.New("service unavailable"), wantFallback: true, wantRecognize: true},
+		{name: "fallback disabled", threshold: 0, recognized: "こんにちは"},
+	}
+
+	useUnreachableSpeechService(t)
+	useTranslationProvider(t, &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: "Hello"}})
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousThreshold, previousRecognize := streamingFallbackAfter, recognizeShortAudio
+			SetStreamingFallbackAfter(tt.threshold)
+			var recognizedBytes atomic.Int64
+			recognizeShortAudio = func(ctx context.Context, config *gospeech.SpeechConfig, format *gospeech.AudioStreamFormat, audio []byte) (string, error) {
+				recognizedBytes.Add(int64(len(audio)))
+				return tt.recognized, tt.recognizeErr
+			}
+			t.Cleanup(func() {
+				streamingFallbackAfter, recognizeShortAudio = previousThreshold, previousRecognize
+			})
+
+			client := startStreamingSession(t, server, fmt.Sprintf("fallback-%d", i), StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}})
+			if tt.wantFallback {
+				message := readMessage(t, client)
+				if message["type"] != "fallback" || message["mode"] != "batch" {
+					t.Fatalf("message = %v, want the batch fallback notice", message)
+				}
+			}
+
+			audio := make([]byte, 3200)
+			if err := client.WriteMessage(websocket.BinaryMessage, audio); err != nil {
+				t.Fatalf("failed to send audio: %v", err)
+			}
+			if err := client.WriteJSON(map[string]string{"type": "end"}); err != nil {
+				t.Fatalf("failed to send the end message: %v", err)
+			}
+
+			var results []map[string]interface{}
+			for _, message := range readUntilClosed(t, client) {
+				if _, ok := message["translatedText"]; ok {
+					results = append(results, message)
+				}
+			}
+
+			if got := recognizedBytes.Load(); tt.wantRecognize != (got > 0) || (tt.wantRecognize && got != int64(len(audio))) {
+				t.Errorf("recognized %d bytes, want %d (batch path %v)", got, len(audio), tt.wantRecognize)
+			}
+			if !tt.wantResult {
+				if len(results) != 0 {
+					t.Errorf("results = %v, want none", results)
+				}
+				return
+			}
+			if len(results) != 1 {
+				t.Fatalf("results = %v, want one batch result", results)
+			}
+			result := results[0]
+			if result["originalText"] != tt.recognized || result["translatedText"] != "Hello" || result["isFinal"] != true {
+				t.Errorf("result = %v, want the final translation of %q", result, tt.recognized)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerBatchFallbackOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments int // end の前に送る batchFallbackSegment 単位の音声の数
+	}{
+		{name: "one segment", segments: 1},
+		{name: "segments are processed in order", segments: 3},
+	}
+
+	useUnreachableSpeechService(t)
+	useTranslationProvider(t, &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: "Hello"}})
+	server := newTestRouter(t)
+	segmentBytes := gospeech.GetDefaultInputFormat().BytesPerSecond() * int(batchFallbackSegment/time.Second)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousThreshold, previousRecognize := streamingFallbackAfter, recognizeShortAudio
+			SetStreamingFallbackAfter(1)
+			var calls, running, maxRunning atomic.Int32
+			recognizeShortAudio = func(ctx context.Context, config *gospeech.SpeechConfig, format *gospeech.AudioStreamFormat, audio []byte) (string, error) {
+				n := calls.Add(1)
+				if r := running.Add(1); r > maxRunning.Load() {
+					maxRunning.Store(r)
+				}
+				defer running.Add(-1)
+				// 先の区間ほど認識に時間がかかっても、結果は音声の順に送信される
+				time.Sleep(time.Duration(tt.segments-int(n)+1) * 20 * time.Millisecond)
+				return fmt.Sprintf("segment %d", n), nil
+			}
+			t.Cleanup(func() {
+				streamingFallbackAfter, recognizeShortAudio = previousThreshold, previousRecognize
+			})
+
+			client := startStreamingSession(t, server, fmt.Sprintf("fallback-order-%d", i), StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}})
+			if message := readMessage(t, client); message["type"] != "fallback" {
+				t.Fatalf("message = %v, want the batch fallback notice", message)
+			}
+			for j := 0; j < tt.segments; j++ {
+				if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, segmentBytes)); err != nil {
+					t.Fatalf("failed to send audio: %v", err)
+				}
+			}
+			if err := client.WriteJSON(map[string]string{"type": "end"}); err != nil {
+				t.Fatalf("failed to send the end message: %v", err)
+			}
+
+			var got []string
+			for _, message := range readUntilClosed(t, client) {
+				if text, ok := message["originalText"].(string); ok {
+					got = append(got, text)
+				}
+			}
+			var want []string
+			for j := 1; j <= tt.segments; j++ {
+				want = append(want, fmt.Sprintf("segment %d", j))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("results = %v, want %v", got, want)
+			}
+			if got := maxRunning.Load(); got != 1 {
+				t.Errorf("%d segments were recognized at the same time, want 1", got)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerCancellation(t *testing.T) {
+	tests := []struct {
+		name        string
+		unreachable bool
+		// closeCode が 0 の場合はサービス側で接続を切断する
+		closeCode     int
+		wantErrorCode string
+	}{
+		{name: "unreachable service without fallback", unreachable: true, wantErrorCode: "ConnectionFailure"},
+		{name: "service rejects the session", closeCode: websocket.ClosePolicyViolation, wantErrorCode: "Forbidden"},
+		{name: "service error", closeCode: websocket.CloseInternalServerErr, wantErrorCode: "ServiceError"},
+		// 一時的な切断は認識器が再接続するため、クライアントにはエラーを通知しない
+		{name: "dropped connection reconnects"},
+	}
+
+	previousThreshold := streamingFallbackAfter
+	SetStreamingFallbackAfter(0)
+	t.Cleanup(func() { streamingFallbackAfter = previousThreshold })
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var service *fakeSpeechService
+			if tt.unreachable {
+				useUnreachableSpeechService(t)
+			} else {
+				service = newFakeSpeechService(t)
+				useFakeSpeechService(t, service)
+			}
+
+			client := startStreamingSession(t, server, fmt.Sprintf("canceled-%d", i), StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}})
+			if service != nil {
+				fc := service.waitForConn(t)
+				if tt.closeCode == 0 {
+					fc.conn.UnderlyingConn().Close()
+				} else {
+					fc.writeMu.Lock()
+					fc.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(tt.closeCode, "closing"))
+					fc.writeMu.Unlock()
+				}
+			}
+
+			if tt.wantErrorCode == "" {
+				next := service.waitForConn(t)
+				next.sendPhrase(t, "こんにちは", map[string]string{"en": "Hello"})
+				if message := readMessage(t, client); message["translatedText"] != "Hello" {
+					t.Fatalf("message = %v, want the translation from the new connection", message)
+				}
+				expectNoMessage(t, client, 200*time.Millisecond)
+				return
+			}
+			message := readMessage(t, client)
+			if message["type"] != "error" || message["errorCode"] != tt.wantErrorCode {
+				t.Fatalf("message = %v, want an error with code %s", message, tt.wantErrorCode)
+			}
+			if details, _ := message["error"].(string); details == "" {
+				t.Errorf("error message has no details: %v", message)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultBatchWorkers はバッチ翻訳で同時に翻訳する項目数の既定値
+const defaultBatchWorkers = 4
+
+// batchWorkers はバッチ翻訳で同時に翻訳する項目数
+var batchWorkers = defaultBatchWorkers
+
+// batchItemTimeout はバッチ翻訳の1項目あたりの期限（0は無制限）
+var batchItemTimeout time.Duration
+
+// SetBatchTranslation はバッチ翻訳で同時に翻訳する項目数と1項目あたりの期限をセットします
+// 期限を超えた項目はエラーとして扱い、他の項目の完了を妨げないようにします
+// （workers が0の場合は既定値、timeout が0の場合は無制限）
+func SetBatchTranslation(workers int, timeout time.Duration) {
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	if timeout < 0 {
+		timeout = 0
+	}
+	batchWorkers = workers
+	batchItemTimeout = timeout
+}
+
+// batchItem はバッチ翻訳の1項目
+type batchItem struct {
+	Text           string
+	SourceLanguage string
+	TargetLanguage string
+}
+
+// batchItemResult はバッチ翻訳の1項目の結果（Err が nil でない場合は Output は nil）
+type batchItemResult struct {
+	Output *TranslationOutput
+	Err    error
+}
+
+// translateItems は項目を batchWorkers 個のゴルーチンで翻訳し、入力と同じ順序で項目ごとの結果を返します
+// ctx がキャンセルされた場合は項目ごとの結果ではなく ctx のエラーを返します
+func translateItems(ctx context.Context, provider TranslationProvider, items []batchItem) ([]batchItemResult, error) {
+	results := make([]batchItemResult, len(items))
+	workers := batchWorkers
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = translateItem(ctx, provider, items[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range items {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// translateItem は1項目を期限付きで翻訳します（上流サービスの呼び出し枠の待機時間も期限に含みます）
+func translateItem(ctx context.Context, provider TranslationProvider, item batchItem) batchItemResult {
+	if batchItemTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, batchItemTimeout)
+		defer cancel()
+	}
+
+	release, err := acquireUpstream(ctx)
+	if err != nil {
+		return batchItemResult{Err: err}
+	}
+	defer release()
+
+	output, err := provider.Translate(ctx, item.Text, item.SourceLanguage, item.TargetLanguage)
+	if err != nil {
+		return batchItemResult{Err: err}
+	}
+	return batchItemResult{Output: output}
+}
+
+// translateAll は全項目を BatchTranslationProvider の1回のリクエストで翻訳します
+// 項目はまとめて翻訳されるため、1項目あたりの期限をリクエスト全体に適用します
+func translateAll(ctx context.Context, provider BatchTranslationProvider, texts []string, sourceLanguage, targetLanguage string) ([]*TranslationOutput, error) {
+	if batchItemTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, batchItemTimeout)
+		defer cancel()
+	}
+
+	release, err := acquireUpstream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	outputs, err := provider.TranslateBatch(ctx, texts, sourceLanguage, targetLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) != len(texts) {
+		return nil, errNoTranslationResult
+	}
+	return outputs, nil
+}
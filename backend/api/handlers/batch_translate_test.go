@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// delayedTranslationProvider は翻訳先言語ごとに指定した時間だけ待ってから結果を返すテスト用の翻訳プロバイダー
+type delayedTranslationProvider struct {
+	counter concurrencyCounter
+	delays  map[string]time.Duration
+	errs    map[string]error
+}
+
+func (p *delayedTranslationProvider) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error) {
+	p.counter.enter()
+	defer p.counter.leave()
+	select {
+	case <-time.After(p.delays[targetLanguage]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err := p.errs[targetLanguage]; err != nil {
+		return nil, err
+	}
+	return &TranslationOutput{TranslatedText: targetLanguage + ":" + text}, nil
+}
+
+func (p *delayedTranslationProvider) DetectLanguage(ctx context.Context, text string) (string, float64, error) {
+	return "ja", 1, nil
+}
+
+// useBatchTranslation はテストの間だけバッチ翻訳の設定を差し替えます
+func useBatchTranslation(t *testing.T, workers int, timeout time.Duration) {
+	t.Helper()
+	previousWorkers, previousTimeout := batchWorkers, batchItemTimeout
+	SetBatchTranslation(workers, timeout)
+	t.Cleanup(func() { batchWorkers, batchItemTimeout = previousWorkers, previousTimeout })
+}
+
+func TestTranslateItems(t *testing.T) {
+	errUnsupported := errors.New("unsupported language")
+	tests := []struct {
+		name        string
+		workers     int
+		timeout     time.Duration
+		targets     []string
+		delays      map[string]time.Duration
+		errs        map[string]error
+		cancelAfter time.Duration
+		wantErr     error
+		wantItems   []error // 項目ごとの期待するエラー（nil は成功）
+		wantMax     int32
+	}{
+		{
+			name: "all items complete", workers: 4, targets: []string{"en", "fr", "de"},
+			wantItems: []error{nil, nil, nil},
+		},
+		{
+			name: "slow item exceeds its deadline while the others complete", workers: 4, timeout: 50 * time.Millisecond,
+			targets: []string{"en", "fr", "de"}, delays: map[string]time.Duration{"fr": time.Second},
+			wantItems: []error{nil, context.DeadlineExceeded, nil},
+		},
+		{
+			name: "failed item is reported on its own", workers: 4, targets: []string{"en", "xx"},
+			errs:      map[string]error{"xx": errUnsupported},
+			wantItems: []error{nil, errUnsupported},
+		},
+		{
+			name: "workers bound the concurrency", workers: 2, targets: []string{"en", "fr", "de", "es", "it", "ko"},
+			delays:    map[string]time.Duration{"en": 20 * time.Millisecond, "fr": 20 * time.Millisecond, "de": 20 * time.Millisecond, "es": 20 * time.Millisecond, "it": 20 * time.Millisecond, "ko": 20 * time.Millisecond},
+			wantItems: []error{nil, nil, nil, nil, nil, nil}, wantMax: 2,
+		},
+		{
+			name: "canceled batch", workers: 1, targets: []string{"en", "fr", "de"},
+			delays:      map[string]time.Duration{"en": time.Second},
+			cancelAfter: 50 * time.Millisecond, wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useBatchTranslation(t, tt.workers, tt.timeout)
+			provider := &delayedTranslationProvider{delays: tt.delays, errs: tt.errs}
+			items := make([]batchItem, len(tt.targets))
+			for i, target := range tt.targets {
+				items[i] = batchItem{Text: "こんにちは", SourceLanguage: "ja", TargetLanguage: target}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAfter > 0 {
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+
+			start := time.Now()
+			results, err := translateItems(ctx, provider, items)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+					t.Errorf("canceled batch returned after %v", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("translateItems: %v", err)
+			}
+			if len(results) != len(tt.wantItems) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantItems))
+			}
+			for i, want := range tt.wantItems {
+				result := results[i]
+				if want != nil {
+					if !errors.Is(result.Err, want) || result.Output != nil {
+						t.Errorf("item %d = (%v, %v), want error %v", i, result.Output, result.Err, want)
+					}
+					continue
+				}
+				if result.Err != nil || result.Output == nil || result.Output.TranslatedText != tt.targets[i]+":こんにちは" {
+					t.Errorf("item %d = (%v, %v), want the translation into %s", i, result.Output, result.Err, tt.targets[i])
+				}
+			}
+			if tt.wantMax > 0 && provider.counter.max.Load() != tt.wantMax {
+				t.Errorf("max concurrent translations = %d, want %d", provider.counter.max.Load(), tt.wantMax)
+			}
+		})
+	}
+}
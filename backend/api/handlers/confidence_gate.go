@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultConfidenceKey は言語別の設定がない翻訳先言語に適用するしきい値のキー
+const defaultConfidenceKey = "*"
+
+// minFinalConfidence は翻訳先言語ごとの確定結果の最小信頼度（キーは小文字の言語コード、"*" は既定値）
+var minFinalConfidence map[string]float64
+
+// flagLowConfidence は最小信頼度に満たない確定結果を破棄せず、lowConfidence を付けて送信するかどうか
+var flagLowConfidence bool
+
+// SetMinFinalConfidence は翻訳先言語ごとの確定結果の最小信頼度をセットします
+// spec は "ja=0.6,en=0.5,*=0.3" の形式で、"*" は指定のない言語に適用します（空の場合は無効）
+// 言語ごとに認識の精度が異なるため、1つのしきい値ではなく言語別に指定できるようにしています
+func SetMinFinalConfidence(spec string) error {
+	thresholds := make(map[string]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		lang, value, ok := strings.Cut(entry, "=")
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if !ok || lang == "" {
+			return fmt.Errorf("invalid confidence threshold %q: expected language=value", entry)
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return fmt.Errorf("invalid confidence threshold for %s: %q must be between 0 and 1", lang, value)
+		}
+		thresholds[lang] = threshold
+	}
+	if len(thresholds) == 0 {
+		thresholds = nil
+	}
+	minFinalConfidence = thresholds
+	return nil
+}
+
+// SetFlagLowConfidence は最小信頼度に満たない確定結果を破棄する代わりに lowConfidence を付けて送信するようセットします
+func SetFlagLowConfidence(flag bool) {
+	flagLowConfidence = flag
+}
+
+// confidenceThreshold は翻訳先言語の最小信頼度を返します
+// 言語コードが一致しない場合は地域を除いた言語（zh-Hans なら zh）、次に既定値を使用します
+func confidenceThreshold(targetLanguage string) (float64, bool) {
+	lang := strings.ToLower(targetLanguage)
+	if threshold, ok := minFinalConfidence[lang]; ok {
+		return threshold, true
+	}
+	if base, _, found := strings.Cut(lang, "-"); found {
+		if threshold, ok := minFinalConfidence[base]; ok {
+			return threshold, true
+		}
+	}
+	threshold, ok := minFinalConfidence[defaultConfidenceKey]
+	return threshold, ok
+}
+
+// belowMinConfidence は確定結果の信頼度が翻訳先言語の最小信頼度に満たないかどうかを返します
+// 翻訳先言語の信頼度がない場合（認識テキストをそのまま返す場合など）は認識の信頼度を使用し、
+// どちらも返されなかった場合は判定できないため、満たないとはみなしません
+func belowMinConfidence(targetLanguage string, confidences map[string]float64, recognitionConfidence float64) bool {
+	threshold, ok := confidenceThreshold(targetLanguage)
+	if !ok {
+		return false
+	}
+	confidence, ok := confidences[targetLanguage]
+	if !ok {
+		if recognitionConfidence <= 0 {
+			return false
+		}
+		confidence = recognitionConfidence
+	}
+	return confidence < threshold
+}
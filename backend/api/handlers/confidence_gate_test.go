@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSetMinFinalConfidence(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{name: "empty disables the gate", spec: "", want: nil},
+		{name: "only separators", spec: " , ,", want: nil},
+		{name: "single language", spec: "ja=0.6", want: map[string]float64{"ja": 0.6}},
+		{name: "languages and default", spec: "ja=0.6, EN = 0.5 ,*=0.3", want: map[string]float64{"ja": 0.6, "en": 0.5, "*": 0.3}},
+		{name: "bounds are inclusive", spec: "ja=0,en=1", want: map[string]float64{"ja": 0, "en": 1}},
+		{name: "missing value", spec: "ja", wantErr: true},
+		{name: "missing language", spec: "=0.5", wantErr: true},
+		{name: "not a number", spec: "ja=high", wantErr: true},
+		{name: "above 1", spec: "ja=1.5", wantErr: true},
+		{name: "below 0", spec: "ja=-0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { minFinalConfidence = nil })
+			minFinalConfidence = map[string]float64{"previous": 0.9}
+
+			err := SetMinFinalConfidence(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SetMinFinalConfidence(%q) succeeded, want an error", tt.spec)
+				}
+				// 不正な値の場合は以前の設定を変更しない
+				if _, ok := minFinalConfidence["previous"]; !ok {
+					t.Errorf("previous thresholds were replaced after an error: %v", minFinalConfidence)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetMinFinalConfidence(%q): %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(minFinalConfidence, tt.want) {
+				t.Errorf("thresholds = %v, want %v", minFinalConfidence, tt.want)
+			}
+		})
+	}
+}
+
+func TestBelowMinConfidence(t *testing.T) {
+	tests := []struct {
+		name                  string
+		spec                  string
+		targetLanguage        string
+		confidences           map[string]float64
+		recognitionConfidence float64
+		want                  bool
+	}{
+		{name: "gate disabled", spec: "", targetLanguage: "en", confidences: map[string]float64{"en": 0.1}, want: false},
+		{name: "above the language threshold", spec: "en=0.5", targetLanguage: "en", confidences: map[string]float64{"en": 0.7}, want: false},
+		{name: "equal to the threshold", spec: "en=0.5", targetLanguage: "en", confidences: map[string]float64{"en": 0.5}, want: false},
+		{name: "below the language threshold", spec: "en=0.5", targetLanguage: "en", confidences: map[string]float64{"en": 0.4}, want: true},
+		{name: "language without a threshold", spec: "ja=0.9", targetLanguage: "en", confidences: map[string]float64{"en": 0.1}, want: false},
+		{name: "default threshold", spec: "ja=0.9,*=0.5", targetLanguage: "en", confidences: map[string]float64{"en": 0.4}, want: true},
+		{name: "language threshold wins over default", spec: "en=0.3,*=0.9", targetLanguage: "en", confidences: map[string]float64{"en": 0.4}, want: false},
+		{name: "base language threshold", spec: "zh=0.5", targetLanguage: "zh-Hans", confidences: map[string]float64{"zh-Hans": 0.4}, want: true},
+		{name: "case insensitive language", spec: "zh-hans=0.5", targetLanguage: "zh-Hans", confidences: map[string]float64{"zh-Hans": 0.4}, want: true},
+		{name: "falls back to recognition confidence", spec: "ja=0.5", targetLanguage: "ja", recognitionConfidence: 0.3, want: true},
+		{name: "recognition confidence above threshold", spec: "ja=0.5", targetLanguage: "ja", recognitionConfidence: 0.8, want: false},
+		{name: "no confidence at all", spec: "ja=0.5", targetLanguage: "ja", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { minFinalConfidence = nil })
+			if err := SetMinFinalConfidence(tt.spec); err != nil {
+				t.Fatalf("SetMinFinalConfidence(%q): %v", tt.spec, err)
+			}
+
+			got := belowMinConfidence(tt.targetLanguage, tt.confidences, tt.recognitionConfidence)
+			if got != tt.want {
+				t.Errorf("belowMinConfidence(%q, %v, %v) = %v, want %v",
+					tt.targetLanguage, tt.confidences, tt.recognitionConfidence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerConfidenceGate(t *testing.T) {
+	// en の信頼度は 0.8、de の信頼度は 0.7
+	phrase := `{"type":"final","NBest":[{"Display":"こんにちは","Confidence":0.9}],"Translations":{"en":{"Text":"Hello","Confidence":0.8},"de":{"Text":"Hallo","Confidence":0.7}}}`
+	// 判定対象の確定結果の後に、どの言語でも必ず送信される確定結果を送る
+	sentinel := `{"type":"final","NBest":[{"Display":"以上","Confidence":1}],"Translations":{"en":{"Text":"end","Confidence":1},"de":{"Text":"Ende","Confidence":1}}}`
+
+	tests := []struct {
+		name string
+		spec string
+		flag bool
+		want []string // 送信された確定結果（低信頼度の場合は "(low)" 付き）
+	}{
+		{name: "gate disabled", want: []string{"en:Hello", "de:Hallo"}},
+		{name: "both languages below their thresholds", spec: "en=0.85,de=0.75", want: nil},
+		{name: "only one language is dropped", spec: "en=0.75,de=0.75", want: []string{"en:Hello"}},
+		{name: "thresholds differ per language", spec: "en=0.9,de=0.6", want: []string{"de:Hallo"}},
+		{name: "language threshold wins over the default", spec: "*=0.75,de=0.5", want: []string{"en:Hello", "de:Hallo"}},
+		{name: "flagged instead of dropped", spec: "en=0.9", flag: true, want: []string{"en:Hello(low)", "de:Hallo"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousGate, previousFlag := minFinalConfidence, flagLowConfidence
+			t.Cleanup(func() { minFinalConfidence, flagLowConfidence = previousGate, previousFlag })
+			if err := SetMinFinalConfidence(tt.spec); err != nil {
+				t.Fatalf("SetMinFinalConfidence(%q): %v", tt.spec, err)
+			}
+			SetFlagLowConfidence(tt.flag)
+
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), fmt.Sprintf("confidence-gate-%d", i), StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en", "de"}, AudioFormat: "pcm",
+			})
+			fc := service.waitForConn(t)
+			fc.send(t, "speech.phrase", phrase)
+			fc.send(t, "speech.phrase", sentinel)
+
+			var got []string
+			for sentinels := 0; sentinels < 2; {
+				final := readFinal(t, client)
+				if final["originalText"] == "以上" {
+					sentinels++
+					continue
+				}
+				result := fmt.Sprintf("%s:%s", final["targetLanguage"], final["translatedText"])
+				if final["lowConfidence"] == true {
+					result += "(low)"
+				}
+				got = append(got, result)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("finals = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
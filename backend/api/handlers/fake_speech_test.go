@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// fakeSpeechService は Speech Service の代わりに認識結果を返すテスト用のサーバー
+type fakeSpeechService struct {
+	server   *httptest.Server
+	accepted chan *fakeSpeechConn
+	// holdTurnEnd が true の間は終端マーカーを受け取ってもターンを終了しない
+	holdTurnEnd atomic.Bool
+}
+
+// fakeSpeechConn は認識器からの1つの接続
+type fakeSpeechConn struct {
+	conn   *websocket.Conn
+	header http.Header
+	query  url.Values
+
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	messages []fakeSpeechMessage
+}
+
+type fakeSpeechMessage struct {
+	messageType int
+	data        []byte
+}
+
+func newFakeSpeechService(t *testing.T) *fakeSpeechService {
+	t.Helper()
+	service := &fakeSpeechService{accepted: make(chan *fakeSpeechConn, 10)}
+	upgrader := websocket.Upgrader{}
+	service.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fc := &fakeSpeechConn{conn: conn, header: req.Header.Clone(), query: req.URL.Query()}
+		service.accepted <- fc
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			fc.mu.Lock()
+			fc.messages = append(fc.messages, fakeSpeechMessage{messageType: messageType, data: message})
+			fc.mu.Unlock()
+			// 実際のサービスと同様に、音声の終端を受け取ったらターンを終了する
+			if messageType == websocket.BinaryMessage && len(message) == 0 && !service.holdTurnEnd.Load() {
+				fc.writeMu.Lock()
+				conn.WriteMessage(websocket.TextMessage, []byte("Path: turn.end\r\nX-RequestId: test\r\nContent-Type: application/json\r\n\r\n{}"))
+				fc.writeMu.Unlock()
+			}
+		}
+	}))
+	t.Cleanup(service.server.Close)
+	return service
+}
+
+// url は認識器の接続先となる WebSocket の URL を返します
+func (s *fakeSpeechService) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/speech/universal/v2"
+}
+
+// waitForConn は認識器からの次の接続を待ちます
+func (s *fakeSpeechService) waitForConn(t *testing.T) *fakeSpeechConn {
+	t.Helper()
+	select {
+	case fc := <-s.accepted:
+		return fc
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the recognizer to connect")
+		return nil
+	}
+}
+
+// send は指定したパスと JSON ボディのメッセージを認識器に送ります
+func (c *fakeSpeechConn) send(t *testing.T, path, body string) {
+	t.Helper()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	frame := fmt.Sprintf("Path: %s\r\nX-RequestId: test\r\nContent-Type: application/json\r\n\r\n%s", path, body)
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatalf("failed to send %s: %v", path, err)
+	}
+}
+
+// sendPhrase は確定した認識結果と翻訳を認識器に送ります
+func (c *fakeSpeechConn) sendPhrase(t *testing.T, text string, translations map[string]string) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"type":         "final",
+		"NBest":        []map[string]interface{}{{"Display": text}},
+		"Translations": translations,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.send(t, "speech.phrase", string(body))
+}
+
+// sendHypothesis は途中の認識結果と翻訳を認識器に送ります
+func (c *fakeSpeechConn) sendHypothesis(t *testing.T, text string, translations map[string]string) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"Text":         text,
+		"Translations": translations,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.send(t, "translation.hypothesis", string(body))
+}
+
+// waitForText は指定したパスのテキストメッセージが届くまで待ち、そのボディを返します
+func (c *fakeSpeechConn) waitForText(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, m := range c.messages {
+			if m.messageType != websocket.TextMessage {
+				continue
+			}
+			header, body, _ := strings.Cut(string(m.data), "\r\n\r\n")
+			if strings.HasPrefix(header, "Path: "+path+"\r\n") {
+				c.mu.Unlock()
+				return body
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for a %s message", path)
+	return ""
+}
+
+// useFakeSpeechService は WebSocket セッションの認識器が fake に接続するよう差し替えます
+func useFakeSpeechService(t *testing.T, service *fakeSpeechService) {
+	t.Helper()
+	previousKey, previousRegion, previousConfig := speechSubscriptionKey, speechRegion, newTranslationConfig
+	SetSpeechCredentials("test-key", "japaneast")
+	newTranslationConfig = func() (*gospeech.SpeechTranslationConfig, error) {
+		return gospeech.SpeechTranslationConfigFromEndpoint(service.url(), "test-key")
+	}
+	t.Cleanup(func() {
+		SetSpeechCredentials(previousKey, previousRegion)
+		newTranslationConfig = previousConfig
+	})
+}
+
+// newTestRouter はストリーミング用のルートを登録したテストサーバーを起動します
+func newTestRouter(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws/:sessionId", WebSocketHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// startStreamingSession は WebSocket セッションを開始し、準備完了の通知まで読み進めます
+func startStreamingSession(t *testing.T, server *httptest.Server, sessionID string, setup interface{}) *websocket.Conn {
+	t.Helper()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID
+	client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to connect to the streaming handler: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.WriteJSON(setup); err != nil {
+		t.Fatalf("failed to send the setup message: %v", err)
+	}
+	ready := readMessage(t, client)
+	if ready["status"] != "ready" {
+		t.Fatalf("first message = %v, want the ready status", ready)
+	}
+	return client
+}
+
+// readMessage はクライアントが受信する次の JSON メッセージを読みます
+func readMessage(t *testing.T, client *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message map[string]interface{}
+	if err := client.ReadJSON(&message); err != nil {
+		t.Fatalf("failed to read from the streaming handler: %v", err)
+	}
+	return message
+}
+
+// expectNoMessage はしばらくの間クライアントにメッセージが届かないことを確認します
+func expectNoMessage(t *testing.T, client *websocket.Conn, wait time.Duration) {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(wait))
+	var message map[string]interface{}
+	if err := client.ReadJSON(&message); err == nil {
+		t.Fatalf("unexpected message %v", message)
+	}
+}
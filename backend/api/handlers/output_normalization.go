@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// OutputNormalization は翻訳結果の整形方法を指定します（すべて省略時は無効）
+type OutputNormalization struct {
+	Trim               bool `json:"trim"`               // 前後の空白を除去
+	CollapseWhitespace bool `json:"collapseWhitespace"` // 連続する空白を1つの空白にまとめる
+	SentenceCase       bool `json:"sentenceCase"`       // 先頭の文字を大文字にする
+}
+
+// apply は指定された整形を text に適用します
+func (n OutputNormalization) apply(text string) string {
+	if n.CollapseWhitespace && text != "" {
+		// 前後の空白は Trim の指定に従って残す
+		leading := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+		trailing := len(text) - len(strings.TrimRightFunc(text, unicode.IsSpace))
+		if leading == len(text) {
+			text = " "
+		} else {
+			prefix, suffix := "", ""
+			if leading > 0 {
+				prefix = " "
+			}
+			if trailing > 0 {
+				suffix = " "
+			}
+			text = prefix + strings.Join(strings.Fields(text), " ") + suffix
+		}
+	}
+	if n.Trim {
+		text = strings.TrimSpace(text)
+	}
+	if n.SentenceCase {
+		text = sentenceCase(text)
+	}
+	return text
+}
+
+// sentenceCase は最初の文字（空白以外）を大文字にします
+func sentenceCase(text string) string {
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		upper := unicode.ToUpper(r)
+		if upper == r {
+			return text
+		}
+		return text[:i] + string(upper) + text[i+utf8.RuneLen(r):]
+	}
+	return text
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOutputNormalization(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize OutputNormalization
+		text      string
+		want      string
+	}{
+		{name: "off by default", text: "  hello   world  ", want: "  hello   world  "},
+		{name: "trim", normalize: OutputNormalization{Trim: true}, text: " \thello   world \n", want: "hello   world"},
+		{name: "collapse whitespace", normalize: OutputNormalization{CollapseWhitespace: true}, text: "  hello \t\n world  ", want: " hello world "},
+		{name: "collapse whitespace only", normalize: OutputNormalization{CollapseWhitespace: true}, text: " \t ", want: " "},
+		{name: "sentence case", normalize: OutputNormalization{SentenceCase: true}, text: "hello World", want: "Hello World"},
+		{name: "sentence case after leading space", normalize: OutputNormalization{SentenceCase: true}, text: "  élan", want: "  Élan"},
+		{name: "sentence case without cased letters", normalize: OutputNormalization{SentenceCase: true}, text: "こんにちは", want: "こんにちは"},
+		{name: "all", normalize: OutputNormalization{Trim: true, CollapseWhitespace: true, SentenceCase: true}, text: "  hello   world  ", want: "Hello world"},
+		{name: "empty", normalize: OutputNormalization{Trim: true, CollapseWhitespace: true, SentenceCase: true}, text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.normalize.apply(tt.text); got != tt.want {
+				t.Errorf("apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputNormalizationInTranslationPaths(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalize  OutputNormalization
+		translated string
+		want       string
+	}{
+		{name: "off by default", translated: " hello  world ", want: " hello  world "},
+		{name: "trim and collapse", normalize: OutputNormalization{Trim: true, CollapseWhitespace: true}, translated: " hello  world ", want: "hello world"},
+		{name: "sentence case", normalize: OutputNormalization{SentenceCase: true}, translated: "hello world", want: "Hello world"},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name+"/rest", func(t *testing.T) {
+			useTranslationProvider(t, &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: tt.translated}})
+			recorder := performJSON(t, TranslateHandler, http.MethodPost, TranslationRequest{
+				Text: "こんにちは", TargetLanguage: "en", SourceLanguage: "ja", Normalize: tt.normalize,
+			})
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+			}
+			var got TranslationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if got.TranslatedText != tt.want {
+				t.Errorf("translatedText = %q, want %q", got.TranslatedText, tt.want)
+			}
+		})
+
+		t.Run(tt.name+"/streaming", func(t *testing.T) {
+			client := startStreamingSession(t, server, fmt.Sprintf("normalize-%d", i), StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm", Normalize: tt.normalize,
+			})
+			service.waitForConn(t).sendPhrase(t, "こんにちは", map[string]string{"en": tt.translated})
+
+			// 途中結果と確定結果の両方に整形が適用される（確定結果の直前の途中結果は置き換えられることがある）
+			for {
+				message := readMessage(t, client)
+				if message["translatedText"] != tt.want {
+					t.Errorf("translatedText = %q (final %v), want %q", message["translatedText"], message["isFinal"], tt.want)
+				}
+				if message["isFinal"] == true {
+					break
+				}
+			}
+		})
+	}
+}
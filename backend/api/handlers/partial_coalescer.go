@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// subtitleCoalesceWindow は字幕表示向けに途中経過をまとめて送信する間隔（0は無効）
+var subtitleCoalesceWindow time.Duration
+
+// SetSubtitleCoalesceWindow は途中経過をまとめて送信する間隔をセットします
+// 間隔内に届いた途中経過は翻訳先言語ごとに最新のものだけが、間隔ごとに一定のリズムで送信されます
+func SetSubtitleCoalesceWindow(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	subtitleCoalesceWindow = d
+}
+
+// partialCoalescer は途中経過を一定間隔でまとめ、各間隔で翻訳先言語ごとの最新の値を送信します
+// 頻繁に変わる途中経過による字幕のちらつきを抑えるためのもので、送信レートの制限とは独立しています
+type partialCoalescer struct {
+	window time.Duration
+	emit   func(StreamingTranslationResponse)
+
+	mu      sync.Mutex
+	pending map[string]StreamingTranslationResponse
+	order   []string // 途中経過が届いた順の言語
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPartialCoalescer は送信用ゴルーチンを開始します（window が0の場合は途中経過をそのまま送信します）
+func newPartialCoalescer(window time.Duration, emit func(StreamingTranslationResponse)) *partialCoalescer {
+	c := &partialCoalescer{
+		window:  window,
+		emit:    emit,
+		pending: make(map[string]StreamingTranslationResponse),
+		done:    make(chan struct{}),
+	}
+	if window > 0 {
+		go c.run()
+	}
+	return c
+}
+
+// add は途中経過を次の送信まで保持します（同じ言語の未送信の途中経過は置き換えます）
+func (c *partialCoalescer) add(response StreamingTranslationResponse) {
+	if c.window <= 0 {
+		c.emit(response)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.pending[response.TargetLanguage]; !exists {
+		c.order = append(c.order, response.TargetLanguage)
+	}
+	c.pending[response.TargetLanguage] = response
+}
+
+// discard は未送信の途中経過を破棄します（確定結果の後に古い途中経過が届かないようにするため）
+func (c *partialCoalescer) discard() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = make(map[string]StreamingTranslationResponse)
+	c.order = nil
+}
+
+// stop は送信用ゴルーチンを終了します（未送信の途中経過は破棄します）
+func (c *partialCoalescer) stop() {
+	c.stopOnce.Do(func() { close(c.done) })
+}
+
+func (c *partialCoalescer) run() {
+	ticker := time.NewTicker(c.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.flush()
+		}
+	}
+}
+
+// flush は保持している途中経過を届いた順に送信します
+func (c *partialCoalescer) flush() {
+	c.mu.Lock()
+	responses := make([]StreamingTranslationResponse, 0, len(c.order))
+	for _, lang := range c.order {
+		responses = append(responses, c.pending[lang])
+	}
+	c.pending = make(map[string]StreamingTranslationResponse)
+	c.order = nil
+	c.mu.Unlock()
+
+	for _, response := range responses {
+		c.emit(response)
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// coalescedOutput は partialCoalescer が送信した途中経過を記録します
+type coalescedOutput struct {
+	mu        sync.Mutex
+	responses []StreamingTranslationResponse
+	times     []time.Time
+}
+
+func (o *coalescedOutput) emit(response StreamingTranslationResponse) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.responses = append(o.responses, response)
+	o.times = append(o.times, time.Now())
+}
+
+func (o *coalescedOutput) texts() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	texts := make([]string, 0, len(o.responses))
+	for _, response := range o.responses {
+		texts = append(texts, response.TargetLanguage+":"+response.TranslatedText)
+	}
+	return texts
+}
+
+func TestPartialCoalescer(t *testing.T) {
+	partial := func(lang, text string) StreamingTranslationResponse {
+		return StreamingTranslationResponse{TargetLanguage: lang, TranslatedText: text}
+	}
+	tests := []struct {
+		name    string
+		window  time.Duration
+		adds    []StreamingTranslationResponse
+		discard bool
+		want    []string
+	}{
+		{
+			name: "disabled sends every partial", adds: []StreamingTranslationResponse{partial("en", "He"), partial("en", "Hello")},
+			want: []string{"en:He", "en:Hello"},
+		},
+		{
+			name: "latest partial wins within the window", window: 100 * time.Millisecond,
+			adds: []StreamingTranslationResponse{partial("en", "He"), partial("en", "Hell"), partial("en", "Hello")},
+			want: []string{"en:Hello"},
+		},
+		{
+			name: "latest partial per target language in arrival order", window: 100 * time.Millisecond,
+			adds: []StreamingTranslationResponse{partial("fr", "Bon"), partial("en", "He"), partial("fr", "Bonjour"), partial("en", "Hello")},
+			want: []string{"fr:Bonjour", "en:Hello"},
+		},
+		{
+			name: "discarded partials are not sent", window: 100 * time.Millisecond,
+			adds: []StreamingTranslationResponse{partial("en", "Hello")}, discard: true,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output coalescedOutput
+			c := newPartialCoalescer(tt.window, output.emit)
+			defer c.stop()
+
+			for _, response := range tt.adds {
+				c.add(response)
+			}
+			if tt.discard {
+				c.discard()
+			}
+			time.Sleep(tt.window * 3)
+
+			if got := output.texts(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("emitted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartialCoalescerCadence(t *testing.T) {
+	const window = 100 * time.Millisecond
+	var output coalescedOutput
+	c := newPartialCoalescer(window, output.emit)
+
+	// 途中経過が10msごとに届いても、送信は間隔ごとに1回だけ行われる
+	start := time.Now()
+	texts := []string{}
+	for time.Since(start) < 5*window {
+		text := time.Since(start).String()
+		texts = append(texts, text)
+		c.add(StreamingTranslationResponse{TargetLanguage: "en", TranslatedText: text})
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(2 * window)
+	c.stop()
+
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if n := len(output.responses); n < 4 || n > 7 {
+		t.Fatalf("emitted %d updates for %d partials over %v, want one per %v", n, len(texts), 5*window, window)
+	}
+	for i := 1; i < len(output.times); i++ {
+		if gap := output.times[i].Sub(output.times[i-1]); gap < window/2 {
+			t.Errorf("updates %d and %d were %v apart, want about %v", i-1, i, gap, window)
+		}
+	}
+	if last := output.responses[len(output.responses)-1].TranslatedText; last != texts[len(texts)-1] {
+		t.Errorf("last update = %q, want the latest partial %q", last, texts[len(texts)-1])
+	}
+}
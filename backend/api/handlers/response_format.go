@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 翻訳結果のレスポンス形式
+const (
+	formatJSON = "json"
+	formatText = "text"
+	formatCSV  = "csv"
+)
+
+// responseFormat は ?format= クエリまたは Accept ヘッダーからレスポンス形式を決定します
+// クエリが優先され、未対応の形式を指定した場合はエラーを返します
+func responseFormat(c *gin.Context) (string, error) {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		switch format {
+		case formatJSON, formatText, formatCSV:
+			return format, nil
+		default:
+			return "", fmt.Errorf("unsupported format: %s (use json, text or csv)", format)
+		}
+	}
+
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return formatCSV, nil
+	case strings.Contains(accept, "text/plain"):
+		return formatText, nil
+	default:
+		return formatJSON, nil
+	}
+}
+
+// writeTranslationResponses は翻訳結果を指定された形式で書き込みます
+// JSON の場合、single が true なら1件のオブジェクト、そうでなければ配列として返します
+func writeTranslationResponses(c *gin.Context, status int, format string, responses []TranslationResponse, single bool) {
+	switch format {
+	case formatText:
+		// 翻訳テキストを1行に1件
+		lines := make([]string, len(responses))
+		for i, response := range responses {
+			lines[i] = response.TranslatedText
+		}
+		c.Data(status, "text/plain; charset=utf-8", []byte(strings.Join(lines, "\n")+"\n"))
+
+	case formatCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"originalText", "translatedText", "sourceLanguage", "targetLanguage", "detectionConfidence", "confidence"})
+		for _, response := range responses {
+			w.Write([]string{
+				response.OriginalText,
+				response.TranslatedText,
+				response.SourceLanguage,
+				response.TargetLanguage,
+				strconv.FormatFloat(response.DetectionConfidence, 'f', -1, 64),
+				strconv.FormatFloat(response.Confidence, 'f', -1, 64),
+			})
+		}
+		w.Flush()
+		c.Data(status, "text/csv; charset=utf-8", buf.Bytes())
+
+	default:
+		if single && len(responses) == 1 {
+			c.JSON(status, responses[0])
+			return
+		}
+		c.JSON(status, responses)
+	}
+}
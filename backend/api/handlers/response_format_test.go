@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTranslateHandlerResponseFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		translated      string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json by default",
+			translated:      "Hello",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"originalText":"こんにちは","translatedText":"Hello","sourceLanguage":"ja","targetLanguage":"en"}`,
+		},
+		{
+			name:            "text query",
+			query:           "?format=text",
+			translated:      "Hello",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain",
+			wantBody:        "Hello\n",
+		},
+		{
+			name:            "csv query",
+			query:           "?format=CSV",
+			translated:      "Hello, \"world\"",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody:        "originalText,translatedText,sourceLanguage,targetLanguage,detectionConfidence,confidence\nこんにちは,\"Hello, \"\"world\"\"\",ja,en,0,0\n",
+		},
+		{
+			name:            "text accept header",
+			accept:          "text/plain",
+			translated:      "Hello",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain",
+			wantBody:        "Hello\n",
+		},
+		{
+			name:            "csv accept header",
+			accept:          "text/csv, */*;q=0.1",
+			translated:      "Hello",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody:        "originalText,translatedText,sourceLanguage,targetLanguage,detectionConfidence,confidence\nこんにちは,Hello,ja,en,0,0\n",
+		},
+		{
+			name:            "query takes precedence over accept header",
+			query:           "?format=json",
+			accept:          "text/csv",
+			translated:      "Hello",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"originalText":"こんにちは","translatedText":"Hello","sourceLanguage":"ja","targetLanguage":"en"}`,
+		},
+		{
+			name:            "unsupported format",
+			query:           "?format=xml",
+			translated:      "Hello",
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        `{"error":"unsupported format: xml (use json, text or csv)"}`,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/translate", TranslateHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTranslationProvider(t, &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: tt.translated}})
+
+			payload, err := json.Marshal(TranslationRequest{Text: "こんにちは", TargetLanguage: "en", SourceLanguage: "ja"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/translate"+tt.query, bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := recorder.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
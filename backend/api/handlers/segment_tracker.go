@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// segmentTracker は発話ごとの SegmentID と更新番号を管理します
+// 同じ発話の途中経過と確定結果は同じ SegmentID を持ち、Revision はその中で1から順に増えます
+// クライアントは SegmentID ごとに最大の Revision の内容を表示すればよく、自分で結果をつなぎ合わせる必要がありません
+type segmentTracker struct {
+	mu       sync.Mutex
+	id       string // 現在の発話の SegmentID（確定後は空）
+	revision int
+}
+
+// next は次に送信する結果の SegmentID と Revision を返します
+// final が true の場合はその発話を終え、次の結果から新しい SegmentID を使用します
+func (t *segmentTracker) next(final bool) (string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.id == "" {
+		t.id = uuid.New().String()
+		t.revision = 0
+	}
+	t.revision++
+	id, revision := t.id, t.revision
+	if final {
+		t.id = ""
+	}
+	return id, revision
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSegmentTracker(t *testing.T) {
+	tests := []struct {
+		name string
+		// results は結果の種類の並び（i は途中経過、f は確定結果）
+		results string
+		// want は結果ごとの「区間の番号/Revision」
+		want []string
+	}{
+		{name: "final only", results: "f", want: []string{"0/1"}},
+		{name: "interims then final", results: "iiif", want: []string{"0/1", "0/2", "0/3", "0/4"}},
+		{name: "final starts a new segment", results: "iifif", want: []string{"0/1", "0/2", "0/3", "1/1", "1/2"}},
+		{name: "consecutive finals", results: "ff", want: []string{"0/1", "1/1"}},
+		{name: "interims without a final", results: "ii", want: []string{"0/1", "0/2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &segmentTracker{}
+			segments := map[string]int{}
+			var got []string
+			for _, kind := range tt.results {
+				id, revision := tracker.next(kind == 'f')
+				if _, ok := segments[id]; !ok {
+					segments[id] = len(segments)
+				}
+				got = append(got, fmt.Sprintf("%d/%d", segments[id], revision))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("segments = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerSegmentRevisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		interims []string
+		targets  LanguageList
+	}{
+		{name: "revisions increase until final", interims: []string{"こ", "こん", "こんに"}, targets: LanguageList{"en"}},
+		{name: "final without interims", targets: LanguageList{"en"}},
+		{name: "every target language shares the revision", interims: []string{"こ", "こん"}, targets: LanguageList{"en", "de"}},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startStreamingSession(t, server, fmt.Sprintf("revisions-%d", i), StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: tt.targets, AudioFormat: "pcm"})
+			fc := service.waitForConn(t)
+			translations := func(text string) map[string]string {
+				m := map[string]string{}
+				for _, lang := range tt.targets {
+					m[lang] = lang + ":" + text
+				}
+				return m
+			}
+
+			// 2つの発話を続けて送り、発話ごとに SegmentID が変わることも確認する
+			var firstSegment string
+			for utterance := 0; utterance < 2; utterance++ {
+				var segmentID string
+				for revision, text := range append(append([]string(nil), tt.interims...), "こんにちは") {
+					isFinal := revision == len(tt.interims)
+					if isFinal {
+						fc.sendPhrase(t, text, translations(text))
+					} else {
+						fc.sendHypothesis(t, text, translations(text))
+					}
+					for range tt.targets {
+						message := readMessage(t, client)
+						if message["isFinal"] != isFinal || message["originalText"] != text {
+							t.Fatalf("message = %v, want isFinal %v for %q", message, isFinal, text)
+						}
+						if segmentID == "" {
+							segmentID, _ = message["segmentId"].(string)
+						}
+						if message["segmentId"] != segmentID || segmentID == "" {
+							t.Errorf("segmentId = %v, want %q for every message of the utterance", message["segmentId"], segmentID)
+						}
+						if got := message["revision"]; got != float64(revision+1) {
+							t.Errorf("revision of %q = %v, want %d", text, got, revision+1)
+						}
+					}
+				}
+				if utterance == 0 {
+					firstSegment = segmentID
+				} else if segmentID == firstSegment {
+					t.Errorf("the second utterance reused segmentId %q", segmentID)
+				}
+			}
+		})
+	}
+}
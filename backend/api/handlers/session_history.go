@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// transcriptHistorySize はセッションごとに保持する直近の確定結果の数
+var transcriptHistorySize = 10
+
+// SetTranscriptHistorySize は途中から参加したクライアントに送信する直近の確定結果の数をセットします
+// 0の場合は履歴を保持しません
+func SetTranscriptHistorySize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	transcriptHistorySize = n
+}
+
+// transcriptHistory は直近の確定結果を保持するリングバッファ
+type transcriptHistory struct {
+	entries []StreamingTranslationResponse
+	next    int
+	full    bool
+}
+
+// newTranscriptHistory は size 件を保持する履歴を作成します
+func newTranscriptHistory(size int) *transcriptHistory {
+	return &transcriptHistory{entries: make([]StreamingTranslationResponse, size)}
+}
+
+// add は確定結果を追加し、保持数を超えた場合は最も古いものを破棄します
+func (h *transcriptHistory) add(response StreamingTranslationResponse) {
+	if len(h.entries) == 0 {
+		return
+	}
+	h.entries[h.next] = response
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// snapshot は保持している確定結果を古い順に返します
+func (h *transcriptHistory) snapshot() []StreamingTranslationResponse {
+	if !h.full {
+		return append([]StreamingTranslationResponse(nil), h.entries[:h.next]...)
+	}
+	result := make([]StreamingTranslationResponse, 0, len(h.entries))
+	result = append(result, h.entries[h.next:]...)
+	return append(result, h.entries[:h.next]...)
+}
+
+// viewerSet はセッションを閲覧している（途中から参加した）クライアントを管理します
+type viewerSet struct {
+	mu      sync.Mutex
+	history *transcriptHistory
+	viewers map[*sessionWriter]messageConn
+}
+
+// newViewerSet は履歴を size 件保持する閲覧者の集合を作成します
+func newViewerSet(size int) *viewerSet {
+	return &viewerSet{
+		history: newTranscriptHistory(size),
+		viewers: make(map[*sessionWriter]messageConn),
+	}
+}
+
+// join は閲覧者を登録し、履歴を送信します
+// 登録と履歴の送信はロック中に行うため、履歴とライブの結果が重複・欠落することはありません
+func (v *viewerSet) join(writer *sessionWriter, conn messageConn) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, response := range v.history.snapshot() {
+		writer.send(response)
+	}
+	v.viewers[writer] = conn
+}
+
+// leave は閲覧者の登録を解除します
+func (v *viewerSet) leave(writer *sessionWriter) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.viewers, writer)
+}
+
+// publishFinal は確定結果を履歴に追加し、閲覧者に送信します
+func (v *viewerSet) publishFinal(response StreamingTranslationResponse) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.history.add(response)
+	for writer := range v.viewers {
+		writer.send(response)
+	}
+}
+
+// publishPartial は途中経過を閲覧者に送信します
+func (v *viewerSet) publishPartial(response StreamingTranslationResponse) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for writer := range v.viewers {
+		writer.sendPartial(response.TargetLanguage, response)
+	}
+}
+
+// closeAll はすべての閲覧者の接続を閉じます（各閲覧者の受信ループが後処理を行います）
+func (v *viewerSet) closeAll() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, conn := range v.viewers {
+		conn.Close()
+	}
+}
+
+// joinSessionAsViewer は既存のセッションに閲覧者として参加し、接続が閉じるまで結果を送信します
+func joinSessionAsViewer(session *StreamingSession, conn *websocket.Conn) {
+	log.Printf("Client joined existing session as viewer: sessionID=%s", session.ID)
+	writer := newSessionWriter(conn, maxMessageRate, writeTimeout, maxBufferedFrames)
+	session.viewers.join(writer, conn)
+
+	// 閲覧者からのメッセージは使用しないが、切断を検出するために読み続ける
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	session.viewers.leave(writer)
+	writer.close()
+	conn.Close()
+	log.Printf("Viewer left session: sessionID=%s", session.ID)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestTranscriptHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		added []string
+		want  []string
+	}{
+		{name: "empty", size: 3, want: nil},
+		{name: "fewer than the size", size: 3, added: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "exactly the size", size: 3, added: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "oldest entries are dropped", size: 3, added: []string{"a", "b", "c", "d", "e"}, want: []string{"c", "d", "e"}},
+		{name: "disabled", size: 0, added: []string{"a", "b"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := newTranscriptHistory(tt.size)
+			for _, text := range tt.added {
+				history.add(StreamingTranslationResponse{OriginalText: text})
+			}
+			var got []string
+			for _, response := range history.snapshot() {
+				got = append(got, response.OriginalText)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshot = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// readFinal は次の確定結果が届くまで読み進め、その結果を返します
+func readFinal(t *testing.T, client *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	for {
+		if message := readMessage(t, client); message["isFinal"] == true {
+			return message
+		}
+	}
+}
+
+// waitForViewers はセッションの閲覧者が want 人になるまで待ちます
+func waitForViewers(t *testing.T, sessionID string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		activeSessionsMutex.RLock()
+		session := activeSessions[sessionID]
+		activeSessionsMutex.RUnlock()
+		got := 0
+		if session != nil {
+			session.viewers.mu.Lock()
+			got = len(session.viewers.viewers)
+			session.viewers.mu.Unlock()
+		}
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session %s has %d viewers, want %d", sessionID, got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebSocketHandlerLateJoiner(t *testing.T) {
+	tests := []struct {
+		name        string
+		historySize int
+		before      []string
+		wantHistory []string
+	}{
+		{name: "all finals are replayed", historySize: 10, before: []string{"一", "二", "三"}, wantHistory: []string{"一", "二", "三"}},
+		{name: "only the last N finals are replayed", historySize: 2, before: []string{"一", "二", "三"}, wantHistory: []string{"二", "三"}},
+		{name: "history disabled", historySize: 0, before: []string{"一", "二"}},
+		{name: "nothing to replay", historySize: 10},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := transcriptHistorySize
+			SetTranscriptHistorySize(tt.historySize)
+			t.Cleanup(func() { transcriptHistorySize = previous })
+
+			sessionID := fmt.Sprintf("history-%d", i)
+			owner := startStreamingSession(t, server, sessionID, StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"})
+			fc := service.waitForConn(t)
+			for _, text := range tt.before {
+				fc.sendPhrase(t, text, map[string]string{"en": "en:" + text})
+				if final := readFinal(t, owner); final["originalText"] != text {
+					t.Fatalf("owner final = %v, want %q", final, text)
+				}
+			}
+
+			viewerURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID
+			viewer, _, err := websocket.DefaultDialer.Dial(viewerURL, nil)
+			if err != nil {
+				t.Fatalf("failed to join the session: %v", err)
+			}
+			t.Cleanup(func() { viewer.Close() })
+
+			// 履歴の後にライブの結果が届く
+			for _, text := range tt.wantHistory {
+				message := readMessage(t, viewer)
+				if message["originalText"] != text || message["translatedText"] != "en:"+text || message["isFinal"] != true {
+					t.Fatalf("history message = %v, want the final for %q", message, text)
+				}
+			}
+			waitForViewers(t, sessionID, 1)
+			fc.sendPhrase(t, "ライブ", map[string]string{"en": "live"})
+			if final := readFinal(t, viewer); final["originalText"] != "ライブ" || final["translatedText"] != "live" {
+				t.Errorf("live final = %v, want the final for %q", final, "ライブ")
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerViewerTenant(t *testing.T) {
+	tests := []struct {
+		name         string
+		ownerTenant  string
+		viewerTenant string
+		wantStatus   int // 0 の場合は閲覧者として参加できる
+	}{
+		{name: "same tenant joins", ownerTenant: "tenant-a", viewerTenant: "tenant-a"},
+		{name: "no tenant joins a session without a tenant"},
+		{name: "another tenant is forbidden", ownerTenant: "tenant-a", viewerTenant: "tenant-b", wantStatus: http.StatusForbidden},
+		{name: "missing tenant is forbidden", ownerTenant: "tenant-a", wantStatus: http.StatusForbidden},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionID := fmt.Sprintf("viewer-tenant-%d", i)
+			owner := startStreamingSession(t, server, sessionID+"?tenantId="+tt.ownerTenant, StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"})
+			fc := service.waitForConn(t)
+
+			viewerURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID + "?tenantId=" + tt.viewerTenant
+			viewer, resp, err := websocket.DefaultDialer.Dial(viewerURL, nil)
+			if tt.wantStatus != 0 {
+				if err == nil {
+					viewer.Close()
+					t.Fatal("viewer from another tenant joined the session")
+				}
+				if resp == nil || resp.StatusCode != tt.wantStatus {
+					t.Fatalf("handshake response = %v, want status %d", resp, tt.wantStatus)
+				}
+				waitForViewers(t, sessionID, 0)
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to join the session: %v", err)
+			}
+			t.Cleanup(func() { viewer.Close() })
+			waitForViewers(t, sessionID, 1)
+			fc.sendPhrase(t, "こんにちは", map[string]string{"en": "Hello"})
+			for _, client := range []*websocket.Conn{owner, viewer} {
+				if final := readFinal(t, client); final["translatedText"] != "Hello" {
+					t.Errorf("final = %v, want the translation", final)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerReservesSessionID(t *testing.T) {
+	tests := []struct {
+		name string
+		// beforeSetup は最初のクライアントが初期設定を送る前に行う操作
+		beforeSetup func(t *testing.T, server *httptest.Server, sessionID string) *websocket.Conn
+	}{
+		{name: "second client joins as a viewer", beforeSetup: func(t *testing.T, server *httptest.Server, sessionID string) *websocket.Conn {
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID
+			viewer, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if err != nil {
+				t.Fatalf("failed to connect the second client: %v", err)
+			}
+			t.Cleanup(func() { viewer.Close() })
+			waitForViewers(t, sessionID, 1)
+			return viewer
+		}},
+		{name: "audio chunks wait for the setup", beforeSetup: func(t *testing.T, server *httptest.Server, sessionID string) *websocket.Conn {
+			recorder := performJSON(t, ProcessAudioChunkHandler, http.MethodPost, AudioChunkRequest{SessionID: sessionID, AudioChunk: "AAAA"})
+			if recorder.Code != http.StatusConflict {
+				t.Errorf("audio chunk before the setup: status = %d, want %d: %s", recorder.Code, http.StatusConflict, recorder.Body.String())
+			}
+			return nil
+		}},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionID := fmt.Sprintf("reserved-%d", i)
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID
+			owner, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if err != nil {
+				t.Fatalf("failed to connect to the streaming handler: %v", err)
+			}
+			t.Cleanup(func() { owner.Close() })
+			waitForSessions(t, func(sessions map[string]*StreamingSession) bool { return sessions[sessionID] != nil })
+
+			viewer := tt.beforeSetup(t, server, sessionID)
+
+			if err := owner.WriteJSON(StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"}); err != nil {
+				t.Fatalf("failed to send the setup message: %v", err)
+			}
+			if ready := readMessage(t, owner); ready["status"] != "ready" {
+				t.Fatalf("first message = %v, want the ready status", ready)
+			}
+			fc := service.waitForConn(t)
+			fc.sendPhrase(t, "こんにちは", map[string]string{"en": "Hello"})
+			if final := readFinal(t, owner); final["translatedText"] != "Hello" {
+				t.Errorf("owner final = %v, want the translation", final)
+			}
+			if viewer != nil {
+				if final := readFinal(t, viewer); final["translatedText"] != "Hello" {
+					t.Errorf("viewer final = %v, want the translation", final)
+				}
+			}
+			select {
+			case <-service.accepted:
+				t.Error("a second recognizer connected for the same session ID")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
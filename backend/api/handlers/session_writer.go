@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// maxMessageRate はセッションごとにクライアントへ送信する1秒あたりの最大メッセージ数（0は無制限）
+var maxMessageRate int
+
+// SetMaxMessageRate はセッションごとの1秒あたりの最大送信メッセージ数をセットします
+// 上限を超える場合は古い途中経過を破棄し、確定結果と制御メッセージは必ず送信します
+func SetMaxMessageRate(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxMessageRate = n
+}
+
+// writeTimeout はクライアントへの1回の書き込みの期限（0は無制限）
+var writeTimeout time.Duration
+
+// SetWriteTimeout はクライアントへの書き込みの期限をセットします
+// 期限内に書き込めないクライアントは切断されたものとみなし、セッションを終了します
+func SetWriteTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	writeTimeout = d
+}
+
+// maxBufferedFrames はセッションごとに送信待ちにできるメッセージ数の上限（0は無制限）
+var maxBufferedFrames int
+
+// SetMaxBufferedFrames はセッションごとに送信待ちにできるメッセージ数の上限をセットします
+// 上限を超える場合は最も古い途中経過から破棄します（確定結果と制御メッセージは上限を超えても保持します）
+func SetMaxBufferedFrames(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxBufferedFrames = n
+}
+
+// messageConn はセッションの結果を書き込む接続（WebSocket または Server-Sent Events）
+type messageConn interface {
+	WriteJSON(v interface{}) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// sessionWriter はWebSocketへの書き込みを1つのゴルーチンに集約します
+// 認識イベントのコールバックとメイン処理から同時に書き込まれるのを防ぎ、送信レートを制限します
+type sessionWriter struct {
+	conn        messageConn
+	interval    time.Duration
+	timeout     time.Duration
+	maxBuffered int
+
+	mu           sync.Mutex
+	queue        []interface{}          // 確定結果と制御メッセージ（破棄しない）
+	partials     map[string]interface{} // 未送信の最新の途中経過（翻訳先言語ごと）
+	partialOrder []string               // 途中経過が届いた順の言語
+	dropped      int
+	closed       bool
+
+	notify chan struct{}
+	done   chan struct{}
+	exited chan struct{}
+}
+
+// newSessionWriter は書き込み用ゴルーチンを開始します
+func newSessionWriter(conn messageConn, rate int, timeout time.Duration, maxBuffered int) *sessionWriter {
+	w := &sessionWriter{
+		conn:        conn,
+		timeout:     timeout,
+		maxBuffered: maxBuffered,
+		notify:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+	if rate > 0 {
+		w.interval = time.Second / time.Duration(rate)
+	}
+	go w.run()
+	return w
+}
+
+// send は確定結果や制御メッセージを送信キューに追加します
+// 未送信の途中経過は確定結果より古いため破棄します
+func (w *sessionWriter) send(msg interface{}) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.dropPartialsLocked()
+	w.queue = append(w.queue, msg)
+	w.mu.Unlock()
+	w.wake()
+}
+
+// sendPartial は途中経過を送信します
+// 同じキー（翻訳先言語）の送信待ちの途中経過がある場合は新しいもので置き換えます
+func (w *sessionWriter) sendPartial(key string, msg interface{}) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	if w.partials == nil {
+		w.partials = make(map[string]interface{})
+	}
+	if _, pending := w.partials[key]; pending {
+		w.dropped++
+	} else {
+		w.partialOrder = append(w.partialOrder, key)
+	}
+	w.partials[key] = msg
+	w.trimPartialsLocked()
+	w.mu.Unlock()
+	w.wake()
+}
+
+// trimPartialsLocked は送信待ちのメッセージ数が上限を超えている間、最も古い途中経過を破棄します（w.mu を保持して呼び出す）
+// 翻訳先言語が多い場合でも送信待ちの途中経過が増え続けないようにするためのもの
+func (w *sessionWriter) trimPartialsLocked() {
+	if w.maxBuffered <= 0 {
+		return
+	}
+	for len(w.partialOrder) > 0 && len(w.queue)+len(w.partialOrder) > w.maxBuffered {
+		delete(w.partials, w.partialOrder[0])
+		w.partialOrder = w.partialOrder[1:]
+		w.dropped++
+	}
+}
+
+// dropPartialsLocked は送信待ちの途中経過をすべて破棄します（w.mu を保持して呼び出す）
+func (w *sessionWriter) dropPartialsLocked() {
+	w.dropped += len(w.partialOrder)
+	w.partials = nil
+	w.partialOrder = nil
+}
+
+func (w *sessionWriter) wake() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next は次に送信するメッセージを取り出します
+func (w *sessionWriter) next() (interface{}, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) > 0 {
+		msg := w.queue[0]
+		w.queue = w.queue[1:]
+		return msg, true
+	}
+	if len(w.partialOrder) > 0 {
+		key := w.partialOrder[0]
+		w.partialOrder = w.partialOrder[1:]
+		msg := w.partials[key]
+		delete(w.partials, key)
+		return msg, true
+	}
+	return nil, false
+}
+
+func (w *sessionWriter) run() {
+	defer close(w.exited)
+
+	var lastWrite time.Time
+	for {
+		select {
+		case <-w.notify:
+		case <-w.done:
+			w.flush()
+			return
+		}
+
+		for {
+			// レート制限: 前回の送信から間隔が空くまで待つ（その間の途中経過は最新のものに置き換わる）
+			if w.interval > 0 && !lastWrite.IsZero() {
+				if wait := w.interval - time.Since(lastWrite); wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-w.done:
+						w.flush()
+						return
+					}
+				}
+			}
+
+			msg, ok := w.next()
+			if !ok {
+				break
+			}
+			if err := w.write(msg); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+	}
+}
+
+// flush は終了時に残っている確定結果と制御メッセージを送信します
+func (w *sessionWriter) flush() {
+	w.mu.Lock()
+	queue := w.queue
+	w.queue = nil
+	w.dropPartialsLocked()
+	w.mu.Unlock()
+
+	for _, msg := range queue {
+		if err := w.write(msg); err != nil {
+			return
+		}
+	}
+}
+
+// write は書き込み期限を設定してメッセージを書き込みます
+// 書き込みに失敗した場合は接続を閉じ、受信ループ側でセッションのクリーンアップを行わせます
+func (w *sessionWriter) write(msg interface{}) error {
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	err := w.conn.WriteJSON(msg)
+	if err != nil {
+		log.Printf("Failed to write to client, closing connection: %v", err)
+		w.mu.Lock()
+		w.queue = nil
+		w.partials = nil
+		w.partialOrder = nil
+		w.closed = true // 以降の送信は破棄する
+		w.mu.Unlock()
+		w.conn.Close()
+	}
+	return err
+}
+
+// close は残りのメッセージを送信して書き込み用ゴルーチンを終了します
+func (w *sessionWriter) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.exited
+
+	w.mu.Lock()
+	dropped := w.dropped
+	w.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("[DEBUG] Dropped %d interim results to respect the message rate and buffer limits", dropped)
+	}
+}
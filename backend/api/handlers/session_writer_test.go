@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingConn は書き込まれたメッセージと時刻を記録するテスト用の接続
+type recordingConn struct {
+	mu       sync.Mutex
+	messages []interface{}
+	times    []time.Time
+}
+
+func (c *recordingConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, v)
+	c.times = append(c.times, time.Now())
+	return nil
+}
+
+func (c *recordingConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) written() ([]interface{}, []time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]interface{}(nil), c.messages...), append([]time.Time(nil), c.times...)
+}
+
+func TestSessionWriterRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        int
+		minInterval time.Duration
+	}{
+		{name: "unlimited", rate: 0},
+		{name: "20 messages per second", rate: 20, minInterval: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &recordingConn{}
+			w := newSessionWriter(conn, tt.rate, 0, 0)
+			want := []interface{}{"a", "b", "c", "d"}
+			for _, msg := range want {
+				w.send(msg)
+			}
+			// close は残りをすぐに送信するため、レート制限のもとですべて書き込まれるのを待ってから閉じる
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				if messages, _ := conn.written(); len(messages) == len(want) || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			w.close()
+
+			messages, times := conn.written()
+			if !reflect.DeepEqual(messages, want) {
+				t.Fatalf("written %v, want %v", messages, want)
+			}
+			// タイマーの誤差を考慮して少し短い間隔まで許容する
+			tolerance := 5 * time.Millisecond
+			for i := 1; i < len(times); i++ {
+				if gap := times[i].Sub(times[i-1]); tt.minInterval > 0 && gap < tt.minInterval-tolerance {
+					t.Errorf("messages %d and %d were written %v apart, want at least %v", i-1, i, gap, tt.minInterval)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionWriterPartialsAreReplacedUnderRateLimit(t *testing.T) {
+	conn := &recordingConn{}
+	w := newSessionWriter(conn, 10, 0, 0)
+
+	// 最初の送信の後は100ms待つ必要があるため、その間の途中経過は最新のものに置き換わる
+	w.send("ready")
+	for i := 0; i < 5; i++ {
+		w.sendPartial("en", i)
+	}
+	time.Sleep(250 * time.Millisecond)
+	w.close()
+
+	messages, _ := conn.written()
+	want := []interface{}{"ready", 4}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("written %v, want %v", messages, want)
+	}
+}
+
+func TestSessionWriterBufferTrimming(t *testing.T) {
+	type op struct {
+		final bool
+		key   string
+		msg   interface{}
+	}
+	// 確定結果の後に50言語の途中経過が続く
+	manyLanguageOps := []op{{final: true, msg: "f1"}}
+	for i := 0; i < 50; i++ {
+		manyLanguageOps = append(manyLanguageOps, op{key: fmt.Sprintf("lang%d", i), msg: i})
+	}
+	tests := []struct {
+		name        string
+		maxBuffered int
+		ops         []op
+		wantQueue   []interface{}
+		wantOrder   []string
+		wantDropped int
+	}{
+		{
+			name:        "unlimited keeps one partial per language",
+			maxBuffered: 0,
+			ops:         []op{{key: "en", msg: 1}, {key: "de", msg: 2}, {key: "fr", msg: 3}},
+			wantOrder:   []string{"en", "de", "fr"},
+		},
+		{
+			name:        "newer partial for the same language replaces the older one",
+			maxBuffered: 0,
+			ops:         []op{{key: "en", msg: 1}, {key: "en", msg: 2}},
+			wantOrder:   []string{"en"},
+			wantDropped: 1,
+		},
+		{
+			name:        "oldest partial is dropped over the limit",
+			maxBuffered: 2,
+			ops:         []op{{key: "en", msg: 1}, {key: "de", msg: 2}, {key: "fr", msg: 3}},
+			wantOrder:   []string{"de", "fr"},
+			wantDropped: 1,
+		},
+		{
+			name:        "finals count toward the limit but are never dropped",
+			maxBuffered: 2,
+			ops:         []op{{final: true, msg: "f1"}, {final: true, msg: "f2"}, {key: "en", msg: 1}, {final: true, msg: "f3"}},
+			wantQueue:   []interface{}{"f1", "f2", "f3"},
+			wantDropped: 1,
+		},
+		{
+			name:        "a final drops the pending partials",
+			maxBuffered: 0,
+			ops:         []op{{key: "en", msg: 1}, {key: "de", msg: 2}, {final: true, msg: "f1"}},
+			wantQueue:   []interface{}{"f1"},
+			wantDropped: 2,
+		},
+		{
+			name:        "many languages stay within the limit",
+			maxBuffered: 5,
+			ops:         manyLanguageOps,
+			wantQueue:   []interface{}{"f1"},
+			wantOrder:   []string{"lang46", "lang47", "lang48", "lang49"},
+			wantDropped: 46,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 書き込み用ゴルーチンを起動せず、送信待ちの状態だけを確認する
+			w := &sessionWriter{maxBuffered: tt.maxBuffered, notify: make(chan struct{}, 1)}
+			for _, o := range tt.ops {
+				if o.final {
+					w.send(o.msg)
+				} else {
+					w.sendPartial(o.key, o.msg)
+				}
+			}
+
+			if !reflect.DeepEqual(w.queue, tt.wantQueue) {
+				t.Errorf("queue = %v, want %v", w.queue, tt.wantQueue)
+			}
+			if !reflect.DeepEqual(w.partialOrder, tt.wantOrder) {
+				t.Errorf("pending partials = %v, want %v", w.partialOrder, tt.wantOrder)
+			}
+			if w.dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", w.dropped, tt.wantDropped)
+			}
+		})
+	}
+}
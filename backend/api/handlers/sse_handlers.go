@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errSSEClosed は閉じられた SSE 接続に書き込もうとした場合のエラー
+var errSSEClosed = errors.New("SSE connection closed")
+
+// sseConn は Server-Sent Events のレスポンスを messageConn として扱います
+type sseConn struct {
+	w          gin.ResponseWriter
+	controller *http.ResponseController
+
+	mu        sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// newSSEConn は SSE のヘッダーを書き込み、接続を作成します
+func newSSEConn(w gin.ResponseWriter) *sseConn {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // プロキシでのバッファリングを無効化
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	return &sseConn{
+		w:          w,
+		controller: http.NewResponseController(w),
+		closed:     make(chan struct{}),
+	}
+}
+
+// WriteJSON はメッセージを1つの SSE イベントとして書き込みます
+func (s *sseConn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return errSSEClosed
+	default:
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+// SetWriteDeadline は書き込みの期限を設定します
+func (s *sseConn) SetWriteDeadline(t time.Time) error {
+	return s.controller.SetWriteDeadline(t)
+}
+
+// Close は接続を閉じたものとして扱い、ハンドラーを終了させます
+func (s *sseConn) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+// SSEHandler は既存のセッションの認識・翻訳結果を Server-Sent Events で配信するハンドラー
+// 結果は WebSocket の閲覧者と同じく、直近の確定結果の履歴の後にライブの結果が送信されます
+func SSEHandler(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	activeSessionsMutex.RLock()
+	session, exists := activeSessions[sessionID]
+	activeSessionsMutex.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	// 別のテナントのセッションの結果は配信しない
+	if tenantID := requestTenantID(c); session.TenantID != tenantID {
+		log.Printf("Rejected SSE client from another tenant: sessionID=%s, tenantID=%s", sessionID, tenantID)
+		c.JSON(http.StatusForbidden, gin.H{"error": errSessionTenantMismatch.Error()})
+		return
+	}
+
+	log.Printf("SSE client subscribed to session: sessionID=%s", sessionID)
+	conn := newSSEConn(c.Writer)
+	writer := newSessionWriter(conn, maxMessageRate, writeTimeout, maxBufferedFrames)
+	session.viewers.join(writer, conn)
+
+	// クライアントの切断、またはセッションの終了まで待機する
+	select {
+	case <-c.Request.Context().Done():
+	case <-conn.closed:
+	}
+
+	session.viewers.leave(writer)
+	writer.close()
+	conn.Close()
+	log.Printf("SSE client left session: sessionID=%s", sessionID)
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readSSEEvent は次の SSE イベントの data を JSON として読みます
+func readSSEEvent(t *testing.T, events <-chan string) map[string]interface{} {
+	t.Helper()
+	select {
+	case data, ok := <-events:
+		if !ok {
+			t.Fatal("SSE stream ended")
+		}
+		var message map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			t.Fatalf("SSE data is not JSON: %q", data)
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an SSE event")
+		return nil
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		sessionID       string // 空の場合は REST で開始したセッションの ID を使う
+		start           string // "ws": WebSocket で開始、"rest": REST で開始、空: 開始しない
+		tenant          string // セッションを開始したテナント
+		subscribeTenant string // SSE で購読するテナント
+		before          []string
+		wantStatus      int
+	}{
+		{name: "history then live results", sessionID: "sse-0", start: "ws", before: []string{"一", "二"}, wantStatus: http.StatusOK},
+		{name: "live results only", sessionID: "sse-1", start: "ws", wantStatus: http.StatusOK},
+		{name: "session started over REST", start: "rest", wantStatus: http.StatusOK},
+		{name: "same tenant", start: "rest", tenant: "tenant-a", subscribeTenant: "tenant-a", wantStatus: http.StatusOK},
+		{name: "another tenant", start: "rest", tenant: "tenant-a", subscribeTenant: "tenant-b", wantStatus: http.StatusForbidden},
+		{name: "unknown session", sessionID: "sse-unknown", wantStatus: http.StatusNotFound},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws/:sessionId", WebSocketHandler)
+	router.GET("/sse/:sessionId", SSEHandler)
+	router.POST("/start", StartStreamingSessionHandler)
+	router.POST("/process", ProcessAudioChunkHandler)
+	router.POST("/close", CloseStreamingSessionHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"}
+			sessionID := tt.sessionID
+			var fc *fakeSpeechConn
+			switch tt.start {
+			case "ws":
+				owner := startStreamingSession(t, server, sessionID, setup)
+				fc = service.waitForConn(t)
+				for _, text := range tt.before {
+					fc.sendPhrase(t, text, map[string]string{"en": "en:" + text})
+					readFinal(t, owner)
+				}
+			case "rest":
+				// REST で開始したセッションは認識器とプッシュストリームを持ち、すぐに音声を受け付ける
+				var started map[string]interface{}
+				sessionID, started = startRESTSessionForTenant(t, router, tt.tenant)
+				if started["sseURL"] != "/api/v1/streaming/sse/"+sessionID {
+					t.Errorf("sseURL = %v, want the SSE endpoint of the session", started["sseURL"])
+				}
+				fc = service.waitForConn(t)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/sse/"+sessionID, nil)
+			req.Header.Set("X-Tenant-ID", tt.subscribeTenant)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+				t.Errorf("Content-Type = %q, want text/event-stream", got)
+			}
+
+			events := make(chan string, 10)
+			go func() {
+				defer close(events)
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+						events <- data
+					}
+				}
+			}()
+
+			// 履歴の確定結果が先に届く
+			for _, text := range tt.before {
+				if message := readSSEEvent(t, events); message["originalText"] != text || message["translatedText"] != "en:"+text {
+					t.Fatalf("history event = %v, want the final for %q", message, text)
+				}
+			}
+
+			// REST で送った音声の結果がライブで届く
+			waitForViewers(t, sessionID, 1)
+			chunk := performRequest(t, router, http.MethodPost, "/process", AudioChunkRequest{
+				SessionID: sessionID, AudioChunk: base64.StdEncoding.EncodeToString(make([]byte, 3200)),
+			})
+			if chunk.Code != http.StatusOK {
+				t.Fatalf("process status = %d: %s", chunk.Code, chunk.Body.String())
+			}
+			fc.waitForText(t, "audio")
+			fc.sendPhrase(t, "ライブ", map[string]string{"en": "live"})
+			for {
+				message := readSSEEvent(t, events)
+				if message["isFinal"] != true {
+					continue
+				}
+				if message["originalText"] != "ライブ" || message["translatedText"] != "live" {
+					t.Errorf("live event = %v, want the final for %q", message, "ライブ")
+				}
+				break
+			}
+
+			// 切断すると閲覧者から外れる
+			cancel()
+			waitForViewers(t, sessionID, 0)
+		})
+	}
+}
+
+// TestStartStreamingSessionHandlerClose は REST で開始したセッションを終了すると、
+// 認識が停止して SSE の購読者の接続も閉じられることを確認します
+func TestStartStreamingSessionHandlerClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(t *testing.T, router http.Handler, sessionID string)
+	}{
+		{name: "close endpoint", close: func(t *testing.T, router http.Handler, sessionID string) {
+			performRequest(t, router, http.MethodPost, "/close", SessionCloseRequest{SessionID: sessionID})
+		}},
+		{name: "tenant sessions closed", close: func(t *testing.T, router http.Handler, sessionID string) {
+			if closed := CloseSessionsForTenant("tenant-close"); closed != 1 {
+				t.Errorf("closed sessions = %d, want 1", closed)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/sse/:sessionId", SSEHandler)
+			router.POST("/start", StartStreamingSessionHandler)
+			router.POST("/close", CloseStreamingSessionHandler)
+			server := httptest.NewServer(router)
+			t.Cleanup(server.Close)
+
+			sessionID, _ := startRESTSessionForTenant(t, router, "tenant-close")
+			service.waitForConn(t)
+			activeSessionsMutex.RLock()
+			recognizer := activeSessions[sessionID].Recognizer
+			activeSessionsMutex.RUnlock()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/sse/"+sessionID, nil)
+			req.Header.Set("X-Tenant-ID", "tenant-close")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			defer resp.Body.Close()
+			waitForViewers(t, sessionID, 1)
+
+			tt.close(t, router, sessionID)
+
+			// セッションが削除され、認識が停止し、SSE のレスポンスが終わる
+			waitForSessions(t, func(sessions map[string]*StreamingSession) bool { return sessions[sessionID] == nil })
+			done := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, resp.Body)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("SSE response did not end after the session was closed")
+			}
+			if recognizer.IsRunning() {
+				t.Error("recognizer is still running after the session was closed")
+			}
+		})
+	}
+}
+
+// startRESTSessionForTenant は REST でセッションを開始し、テスト終了時にセッションを終了します
+// セッションIDと開始時の応答を返します
+func startRESTSessionForTenant(t *testing.T, router http.Handler, tenantID string) (string, map[string]interface{}) {
+	t.Helper()
+	payload, _ := json.Marshal(StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"})
+	req := httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", tenantID)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	var started map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &started); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("start status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	sessionID, _ := started["sessionId"].(string)
+	t.Cleanup(func() {
+		performRequest(t, router, http.MethodPost, "/close", SessionCloseRequest{SessionID: sessionID})
+	})
+	return sessionID, started
+}
+
+// performRequest は JSON ボディのリクエストをルーターで処理します
+func performRequest(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
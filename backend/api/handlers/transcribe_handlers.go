@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadSize はアップロードできる音声ファイルの最大サイズ（バイト）
+var maxUploadSize int64 = 25 << 20
+
+// SetMaxUploadSize は文字起こし・翻訳エンドポイントにアップロードできる音声ファイルの最大サイズをセットします
+func SetMaxUploadSize(n int64) {
+	if n <= 0 {
+		return
+	}
+	maxUploadSize = n
+}
+
+// transcribeTimeout はアップロードされた音声の認識を待つ最大時間
+const transcribeTimeout = 10 * time.Minute
+
+// TranscribeSegment は文字起こし・翻訳結果の1区間
+type TranscribeSegment struct {
+	OriginalText   string `json:"originalText"`
+	TranslatedText string `json:"translatedText"`
+	StartMs        int64  `json:"startMs"` // 音声の先頭からの開始位置（ミリ秒）
+	EndMs          int64  `json:"endMs"`
+}
+
+// TranscribeTranslateResponse は文字起こし・翻訳エンドポイントのレスポンス
+type TranscribeTranslateResponse struct {
+	SourceLanguage string              `json:"sourceLanguage"`
+	TargetLanguage string              `json:"targetLanguage"`
+	Transcript     string              `json:"transcript"`
+	Translation    string              `json:"translation"`
+	Segments       []TranscribeSegment `json:"segments"`
+}
+
+// recognizeFile はアップロードされた音声を最後まで認識し、確定結果を返します（テストで差し替え可能）
+var recognizeFile = recognizeToCompletion
+
+// TranscribeTranslateHandler はアップロードされた音声ファイルを文字起こし・翻訳するハンドラー
+// multipart の audio（WAV）、sourceLanguage、targetLanguage を受け取り、?format=srt の場合は字幕を返します
+func TranscribeTranslateHandler(c *gin.Context) {
+	if !speechConfigured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSpeechNotConfigured.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("audio file exceeds %d bytes", maxUploadSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("audio file is required: %v", err)})
+		return
+	}
+	if fileHeader.Size > maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("audio file exceeds %d bytes", maxUploadSize)})
+		return
+	}
+
+	sourceLanguage := c.PostForm("sourceLanguage")
+	targetLanguage := c.PostForm("targetLanguage")
+	if sourceLanguage == "" || targetLanguage == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sourceLanguage and targetLanguage are required"})
+		return
+	}
+	if !gospeech.IsSupportedSourceLanguage(sourceLanguage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported source language: %s", sourceLanguage)})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "srt" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported format: %s (use json or srt)", format)})
+		return
+	}
+
+	// WAVヘッダーを解析するため一時ファイルに保存する
+	tmp, err := os.CreateTemp("", "upload-*.wav")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store uploaded audio"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	tmp.Close()
+	if err := c.SaveUploadedFile(fileHeader, tmp.Name()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store uploaded audio"})
+		return
+	}
+
+	reader, err := gospeech.NewAudioFileReader(tmp.Name())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid WAV file: %v", err)})
+		return
+	}
+	defer reader.Close()
+
+	log.Printf("Transcribe request: file=%s, size=%d, source=%s, target=%s", fileHeader.Filename, fileHeader.Size, sourceLanguage, targetLanguage)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), transcribeTimeout)
+	defer cancel()
+	// 音声ファイルの認識も Speech Service の呼び出しとして同時実行数の上限に数える
+	release, err := acquireUpstream(ctx)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	results, err := recognizeFile(ctx, reader, sourceLanguage, targetLanguage)
+	release()
+	if err != nil {
+		log.Printf("Transcription failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to transcribe audio: %v", err)})
+		return
+	}
+
+	response := TranscribeTranslateResponse{
+		SourceLanguage: sourceLanguage,
+		TargetLanguage: targetLanguage,
+		Segments:       make([]TranscribeSegment, 0, len(results)),
+	}
+	transcript := make([]string, 0, len(results))
+	translation := make([]string, 0, len(results))
+	for _, result := range results {
+		translatedText := result.Translations[targetLanguage]
+		if isPassThrough(sourceLanguage, targetLanguage) {
+			translatedText = result.Text
+		}
+		start := time.Duration(result.Offset)
+		response.Segments = append(response.Segments, TranscribeSegment{
+			OriginalText:   result.Text,
+			TranslatedText: translatedText,
+			StartMs:        start.Milliseconds(),
+			EndMs:          (start + result.Duration).Milliseconds(),
+		})
+		transcript = append(transcript, result.Text)
+		translation = append(translation, translatedText)
+	}
+	response.Transcript = strings.Join(transcript, " ")
+	response.Translation = strings.Join(translation, " ")
+
+	if format == "srt" {
+		c.Data(http.StatusOK, "application/x-subrip; charset=utf-8", []byte(formatSRT(response.Segments)))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// recognizeToCompletion は音声を最後まで連続認識し、確定結果を順に返します
+func recognizeToCompletion(ctx context.Context, reader *gospeech.AudioFileReader, sourceLanguage, targetLanguage string) ([]*gospeech.TranslationRecognitionResult, error) {
+	config, err := gospeech.SpeechTranslationConfigFromSubscription(speechSubscriptionKey, speechRegion)
+	if err != nil {
+		return nil, err
+	}
+	config.SetSpeechRecognitionLanguage(sourceLanguage)
+	if !isPassThrough(sourceLanguage, targetLanguage) {
+		config.AddTargetLanguage(targetLanguage)
+	}
+	config.SetOutputFormat(gospeech.OutputFormatDetailed)
+
+	audioConfig, err := gospeech.NewAudioConfigFromStream(io.NopCloser(reader), reader.Format())
+	if err != nil {
+		return nil, err
+	}
+	recognizer, err := gospeech.NewTranslationRecognizer(config, audioConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer recognizer.Close()
+
+	var mu sync.Mutex
+	var results []*gospeech.TranslationRecognitionResult
+	var recognitionErr error
+	done := make(chan struct{})
+	var doneOnce sync.Once
+	finish := func() { doneOnce.Do(func() { close(done) }) }
+
+	recognizer.Recognized().Connect(func(eventArgs interface{}) {
+		args, ok := eventArgs.(*gospeech.TranslationRecognitionEventArgs)
+		if !ok || args.Result == nil || args.Result.Text == "" {
+			return
+		}
+		if args.Result.Reason != gospeech.ResultReasonTranslatedSpeech && args.Result.Reason != gospeech.ResultReasonRecognizedSpeech {
+			return
+		}
+		mu.Lock()
+		results = append(results, args.Result)
+		mu.Unlock()
+	})
+	recognizer.SessionStopped().Connect(func(eventArgs interface{}) {
+		finish()
+	})
+	recognizer.Canceled().Connect(func(eventArgs interface{}) {
+		args, ok := eventArgs.(*gospeech.TranslationRecognitionCanceledEventArgs)
+		if ok && args.CancellationDetails != nil && args.CancellationDetails.Reason == gospeech.CancellationReasonError {
+			mu.Lock()
+			recognitionErr = errors.New(args.CancellationDetails.ErrorDetails)
+			mu.Unlock()
+		}
+		finish()
+	})
+
+	if err := recognizer.StartContinuousRecognition(ctx); err != nil {
+		return nil, err
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		recognizer.StopContinuousRecognition()
+		return nil, ctx.Err()
+	}
+	recognizer.StopContinuousRecognition()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if recognitionErr != nil {
+		return nil, recognitionErr
+	}
+	return results, nil
+}
+
+// formatSRT は区間を SubRip 形式の字幕に変換します（翻訳テキストを使用）
+func formatSRT(segments []TranscribeSegment) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, srtTimestamp(segment.StartMs), srtTimestamp(segment.EndMs), segment.TranslatedText)
+	}
+	return b.String()
+}
+
+// srtTimestamp はミリ秒を SubRip のタイムスタンプ（HH:MM:SS,mmm）に変換します
+func srtTimestamp(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
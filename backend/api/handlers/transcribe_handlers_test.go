@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testWAV は 16kHz 16bit モノラルの PCM を WAV ファイルの内容にします
+func testWAV(pcm []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(pcm)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, uint16(1))
+	binary.Write(&b, binary.LittleEndian, uint16(1))
+	binary.Write(&b, binary.LittleEndian, uint32(16000))
+	binary.Write(&b, binary.LittleEndian, uint32(32000))
+	binary.Write(&b, binary.LittleEndian, uint16(2))
+	binary.Write(&b, binary.LittleEndian, uint16(16))
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+// useRecognizeFile はテストの間だけアップロードされた音声の認識処理を差し替えます
+func useRecognizeFile(t *testing.T, fn func(ctx context.Context, reader *gospeech.AudioFileReader, sourceLanguage, targetLanguage string) ([]*gospeech.TranslationRecognitionResult, error)) {
+	t.Helper()
+	previous := recognizeFile
+	recognizeFile = fn
+	t.Cleanup(func() { recognizeFile = previous })
+}
+
+// transcribeRequest は文字起こし・翻訳エンドポイントへの multipart リクエストを作成します
+func transcribeRequest(t *testing.T, query string, audio []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if audio != nil {
+		part, err := writer.CreateFormFile("audio", "recording.wav")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(audio)
+	}
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/transcribe-translate"+query, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestTranscribeTranslateHandler(t *testing.T) {
+	pcm := make([]byte, 6400)
+	results := []*gospeech.TranslationRecognitionResult{
+		{Text: "こんにちは", Offset: int64(500 * time.Millisecond), Duration: 1200 * time.Millisecond, Translations: map[string]string{"en": "Hello"}},
+		{Text: "さようなら", Offset: int64(2 * time.Second), Duration: time.Second, Translations: map[string]string{"en": "Goodbye"}},
+	}
+	languages := map[string]string{"sourceLanguage": "ja-JP", "targetLanguage": "en"}
+
+	tests := []struct {
+		name          string
+		query         string
+		maxUpload     int64
+		audio         []byte
+		fields        map[string]string
+		recognizeErr  error
+		wantStatus    int
+		wantRecognize bool
+		wantBody      string
+		wantResponse  *TranscribeTranslateResponse
+	}{
+		{
+			name: "transcript and translation", audio: testWAV(pcm), fields: languages,
+			wantStatus: http.StatusOK, wantRecognize: true,
+			wantResponse: &TranscribeTranslateResponse{
+				SourceLanguage: "ja-JP", TargetLanguage: "en",
+				Transcript: "こんにちは さようなら", Translation: "Hello Goodbye",
+				Segments: []TranscribeSegment{
+					{OriginalText: "こんにちは", TranslatedText: "Hello", StartMs: 500, EndMs: 1700},
+					{OriginalText: "さようなら", TranslatedText: "Goodbye", StartMs: 2000, EndMs: 3000},
+				},
+			},
+		},
+		{
+			name: "subtitles", query: "?format=srt", audio: testWAV(pcm), fields: languages,
+			wantStatus: http.StatusOK, wantRecognize: true,
+			wantBody: "1\n00:00:00,500 --> 00:00:01,700\nHello\n\n2\n00:00:02,000 --> 00:00:03,000\nGoodbye\n\n",
+		},
+		{
+			name: "same language passes the transcript through", audio: testWAV(pcm),
+			fields:     map[string]string{"sourceLanguage": "ja-JP", "targetLanguage": "ja"},
+			wantStatus: http.StatusOK, wantRecognize: true,
+			wantResponse: &TranscribeTranslateResponse{
+				SourceLanguage: "ja-JP", TargetLanguage: "ja",
+				Transcript: "こんにちは さようなら", Translation: "こんにちは さようなら",
+				Segments: []TranscribeSegment{
+					{OriginalText: "こんにちは", TranslatedText: "こんにちは", StartMs: 500, EndMs: 1700},
+					{OriginalText: "さようなら", TranslatedText: "さようなら", StartMs: 2000, EndMs: 3000},
+				},
+			},
+		},
+		{name: "file too large", maxUpload: 1024, audio: testWAV(pcm), fields: languages, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "missing file", fields: languages, wantStatus: http.StatusBadRequest},
+		{name: "missing languages", audio: testWAV(pcm), fields: map[string]string{"sourceLanguage": "ja-JP"}, wantStatus: http.StatusBadRequest},
+		{name: "unsupported source language", audio: testWAV(pcm), fields: map[string]string{"sourceLanguage": "xx", "targetLanguage": "en"}, wantStatus: http.StatusBadRequest},
+		{name: "unsupported format", query: "?format=vtt", audio: testWAV(pcm), fields: languages, wantStatus: http.StatusBadRequest},
+		{name: "not a WAV file", audio: []byte("not audio"), fields: languages, wantStatus: http.StatusBadRequest},
+		{name: "recognition error", audio: testWAV(pcm), fields: languages, recognizeErr: errors.New("service unavailable"), wantStatus: http.StatusBadGateway, wantRecognize: true},
+	}
+
+	previousKey, previousRegion := speechSubscriptionKey, speechRegion
+	SetSpeechCredentials("test-key", "japaneast")
+	t.Cleanup(func() { SetSpeechCredentials(previousKey, previousRegion) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/transcribe-translate", TranscribeTranslateHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousMax := maxUploadSize
+			if tt.maxUpload > 0 {
+				SetMaxUploadSize(tt.maxUpload)
+			}
+			t.Cleanup(func() { maxUploadSize = previousMax })
+
+			recognized := false
+			useRecognizeFile(t, func(ctx context.Context, reader *gospeech.AudioFileReader, sourceLanguage, targetLanguage string) ([]*gospeech.TranslationRecognitionResult, error) {
+				recognized = true
+				if sourceLanguage != tt.fields["sourceLanguage"] || targetLanguage != tt.fields["targetLanguage"] {
+					t.Errorf("languages = (%s, %s), want (%s, %s)", sourceLanguage, targetLanguage, tt.fields["sourceLanguage"], tt.fields["targetLanguage"])
+				}
+				if tt.recognizeErr != nil {
+					return nil, tt.recognizeErr
+				}
+				return results, nil
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, transcribeRequest(t, tt.query, tt.audio, tt.fields))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if recognized != tt.wantRecognize {
+				t.Errorf("recognizer called = %v, want %v", recognized, tt.wantRecognize)
+			}
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), tt.wantBody)
+			}
+			if tt.wantResponse != nil {
+				var got TranscribeTranslateResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+					t.Fatalf("response is not JSON: %v", err)
+				}
+				if !reflect.DeepEqual(&got, tt.wantResponse) {
+					t.Errorf("response = %+v, want %+v", got, *tt.wantResponse)
+				}
+			}
+			if tt.wantStatus != http.StatusOK && !strings.Contains(recorder.Body.String(), `"error"`) {
+				t.Errorf("body = %s, want an error message", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestSRTTimestamp(t *testing.T) {
+	tests := []struct {
+		ms   int64
+		want string
+	}{
+		{ms: 0, want: "00:00:00,000"},
+		{ms: 1234, want: "00:00:01,234"},
+		{ms: 3723004, want: "01:02:03,004"},
+		{ms: -5, want: "00:00:00,000"},
+	}
+
+	for _, tt := range tests {
+		if got := srtTimestamp(tt.ms); got != tt.want {
+			t.Errorf("srtTimestamp(%d) = %q, want %q", tt.ms, got, tt.want)
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"go-realtime-translation-with-speech-service/backend/gospeech"
 	translatortext "go-realtime-translation-with-speech-service/backend/translatortext"
@@ -17,9 +21,6 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// translatorClient はアプリケーション全体で使用する翻訳クライアント
-var translatorClient *translatortext.TranslatorClient
-
 // speechSubscriptionKey はAzure Speech Serviceのサブスクリプションキー
 var speechSubscriptionKey string
 
@@ -32,16 +33,414 @@ func SetSpeechCredentials(subscriptionKey, region string) {
 	speechRegion = region
 }
 
+// newTranslationConfig はセッションごとの Speech Translation 設定を作成します
+// テストではローカルのサーバーに接続する設定に差し替えます
+var newTranslationConfig = func() (*gospeech.SpeechTranslationConfig, error) {
+	return gospeech.SpeechTranslationConfigFromSubscription(speechSubscriptionKey, speechRegion)
+}
+
+// errSpeechNotConfigured はSpeech Serviceの認証情報がセットされていない場合のエラー
+var errSpeechNotConfigured = errors.New("speech service not configured")
+
+// errSessionTenantMismatch は別のテナントが開始したセッションに接続しようとした場合のエラー
+var errSessionTenantMismatch = errors.New("session belongs to another tenant")
+
+// speechConfigured はSpeech Serviceの認証情報がセットされているかどうかを返します
+func speechConfigured() bool {
+	return speechSubscriptionKey != "" && speechRegion != ""
+}
+
+// maskedKey はログ出力用にサブスクリプションキーの先頭だけを残します
+func maskedKey(key string) string {
+	if len(key) <= 5 {
+		return "..."
+	}
+	return key[:5] + "..."
+}
+
+// minInterimLength は途中経過を送信するために必要な認識テキストの最小文字数
+var minInterimLength int
+
+// SetMinInterimLength は途中経過として送信する認識テキストの最小文字数をセットします
+// UIで1文字だけの途中経過がちらつくのを防ぐためのもので、0の場合はすべて送信します
+func SetMinInterimLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	minInterimLength = n
+}
+
+// forwardUntranslated は認識できたが翻訳結果がない確定結果を元のテキストのみで送信するかどうか
+var forwardUntranslated = true
+
+// SetForwardUntranslated は認識できたが翻訳結果がない確定結果をクライアントに送信するかどうかをセットします
+// 送信する場合、reason が RecognizedSpeech で translatedText が空のレスポンスになります
+func SetForwardUntranslated(forward bool) {
+	forwardUntranslated = forward
+}
+
+// sessionLogMaxLines, sessionLogMaxBytes はセッションごとのデバッグログの上限（0は無制限）
+var sessionLogMaxLines, sessionLogMaxBytes int
+
+// SetSessionLogLimit は音声認識セッションごとに出力するデバッグログの行数とバイト数の上限をセットします
+// 上限に達した後のログは抑制され、セッション終了時に抑制した件数がまとめて出力されます
+func SetSessionLogLimit(maxLines, maxBytes int) {
+	if maxLines < 0 {
+		maxLines = 0
+	}
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	sessionLogMaxLines = maxLines
+	sessionLogMaxBytes = maxBytes
+}
+
+// keepAliveInterval は無音が続いた場合に Speech Service へ無音フレームを送る間隔（0は送信しない）
+var keepAliveInterval time.Duration
+
+// SetKeepAliveInterval は音声が途切れている間、接続とターンを維持するために無音フレームを送る間隔をセットします
+// 無音フレームは音声のみで送信され、speech.config は再送しません
+func SetKeepAliveInterval(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	keepAliveInterval = d
+}
+
+// readTimeout は Speech Service から何も届かない状態がこれを超えた場合に接続が停止したとみなす時間（0は無効）
+var readTimeout time.Duration
+
+// SetReadTimeout は Speech Service との接続が応答しなくなったとみなすまでの時間をセットします
+// 超えた場合は再接続ポリシーに従って再接続します（無音の間もサービスからは何も届かないため、想定される最長の無音より長くします）
+func SetReadTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	readTimeout = d
+}
+
+// minResultDuration はこれより短い確定結果を破棄する長さ（0は無効）
+var minResultDuration time.Duration
+
+// SetMinResultDuration はノイズによる短い誤認識を除くため、これより短い確定結果を破棄するようセットします
+func SetMinResultDuration(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	minResultDuration = d
+}
+
+// maxTargetLanguages はストリーミングセッションで指定できる翻訳先言語の最大数
+var maxTargetLanguages = gospeech.DefaultMaxTargetLanguages
+
+// SetMaxTargetLanguages はストリーミングセッションで指定できる翻訳先言語の最大数をセットします（0以下の場合は既定値）
+func SetMaxTargetLanguages(n int) {
+	if n <= 0 {
+		n = gospeech.DefaultMaxTargetLanguages
+	}
+	maxTargetLanguages = n
+}
+
+// interimTranslations は途中経過の翻訳を Speech Service に要求するかどうか
+var interimTranslations bool
+
+// SetInterimTranslations は確定前の途中経過も翻訳して送信するかどうかをセットします
+func SetInterimTranslations(enabled bool) {
+	interimTranslations = enabled
+}
+
+// pcmAlignment はサンプルフレームの境界で終わらない音声チャンクの扱い
+var pcmAlignment = gospeech.PCMAlignmentNone
+
+// pcmAlignments は設定値と PCMAlignment の対応
+var pcmAlignments = map[string]gospeech.PCMAlignment{
+	"none":     gospeech.PCMAlignmentNone,
+	"pad":      gospeech.PCMAlignmentPad,
+	"truncate": gospeech.PCMAlignmentTruncate,
+	"reject":   gospeech.PCMAlignmentReject,
+}
+
+// SetPCMAlignment はサンプルフレームの境界で終わらない音声チャンクの扱い（none, pad, truncate, reject）をセットします
+// 奇数バイトなどのずれた PCM はそれ以降のサンプルをずらし、雑音として認識されるのを防ぎます
+func SetPCMAlignment(name string) error {
+	alignment, ok := pcmAlignments[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown PCM alignment %q: use none, pad, truncate or reject", name)
+	}
+	pcmAlignment = alignment
+	return nil
+}
+
+// passThroughSameLanguage は認識言語と翻訳先言語が同じ場合に翻訳を行わず認識結果をそのまま返すかどうか
+var passThroughSameLanguage = true
+
+// SetPassThroughSameLanguage は認識言語と翻訳先言語（地域を除く）が同じ場合に、
+// 翻訳を行わず認識テキストを翻訳結果として返すかどうかをセットします
+func SetPassThroughSameLanguage(enabled bool) {
+	passThroughSameLanguage = enabled
+}
+
+// isPassThrough は source と target が地域を除いて同じ言語で、翻訳が不要かどうかを返します
+func isPassThrough(source, target string) bool {
+	if !passThroughSameLanguage || source == "" || target == "" {
+		return false
+	}
+	sourceBase := strings.SplitN(source, "-", 2)[0]
+	targetBase := strings.SplitN(target, "-", 2)[0]
+	return strings.EqualFold(sourceBase, targetBase)
+}
+
 // セッション情報を保持する構造体
 type StreamingSession struct {
-	ID             string
-	SourceLanguage string
-	TargetLanguage string
-	AudioFormat    string
-	Recognizer     *gospeech.TranslationRecognizer
-	WSConnection   *websocket.Conn
-	Context        context.Context
-	CancelFunc     context.CancelFunc
+	ID              string
+	TenantID        string // セッションを開始したテナント（X-Tenant-ID ヘッダーまたは tenantId クエリ）
+	SourceLanguage  string
+	TargetLanguages []string
+	AudioFormat     string
+	Recognizer      *gospeech.TranslationRecognizer
+	PushStream      *gospeech.PushAudioInputStream
+	WSConnection    *websocket.Conn
+	Context         context.Context
+	CancelFunc      context.CancelFunc
+
+	// mu はセッション中に変更される設定（認識言語など）を保護します
+	mu sync.RWMutex
+
+	// audioWriter は受信した音声の書き込み先（未設定の場合は PushStream）
+	audioWriter func([]byte) (int, error)
+
+	// viewers は途中から参加したクライアントと、それらに送信する直近の確定結果の履歴
+	viewers *viewerSet
+}
+
+// writeAudio は受信した音声をセッションに書き込みます
+func (s *StreamingSession) writeAudio(data []byte) (int, error) {
+	if s.audioWriter != nil {
+		return s.audioWriter(data)
+	}
+	return s.PushStream.Write(data)
+}
+
+// currentSourceLanguage は現在の認識言語を返します
+func (s *StreamingSession) currentSourceLanguage() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.SourceLanguage
+}
+
+// setSourceLanguage は認識言語を変更します
+func (s *StreamingSession) setSourceLanguage(lang string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SourceLanguage = lang
+}
+
+// isPassThrough は現在の認識言語が翻訳先言語と同じで、認識テキストをそのまま返すかどうかを返します
+func (s *StreamingSession) isPassThrough(targetLanguage string) bool {
+	return isPassThrough(s.currentSourceLanguage(), targetLanguage)
+}
+
+// configureSession は初期設定の認識言語・翻訳先言語・合成音声を Speech Translation 設定に反映します
+// 合成音声を使用する場合は音声を合成する翻訳先言語を返します
+func configureSession(translationConfig *gospeech.SpeechTranslationConfig, setup StreamingTranslationRequest, primaryTarget string) (string, error) {
+	// 認識する言語の設定
+	log.Printf("Setting speech recognition language: %s", setup.SourceLanguage)
+	translationConfig.SetSpeechRecognitionLanguage(setup.SourceLanguage)
+
+	// 翻訳先言語の追加（認識言語と同じ場合は翻訳しない）
+	for _, targetLanguage := range setup.TargetLanguages {
+		if isPassThrough(setup.SourceLanguage, targetLanguage) {
+			log.Printf("Source and target languages match, passing recognized text through: %s", targetLanguage)
+			continue
+		}
+		log.Printf("Adding target language: %s", targetLanguage)
+		translationConfig.AddTargetLanguage(targetLanguage)
+	}
+	if !isPassThrough(setup.SourceLanguage, primaryTarget) {
+		if err := translationConfig.SetPrimaryTargetLanguage(primaryTarget); err != nil {
+			log.Printf("Failed to set primary target language: %v", err)
+		}
+	}
+
+	// 合成音声の設定
+	return setup.applyVoices(translationConfig)
+}
+
+// newSessionAudio はセッションの音声を書き込むプッシュストリームと、それを音声源とするオーディオ設定を作成します
+func newSessionAudio() (*gospeech.PushAudioInputStream, *gospeech.AudioConfig, error) {
+	pushStream := gospeech.NewPushAudioInputStream(gospeech.GetDefaultInputFormat())
+	pushStream.SetAlignment(pcmAlignment)
+	audioConfig, err := gospeech.NewAudioConfigFromPushStream(pushStream)
+	if err != nil {
+		return nil, nil, err
+	}
+	if audioConfig.Source() == nil {
+		return nil, nil, errors.New("audio source is nil")
+	}
+	return pushStream, audioConfig, nil
+}
+
+// newSessionRecognizer はサーバーの設定を適用した音声認識器を作成します
+func newSessionRecognizer(translationConfig *gospeech.SpeechTranslationConfig, audioConfig *gospeech.AudioConfig) (*gospeech.TranslationRecognizer, error) {
+	recognizer, err := gospeech.NewTranslationRecognizer(translationConfig, audioConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := recognizer.SetSessionLogLimit(sessionLogMaxLines, sessionLogMaxBytes); err != nil {
+		log.Printf("Failed to set session log limit: %v", err)
+	}
+	if err := recognizer.SetKeepAliveInterval(keepAliveInterval); err != nil {
+		log.Printf("Failed to set keepalive interval: %v", err)
+	}
+	if err := recognizer.SetMinResultDuration(minResultDuration); err != nil {
+		log.Printf("Failed to set minimum result duration: %v", err)
+	}
+	if err := recognizer.SetMaxTargetLanguages(maxTargetLanguages); err != nil {
+		log.Printf("Failed to set maximum number of target languages: %v", err)
+	}
+	recognizer.SetInterimTranslations(interimTranslations)
+	if err := recognizer.SetReadTimeout(readTimeout); err != nil {
+		log.Printf("Failed to set read timeout: %v", err)
+	}
+	return recognizer, nil
+}
+
+// finalResponses は確定結果から翻訳先言語ごとのレスポンスを作成します
+// 同じ発話のレスポンスは途中経過も含めて同じ SegmentID を持ちます
+func (s *StreamingSession) finalResponses(result *gospeech.TranslationRecognitionResult, segments *segmentTracker, normalize OutputNormalization) []StreamingTranslationResponse {
+	var responses []StreamingTranslationResponse
+	segmentID, revision := segments.next(true)
+	confidences := translationConfidences(result)
+	for _, targetLanguage := range s.TargetLanguages {
+		// 翻訳先言語ごとの最小信頼度に満たない確定結果は破棄する（または lowConfidence を付ける）
+		lowConfidence := false
+		if result.Reason == gospeech.ResultReasonTranslatedSpeech || result.Reason == gospeech.ResultReasonRecognizedSpeech {
+			lowConfidence = belowMinConfidence(targetLanguage, confidences, result.Confidence)
+			if lowConfidence && !flagLowConfidence {
+				log.Printf("Dropping final result below minimum confidence: targetLanguage=%s, text=%s", targetLanguage, result.Text)
+				continue
+			}
+		}
+
+		if s.isPassThrough(targetLanguage) && result.Text != "" &&
+			(result.Reason == gospeech.ResultReasonTranslatedSpeech || result.Reason == gospeech.ResultReasonRecognizedSpeech) {
+			// 認識言語と翻訳先言語が同じ場合は認識テキストをそのまま返す
+			response := StreamingTranslationResponse{
+				SourceLanguage: s.currentSourceLanguage(),
+				TargetLanguage: targetLanguage,
+				TranslatedText: normalize.apply(result.Text),
+				OriginalText:   result.Text,
+				IsFinal:        true,
+				SegmentID:      segmentID,
+				Revision:       revision,
+				Reason:         result.Reason.String(),
+				PassThrough:    true,
+				LowConfidence:  lowConfidence,
+			}
+
+			log.Printf("Sending pass-through result: %+v", response)
+			responses = append(responses, response)
+		} else if result.Reason == gospeech.ResultReasonTranslatedSpeech {
+			// 翻訳結果を取得
+			translatedText, exists := result.Translations[targetLanguage]
+			if !exists {
+				log.Printf("No translation result for specified language: targetLanguage=%s", targetLanguage)
+				continue
+			}
+
+			response := StreamingTranslationResponse{
+				SourceLanguage: s.currentSourceLanguage(),
+				TargetLanguage: targetLanguage,
+				TranslatedText: normalize.apply(translatedText),
+				OriginalText:   result.Text,
+				IsFinal:        true,
+				SegmentID:      segmentID,
+				Revision:       revision,
+				Reason:         result.Reason.String(),
+				Confidences:    confidences,
+				LowConfidence:  lowConfidence,
+			}
+
+			log.Printf("Sending final translation result: %+v", response)
+			responses = append(responses, response)
+		} else if result.Reason == gospeech.ResultReasonRecognizedSpeech {
+			if !forwardUntranslated {
+				log.Printf("Dropping recognized but untranslated result: text=%s", result.Text)
+				continue
+			}
+
+			// 認識はできたが翻訳されなかったことを通知し、元のテキストを送信する
+			response := StreamingTranslationResponse{
+				SourceLanguage: s.currentSourceLanguage(),
+				TargetLanguage: targetLanguage,
+				OriginalText:   result.Text,
+				IsFinal:        true,
+				SegmentID:      segmentID,
+				Revision:       revision,
+				Reason:         result.Reason.String(),
+			}
+
+			log.Printf("Sending untranslated recognition result: %+v", response)
+			responses = append(responses, response)
+		} else if result.Reason == gospeech.ResultReasonNoMatch {
+			// 音声を認識できなかったことを空の翻訳と区別できるよう通知する
+			response := StreamingTranslationResponse{
+				SourceLanguage: s.currentSourceLanguage(),
+				TargetLanguage: targetLanguage,
+				IsFinal:        true,
+				SegmentID:      segmentID,
+				Revision:       revision,
+				Reason:         result.Reason.String(),
+			}
+
+			log.Printf("Sending no-match result: %+v", response)
+			responses = append(responses, response)
+		}
+	}
+	return responses
+}
+
+// interimResponses は途中経過から翻訳先言語ごとのレスポンスを作成します（短すぎる途中経過は送信しない）
+func (s *StreamingSession) interimResponses(result *gospeech.TranslationRecognitionResult, segments *segmentTracker, normalize OutputNormalization) []StreamingTranslationResponse {
+	if result.Reason != gospeech.ResultReasonTranslatingSpeech {
+		return nil
+	}
+	if utf8.RuneCountInString(result.Text) < minInterimLength {
+		log.Printf("[DEBUG] Suppressing short interim result: length=%d, minimum=%d", utf8.RuneCountInString(result.Text), minInterimLength)
+		return nil
+	}
+
+	var responses []StreamingTranslationResponse
+	segmentID, revision := segments.next(false)
+	for _, targetLanguage := range s.TargetLanguages {
+		// 翻訳結果を取得（認識言語と同じ場合は認識テキストをそのまま使用）
+		passThrough := s.isPassThrough(targetLanguage)
+		translatedText, exists := result.Translations[targetLanguage]
+		if passThrough {
+			translatedText, exists = result.Text, true
+		}
+		if !exists {
+			log.Printf("No interim translation result for specified language: targetLanguage=%s", targetLanguage)
+			continue
+		}
+
+		response := StreamingTranslationResponse{
+			SourceLanguage: s.currentSourceLanguage(),
+			TargetLanguage: targetLanguage,
+			TranslatedText: normalize.apply(translatedText),
+			OriginalText:   result.Text,
+			IsFinal:        false,
+			SegmentID:      segmentID,
+			Revision:       revision,
+			Reason:         result.Reason.String(),
+			Confidences:    translationConfidences(result),
+			PassThrough:    passThrough,
+		}
+
+		log.Printf("Sending interim translation result: %+v", response)
+		responses = append(responses, response)
+	}
+	return responses
 }
 
 // WebSocketアップグレードの設定
@@ -60,31 +459,195 @@ var (
 )
 
 // SetTranslatorClient は翻訳クライアントをセットします
+// Azure Translator を既定の翻訳プロバイダーとして使用します
 func SetTranslatorClient(client *translatortext.TranslatorClient) {
-	translatorClient = client
+	if client == nil {
+		SetTranslationProvider(nil)
+		return
+	}
+	SetTranslationProvider(&azureTranslationProvider{client: client})
 }
 
 // TranslationRequest は翻訳リクエストの構造体
 type TranslationRequest struct {
 	Text           string `json:"text" binding:"required"`
 	TargetLanguage string `json:"targetLanguage" binding:"required"`
-	SourceLanguage string `json:"sourceLanguage"`
+	SourceLanguage string `json:"sourceLanguage"` // 空または "auto" の場合は自動検出
+
+	// Normalize は翻訳結果の整形（省略時は整形しない）
+	Normalize OutputNormalization `json:"normalize"`
+}
+
+// BatchTranslationRequest は複数テキストの翻訳リクエストの構造体
+type BatchTranslationRequest struct {
+	Texts          []string `json:"texts" binding:"required"`
+	TargetLanguage string   `json:"targetLanguage" binding:"required"`
+	SourceLanguage string   `json:"sourceLanguage"` // 空または "auto" の場合はテキストごとに自動検出
+
+	// Normalize は翻訳結果の整形（省略時は整形しない）
+	Normalize OutputNormalization `json:"normalize"`
+}
+
+// autoDetectLanguage は翻訳元言語の自動検出を指定する値
+const autoDetectLanguage = "auto"
+
+// sourceLanguageOrAuto は "auto" を空文字列（Translator による自動検出）に置き換えます
+func sourceLanguageOrAuto(language string) string {
+	if strings.EqualFold(strings.TrimSpace(language), autoDetectLanguage) {
+		return ""
+	}
+	return language
 }
 
+// maxBatchTexts は1回のバッチ翻訳リクエストで受け付けるテキストの最大数（Translator の上限）
+const maxBatchTexts = 100
+
 // TranslationResponse は翻訳レスポンスの構造体
 type TranslationResponse struct {
-	OriginalText   string  `json:"originalText"`
-	TranslatedText string  `json:"translatedText"`
-	SourceLanguage string  `json:"sourceLanguage"`
-	TargetLanguage string  `json:"targetLanguage"`
-	Confidence     float64 `json:"confidence,omitempty"`
+	OriginalText   string `json:"originalText"`
+	TranslatedText string `json:"translatedText"`
+	SourceLanguage string `json:"sourceLanguage"`
+	TargetLanguage string `json:"targetLanguage"`
+	// DetectionConfidence は翻訳元言語を自動検出した場合の検出の信頼度（0〜1）
+	// 翻訳の品質を表す値ではありません（Translator は翻訳の品質スコアを返しません）
+	DetectionConfidence float64 `json:"detectionConfidence,omitempty"`
+	// Confidence は DetectionConfidence と同じ値です
+	// Deprecated: 既存のクライアントとの互換性のため次のリリースまで残します。DetectionConfidence を使用してください
+	Confidence float64 `json:"confidence,omitempty"`
+	// Error はバッチ翻訳でこのテキストの翻訳に失敗した場合のエラー（成功した場合は空）
+	Error string `json:"error,omitempty"`
+}
+
+// LanguageList は言語コードのリスト
+// JSON では1つの言語の文字列、または文字列の配列を受け付けます
+type LanguageList []string
+
+// UnmarshalJSON は文字列または文字列の配列を言語コードのリストとして読み込みます
+func (l *LanguageList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = nil
+		if single != "" {
+			*l = LanguageList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("language must be a string or an array of strings: %v", err)
+	}
+	*l = make(LanguageList, 0, len(list))
+	for _, lang := range list {
+		if lang != "" {
+			*l = append(*l, lang)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON は1つの言語の場合は文字列、複数の場合は配列として書き出します（単一言語のクライアントとの互換性のため）
+func (l LanguageList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
 }
 
 // StreamingTranslationRequest はストリーミング翻訳開始リクエストの構造体
 type StreamingTranslationRequest struct {
-	SourceLanguage string `json:"sourceLanguage" binding:"required"`
-	TargetLanguage string `json:"targetLanguage" binding:"required"`
-	AudioFormat    string `json:"audioFormat" binding:"required"`
+	SourceLanguage  string       `json:"sourceLanguage" binding:"required"`
+	TargetLanguages LanguageList `json:"targetLanguage" binding:"required"` // 1つの言語または言語の配列
+	AudioFormat     string       `json:"audioFormat" binding:"required"`
+
+	// PrimaryTargetLanguage は単一の値が必要な項目に使用する翻訳先言語（省略時は最初の翻訳先言語）
+	PrimaryTargetLanguage string `json:"primaryTargetLanguage,omitempty"`
+
+	// Voices は翻訳先言語ごとの合成音声の名前（省略時は音声を合成しない）
+	Voices map[string]string `json:"voices,omitempty"`
+
+	// Normalize は翻訳結果の整形（省略時は整形しない）
+	Normalize OutputNormalization `json:"normalize"`
+}
+
+// checkTargetLanguageCount は翻訳先言語の数が上限を超えていないか検証します
+func (r *StreamingTranslationRequest) checkTargetLanguageCount() error {
+	if len(r.TargetLanguages) > maxTargetLanguages {
+		return fmt.Errorf("too many target languages: %d requested, at most %d", len(r.TargetLanguages), maxTargetLanguages)
+	}
+	return nil
+}
+
+// primaryTargetLanguage は主となる翻訳先言語を返します（指定された言語が翻訳先言語に含まれない場合はエラー）
+func (r *StreamingTranslationRequest) primaryTargetLanguage() (string, error) {
+	if len(r.TargetLanguages) == 0 {
+		return "", nil
+	}
+	if r.PrimaryTargetLanguage == "" {
+		return r.TargetLanguages[0], nil
+	}
+	for _, lang := range r.TargetLanguages {
+		if lang == r.PrimaryTargetLanguage {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("primaryTargetLanguage %s is not one of the target languages", r.PrimaryTargetLanguage)
+}
+
+// applyVoices は Voices を検証し、合成音声を config に設定します（合成する翻訳先言語を返します）
+// Speech Service は1つの接続で1つの言語のみ音声を合成するため、指定できる音声は1つまでです
+func (r *StreamingTranslationRequest) applyVoices(config *gospeech.SpeechTranslationConfig) (string, error) {
+	if len(r.Voices) == 0 {
+		return "", nil
+	}
+	if len(r.Voices) > 1 {
+		return "", fmt.Errorf("voices: only one target language can be synthesized per session, got %d", len(r.Voices))
+	}
+
+	for lang, name := range r.Voices {
+		known := false
+		for _, target := range r.TargetLanguages {
+			if target == lang {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("voices: %s is not one of the target languages", lang)
+		}
+		if isPassThrough(r.SourceLanguage, lang) {
+			return "", fmt.Errorf("voices: %s is the source language and is not translated", lang)
+		}
+
+		voice, ok := gospeech.LookupVoice(name)
+		if !ok {
+			// 一覧にない音声は "<ロケール>-<名前>" の形式からロケールを取得する
+			voice = gospeech.Voice{Name: name}
+			if parts := strings.SplitN(name, "-", 3); len(parts) == 3 {
+				voice.Locale = parts[0] + "-" + parts[1]
+			}
+		}
+		if voice.Language() != (gospeech.Voice{Locale: lang}).Language() {
+			return "", fmt.Errorf("voices: voice %s does not speak %s", name, lang)
+		}
+		if err := config.SetVoice(voice); err != nil {
+			return "", fmt.Errorf("voices: %v", err)
+		}
+		return lang, nil
+	}
+	return "", nil
+}
+
+// orderedTargetLanguages は主となる翻訳先言語を先頭にした翻訳先言語を返します（結果を決まった順序で送信するため）
+func (r *StreamingTranslationRequest) orderedTargetLanguages(primary string) []string {
+	ordered := make([]string, 0, len(r.TargetLanguages))
+	ordered = append(ordered, primary)
+	for _, lang := range r.TargetLanguages {
+		if lang != primary {
+			ordered = append(ordered, lang)
+		}
+	}
+	return ordered
 }
 
 // AudioChunkRequest は音声チャンクリクエストの構造体
@@ -101,6 +664,17 @@ type StreamingTranslationResponse struct {
 	OriginalText   string `json:"originalText"`
 	IsFinal        bool   `json:"isFinal"`
 	SegmentID      string `json:"segmentId"`
+	Revision       int    `json:"revision"` // 同じ SegmentID の中で1から増える更新番号（確定結果が最後）
+	Reason         string `json:"reason"`   // 結果の理由（TranslatedSpeech, NoMatch など）
+
+	// Confidences は翻訳先言語ごとの信頼度（言語別の値がない場合は認識の信頼度）
+	Confidences map[string]float64 `json:"confidences,omitempty"`
+
+	// PassThrough は認識言語と翻訳先言語が同じため、認識テキストをそのまま返したことを示します
+	PassThrough bool `json:"passThrough,omitempty"`
+
+	// LowConfidence は確定結果の信頼度が翻訳先言語の最小信頼度に満たないことを示します
+	LowConfidence bool `json:"lowConfidence,omitempty"`
 }
 
 // SessionCloseRequest はセッション終了リクエストの構造体
@@ -115,52 +689,167 @@ func TranslateHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.SourceLanguage = sourceLanguageOrAuto(req.SourceLanguage)
 
-	// ターゲット言語の設定
-	targetLanguages := []string{req.TargetLanguage}
+	// レスポンス形式（json, text, csv）
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 翻訳リクエストの作成
-	textParam := []*translatortext.TranslateTextInput{
-		{
-			Text: &req.Text,
-		},
+	// 翻訳サービスが設定されていない場合はパニックせずに 503 を返す
+	if translationProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errTranslationNotConfigured.Error()})
+		return
 	}
 
 	// 翻訳の実行
 	log.Printf("Translation request: %s", req.Text)
 	log.Printf("Target language: %s", req.TargetLanguage)
-	result, err := translatorClient.Translate(context.Background(), targetLanguages, textParam, nil)
+	release, err := acquireUpstream(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	output, err := translationProvider.Translate(c.Request.Context(), req.Text, req.SourceLanguage, req.TargetLanguage)
+	release()
+	if errors.Is(err, errNoTranslationResult) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to execute translation: %v", err)})
 		return
 	}
 
-	// レスポンスの作成
-	if len(result.TranslateResultAllItemArray) > 0 {
-		item := result.TranslateResultAllItemArray[0]
+	response := newTranslationResponse(req.Text, req.SourceLanguage, req.TargetLanguage, req.Normalize, output)
+	writeTranslationResponses(c, http.StatusOK, format, []TranslationResponse{response}, true)
+}
 
-		response := TranslationResponse{
-			OriginalText:   req.Text,
-			TargetLanguage: req.TargetLanguage,
+// TranslateBatchHandler は複数テキストの翻訳のハンドラー
+// 結果は入力と同じ順序で、テキストごとに検出された言語とともに返します
+func TranslateBatchHandler(c *gin.Context) {
+	var req BatchTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.SourceLanguage = sourceLanguageOrAuto(req.SourceLanguage)
+	if len(req.Texts) == 0 || len(req.Texts) > maxBatchTexts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("texts must contain between 1 and %d items", maxBatchTexts)})
+		return
+	}
+
+	// レスポンス形式（json, text, csv）
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 翻訳サービスが設定されていない場合はパニックせずに 503 を返す
+	if translationProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errTranslationNotConfigured.Error()})
+		return
+	}
+
+	log.Printf("Batch translation request: %d texts, targetLanguage=%s", len(req.Texts), req.TargetLanguage)
+	responses, itemErrs, err := translateBatch(c.Request.Context(), translationProvider, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to execute translation: %v", err)})
+		return
+	}
+
+	// 一部のテキストだけ失敗した場合は 207 で項目ごとの結果を返し、すべて失敗した場合はエラーを返す
+	var failed int
+	var firstErr error
+	for _, itemErr := range itemErrs {
+		if itemErr != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = itemErr
+			}
 		}
+	}
+	switch {
+	case failed == len(responses) && errors.Is(firstErr, errUpstreamBusy):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": firstErr.Error()})
+	case failed == len(responses):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to execute translation: %v", firstErr)})
+	case failed > 0:
+		log.Printf("Batch translation partially failed: %d of %d texts", failed, len(responses))
+		writeTranslationResponses(c, http.StatusMultiStatus, format, responses, false)
+	default:
+		writeTranslationResponses(c, http.StatusOK, format, responses, false)
+	}
+}
 
-		// 検出された言語情報
-		if item.DetectedLanguage != nil {
-			response.SourceLanguage = *item.DetectedLanguage.Language
-			response.Confidence = *item.DetectedLanguage.Score
-		} else if req.SourceLanguage != "" {
-			response.SourceLanguage = req.SourceLanguage
+// translateBatch は req.Texts を翻訳し、入力と同じ順序でレスポンスとテキストごとのエラーを返します
+// 失敗したテキストのレスポンスには Error を設定し、ctx がキャンセルされた場合だけ err を返します
+// プロバイダーが BatchTranslationProvider を実装している場合は1回のリクエストで翻訳し、
+// そのリクエストが失敗（または期限切れ）した場合はテキストごとに翻訳し直します
+func translateBatch(ctx context.Context, provider TranslationProvider, req BatchTranslationRequest) ([]TranslationResponse, []error, error) {
+	var results []batchItemResult
+	if batch, ok := provider.(BatchTranslationProvider); ok {
+		outputs, err := translateAll(ctx, batch, req.Texts, req.SourceLanguage, req.TargetLanguage)
+		if err == nil {
+			results = make([]batchItemResult, len(outputs))
+			for i, output := range outputs {
+				results[i] = batchItemResult{Output: output}
+				if output == nil {
+					results[i] = batchItemResult{Err: errNoTranslationResult}
+				}
+			}
+		} else if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		} else {
+			log.Printf("Batch translation request failed, translating texts one by one: %v", err)
+		}
+	}
+	if results == nil {
+		items := make([]batchItem, len(req.Texts))
+		for i, text := range req.Texts {
+			items[i] = batchItem{Text: text, SourceLanguage: req.SourceLanguage, TargetLanguage: req.TargetLanguage}
 		}
+		var err error
+		results, err = translateItems(ctx, provider, items)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-		// 翻訳テキスト
-		if len(item.Translations) > 0 {
-			response.TranslatedText = *item.Translations[0].Text
+	responses := make([]TranslationResponse, len(req.Texts))
+	itemErrs := make([]error, len(req.Texts))
+	for i, text := range req.Texts {
+		if err := results[i].Err; err != nil {
+			itemErrs[i] = err
+			responses[i] = TranslationResponse{OriginalText: text, SourceLanguage: req.SourceLanguage, TargetLanguage: req.TargetLanguage, Error: err.Error()}
+			continue
 		}
+		responses[i] = newTranslationResponse(text, req.SourceLanguage, req.TargetLanguage, req.Normalize, results[i].Output)
+	}
+	return responses, itemErrs, nil
+}
+
+// newTranslationResponse は翻訳結果からレスポンスを作成します
+// 言語が検出された場合はその言語と検出の信頼度を、そうでなければ指定された翻訳元言語を設定します
+func newTranslationResponse(text, sourceLanguage, targetLanguage string, normalize OutputNormalization, output *TranslationOutput) TranslationResponse {
+	response := TranslationResponse{
+		OriginalText:   text,
+		TranslatedText: normalize.apply(output.TranslatedText),
+		TargetLanguage: targetLanguage,
+	}
 
-		c.JSON(http.StatusOK, response)
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "翻訳結果がありません"})
+	// 検出された言語情報
+	if output.DetectedLanguage != "" {
+		response.SourceLanguage = output.DetectedLanguage
+		response.DetectionConfidence = output.DetectedScore
+		response.Confidence = output.DetectedScore
+	} else if sourceLanguage != "" {
+		response.SourceLanguage = sourceLanguage
 	}
+	return response
 }
 
 // HealthCheckHandler はヘルスチェックのハンドラー
@@ -170,23 +859,139 @@ func HealthCheckHandler(c *gin.Context) {
 	})
 }
 
+// requestTenantID はリクエストのテナントの識別子を返します
+// ブラウザは WebSocket や EventSource にヘッダーを付けられないため、X-Tenant-ID ヘッダーがない場合は tenantId クエリを使用します
+func requestTenantID(c *gin.Context) string {
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		return tenantID
+	}
+	return c.Query("tenantId")
+}
+
 // StartStreamingSessionHandler はストリーミング翻訳セッションを開始するハンドラー
+// 音声認識器を作成してセッションを登録し、ProcessAudioChunkHandler で送信された音声の結果を
+// SSE または WebSocket の閲覧者に配信します。セッションは CloseStreamingSessionHandler で終了します
 func StartStreamingSessionHandler(c *gin.Context) {
 	var req StreamingTranslationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := req.checkTargetLanguageCount(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	primary, err := req.primaryTargetLanguage()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !speechConfigured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSpeechNotConfigured.Error()})
+		return
+	}
 
 	// 新しいセッションIDを生成
 	sessionID := uuid.New().String()
 
-	// WebSocketへのアップグレードを待機するエンドポイントのURLを返す
+	translationConfig, err := newTranslationConfig()
+	if err != nil {
+		log.Printf("Failed to create Speech Translation config: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Speech Translation config"})
+		return
+	}
+	// 音声を返す接続がないため、合成音声は使用しない
+	if _, err := configureSession(translationConfig, req, primary); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pushStream, audioConfig, err := newSessionAudio()
+	if err != nil {
+		log.Printf("Failed to create audio configuration: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create audio configuration"})
+		return
+	}
+	recognizer, err := newSessionRecognizer(translationConfig, audioConfig)
+	if err != nil {
+		// 認識器の作成は翻訳先言語などの設定を検証するため、リクエストの誤りとして返す
+		log.Printf("Failed to create speech recognizer: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	viewers := newViewerSet(transcriptHistorySize)
+	session := &StreamingSession{
+		ID:              sessionID,
+		TenantID:        requestTenantID(c),
+		SourceLanguage:  req.SourceLanguage,
+		TargetLanguages: req.orderedTargetLanguages(primary),
+		AudioFormat:     req.AudioFormat,
+		Recognizer:      recognizer,
+		PushStream:      pushStream,
+		Context:         ctx,
+		CancelFunc:      cancel,
+		viewers:         viewers,
+	}
+
+	// 結果は閲覧者（SSE と WebSocket）にだけ配信する
+	segments := &segmentTracker{}
+	recognizer.Recognized().Connect(func(eventArgs interface{}) {
+		if args, ok := eventArgs.(*gospeech.TranslationRecognitionEventArgs); ok {
+			for _, response := range session.finalResponses(args.Result, segments, req.Normalize) {
+				viewers.publishFinal(response)
+			}
+		}
+	})
+	recognizer.Recognizing().Connect(func(eventArgs interface{}) {
+		if args, ok := eventArgs.(*gospeech.TranslationRecognitionEventArgs); ok {
+			for _, response := range session.interimResponses(args.Result, segments, req.Normalize) {
+				viewers.publishPartial(response)
+			}
+		}
+	})
+
+	activeSessionsMutex.Lock()
+	activeSessions[sessionID] = session
+	activeSessionsMutex.Unlock()
+	unregister := func() {
+		activeSessionsMutex.Lock()
+		if activeSessions[sessionID] == session {
+			delete(activeSessions, sessionID)
+		}
+		activeSessionsMutex.Unlock()
+	}
+
+	if err := recognizer.StartContinuousRecognition(ctx); err != nil {
+		log.Printf("Failed to start continuous recognition: %v", err)
+		unregister()
+		cancel()
+		recognizer.Close()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start continuous recognition"})
+		return
+	}
+	log.Printf("Started streaming session: sessionID=%s", sessionID)
+
+	// セッションが終了（キャンセル）されたら認識を停止し、閲覧者の接続を閉じる
+	go func() {
+		<-ctx.Done()
+		unregister()
+		if err := recognizer.Close(); err != nil {
+			log.Printf("Failed to clean up recognizer: %v", err)
+		}
+		viewers.closeAll()
+		log.Printf("Session %s terminated", sessionID)
+	}()
+
 	c.JSON(http.StatusOK, gin.H{
-		"sessionId":      sessionID,
-		"webSocketURL":   fmt.Sprintf("/api/v1/streaming/ws/%s", sessionID),
-		"sourceLanguage": req.SourceLanguage,
-		"targetLanguage": req.TargetLanguage,
+		"sessionId":             sessionID,
+		"webSocketURL":          fmt.Sprintf("/api/v1/streaming/ws/%s", sessionID),
+		"sseURL":                fmt.Sprintf("/api/v1/streaming/sse/%s", sessionID),
+		"sourceLanguage":        req.SourceLanguage,
+		"targetLanguage":        req.TargetLanguages,
+		"primaryTargetLanguage": primary,
 	})
 }
 
@@ -200,7 +1005,7 @@ func ProcessAudioChunkHandler(c *gin.Context) {
 
 	// セッションの存在確認
 	activeSessionsMutex.RLock()
-	_, exists := activeSessions[req.SessionID]
+	session, exists := activeSessions[req.SessionID]
 	activeSessionsMutex.RUnlock()
 
 	if !exists {
@@ -209,16 +1014,61 @@ func ProcessAudioChunkHandler(c *gin.Context) {
 	}
 
 	// Base64エンコードされた音声データをデコード
-	_, err := base64.StdEncoding.DecodeString(req.AudioChunk)
+	audioData, err := decodeAudioChunk(req.AudioChunk)
 	if err != nil {
+		log.Printf("Failed to Base64 decode audio chunk: sessionID=%s, error=%v", req.SessionID, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "音声データのデコードに失敗しました"})
 		return
 	}
 
-	// このエンドポイントは主にRESTfulなアプローチの場合に使用されます
-	// WebSocketを使用する場合は、WebSocketハンドラー内で音声処理を行います
-	// ここでは、シンプルなレスポンスを返します
-	c.JSON(http.StatusOK, gin.H{"status": "音声チャンクを受信しました"})
+	if session.PushStream == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "セッションの音声ストリームが準備できていません"})
+		return
+	}
+
+	// セッションの音声ストリームへ書き込む
+	bytesWritten, err := session.writeAudio(audioData)
+	if errors.Is(err, gospeech.ErrMisalignedAudio) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to write audio chunk: sessionID=%s, error=%v", req.SessionID, err)
+		c.JSON(http.StatusGone, gin.H{"error": "セッションの音声ストリームは終了しています"})
+		return
+	}
+	log.Printf("[DEBUG] Wrote audio chunk to PushAudioInputStream: sessionID=%s, written=%d bytes", req.SessionID, bytesWritten)
+
+	c.JSON(http.StatusOK, gin.H{"status": "音声チャンクを受信しました", "bytesWritten": bytesWritten})
+}
+
+// translationConfidences は翻訳先言語ごとの信頼度を返します
+// サービスが言語別の値を返さない言語には認識の信頼度を使用し、どちらもない場合は nil を返します
+func translationConfidences(result *gospeech.TranslationRecognitionResult) map[string]float64 {
+	if len(result.TranslationConfidences) == 0 && result.Confidence == 0 {
+		return nil
+	}
+
+	confidences := make(map[string]float64, len(result.Translations))
+	for lang := range result.Translations {
+		if confidence, ok := result.TranslationConfidences[lang]; ok {
+			confidences[lang] = confidence
+		} else if result.Confidence > 0 {
+			confidences[lang] = result.Confidence
+		}
+	}
+	return confidences
+}
+
+// decodeAudioChunk は標準およびURLセーフなBase64（パディングの有無を問わない）をデコードします
+// ブラウザによってはURLセーフなBase64やパディングなしの文字列を送信するため
+func decodeAudioChunk(encoded string) ([]byte, error) {
+	// 改行などの空白を除去し、URLセーフな文字を標準の文字に揃える
+	encoded = strings.Join(strings.Fields(encoded), "")
+	encoded = strings.NewReplacer("-", "+", "_", "/").Replace(encoded)
+	encoded = strings.TrimRight(encoded, "=")
+
+	return base64.RawStdEncoding.DecodeString(encoded)
 }
 
 // WebSocketHandler はWebSocket接続を処理するハンドラー
@@ -226,6 +1076,24 @@ func WebSocketHandler(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	log.Printf("WebSocket connection started: sessionID=%s", sessionID)
 
+	tenantID := requestTenantID(c)
+
+	// Speech Service が設定されていない場合はアップグレードせずに 503 を返す
+	if !speechConfigured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSpeechNotConfigured.Error()})
+		return
+	}
+
+	// 別のテナントのセッションにはアップグレードせずに 403 を返す
+	activeSessionsMutex.RLock()
+	existing, exists := activeSessions[sessionID]
+	activeSessionsMutex.RUnlock()
+	if exists && existing.TenantID != tenantID {
+		log.Printf("Rejected viewer from another tenant: sessionID=%s, tenantID=%s", sessionID, tenantID)
+		c.JSON(http.StatusForbidden, gin.H{"error": errSessionTenantMismatch.Error()})
+		return
+	}
+
 	// WebSocketにアップグレード
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -233,30 +1101,85 @@ func WebSocketHandler(c *gin.Context) {
 		return
 	}
 
+	// 途中から参加するクライアント（閲覧者）と確定結果の履歴
+	viewers := newViewerSet(transcriptHistorySize)
+
+	// 初期設定を受信する前にセッションIDを予約し、同じIDで同時に接続したクライアントが別のセッションを作らないようにする
+	// 予約中のセッションには音声ストリームがないため、音声チャンクは受け付けず、閲覧者だけが参加できる
+	reserved := &StreamingSession{ID: sessionID, TenantID: tenantID, WSConnection: conn, viewers: viewers}
+	activeSessionsMutex.Lock()
+	existing, exists = activeSessions[sessionID]
+	if !exists {
+		activeSessions[sessionID] = reserved
+	}
+	activeSessionsMutex.Unlock()
+
+	// 既存のセッションへの接続は閲覧者として参加させる
+	if exists {
+		if existing.TenantID != tenantID {
+			// アップグレードの直前に別のテナントがセッションを作成した場合
+			log.Printf("Rejected viewer from another tenant: sessionID=%s, tenantID=%s", sessionID, tenantID)
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, errSessionTenantMismatch.Error()), time.Now().Add(writeTimeout))
+			conn.Close()
+			return
+		}
+		joinSessionAsViewer(existing, conn)
+		return
+	}
+
+	// クライアントへの書き込みはすべてwriterを経由する
+	writer := newSessionWriter(conn, maxMessageRate, writeTimeout, maxBufferedFrames)
+
 	// バックグラウンドでのキャンセルを防ぐため、背景コンテキストを使用
 	ctx := context.Background()
 	// 明示的なキャンセルのためのキャンセル関数を作成
 	ctx, cancel := context.WithCancel(ctx)
 
+	// 途中経過をまとめて送信する（認識の設定後に作成する）
+	var partials *partialCoalescer
+	// ストリーミング接続が続けて失敗した場合のバッチ処理（セッションの作成後に作成する）
+	var fallback *batchFallback
+
+	// このセッション（または予約）を削除する（同じセッションIDで再接続できるようになる）
+	var session *StreamingSession
+	unregister := func() {
+		activeSessionsMutex.Lock()
+		if current := activeSessions[sessionID]; current == reserved || (session != nil && current == session) {
+			delete(activeSessions, sessionID)
+		}
+		activeSessionsMutex.Unlock()
+	}
+	// 初期設定の途中で終了した場合も予約を解除する
+	defer unregister()
+
 	// クリーンアップ関数
 	cleanup := func() {
 		cancel() // コンテキストをキャンセル
+		if partials != nil {
+			partials.stop()
+		}
+		if fallback != nil {
+			fallback.stop()
+		}
 
 		// セッションを削除
-		activeSessionsMutex.Lock()
-		delete(activeSessions, sessionID)
-		activeSessionsMutex.Unlock()
+		unregister()
 
-		// WebSocket接続を閉じる
+		// 閲覧者の接続を閉じる
+		viewers.closeAll()
+
+		// 残りのメッセージを送信してからWebSocket接続を閉じる
+		writer.close()
 		conn.Close()
 		log.Printf("Session %s terminated", sessionID)
 	}
 
 	// Speech Translation設定
-	log.Printf("Creating Speech Translation config: key=%s, region=%s", speechSubscriptionKey[:5]+"...", speechRegion)
-	translationConfig, err := gospeech.SpeechTranslationConfigFromSubscription(speechSubscriptionKey, speechRegion)
+	log.Printf("Creating Speech Translation config: key=%s, region=%s", maskedKey(speechSubscriptionKey), speechRegion)
+	translationConfig, err := newTranslationConfig()
 	if err != nil {
 		log.Printf("Failed to create Speech Translation config: %v", err)
+		writer.close()
 		conn.Close()
 		return
 	}
@@ -264,15 +1187,10 @@ func WebSocketHandler(c *gin.Context) {
 
 	// オーディオ設定（カスタムストリーム）
 	log.Printf("Creating audio configuration")
-	pushStream := gospeech.NewPushAudioInputStream(gospeech.GetDefaultInputFormat())
-	audioConfig, err := gospeech.NewAudioConfigFromPushStream(pushStream)
+	pushStream, audioConfig, err := newSessionAudio()
 	if err != nil {
 		log.Printf("Failed to create audio configuration: %v", err)
-		conn.Close()
-		return
-	}
-	if audioConfig.Source() == nil {
-		log.Printf("Audio source is nil")
+		writer.close()
 		conn.Close()
 		return
 	}
@@ -282,49 +1200,104 @@ func WebSocketHandler(c *gin.Context) {
 	var setupMsg StreamingTranslationRequest
 	if err := conn.ReadJSON(&setupMsg); err != nil {
 		log.Printf("Failed to read initial setup message: %v", err)
+		writer.close()
+		conn.Close()
+		return
+	}
+	log.Printf("Received initial setup from client: sourceLanguage=%s, targetLanguages=%v", setupMsg.SourceLanguage, setupMsg.TargetLanguages)
+	if len(setupMsg.TargetLanguages) == 0 {
+		log.Printf("No target language in setup message")
+		writer.send(gin.H{"error": "targetLanguage is required"})
+		writer.close()
+		conn.Close()
+		return
+	}
+	if err := setupMsg.checkTargetLanguageCount(); err != nil {
+		log.Printf("Too many target languages in setup message: %v", err)
+		writer.send(gin.H{"error": err.Error()})
+		writer.close()
+		conn.Close()
+		return
+	}
+	primaryTarget, err := setupMsg.primaryTargetLanguage()
+	if err != nil {
+		log.Printf("Invalid primary target language in setup message: %v", err)
+		writer.send(gin.H{"error": err.Error()})
+		writer.close()
 		conn.Close()
 		return
 	}
-	log.Printf("Received initial setup from client: sourceLanguage=%s, targetLanguage=%s", setupMsg.SourceLanguage, setupMsg.TargetLanguage)
-
-	// 認識する言語の設定
-	log.Printf("Setting speech recognition language: %s", setupMsg.SourceLanguage)
-	translationConfig.SetSpeechRecognitionLanguage(setupMsg.SourceLanguage)
 
-	// 翻訳先言語の追加
-	log.Printf("Adding target language: %s", setupMsg.TargetLanguage)
-	translationConfig.AddTargetLanguage(setupMsg.TargetLanguage)
+	// 認識言語・翻訳先言語・合成音声の設定
+	synthesisLanguage, err := configureSession(translationConfig, setupMsg, primaryTarget)
+	if err != nil {
+		log.Printf("Invalid voices in setup message: %v", err)
+		writer.send(gin.H{"error": err.Error()})
+		writer.close()
+		conn.Close()
+		return
+	}
+	if synthesisLanguage != "" {
+		log.Printf("Synthesizing %s with voice %s", synthesisLanguage, translationConfig.GetVoiceName())
+	}
 
 	// 音声認識器の作成
 	log.Printf("Creating TranslationRecognizer")
-	recognizer, err := gospeech.NewTranslationRecognizer(translationConfig, audioConfig)
+	recognizer, err := newSessionRecognizer(translationConfig, audioConfig)
 	if err != nil {
 		log.Printf("Failed to create speech recognizer: %v", err)
+		writer.close()
 		conn.Close()
 		return
 	}
 	log.Printf("TranslationRecognizer created successfully")
 
 	// セッション情報を保存
-	session := &StreamingSession{
-		ID:             sessionID,
-		SourceLanguage: setupMsg.SourceLanguage,
-		TargetLanguage: setupMsg.TargetLanguage,
-		AudioFormat:    setupMsg.AudioFormat,
-		Recognizer:     recognizer,
-		WSConnection:   conn,
-		Context:        ctx,
-		CancelFunc:     cancel,
+	session = &StreamingSession{
+		ID:              sessionID,
+		TenantID:        tenantID,
+		SourceLanguage:  setupMsg.SourceLanguage,
+		TargetLanguages: setupMsg.orderedTargetLanguages(primaryTarget),
+		AudioFormat:     setupMsg.AudioFormat,
+		Recognizer:      recognizer,
+		PushStream:      pushStream,
+		WSConnection:    conn,
+		Context:         ctx,
+		CancelFunc:      cancel,
+		viewers:         viewers,
+	}
+
+	// ストリーミング接続が続けて失敗した場合のバッチ処理への切り替え（オプション）
+	fallback = newBatchFallback(pushStream.Format().BytesPerSecond(), streamingFallbackAfter, func(audio []byte) {
+		translateBatchSegment(ctx, translationConfig, pushStream.Format(), session.currentSourceLanguage(), session.TargetLanguages, setupMsg.Normalize, audio, writer, viewers)
+	})
+	session.audioWriter = func(data []byte) (int, error) {
+		if fallback.isActive() {
+			fallback.enqueue(fallback.add(data))
+			return len(data), nil
+		}
+		return pushStream.Write(data)
 	}
 
-	// セッションの保存
+	// 字幕表示向けに途中経過を一定間隔でまとめて送信する（オプション）
+	partials = newPartialCoalescer(subtitleCoalesceWindow, func(response StreamingTranslationResponse) {
+		writer.sendPartial(response.TargetLanguage, response)
+		viewers.publishPartial(response)
+	})
+
+	// 予約したセッションを設定済みのセッションに置き換える（設定の受信中に終了された場合は登録しない）
 	activeSessionsMutex.Lock()
-	activeSessions[sessionID] = session
+	if activeSessions[sessionID] == reserved {
+		activeSessions[sessionID] = session
+	}
 	activeSessionsMutex.Unlock()
 
 	// クライアントに準備完了を通知
 	log.Printf("Notifying client of ready status: sessionID=%s", sessionID)
-	conn.WriteJSON(gin.H{"status": "ready", "sessionId": sessionID})
+	writer.send(gin.H{"status": "ready", "sessionId": sessionID, "targetLanguage": primaryTarget})
+
+	// 発話ごとの SegmentID と更新番号
+	segments := &segmentTracker{}
 
 	// 認識結果のイベントハンドラーの設定
 	recognizer.Recognized().Connect(func(eventArgs interface{}) {
@@ -334,30 +1307,56 @@ func WebSocketHandler(c *gin.Context) {
 			return
 		}
 
-		result := args.Result
-		if result.Reason == gospeech.ResultReasonTranslatedSpeech {
-			// 翻訳結果を取得
-			translatedText, exists := result.Translations[setupMsg.TargetLanguage]
-			if !exists {
-				log.Printf("No translation result for specified language: targetLanguage=%s", setupMsg.TargetLanguage)
+		// 確定結果の後に古い途中経過が送信されないよう破棄する
+		partials.discard()
+
+		for _, response := range session.finalResponses(args.Result, segments, setupMsg.Normalize) {
+			writer.send(response)
+			viewers.publishFinal(response)
+		}
+	})
+
+	// キャンセルイベントのハンドラー（バッチ処理への切り替えとエラーの通知）
+	// 一時的な切断からの再接続は認識器が行うため、ここでは再接続せず、諦めた場合の結果だけを扱う
+	recognizer.Canceled().Connect(func(eventArgs interface{}) {
+		args, ok := eventArgs.(*gospeech.TranslationRecognitionCanceledEventArgs)
+		if !ok || args.CancellationDetails == nil || args.CancellationDetails.Reason != gospeech.CancellationReasonError {
+			return
+		}
+
+		if fallback.threshold > 0 && args.CancellationDetails.ErrorCode == gospeech.CancellationErrorConnectionFailure {
+			if fallback.recordFailure() {
+				log.Printf("Streaming failed %d times, switching to batch mode: sessionID=%s", fallback.threshold, sessionID)
+				writer.send(gin.H{"type": "fallback", "mode": "batch"})
 				return
 			}
-
-			// WebSocketを通じて結果を送信
-			response := StreamingTranslationResponse{
-				SourceLanguage: setupMsg.SourceLanguage,
-				TargetLanguage: setupMsg.TargetLanguage,
-				TranslatedText: translatedText,
-				OriginalText:   result.Text,
-				IsFinal:        true,
-				SegmentID:      uuid.New().String(),
+			if fallback.isActive() {
+				return
 			}
 
-			log.Printf("Sending final translation result: %+v", response)
-			if err := conn.WriteJSON(response); err != nil {
-				log.Printf("Failed to write to WebSocket: %v", err)
-			}
+			// 切り替えるまではストリーミングでの接続をやり直し、続けて失敗した回数を数える
+			log.Printf("Streaming connection failed, retrying: sessionID=%s, error=%s", sessionID, args.CancellationDetails.ErrorDetails)
+			go func() {
+				if err := recognizer.Reconnect(ctx); err != nil {
+					log.Printf("Failed to restart continuous recognition: %v", err)
+				}
+			}()
+			return
+		}
+
+		log.Printf("Continuous recognition canceled: sessionID=%s, errorCode=%s, error=%s",
+			sessionID, args.CancellationDetails.ErrorCode, args.CancellationDetails.ErrorDetails)
+		writer.send(gin.H{"type": "error", "errorCode": args.CancellationDetails.ErrorCode.String(), "error": args.CancellationDetails.ErrorDetails})
+	})
+
+	// 合成音声のハンドラー（音声は JSON では Base64 で送信される）
+	recognizer.Synthesizing().Connect(func(eventArgs interface{}) {
+		args, ok := eventArgs.(*gospeech.TranslationSynthesisEventArgs)
+		if !ok || args.Result == nil || len(args.Result.Audio) == 0 {
+			return
 		}
+		log.Printf("[DEBUG] Sending synthesized audio: targetLanguage=%s, %d bytes", synthesisLanguage, len(args.Result.Audio))
+		writer.send(gin.H{"type": "synthesis", "targetLanguage": synthesisLanguage, "voice": translationConfig.GetVoiceName(), "audio": args.Result.Audio})
 	})
 
 	// 認識中イベントのハンドラー（途中経過）
@@ -368,29 +1367,8 @@ func WebSocketHandler(c *gin.Context) {
 			return
 		}
 
-		result := args.Result
-		if result.Reason == gospeech.ResultReasonTranslatedSpeech {
-			// 翻訳結果を取得
-			translatedText, exists := result.Translations[setupMsg.TargetLanguage]
-			if !exists {
-				log.Printf("No interim translation result for specified language: targetLanguage=%s", setupMsg.TargetLanguage)
-				return
-			}
-
-			// WebSocketを通じて途中経過を送信
-			response := StreamingTranslationResponse{
-				SourceLanguage: setupMsg.SourceLanguage,
-				TargetLanguage: setupMsg.TargetLanguage,
-				TranslatedText: translatedText,
-				OriginalText:   result.Text,
-				IsFinal:        false,
-				SegmentID:      uuid.New().String(),
-			}
-
-			log.Printf("Sending interim translation result: %+v", response)
-			if err := conn.WriteJSON(response); err != nil {
-				log.Printf("Failed to write to WebSocket: %v", err)
-			}
+		for _, response := range session.interimResponses(args.Result, segments, setupMsg.Normalize) {
+			partials.add(response)
 		}
 	})
 
@@ -400,7 +1378,8 @@ func WebSocketHandler(c *gin.Context) {
 	log.Printf("[DEBUG] Audio source info: SourceType=%s", audioConfig.SourceType())
 	if err := recognizer.StartContinuousRecognition(ctx); err != nil {
 		log.Printf("Failed to start continuous recognition: %v", err)
-		conn.WriteJSON(gin.H{"error": "Failed to start continuous recognition"})
+		writer.send(gin.H{"error": "Failed to start continuous recognition"})
+		writer.close()
 		conn.Close()
 		return
 	}
@@ -414,7 +1393,11 @@ func WebSocketHandler(c *gin.Context) {
 			// クライアントが切断した場合など
 			log.Printf("WebSocket read error: %v", err)
 
-			// 連続認識を停止
+			// 連続認識の停止は認識器の終了を待つため、先にセッションを削除しておく
+			unregister()
+
+			// 連続認識を停止（結果を受け取るクライアントがいないため、残りの結果は待たない）
+			cancel()
 			if err := recognizer.StopContinuousRecognition(); err != nil {
 				log.Printf("Failed to stop continuous recognition: %v", err)
 			}
@@ -434,15 +1417,9 @@ func WebSocketHandler(c *gin.Context) {
 
 		// バイナリメッセージ（音声データ）の処理
 		if messageType == websocket.BinaryMessage {
-			pushStream, ok := audioConfig.Source().(*gospeech.PushAudioInputStream)
-			if !ok {
-				log.Printf("Audio source is not a PushAudioInputStream: %T", audioConfig.Source())
-				continue
-			}
-
 			// 音声データを書き込む
 			if len(message) > 0 {
-				bytesWritten, err := pushStream.Write(message)
+				bytesWritten, err := session.writeAudio(message)
 				if err != nil {
 					log.Printf("Failed to write audio data: %v", err)
 					continue
@@ -470,14 +1447,56 @@ func WebSocketHandler(c *gin.Context) {
 					"type":   "init_response",
 					"status": "ready",
 				}
-				if err := conn.WriteJSON(initResponse); err != nil {
-					log.Printf("Failed to send initialization response: %v", err)
+				writer.send(initResponse)
+
+			case "setLanguage":
+				// 認識言語の変更（誤検出の訂正など）
+				newSource, _ := jsonMsg["source"].(string)
+				log.Printf("Received source language change request: sessionID=%s, source=%s", sessionID, newSource)
+				if !gospeech.IsSupportedSourceLanguage(newSource) {
+					writer.send(gin.H{"type": "setLanguage_response", "status": "error", "error": fmt.Sprintf("unsupported source language: %s", newSource)})
+					continue
+				}
+
+				// 新しい言語で再接続する（セッションは継続する）
+				translationConfig.SetSpeechRecognitionLanguage(newSource)
+				session.setSourceLanguage(newSource)
+				// 翻訳先言語と同じ言語になった（または異なる言語になった）場合は翻訳の要否を切り替える
+				for _, targetLanguage := range session.TargetLanguages {
+					if session.isPassThrough(targetLanguage) {
+						recognizer.RemoveTargetLanguage(targetLanguage)
+					} else {
+						recognizer.AddTargetLanguage(targetLanguage)
+					}
+				}
+				if err := recognizer.Reconnect(ctx); err != nil {
+					log.Printf("Failed to reconnect continuous recognition: %v", err)
+					writer.send(gin.H{"type": "setLanguage_response", "status": "error", "error": "Failed to restart continuous recognition"})
+					if err := recognizer.Close(); err != nil {
+						log.Printf("Failed to clean up recognizer: %v", err)
+					}
+					cleanup()
+					return
 				}
+				log.Printf("Reconnected with new source language: sessionID=%s, source=%s", sessionID, newSource)
+				writer.send(gin.H{"type": "setLanguage_response", "status": "ok", "sourceLanguage": newSource})
 
 			case "end":
 				log.Printf("Received session end request from client")
-				if err := recognizer.StopContinuousRecognition(); err != nil {
-					log.Printf("Failed to stop continuous recognition: %v", err)
+				// 送信済みの音声の確定結果が届くまで待ってから停止する
+				// 確定結果は Recognized イベントのハンドラーで送信キューに追加済みのため、cleanup で送信される
+				finals, err := recognizer.StopContinuousRecognitionAndDrain(ctx)
+				if err != nil {
+					log.Printf("Failed to drain continuous recognition: %v", err)
+				}
+				log.Printf("Drained %d final results before ending session: sessionID=%s", len(finals), sessionID)
+				// バッチ処理中の場合は残りの音声を処理してから終了する
+				if fallback.isActive() {
+					fallback.enqueue(fallback.take())
+					fallback.stop()
+				}
+				if err := recognizer.Close(); err != nil {
+					log.Printf("Failed to clean up recognizer: %v", err)
 				}
 				cleanup()
 				return
@@ -487,20 +1506,14 @@ func WebSocketHandler(c *gin.Context) {
 				if audio, ok := jsonMsg["audio"].(map[string]interface{}); ok {
 					if base64Audio, ok := audio["data"].(string); ok {
 						// Base64デコード
-						audioData, err := base64.StdEncoding.DecodeString(base64Audio)
+						audioData, err := decodeAudioChunk(base64Audio)
 						if err != nil {
 							log.Printf("Failed to Base64 decode audio data: %v", err)
 							continue
 						}
 
-						pushStream, ok := audioConfig.Source().(*gospeech.PushAudioInputStream)
-						if !ok {
-							log.Printf("Audio source is not a PushAudioInputStream: %T", audioConfig.Source())
-							continue
-						}
-
 						// 音声データを書き込む
-						bytesWritten, err := pushStream.Write(audioData)
+						bytesWritten, err := session.writeAudio(audioData)
 						if err != nil {
 							log.Printf("Failed to write audio data: %v", err)
 							continue
@@ -0,0 +1,1235 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// performJSON は JSON ボディでハンドラーを呼び出し、レスポンスを返します
+func performJSON(t *testing.T, handler gin.HandlerFunc, method string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, "/", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+	return recorder
+}
+
+// startRESTSession は REST でセッションを開始し、テスト終了時にセッションを終了します
+func startRESTSession(t *testing.T, req interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := performJSON(t, StartStreamingSessionHandler, http.MethodPost, req)
+	var started struct {
+		SessionID string `json:"sessionId"`
+	}
+	if json.Unmarshal(recorder.Body.Bytes(), &started) == nil && started.SessionID != "" {
+		t.Cleanup(func() {
+			performJSON(t, CloseStreamingSessionHandler, http.MethodPost, SessionCloseRequest{SessionID: started.SessionID})
+		})
+	}
+	return recorder
+}
+
+// registerSession はテスト用のセッションを登録し、テスト終了時に削除します
+func registerSession(t *testing.T, session *StreamingSession) {
+	t.Helper()
+	activeSessionsMutex.Lock()
+	activeSessions[session.ID] = session
+	activeSessionsMutex.Unlock()
+	t.Cleanup(func() {
+		activeSessionsMutex.Lock()
+		delete(activeSessions, session.ID)
+		activeSessionsMutex.Unlock()
+	})
+}
+
+func TestWebSocketHandlerMinInterimLength(t *testing.T) {
+	type phrase struct {
+		text        string
+		wantInterim bool
+	}
+	tests := []struct {
+		name      string
+		minLength int
+		phrases   []phrase
+	}{
+		{
+			name:      "no minimum forwards every interim",
+			minLength: 0,
+			phrases:   []phrase{{"あ", true}, {"こんにちは", true}},
+		},
+		{
+			name:      "short interims are suppressed but finals are kept",
+			minLength: 3,
+			phrases:   []phrase{{"あ", false}, {"こん", false}, {"こんにちは", true}},
+		},
+		{
+			name:      "length is counted in characters, not bytes",
+			minLength: 2,
+			phrases:   []phrase{{"世", false}, {"世界", true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			previous := minInterimLength
+			SetMinInterimLength(tt.minLength)
+			t.Cleanup(func() { minInterimLength = previous })
+
+			client := startStreamingSession(t, newTestRouter(t), "session-1", StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			speech := service.waitForConn(t)
+			for _, p := range tt.phrases {
+				// 途中経過を受け取ってから確定結果を送る（確定結果の直前の途中経過は置き換えられることがあるため）
+				speech.sendHypothesis(t, p.text, map[string]string{"en": "interim " + p.text})
+				if p.wantInterim {
+					message := readMessage(t, client)
+					if message["isFinal"] != false || message["originalText"] != p.text || message["translatedText"] != "interim "+p.text {
+						t.Fatalf("message = %v, want the interim for %q", message, p.text)
+					}
+				}
+				speech.sendPhrase(t, p.text, map[string]string{"en": "translated " + p.text})
+				message := readMessage(t, client)
+				if message["isFinal"] != true || message["originalText"] != p.text || message["translatedText"] != "translated "+p.text {
+					t.Errorf("message = %v, want the final for %q", message, p.text)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeAudioChunk(t *testing.T) {
+	// 0xfb 0xff はURLセーフでない文字 "+" と "/" を含むエンコードになる
+	audio := []byte{0xfb, 0xff, 0x01, 0x02}
+	tests := []struct {
+		name    string
+		encoded string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "standard", encoded: base64.StdEncoding.EncodeToString(audio), want: audio},
+		{name: "URL-safe", encoded: base64.URLEncoding.EncodeToString(audio), want: audio},
+		{name: "unpadded", encoded: base64.RawStdEncoding.EncodeToString(audio), want: audio},
+		{name: "URL-safe and unpadded", encoded: base64.RawURLEncoding.EncodeToString(audio), want: audio},
+		{name: "line breaks are ignored", encoded: "+/8B\nAg==", want: audio},
+		{name: "invalid characters", encoded: "not base64!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAudioChunk(tt.encoded)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeAudioChunk(%q) succeeded, want an error", tt.encoded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeAudioChunk(%q): %v", tt.encoded, err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decodeAudioChunk(%q) = %v, want %v", tt.encoded, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessAudioChunkHandler(t *testing.T) {
+	audio := []byte{0xfb, 0xff, 0x01, 0x02}
+	tests := []struct {
+		name       string
+		sessionID  string
+		noStream   bool
+		closed     bool
+		alignment  gospeech.PCMAlignment
+		chunk      string
+		wantStatus int
+		wantAudio  []byte
+	}{
+		{name: "standard", sessionID: "session-1", chunk: base64.StdEncoding.EncodeToString(audio), wantStatus: http.StatusOK, wantAudio: audio},
+		{name: "URL-safe", sessionID: "session-1", chunk: base64.URLEncoding.EncodeToString(audio), wantStatus: http.StatusOK, wantAudio: audio},
+		{name: "unpadded", sessionID: "session-1", chunk: base64.RawStdEncoding.EncodeToString(audio), wantStatus: http.StatusOK, wantAudio: audio},
+		{name: "undecodable chunk", sessionID: "session-1", chunk: "not base64!", wantStatus: http.StatusBadRequest},
+		{name: "unknown session", sessionID: "missing", chunk: base64.StdEncoding.EncodeToString(audio), wantStatus: http.StatusBadRequest},
+		{name: "session without a stream", sessionID: "session-1", noStream: true, chunk: base64.StdEncoding.EncodeToString(audio), wantStatus: http.StatusConflict},
+		{name: "closed stream", sessionID: "session-1", closed: true, chunk: base64.StdEncoding.EncodeToString(audio), wantStatus: http.StatusGone},
+		{name: "misaligned chunk is padded", sessionID: "session-1", alignment: gospeech.PCMAlignmentPad, chunk: base64.StdEncoding.EncodeToString(audio[:3]), wantStatus: http.StatusOK, wantAudio: []byte{0xfb, 0xff, 0x01, 0x00}},
+		{name: "misaligned chunk is rejected", sessionID: "session-1", alignment: gospeech.PCMAlignmentReject, chunk: base64.StdEncoding.EncodeToString(audio[:3]), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := gospeech.NewPushAudioInputStream(gospeech.GetDefaultInputFormat())
+			stream.SetAlignment(tt.alignment)
+			session := &StreamingSession{ID: "session-1", PushStream: stream}
+			if tt.noStream {
+				session.PushStream = nil
+			}
+			if tt.closed {
+				stream.Close()
+			}
+			registerSession(t, session)
+
+			recorder := performJSON(t, ProcessAudioChunkHandler, http.MethodPost, AudioChunkRequest{SessionID: tt.sessionID, AudioChunk: tt.chunk})
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantAudio == nil {
+				return
+			}
+			buf := make([]byte, 16)
+			n, err := stream.Read(buf)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if !bytes.Equal(buf[:n], tt.wantAudio) {
+				t.Errorf("session stream received %v, want %v", buf[:n], tt.wantAudio)
+			}
+		})
+	}
+}
+
+func TestSetPCMAlignment(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    gospeech.PCMAlignment
+		wantErr bool
+	}{
+		{name: "none", value: "none", want: gospeech.PCMAlignmentNone},
+		{name: "pad", value: "pad", want: gospeech.PCMAlignmentPad},
+		{name: "truncate", value: "truncate", want: gospeech.PCMAlignmentTruncate},
+		{name: "reject in upper case", value: "REJECT", want: gospeech.PCMAlignmentReject},
+		{name: "unknown value", value: "round", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := pcmAlignment
+			t.Cleanup(func() { pcmAlignment = previous })
+
+			err := SetPCMAlignment(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetPCMAlignment(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && pcmAlignment != tt.want {
+				t.Errorf("pcmAlignment = %v, want %v", pcmAlignment, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerSetLanguage(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		wantStatus    string
+		wantReconnect bool
+		wantLanguage  string
+	}{
+		{name: "supported language reconnects", source: "en-US", wantStatus: "ok", wantReconnect: true, wantLanguage: "en-US"},
+		{name: "another supported language", source: "ko-KR", wantStatus: "ok", wantReconnect: true, wantLanguage: "ko-KR"},
+		{name: "unsupported language is rejected", source: "en-US-x", wantStatus: "error", wantLanguage: "ja-JP"},
+		{name: "empty language is rejected", source: "", wantStatus: "error", wantLanguage: "ja-JP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), "session-1", StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			speech := service.waitForConn(t)
+
+			if err := client.WriteJSON(map[string]interface{}{"type": "setLanguage", "source": tt.source}); err != nil {
+				t.Fatal(err)
+			}
+			response := readMessage(t, client)
+			if response["type"] != "setLanguage_response" || response["status"] != tt.wantStatus {
+				t.Fatalf("response = %v, want setLanguage_response with status %q", response, tt.wantStatus)
+			}
+
+			if tt.wantReconnect {
+				speech = service.waitForConn(t)
+			}
+			// 音声と一緒に送られる speech.config で認識言語を確認する
+			if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, 3200)); err != nil {
+				t.Fatal(err)
+			}
+			config := speech.waitForText(t, "speech.config")
+			if want := `"speechRecognitionLanguage":"` + tt.wantLanguage + `"`; !strings.Contains(config, want) {
+				t.Errorf("speech.config = %s, want it to contain %s", config, want)
+			}
+
+			// 結果には現在の認識言語が含まれる
+			speech.sendPhrase(t, "テスト", map[string]string{"en": "test"})
+			message := readMessage(t, client)
+			if message["sourceLanguage"] != tt.wantLanguage {
+				t.Errorf("sourceLanguage = %v, want %q", message["sourceLanguage"], tt.wantLanguage)
+			}
+			if !tt.wantReconnect && len(service.accepted) != 0 {
+				t.Error("the recognizer reconnected after a rejected language change")
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerConfidences(t *testing.T) {
+	tests := []struct {
+		name   string
+		phrase string
+		want   map[string]interface{}
+	}{
+		{
+			name:   "per-language confidences",
+			phrase: `{"type":"final","NBest":[{"Display":"こんにちは","Confidence":0.9}],"Translations":{"en":{"Text":"Hello","Confidence":0.8},"de":{"Text":"Hallo","Confidence":0.7}}}`,
+			want:   map[string]interface{}{"en": 0.8, "de": 0.7},
+		},
+		{
+			name:   "recognition confidence is used when a language has none",
+			phrase: `{"type":"final","NBest":[{"Display":"こんにちは","Confidence":0.9}],"Translations":{"en":{"Text":"Hello","Confidence":0.8},"de":"Hallo"}}`,
+			want:   map[string]interface{}{"en": 0.8, "de": 0.9},
+		},
+		{
+			name:   "recognition confidence only",
+			phrase: `{"type":"final","NBest":[{"Display":"こんにちは","Confidence":0.9}],"Translations":{"en":"Hello","de":"Hallo"}}`,
+			want:   map[string]interface{}{"en": 0.9, "de": 0.9},
+		},
+		{
+			name:   "no confidences",
+			phrase: `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello","de":"Hallo"}}`,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), "session-1", StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			service.waitForConn(t).send(t, "speech.phrase", tt.phrase)
+
+			// 途中結果と確定結果の両方に信頼度が含まれる（確定結果の直前の途中結果は置き換えられることがある）
+			for {
+				message := readMessage(t, client)
+				kind := "interim"
+				if message["isFinal"] == true {
+					kind = "final"
+				}
+				got, _ := message["confidences"].(map[string]interface{})
+				if _, ok := message["confidences"]; tt.want == nil && ok {
+					t.Errorf("%s confidences = %v, want none", kind, message["confidences"])
+				} else if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s confidences = %v, want %v", kind, got, tt.want)
+				}
+				if kind == "final" {
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerMaxMessageRate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate int
+	}{
+		{name: "unlimited", rate: 0},
+		{name: "20 messages per second", rate: 20},
+		{name: "5 messages per second", rate: 5},
+	}
+
+	const finals = 3
+	const partialsPerFinal = 30
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			previous := maxMessageRate
+			SetMaxMessageRate(tt.rate)
+			t.Cleanup(func() { maxMessageRate = previous })
+
+			client := startStreamingSession(t, newTestRouter(t), "session-1", StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			speech := service.waitForConn(t)
+			for f := 0; f < finals; f++ {
+				for p := 0; p < partialsPerFinal; p++ {
+					speech.send(t, "translation.hypothesis", fmt.Sprintf(`{"Text":"partial %d-%d","Translations":{"en":"partial"}}`, f, p))
+				}
+				speech.sendPhrase(t, fmt.Sprintf("final %d", f), map[string]string{"en": "final"})
+			}
+
+			// 確定結果はすべて順番どおりに届く
+			var received int
+			var gotFinals []string
+			start := time.Now()
+			for len(gotFinals) < finals {
+				message := readMessage(t, client)
+				received++
+				if message["isFinal"] == true {
+					gotFinals = append(gotFinals, message["originalText"].(string))
+				}
+			}
+			elapsed := time.Since(start)
+			for i, text := range gotFinals {
+				if want := fmt.Sprintf("final %d", i); text != want {
+					t.Errorf("final %d = %q, want %q", i, text, want)
+				}
+			}
+
+			// 送信数は上限のレートに収まる（最初の1件はすぐに送信される）
+			if tt.rate > 0 {
+				limit := 1 + int(elapsed.Seconds()*float64(tt.rate)) + 1
+				if received > limit {
+					t.Errorf("received %d messages in %v, want at most %d at %d/s", received, elapsed, limit, tt.rate)
+				}
+				if received >= finals*(partialsPerFinal+2) {
+					t.Errorf("received %d messages, want excess partials to be dropped", received)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerResultReason(t *testing.T) {
+	tests := []struct {
+		name        string
+		interim     bool
+		result      *gospeech.TranslationRecognitionResult
+		wantReason  string
+		wantIsFinal bool
+		wantText    string
+	}{
+		{
+			name:        "translated final",
+			result:      &gospeech.TranslationRecognitionResult{Reason: gospeech.ResultReasonTranslatedSpeech, Text: "こんにちは", Translations: map[string]string{"en": "Hello"}},
+			wantReason:  "TranslatedSpeech",
+			wantIsFinal: true,
+			wantText:    "Hello",
+		},
+		{
+			name:       "translated interim",
+			interim:    true,
+			result:     &gospeech.TranslationRecognitionResult{Reason: gospeech.ResultReasonTranslatingSpeech, Text: "こんにち", Translations: map[string]string{"en": "Hell"}},
+			wantReason: "TranslatingSpeech",
+			wantText:   "Hell",
+		},
+		{
+			name:        "no match is distinguishable from an empty translation",
+			result:      &gospeech.TranslationRecognitionResult{Reason: gospeech.ResultReasonNoMatch, Translations: map[string]string{}},
+			wantReason:  "NoMatch",
+			wantIsFinal: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			// 前のサブテストのセッションの終了処理と重ならないよう、セッションIDを分ける
+			sessionID := fmt.Sprintf("session-%d", i)
+			client := startStreamingSession(t, newTestRouter(t), sessionID, StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			service.waitForConn(t)
+
+			activeSessionsMutex.Lock()
+			recognizer := activeSessions[sessionID].Recognizer
+			activeSessionsMutex.Unlock()
+			args := &gospeech.TranslationRecognitionEventArgs{Result: tt.result}
+			if tt.interim {
+				recognizer.Recognizing().Signal(args)
+			} else {
+				recognizer.Recognized().Signal(args)
+			}
+
+			message := readMessage(t, client)
+			if message["reason"] != tt.wantReason || message["isFinal"] != tt.wantIsFinal {
+				t.Errorf("reason = %v, isFinal = %v, want %q, %v", message["reason"], message["isFinal"], tt.wantReason, tt.wantIsFinal)
+			}
+			if message["translatedText"] != tt.wantText {
+				t.Errorf("translatedText = %v, want %q", message["translatedText"], tt.wantText)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerWriteTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantTornDown bool
+	}{
+		{name: "stalled client is disconnected after the deadline", timeout: 200 * time.Millisecond, wantTornDown: true},
+		{name: "no deadline keeps the session", timeout: 0, wantTornDown: false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			previous := writeTimeout
+			SetWriteTimeout(tt.timeout)
+			t.Cleanup(func() { writeTimeout = previous })
+
+			sessionID := fmt.Sprintf("stalled-%d", i)
+			startStreamingSession(t, newTestRouter(t), sessionID, StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm",
+			})
+			speech := service.waitForConn(t)
+
+			// クライアントは読み取りを止めたまま、ソケットのバッファを埋めるだけの結果を送る
+			// セッションが終了すると認識器の接続も閉じられるため、送信エラーで止める
+			large := strings.Repeat("a", 64*1024)
+			for n := 0; n < 300; n++ {
+				body := fmt.Sprintf(`{"type":"final","NBest":[{"Display":"phrase %d"}],"Translations":{"en":%q}}`, n, large)
+				frame := "Path: speech.phrase\r\nX-RequestId: test\r\nContent-Type: application/json\r\n\r\n" + body
+				speech.writeMu.Lock()
+				err := speech.conn.WriteMessage(websocket.TextMessage, []byte(frame))
+				speech.writeMu.Unlock()
+				if err != nil {
+					break
+				}
+			}
+
+			tornDown := func() bool {
+				activeSessionsMutex.RLock()
+				defer activeSessionsMutex.RUnlock()
+				_, ok := activeSessions[sessionID]
+				return !ok
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			if !tt.wantTornDown {
+				deadline = time.Now().Add(time.Second)
+			}
+			for !tornDown() && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if got := tornDown(); got != tt.wantTornDown {
+				t.Errorf("session torn down = %v, want %v", got, tt.wantTornDown)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerUntranslatedFinal(t *testing.T) {
+	tests := []struct {
+		name    string
+		forward bool
+		targets LanguageList
+		// wantPassThrough は破棄されずに送信される、認識言語と同じ翻訳先言語
+		wantPassThrough string
+	}{
+		{name: "forwarded with the source text", forward: true, targets: LanguageList{"en"}},
+		{name: "dropped when disabled", forward: false, targets: LanguageList{"en"}},
+		{name: "dropping one target keeps the pass-through target", forward: false, targets: LanguageList{"en", "ja"}, wantPassThrough: "ja"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := forwardUntranslated
+			SetForwardUntranslated(tt.forward)
+			t.Cleanup(func() { forwardUntranslated = previous })
+
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), fmt.Sprintf("untranslated-%d", i), StreamingTranslationRequest{
+				SourceLanguage: "ja-JP", TargetLanguages: tt.targets, AudioFormat: "pcm",
+			})
+			fc := service.waitForConn(t)
+			fc.send(t, "speech.phrase", `{"type":"final","NBest":[{"Display":"こんにちは"}]}`)
+
+			if tt.wantPassThrough != "" {
+				message := readMessage(t, client)
+				if message["targetLanguage"] != tt.wantPassThrough || message["translatedText"] != "こんにちは" {
+					t.Errorf("message = %v, want the source text passed through to %s", message, tt.wantPassThrough)
+				}
+			}
+
+			if tt.forward {
+				message := readMessage(t, client)
+				if message["reason"] != "RecognizedSpeech" || message["isFinal"] != true {
+					t.Errorf("reason = %v, isFinal = %v, want RecognizedSpeech, true", message["reason"], message["isFinal"])
+				}
+				if message["originalText"] != "こんにちは" || message["translatedText"] != "" {
+					t.Errorf("originalText = %v, translatedText = %v, want the source text only", message["originalText"], message["translatedText"])
+				}
+			}
+
+			// 翻訳された結果はそのまま送信される
+			fc.sendPhrase(t, "さようなら", map[string]string{"en": "Goodbye"})
+			message := readFinal(t, client)
+			if message["reason"] != "TranslatedSpeech" || message["originalText"] != "さようなら" || message["translatedText"] != "Goodbye" {
+				t.Errorf("next final = %v, want the translated result", message)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerPassThrough(t *testing.T) {
+	tests := []struct {
+		name            string
+		disabled        bool
+		source, target  string
+		translations    map[string]string
+		wantTargets     []interface{}
+		wantTranslation string
+		wantPassThrough bool
+	}{
+		{
+			name: "same language passes the recognized text through", source: "ja-JP", target: "ja",
+			wantTargets: []interface{}{}, wantTranslation: "こんにちは", wantPassThrough: true,
+		},
+		{
+			name: "same base language with a region", source: "en-US", target: "en-GB",
+			wantTargets: []interface{}{}, wantTranslation: "こんにちは", wantPassThrough: true,
+		},
+		{
+			name: "different languages are translated", source: "ja-JP", target: "en",
+			translations: map[string]string{"en": "Hello"},
+			wantTargets:  []interface{}{"en"}, wantTranslation: "Hello",
+		},
+		{
+			name: "disabled", disabled: true, source: "ja-JP", target: "ja",
+			translations: map[string]string{"ja": "こんにちは。"},
+			wantTargets:  []interface{}{"ja"}, wantTranslation: "こんにちは。",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := passThroughSameLanguage
+			SetPassThroughSameLanguage(!tt.disabled)
+			t.Cleanup(func() { passThroughSameLanguage = previous })
+
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), fmt.Sprintf("pass-through-%d", i), StreamingTranslationRequest{
+				SourceLanguage: tt.source, TargetLanguages: LanguageList{tt.target}, AudioFormat: "pcm",
+			})
+			fc := service.waitForConn(t)
+
+			// 翻訳が不要な場合は翻訳先言語を指定しない
+			if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, 3200)); err != nil {
+				t.Fatalf("failed to send audio: %v", err)
+			}
+			var config struct {
+				Config struct {
+					SpeechConfig struct {
+						TranslationLanguages []interface{}
+					}
+				}
+			}
+			if err := json.Unmarshal([]byte(fc.waitForText(t, "speech.config")), &config); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			if got := config.Config.SpeechConfig.TranslationLanguages; !reflect.DeepEqual(got, tt.wantTargets) {
+				t.Errorf("translationLanguages = %v, want %v", got, tt.wantTargets)
+			}
+
+			fc.sendPhrase(t, "こんにちは", tt.translations)
+			message := readFinal(t, client)
+			if message["translatedText"] != tt.wantTranslation || message["originalText"] != "こんにちは" {
+				t.Errorf("final = %v, want translatedText %q", message, tt.wantTranslation)
+			}
+			if got := message["passThrough"] == true; got != tt.wantPassThrough {
+				t.Errorf("passThrough = %v, want %v", message["passThrough"], tt.wantPassThrough)
+			}
+		})
+	}
+}
+
+func TestLanguageListJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     LanguageList
+		wantErr  bool
+		wantJSON string
+	}{
+		{name: "single language", input: `"en"`, want: LanguageList{"en"}, wantJSON: `"en"`},
+		{name: "array", input: `["en","zh-Hans"]`, want: LanguageList{"en", "zh-Hans"}, wantJSON: `["en","zh-Hans"]`},
+		{name: "empty entries are skipped", input: `["en",""]`, want: LanguageList{"en"}, wantJSON: `"en"`},
+		{name: "empty string", input: `""`, want: nil, wantJSON: `null`},
+		{name: "number", input: `1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got LanguageList
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.input, got, tt.want)
+			}
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("Marshal(%v) = %s, want %s", got, data, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerMultipleTargetLanguages(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        string
+		translations map[string]string
+		wantTargets  []interface{}
+		wantFinals   map[string]string // 翻訳先言語ごとの確定結果の翻訳テキスト
+	}{
+		{
+			name:         "two target languages",
+			setup:        `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"audioFormat":"pcm"}`,
+			translations: map[string]string{"en": "Hello", "fr": "Bonjour"},
+			wantTargets:  []interface{}{"en", "fr"},
+			wantFinals:   map[string]string{"en": "Hello", "fr": "Bonjour"},
+		},
+		{
+			name:         "same language as the source is passed through",
+			setup:        `{"sourceLanguage":"ja-JP","targetLanguage":["ja","en"],"audioFormat":"pcm"}`,
+			translations: map[string]string{"en": "Hello"},
+			wantTargets:  []interface{}{"en"},
+			wantFinals:   map[string]string{"ja": "こんにちは", "en": "Hello"},
+		},
+		{
+			name:         "single language as a string",
+			setup:        `{"sourceLanguage":"ja-JP","targetLanguage":"en","audioFormat":"pcm"}`,
+			translations: map[string]string{"en": "Hello"},
+			wantTargets:  []interface{}{"en"},
+			wantFinals:   map[string]string{"en": "Hello"},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			client := startStreamingSession(t, newTestRouter(t), fmt.Sprintf("multi-target-%d", i), json.RawMessage(tt.setup))
+			fc := service.waitForConn(t)
+
+			// 翻訳先言語がすべて認識サービスに渡される
+			if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, 3200)); err != nil {
+				t.Fatalf("failed to send audio: %v", err)
+			}
+			var config struct {
+				Config struct {
+					SpeechConfig struct {
+						TranslationLanguages []interface{}
+					}
+				}
+			}
+			if err := json.Unmarshal([]byte(fc.waitForText(t, "speech.config")), &config); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			if got := config.Config.SpeechConfig.TranslationLanguages; !reflect.DeepEqual(got, tt.wantTargets) {
+				t.Errorf("translationLanguages = %v, want %v", got, tt.wantTargets)
+			}
+
+			// 確定結果は翻訳先言語ごとに同じ segmentId で届く
+			fc.sendPhrase(t, "こんにちは", tt.translations)
+			got := make(map[string]string)
+			segmentIDs := make(map[interface{}]bool)
+			for range tt.wantFinals {
+				message := readFinal(t, client)
+				got[message["targetLanguage"].(string)] = message["translatedText"].(string)
+				segmentIDs[message["segmentId"]] = true
+			}
+			if !reflect.DeepEqual(got, tt.wantFinals) {
+				t.Errorf("finals = %v, want %v", got, tt.wantFinals)
+			}
+			if len(segmentIDs) != 1 {
+				t.Errorf("segmentIds = %v, want one shared ID", segmentIDs)
+			}
+			expectNoMessage(t, client, 100*time.Millisecond)
+		})
+	}
+}
+
+func TestWebSocketHandlerRequiresTargetLanguage(t *testing.T) {
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/no-target", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to the streaming handler: %v", err)
+	}
+	defer client.Close()
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"sourceLanguage":"ja-JP","targetLanguage":[],"audioFormat":"pcm"}`)); err != nil {
+		t.Fatalf("failed to send the setup message: %v", err)
+	}
+	if message := readMessage(t, client); message["error"] == nil {
+		t.Errorf("message = %v, want an error", message)
+	}
+}
+
+func TestHandlersNotConfigured(t *testing.T) {
+	tests := []struct {
+		name          string
+		method, path  string
+		body          string
+		noProvider    bool
+		noCredentials bool
+		wantStatus    int
+		wantError     string
+	}{
+		{
+			name: "translation without a translator client", method: http.MethodPost, path: "/translate",
+			body: `{"text":"こんにちは","targetLanguage":"en"}`, noProvider: true,
+			wantStatus: http.StatusServiceUnavailable, wantError: "translation service not configured",
+		},
+		{
+			name: "streaming without speech credentials", method: http.MethodGet, path: "/ws/not-configured",
+			noCredentials: true, wantStatus: http.StatusServiceUnavailable, wantError: "speech service not configured",
+		},
+		{
+			name: "upload without speech credentials", method: http.MethodPost, path: "/transcribe-translate",
+			noCredentials: true, wantStatus: http.StatusServiceUnavailable, wantError: "speech service not configured",
+		},
+		{
+			name: "invalid request is still rejected first", method: http.MethodPost, path: "/translate",
+			body: `{}`, noProvider: true, wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/translate", TranslateHandler)
+	router.GET("/ws/:sessionId", WebSocketHandler)
+	router.POST("/transcribe-translate", TranscribeTranslateHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noProvider {
+				useTranslationProvider(t, nil)
+			}
+			previousKey, previousRegion := speechSubscriptionKey, speechRegion
+			if tt.noCredentials {
+				SetSpeechCredentials("", "")
+			} else {
+				SetSpeechCredentials("test-key", "japaneast")
+			}
+			t.Cleanup(func() { SetSpeechCredentials(previousKey, previousRegion) })
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantError != "" && !strings.Contains(recorder.Body.String(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", recorder.Body.String(), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestMaskedKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "", want: "..."},
+		{key: "abc", want: "..."},
+		{key: "abcde", want: "..."},
+		{key: "abcdef123456", want: "abcde..."},
+	}
+
+	for _, tt := range tests {
+		if got := maskedKey(tt.key); got != tt.want {
+			t.Errorf("maskedKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestPrimaryTargetLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       string
+		wantPrimary string
+		wantOrder   []string // 確定結果が届く翻訳先言語の順序
+		wantErr     bool
+	}{
+		{
+			name:        "defaults to the first target language",
+			setup:       `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"audioFormat":"pcm"}`,
+			wantPrimary: "en",
+			wantOrder:   []string{"en", "fr"},
+		},
+		{
+			name:        "explicit primary comes first",
+			setup:       `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"primaryTargetLanguage":"fr","audioFormat":"pcm"}`,
+			wantPrimary: "fr",
+			wantOrder:   []string{"fr", "en"},
+		},
+		{
+			name:    "primary outside the target languages",
+			setup:   `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"primaryTargetLanguage":"de","audioFormat":"pcm"}`,
+			wantErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req StreamingTranslationRequest
+			if err := json.Unmarshal([]byte(tt.setup), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			// REST: セッション開始の応答に主となる翻訳先言語が含まれる
+			useFakeSpeechService(t, newFakeSpeechService(t))
+			recorder := startRESTSession(t, req)
+			if tt.wantErr {
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("start status = %d, want %d", recorder.Code, http.StatusBadRequest)
+				}
+			} else {
+				var started map[string]interface{}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &started); err != nil {
+					t.Fatalf("start response is not JSON: %v", err)
+				}
+				if started["primaryTargetLanguage"] != tt.wantPrimary {
+					t.Errorf("start primaryTargetLanguage = %v, want %s", started["primaryTargetLanguage"], tt.wantPrimary)
+				}
+			}
+
+			// ストリーミング: 準備完了の通知と確定結果の順序が主となる翻訳先言語に従う
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			server := newTestRouter(t)
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + fmt.Sprintf("/ws/primary-%d", i)
+			client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if err != nil {
+				t.Fatalf("failed to connect to the streaming handler: %v", err)
+			}
+			defer client.Close()
+			if err := client.WriteMessage(websocket.TextMessage, []byte(tt.setup)); err != nil {
+				t.Fatalf("failed to send the setup message: %v", err)
+			}
+			ready := readMessage(t, client)
+			if tt.wantErr {
+				if ready["error"] == nil {
+					t.Errorf("first message = %v, want an error", ready)
+				}
+				return
+			}
+			if ready["status"] != "ready" || ready["targetLanguage"] != tt.wantPrimary {
+				t.Fatalf("first message = %v, want ready with targetLanguage %s", ready, tt.wantPrimary)
+			}
+
+			fc := service.waitForConn(t)
+			fc.sendPhrase(t, "こんにちは", map[string]string{"en": "Hello", "fr": "Bonjour"})
+			var order []string
+			for range tt.wantOrder {
+				order = append(order, readFinal(t, client)["targetLanguage"].(string))
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("final order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+// waitForEndOfAudio は認識器から音声の終端マーカー（空のバイナリメッセージ）が届くまで待ちます
+func (c *fakeSpeechConn) waitForEndOfAudio(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, m := range c.messages {
+			if m.messageType == websocket.BinaryMessage && len(m.data) == 0 {
+				c.mu.Unlock()
+				return
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the end-of-audio marker")
+}
+
+func TestWebSocketHandlerGracefulEnd(t *testing.T) {
+	tests := []struct {
+		name      string
+		lastFinal string // 終端マーカーの後にサービスが返す確定結果（空の場合は返さない）
+		want      []string
+	}{
+		{name: "last utterance is delivered before close", lastFinal: "さようなら", want: []string{"en:さようなら"}},
+		{name: "nothing left to deliver"},
+	}
+
+	service := newFakeSpeechService(t)
+	service.holdTurnEnd.Store(true)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startStreamingSession(t, server, fmt.Sprintf("graceful-end-%d", i), StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"})
+			fc := service.waitForConn(t)
+			if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, 3200)); err != nil {
+				t.Fatalf("failed to send audio: %v", err)
+			}
+			if err := client.WriteJSON(map[string]string{"type": "end"}); err != nil {
+				t.Fatalf("failed to send the end message: %v", err)
+			}
+
+			// 終端マーカーを受け取ってから最後の発話の結果を返す
+			fc.waitForEndOfAudio(t)
+			if tt.lastFinal != "" {
+				fc.sendPhrase(t, tt.lastFinal, map[string]string{"en": "en:" + tt.lastFinal})
+			}
+			fc.send(t, "turn.end", "{}")
+
+			var got []string
+			for _, message := range readUntilClosed(t, client) {
+				if message["isFinal"] == true {
+					got = append(got, message["translatedText"].(string))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("finals before close = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandlerEndClosesRecognizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio int // end の前に送る音声のバイト数
+	}{
+		{name: "end after audio", audio: 3200},
+		{name: "end without audio"},
+	}
+
+	service := newFakeSpeechService(t)
+	useFakeSpeechService(t, service)
+	server := newTestRouter(t)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionID := fmt.Sprintf("end-closes-%d", i)
+			client := startStreamingSession(t, server, sessionID, StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: LanguageList{"en"}, AudioFormat: "pcm"})
+			service.waitForConn(t)
+			var session *StreamingSession
+			waitForSessions(t, func(sessions map[string]*StreamingSession) bool {
+				session = sessions[sessionID]
+				return session != nil
+			})
+
+			if tt.audio > 0 {
+				if err := client.WriteMessage(websocket.BinaryMessage, make([]byte, tt.audio)); err != nil {
+					t.Fatalf("failed to send audio: %v", err)
+				}
+			}
+			if err := client.WriteJSON(map[string]string{"type": "end"}); err != nil {
+				t.Fatalf("failed to send the end message: %v", err)
+			}
+			readUntilClosed(t, client)
+
+			// セッションの終了時に認識器を閉じ、イベントハンドラーの登録も解除する
+			if session.Recognizer.IsRunning() {
+				t.Error("recognizer is still running after the session ended")
+			}
+			before := len(session.viewers.history.snapshot())
+			session.Recognizer.Recognized().Signal(&gospeech.TranslationRecognitionEventArgs{Result: &gospeech.TranslationRecognitionResult{
+				Text:         "遅れた結果",
+				Reason:       gospeech.ResultReasonTranslatedSpeech,
+				Translations: map[string]string{"en": "late result"},
+			}})
+			if after := len(session.viewers.history.snapshot()); after != before {
+				t.Errorf("a result raised after the session ended reached the handler (history %d -> %d)", before, after)
+			}
+		})
+	}
+}
+
+// sendSynthesis は合成音声のフレームと合成の終了通知を認識器に送ります
+func (c *fakeSpeechConn) sendSynthesis(t *testing.T, audio []byte) {
+	t.Helper()
+	headers := "Path: translation.synthesis\r\nX-RequestId: test\r\nContent-Type: audio/x-wav"
+	frame := make([]byte, 2, 2+len(headers)+len(audio))
+	binary.BigEndian.PutUint16(frame, uint16(len(headers)))
+	frame = append(frame, headers...)
+	frame = append(frame, audio...)
+	c.writeMu.Lock()
+	err := c.conn.WriteMessage(websocket.BinaryMessage, frame)
+	c.writeMu.Unlock()
+	if err != nil {
+		t.Fatalf("failed to send synthesized audio: %v", err)
+	}
+	c.send(t, "translation.synthesis.end", `{"SynthesisStatus":"Success"}`)
+}
+
+func TestWebSocketHandlerVoices(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     string
+		wantVoice string // 認識サービスに要求する音声（空の場合は合成しない）
+		wantErr   string
+	}{
+		{
+			name:      "voice for a target language",
+			setup:     `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"audioFormat":"pcm","voices":{"en":"en-US-JennyNeural"}}`,
+			wantVoice: "en-US-JennyNeural",
+		},
+		{
+			name:      "voice outside the catalog",
+			setup:     `{"sourceLanguage":"ja-JP","targetLanguage":["fr"],"audioFormat":"pcm","voices":{"fr":"fr-FR-DeniseNeural"}}`,
+			wantVoice: "fr-FR-DeniseNeural",
+		},
+		{
+			name:  "no voices",
+			setup: `{"sourceLanguage":"ja-JP","targetLanguage":["en"],"audioFormat":"pcm"}`,
+		},
+		{
+			name:    "language is not a target",
+			setup:   `{"sourceLanguage":"ja-JP","targetLanguage":["en"],"audioFormat":"pcm","voices":{"fr":"fr-FR-DeniseNeural"}}`,
+			wantErr: "not one of the target languages",
+		},
+		{
+			name:    "voice speaks another language",
+			setup:   `{"sourceLanguage":"ja-JP","targetLanguage":["en"],"audioFormat":"pcm","voices":{"en":"ja-JP-NanamiNeural"}}`,
+			wantErr: "does not speak en",
+		},
+		{
+			name:    "more than one voice",
+			setup:   `{"sourceLanguage":"ja-JP","targetLanguage":["en","fr"],"audioFormat":"pcm","voices":{"en":"en-US-JennyNeural","fr":"fr-FR-DeniseNeural"}}`,
+			wantErr: "only one target language",
+		},
+		{
+			name:    "source language is not synthesized",
+			setup:   `{"sourceLanguage":"ja-JP","targetLanguage":["ja","en"],"audioFormat":"pcm","voices":{"ja":"ja-JP-NanamiNeural"}}`,
+			wantErr: "is the source language",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			useFakeSpeechService(t, service)
+			server := newTestRouter(t)
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + fmt.Sprintf("/ws/voices-%d", i)
+			client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if err != nil {
+				t.Fatalf("failed to connect to the streaming handler: %v", err)
+			}
+			defer client.Close()
+			if err := client.WriteMessage(websocket.TextMessage, []byte(tt.setup)); err != nil {
+				t.Fatalf("failed to send the setup message: %v", err)
+			}
+
+			first := readMessage(t, client)
+			if tt.wantErr != "" {
+				if message, _ := first["error"].(string); !strings.Contains(message, tt.wantErr) {
+					t.Errorf("first message = %v, want an error containing %q", first, tt.wantErr)
+				}
+				return
+			}
+			if first["status"] != "ready" {
+				t.Fatalf("first message = %v, want the ready status", first)
+			}
+
+			// 指定した音声が認識サービスへの接続に設定される
+			fc := service.waitForConn(t)
+			if got := fc.query.Get("voice"); got != tt.wantVoice {
+				t.Errorf("voice = %q, want %q", got, tt.wantVoice)
+			}
+			if tt.wantVoice == "" {
+				return
+			}
+
+			// 合成音声は翻訳先言語と音声の名前とともにクライアントに届く
+			fc.sendSynthesis(t, []byte{1, 2, 3})
+			message := readMessage(t, client)
+			audio, _ := base64.StdEncoding.DecodeString(fmt.Sprint(message["audio"]))
+			if message["type"] != "synthesis" || message["voice"] != tt.wantVoice || !bytes.Equal(audio, []byte{1, 2, 3}) {
+				t.Errorf("message = %v, want the synthesized audio from %s", message, tt.wantVoice)
+			}
+		})
+	}
+}
+
+func TestMaxTargetLanguages(t *testing.T) {
+	languages := func(n int) LanguageList {
+		// セッションの開始時に検証されるため、サポートされている言語を使う
+		supported := gospeech.SupportedLanguages()
+		list := make(LanguageList, n)
+		for i := range list {
+			list[i] = supported[i].Code
+		}
+		return list
+	}
+	tests := []struct {
+		name      string
+		max       int
+		languages int
+		wantErr   bool
+	}{
+		{name: "within the default limit", languages: 20},
+		{name: "over the default limit", languages: 21, wantErr: true},
+		{name: "over a configured limit", max: 2, languages: 3, wantErr: true},
+	}
+
+	useFakeSpeechService(t, newFakeSpeechService(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := maxTargetLanguages
+			SetMaxTargetLanguages(tt.max)
+			t.Cleanup(func() { maxTargetLanguages = previous })
+
+			req := StreamingTranslationRequest{SourceLanguage: "ja-JP", TargetLanguages: languages(tt.languages), AudioFormat: "pcm"}
+			if err := req.checkTargetLanguageCount(); (err != nil) != tt.wantErr {
+				t.Errorf("checkTargetLanguageCount() = %v, want error %v", err, tt.wantErr)
+			}
+			wantStatus := http.StatusOK
+			if tt.wantErr {
+				wantStatus = http.StatusBadRequest
+			}
+			recorder := startRESTSession(t, req)
+			if recorder.Code != wantStatus {
+				t.Errorf("start status = %d, want %d: %s", recorder.Code, wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	translatortext "go-realtime-translation-with-speech-service/backend/translatortext"
+)
+
+// TranslationProvider はテキスト翻訳を行うバックエンドのインターフェース
+// Azure Translator 以外（オンプレミスなど）のプロバイダーに差し替えるために使用します
+type TranslationProvider interface {
+	// Translate は text を targetLanguage に翻訳します（sourceLanguage が空の場合は自動検出）
+	Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error)
+	// DetectLanguage は text の言語とその信頼度を返します
+	DetectLanguage(ctx context.Context, text string) (language string, score float64, err error)
+}
+
+// BatchTranslationProvider は複数のテキストを1回のリクエストで翻訳できるプロバイダーのインターフェース（任意）
+// 実装していないプロバイダーでは、バッチ翻訳はテキストごとの Translate の呼び出しになります
+type BatchTranslationProvider interface {
+	// TranslateBatch は texts を targetLanguage に翻訳し、入力と同じ順序で結果を返します
+	TranslateBatch(ctx context.Context, texts []string, sourceLanguage, targetLanguage string) ([]*TranslationOutput, error)
+}
+
+// TransliterationProvider は文字体系の変換（翻字）ができるプロバイダーのインターフェース（任意）
+type TransliterationProvider interface {
+	// Transliterate は language のテキストを fromScript から toScript の文字体系に変換します
+	Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error)
+}
+
+// TranslationOutput は翻訳プロバイダーの翻訳結果
+type TranslationOutput struct {
+	TranslatedText   string
+	DetectedLanguage string  // 自動検出された場合のみ
+	DetectedScore    float64 // 自動検出された場合のみ
+}
+
+// errNoTranslationResult は翻訳結果が空の場合のエラー
+var errNoTranslationResult = errors.New("翻訳結果がありません")
+
+// errTranslationNotConfigured は翻訳プロバイダーがセットされていない場合のエラー
+var errTranslationNotConfigured = errors.New("translation service not configured")
+
+// translationProvider はアプリケーション全体で使用する翻訳プロバイダー（nil は未設定）
+var translationProvider TranslationProvider
+
+// SetTranslationProvider は翻訳プロバイダーをセットします
+func SetTranslationProvider(provider TranslationProvider) {
+	translationProvider = provider
+}
+
+// azureTranslationProvider は Azure Translator を使用する既定の翻訳プロバイダー
+type azureTranslationProvider struct {
+	client *translatortext.TranslatorClient
+}
+
+// Translate は Azure Translator でテキストを翻訳します
+func (p *azureTranslationProvider) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error) {
+	textParam := []*translatortext.TranslateTextInput{
+		{
+			Text: &text,
+		},
+	}
+
+	var options *translatortext.TranslatorClientTranslateOptions
+	if sourceLanguage != "" {
+		options = &translatortext.TranslatorClientTranslateOptions{From: &sourceLanguage}
+	}
+
+	result, err := p.client.Translate(ctx, []string{targetLanguage}, textParam, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.TranslateResultAllItemArray) == 0 {
+		return nil, errNoTranslationResult
+	}
+	return translationOutput(result.TranslateResultAllItemArray[0]), nil
+}
+
+// TranslateBatch は Azure Translator の1回のリクエストで複数のテキストを翻訳します
+func (p *azureTranslationProvider) TranslateBatch(ctx context.Context, texts []string, sourceLanguage, targetLanguage string) ([]*TranslationOutput, error) {
+	textParam := make([]*translatortext.TranslateTextInput, len(texts))
+	for i := range texts {
+		textParam[i] = &translatortext.TranslateTextInput{Text: &texts[i]}
+	}
+
+	var options *translatortext.TranslatorClientTranslateOptions
+	if sourceLanguage != "" {
+		options = &translatortext.TranslatorClientTranslateOptions{From: &sourceLanguage}
+	}
+
+	result, err := p.client.Translate(ctx, []string{targetLanguage}, textParam, options)
+	if err != nil {
+		return nil, err
+	}
+	// 結果は入力と同じ順序で返される
+	if len(result.TranslateResultAllItemArray) != len(texts) {
+		return nil, errNoTranslationResult
+	}
+
+	outputs := make([]*TranslationOutput, len(texts))
+	for i, item := range result.TranslateResultAllItemArray {
+		outputs[i] = translationOutput(item)
+	}
+	return outputs, nil
+}
+
+// translationOutput は Azure Translator の翻訳結果の1件を TranslationOutput に変換します
+func translationOutput(item *translatortext.TranslateResultAllItem) *TranslationOutput {
+	output := &TranslationOutput{}
+	if item.DetectedLanguage != nil {
+		if item.DetectedLanguage.Language != nil {
+			output.DetectedLanguage = *item.DetectedLanguage.Language
+		}
+		if item.DetectedLanguage.Score != nil {
+			output.DetectedScore = *item.DetectedLanguage.Score
+		}
+	}
+	if len(item.Translations) > 0 && item.Translations[0].Text != nil {
+		output.TranslatedText = *item.Translations[0].Text
+	}
+	return output
+}
+
+// DetectLanguage は Azure Translator で言語を検出します
+// Detect API のモデルには言語が含まれないため、翻訳時の自動検出結果を使用します
+func (p *azureTranslationProvider) DetectLanguage(ctx context.Context, text string) (string, float64, error) {
+	output, err := p.Translate(ctx, text, "", "en")
+	if err != nil {
+		return "", 0, err
+	}
+	return output.DetectedLanguage, output.DetectedScore, nil
+}
+
+// Transliterate は Azure Translator でテキストの文字体系を変換します
+func (p *azureTranslationProvider) Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error) {
+	texts := []*translatortext.TransliterateTextInput{
+		{
+			Text: &text,
+		},
+	}
+
+	result, err := p.client.Transliterate(ctx, language, fromScript, toScript, texts, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(result.TransliterateResultItemArray) == 0 || result.TransliterateResultItemArray[0].Text == nil {
+		return "", errNoTranslationResult
+	}
+	return *result.TransliterateResultItemArray[0].Text, nil
+}
@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/translatortext"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeTranslationProvider は固定の結果を返すテスト用の翻訳プロバイダー
+type fakeTranslationProvider struct {
+	output *TranslationOutput
+	err    error
+
+	// 最後に受け取った引数
+	ctx                  context.Context
+	text, source, target string
+}
+
+func (p *fakeTranslationProvider) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error) {
+	p.ctx, p.text, p.source, p.target = ctx, text, sourceLanguage, targetLanguage
+	return p.output, p.err
+}
+
+func (p *fakeTranslationProvider) DetectLanguage(ctx context.Context, text string) (string, float64, error) {
+	if p.err != nil {
+		return "", 0, p.err
+	}
+	return p.output.DetectedLanguage, p.output.DetectedScore, nil
+}
+
+// useTranslationProvider はテストの間だけ翻訳プロバイダーを差し替えます
+func useTranslationProvider(t *testing.T, provider TranslationProvider) {
+	t.Helper()
+	previous := translationProvider
+	SetTranslationProvider(provider)
+	t.Cleanup(func() { translationProvider = previous })
+}
+
+func TestTranslateHandlerWithProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    TranslationRequest
+		provider   *fakeTranslationProvider
+		wantStatus int
+		want       TranslationResponse
+		wantError  string
+	}{
+		{
+			name:       "detected source language",
+			request:    TranslationRequest{Text: "こんにちは", TargetLanguage: "en"},
+			provider:   &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: "Hello", DetectedLanguage: "ja", DetectedScore: 0.95}},
+			wantStatus: http.StatusOK,
+			want:       TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en", DetectionConfidence: 0.95, Confidence: 0.95},
+		},
+		{
+			name:       "requested source language",
+			request:    TranslationRequest{Text: "こんにちは", TargetLanguage: "en", SourceLanguage: "ja"},
+			provider:   &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: "Hello"}},
+			wantStatus: http.StatusOK,
+			want:       TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en"},
+		},
+		{
+			name:       "no translation result",
+			request:    TranslationRequest{Text: "こんにちは", TargetLanguage: "en"},
+			provider:   &fakeTranslationProvider{err: errNoTranslationResult},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  errNoTranslationResult.Error(),
+		},
+		{
+			name:       "provider failure",
+			request:    TranslationRequest{Text: "こんにちは", TargetLanguage: "en"},
+			provider:   &fakeTranslationProvider{err: errors.New("provider unavailable")},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Failed to execute translation: provider unavailable",
+		},
+		{
+			name:       "missing target language",
+			request:    TranslationRequest{Text: "こんにちは"},
+			provider:   &fakeTranslationProvider{},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTranslationProvider(t, tt.provider)
+
+			recorder := performJSON(t, TranslateHandler, http.MethodPost, tt.request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var body map[string]string
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				if tt.wantError != "" && body["error"] != tt.wantError {
+					t.Errorf("error = %q, want %q", body["error"], tt.wantError)
+				}
+				return
+			}
+
+			var got TranslationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("response = %+v, want %+v", got, tt.want)
+			}
+			// 検出の信頼度は翻訳の信頼度と区別できる名前で返され、非推奨の confidence も同じ値を返す
+			var raw map[string]interface{}
+			json.Unmarshal(recorder.Body.Bytes(), &raw)
+			if raw["confidence"] != raw["detectionConfidence"] {
+				t.Errorf("deprecated confidence = %v, want the detectionConfidence %v", raw["confidence"], raw["detectionConfidence"])
+			}
+			if tt.provider.text != tt.request.Text || tt.provider.source != tt.request.SourceLanguage || tt.provider.target != tt.request.TargetLanguage {
+				t.Errorf("provider received (%q, %q, %q), want the request fields", tt.provider.text, tt.provider.source, tt.provider.target)
+			}
+		})
+	}
+}
+
+// requestContextKey はリクエストのコンテキストを識別するためのテスト用キー
+type requestContextKey struct{}
+
+func TestTranslateHandlerRequestContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		cancel       bool
+		wantCanceled bool
+	}{
+		{name: "live request", cancel: false, wantCanceled: false},
+		// クライアントが切断したリクエストの翻訳は上流でも取り消される
+		{name: "canceled request", cancel: true, wantCanceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeTranslationProvider{output: &TranslationOutput{TranslatedText: "Hello"}}
+			useTranslationProvider(t, provider)
+
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestContextKey{}, tt.name))
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			payload, _ := json.Marshal(TranslationRequest{Text: "こんにちは", TargetLanguage: "en"})
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)).WithContext(ctx)
+			c.Request.Header.Set("Content-Type", "application/json")
+			TranslateHandler(c)
+
+			if provider.ctx == nil {
+				t.Fatal("provider was not called")
+			}
+			if got := provider.ctx.Value(requestContextKey{}); got != tt.name {
+				t.Errorf("provider context value = %v, want the request context", got)
+			}
+			if canceled := provider.ctx.Err() != nil; canceled != tt.wantCanceled {
+				t.Errorf("provider context canceled = %v, want %v", canceled, tt.wantCanceled)
+			}
+		})
+	}
+}
+
+// textTranslationProvider はテキストごとに決まった結果を返すテスト用の翻訳プロバイダー
+type textTranslationProvider struct {
+	outputs map[string]*TranslationOutput
+}
+
+func (p *textTranslationProvider) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error) {
+	output, ok := p.outputs[text]
+	if !ok {
+		return nil, errNoTranslationResult
+	}
+	return output, nil
+}
+
+func (p *textTranslationProvider) DetectLanguage(ctx context.Context, text string) (string, float64, error) {
+	return "", 0, nil
+}
+
+// batchTextTranslationProvider は1回のリクエストで複数のテキストを翻訳するテスト用の翻訳プロバイダー
+type batchTextTranslationProvider struct {
+	textTranslationProvider
+	err   error
+	delay time.Duration // 結果を返すまでの時間（期限を超えると ctx のエラーを返す）
+	calls int
+}
+
+func (p *batchTextTranslationProvider) TranslateBatch(ctx context.Context, texts []string, sourceLanguage, targetLanguage string) ([]*TranslationOutput, error) {
+	p.calls++
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	outputs := make([]*TranslationOutput, len(texts))
+	for i, text := range texts {
+		outputs[i] = p.outputs[text]
+	}
+	return outputs, nil
+}
+
+func TestTranslateBatchHandler(t *testing.T) {
+	outputs := map[string]*TranslationOutput{
+		"こんにちは":   {TranslatedText: "Hello", DetectedLanguage: "ja", DetectedScore: 0.9},
+		"안녕하세요":   {TranslatedText: "Hi", DetectedLanguage: "ko", DetectedScore: 0.8},
+		"Bonjour": {TranslatedText: "Good morning", DetectedLanguage: "fr", DetectedScore: 0.7},
+	}
+	threeTexts := []string{"こんにちは", "안녕하세요", "Bonjour"}
+	wantThree := []TranslationResponse{
+		{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en", DetectionConfidence: 0.9, Confidence: 0.9},
+		{OriginalText: "안녕하세요", TranslatedText: "Hi", SourceLanguage: "ko", TargetLanguage: "en", DetectionConfidence: 0.8, Confidence: 0.8},
+		{OriginalText: "Bonjour", TranslatedText: "Good morning", SourceLanguage: "fr", TargetLanguage: "en", DetectionConfidence: 0.7, Confidence: 0.7},
+	}
+
+	// "Bonjour" の結果がないプロバイダーでは3件目だけが失敗する
+	twoOutputs := map[string]*TranslationOutput{"こんにちは": outputs["こんにちは"], "안녕하세요": outputs["안녕하세요"]}
+	wantPartial := []TranslationResponse{wantThree[0], wantThree[1], {OriginalText: "Bonjour", TargetLanguage: "en", Error: errNoTranslationResult.Error()}}
+
+	tests := []struct {
+		name           string
+		provider       TranslationProvider
+		timeout        time.Duration
+		request        BatchTranslationRequest
+		wantStatus     int
+		want           []TranslationResponse
+		wantBatchCalls int
+	}{
+		{
+			name:       "three texts translated one by one",
+			provider:   &textTranslationProvider{outputs: outputs},
+			request:    BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus: http.StatusOK,
+			want:       wantThree,
+		},
+		{
+			name:           "three texts in one provider request",
+			provider:       &batchTextTranslationProvider{textTranslationProvider: textTranslationProvider{outputs: outputs}},
+			request:        BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus:     http.StatusOK,
+			want:           wantThree,
+			wantBatchCalls: 1,
+		},
+		{
+			name:       "one text fails while the others are translated",
+			provider:   &textTranslationProvider{outputs: twoOutputs},
+			request:    BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus: http.StatusMultiStatus,
+			want:       wantPartial,
+		},
+		{
+			name:           "one text missing from the provider request",
+			provider:       &batchTextTranslationProvider{textTranslationProvider: textTranslationProvider{outputs: twoOutputs}},
+			request:        BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus:     http.StatusMultiStatus,
+			want:           wantPartial,
+			wantBatchCalls: 1,
+		},
+		{
+			name:           "slow provider request exceeds the deadline and texts are translated one by one",
+			provider:       &batchTextTranslationProvider{textTranslationProvider: textTranslationProvider{outputs: outputs}, delay: time.Second},
+			timeout:        50 * time.Millisecond,
+			request:        BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus:     http.StatusOK,
+			want:           wantThree,
+			wantBatchCalls: 1,
+		},
+		{
+			name:       "all texts fail",
+			provider:   &textTranslationProvider{outputs: map[string]*TranslationOutput{}},
+			request:    BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "provider failure",
+			provider:       &batchTextTranslationProvider{err: errors.New("provider unavailable")},
+			request:        BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus:     http.StatusInternalServerError,
+			wantBatchCalls: 1,
+		},
+		{
+			name:       "no texts",
+			provider:   &textTranslationProvider{outputs: outputs},
+			request:    BatchTranslationRequest{Texts: []string{}, TargetLanguage: "en"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too many texts",
+			provider:   &textTranslationProvider{outputs: outputs},
+			request:    BatchTranslationRequest{Texts: make([]string, maxBatchTexts+1), TargetLanguage: "en"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not configured",
+			request:    BatchTranslationRequest{Texts: threeTexts, TargetLanguage: "en"},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTranslationProvider(t, tt.provider)
+			useBatchTranslation(t, 0, tt.timeout)
+
+			start := time.Now()
+			recorder := performJSON(t, TranslateBatchHandler, http.MethodPost, tt.request)
+			if elapsed := time.Since(start); tt.timeout > 0 && elapsed > 500*time.Millisecond {
+				t.Errorf("batch returned after %v, want the %v deadline to apply", elapsed, tt.timeout)
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if batch, ok := tt.provider.(*batchTextTranslationProvider); ok && batch.calls != tt.wantBatchCalls {
+				t.Errorf("TranslateBatch called %d times, want %d", batch.calls, tt.wantBatchCalls)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			var got []TranslationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not a JSON array: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("responses = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// staticTokenCredential はテスト用の固定のアクセストークン
+type staticTokenCredential struct{}
+
+func (staticTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "test-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestAzureTranslationProviderTranslateBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		texts    []string
+		source   string
+		response string
+		want     []*TranslationOutput
+		wantErr  error
+	}{
+		{
+			name:  "three texts with detected languages",
+			texts: []string{"こんにちは", "안녕하세요", "Bonjour"},
+			response: `[
+				{"detectedLanguage":{"language":"ja","score":0.9},"translations":[{"text":"Hello","to":"en"}]},
+				{"detectedLanguage":{"language":"ko","score":0.8},"translations":[{"text":"Hi","to":"en"}]},
+				{"detectedLanguage":{"language":"fr","score":0.7},"translations":[{"text":"Good morning","to":"en"}]}
+			]`,
+			want: []*TranslationOutput{
+				{TranslatedText: "Hello", DetectedLanguage: "ja", DetectedScore: 0.9},
+				{TranslatedText: "Hi", DetectedLanguage: "ko", DetectedScore: 0.8},
+				{TranslatedText: "Good morning", DetectedLanguage: "fr", DetectedScore: 0.7},
+			},
+		},
+		{
+			name:   "source language given",
+			texts:  []string{"一", "二", "三"},
+			source: "ja",
+			response: `[
+				{"translations":[{"text":"one","to":"en"}]},
+				{"translations":[{"text":"two","to":"en"}]},
+				{"translations":[{"text":"three","to":"en"}]}
+			]`,
+			want: []*TranslationOutput{{TranslatedText: "one"}, {TranslatedText: "two"}, {TranslatedText: "three"}},
+		},
+		{
+			name:     "fewer results than texts",
+			texts:    []string{"一", "二", "三"},
+			response: `[{"translations":[{"text":"one","to":"en"}]}]`,
+			wantErr:  errNoTranslationResult,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTexts []string
+			var gotFrom string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				var body []struct{ Text string }
+				json.NewDecoder(req.Body).Decode(&body)
+				for _, item := range body {
+					gotTexts = append(gotTexts, item.Text)
+				}
+				gotFrom = req.URL.Query().Get("from")
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := translatortext.NewTranslatorClient(server.URL, staticTokenCredential{}, &azcore.ClientOptions{Transport: server.Client()})
+			if err != nil {
+				t.Fatalf("NewTranslatorClient: %v", err)
+			}
+			provider := &azureTranslationProvider{client: client}
+
+			got, err := provider.TranslateBatch(context.Background(), tt.texts, tt.source, "en")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			// 1回のリクエストで入力と同じ順序のテキストが送信される
+			if !reflect.DeepEqual(gotTexts, tt.texts) || gotFrom != tt.source {
+				t.Errorf("request texts = %v from %q, want %v from %q", gotTexts, gotFrom, tt.texts, tt.source)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outputs = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateHandlerAutoDetect(t *testing.T) {
+	detected := &TranslationOutput{TranslatedText: "Hello", DetectedLanguage: "ja", DetectedScore: 0.95}
+	tests := []struct {
+		name           string
+		sourceLanguage string
+		output         *TranslationOutput
+		wantSource     string // プロバイダーが受け取る翻訳元言語
+		want           TranslationResponse
+	}{
+		{
+			name: "auto", sourceLanguage: "auto", output: detected,
+			want: TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en", DetectionConfidence: 0.95, Confidence: 0.95},
+		},
+		{
+			name: "auto is case-insensitive", sourceLanguage: " AUTO ", output: detected,
+			want: TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en", DetectionConfidence: 0.95, Confidence: 0.95},
+		},
+		{
+			name: "empty means auto", output: detected,
+			want: TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en", DetectionConfidence: 0.95, Confidence: 0.95},
+		},
+		{
+			name: "explicit source language", sourceLanguage: "ja", output: &TranslationOutput{TranslatedText: "Hello"}, wantSource: "ja",
+			want: TranslationResponse{OriginalText: "こんにちは", TranslatedText: "Hello", SourceLanguage: "ja", TargetLanguage: "en"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeTranslationProvider{output: tt.output}
+			useTranslationProvider(t, provider)
+
+			// 単一テキスト
+			recorder := performJSON(t, TranslateHandler, http.MethodPost, TranslationRequest{Text: "こんにちは", TargetLanguage: "en", SourceLanguage: tt.sourceLanguage})
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+			}
+			var got TranslationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("response = %+v, want %+v", got, tt.want)
+			}
+			if provider.source != tt.wantSource {
+				t.Errorf("provider source language = %q, want %q", provider.source, tt.wantSource)
+			}
+
+			// バッチ
+			provider.source = "unset"
+			recorder = performJSON(t, TranslateBatchHandler, http.MethodPost, BatchTranslationRequest{Texts: []string{"こんにちは"}, TargetLanguage: "en", SourceLanguage: tt.sourceLanguage})
+			var batch []TranslationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &batch); err != nil {
+				t.Fatalf("batch response is not a JSON array: %v", err)
+			}
+			if len(batch) != 1 || batch[0] != tt.want {
+				t.Errorf("batch responses = %+v, want [%+v]", batch, tt.want)
+			}
+			if provider.source != tt.wantSource {
+				t.Errorf("batch provider source language = %q, want %q", provider.source, tt.wantSource)
+			}
+		})
+	}
+}
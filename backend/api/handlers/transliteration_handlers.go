@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errTransliterationNotSupported は翻訳プロバイダーが文字体系の変換に対応していない場合のエラー
+var errTransliterationNotSupported = errors.New("translation provider does not support transliteration")
+
+// scriptCodePattern は ISO 15924 の文字体系コード（例: Jpan, Latn, Deva）
+var scriptCodePattern = regexp.MustCompile(`^[A-Z][a-z]{3}$`)
+
+// TransliterationRequest は文字体系の変換リクエストの構造体
+type TransliterationRequest struct {
+	Text       string `json:"text" binding:"required"`
+	Language   string `json:"language" binding:"required"`   // テキストの言語（例: ja, hi）
+	FromScript string `json:"fromScript" binding:"required"` // 入力の文字体系（例: Jpan）
+	ToScript   string `json:"toScript" binding:"required"`   // 出力の文字体系（例: Latn）
+}
+
+// TransliterationResponse は文字体系の変換レスポンスの構造体
+type TransliterationResponse struct {
+	OriginalText       string `json:"originalText"`
+	TransliteratedText string `json:"transliteratedText"`
+	Language           string `json:"language"`
+	Script             string `json:"script"`
+}
+
+// validateScripts は入力と出力の文字体系コードを検証します
+func (r *TransliterationRequest) validateScripts() error {
+	if !scriptCodePattern.MatchString(r.FromScript) {
+		return fmt.Errorf("invalid fromScript %q: expected an ISO 15924 script code such as Latn", r.FromScript)
+	}
+	if !scriptCodePattern.MatchString(r.ToScript) {
+		return fmt.Errorf("invalid toScript %q: expected an ISO 15924 script code such as Latn", r.ToScript)
+	}
+	if r.FromScript == r.ToScript {
+		return fmt.Errorf("fromScript and toScript must differ: %s", r.FromScript)
+	}
+	return nil
+}
+
+// TransliterateHandler は文字体系の変換（例: 日本語をローマ字に）のハンドラー
+func TransliterateHandler(c *gin.Context) {
+	var req TransliterationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validateScripts(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 翻訳サービスが設定されていない場合はパニックせずに 503 を返す
+	if translationProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errTranslationNotConfigured.Error()})
+		return
+	}
+	provider, ok := translationProvider.(TransliterationProvider)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": errTransliterationNotSupported.Error()})
+		return
+	}
+
+	log.Printf("Transliteration request: language=%s, %s -> %s", req.Language, req.FromScript, req.ToScript)
+	release, err := acquireUpstream(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	text, err := provider.Transliterate(c.Request.Context(), req.Text, req.Language, req.FromScript, req.ToScript)
+	release()
+	if errors.Is(err, errNoTranslationResult) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to execute transliteration: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, TransliterationResponse{
+		OriginalText:       req.Text,
+		TransliteratedText: text,
+		Language:           req.Language,
+		Script:             req.ToScript,
+	})
+}
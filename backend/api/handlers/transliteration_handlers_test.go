@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-realtime-translation-with-speech-service/backend/translatortext"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// fakeTransliterationProvider は固定の変換結果を返すテスト用の翻訳プロバイダー
+type fakeTransliterationProvider struct {
+	fakeTranslationProvider
+	text string
+	err  error
+
+	// 最後に受け取った引数
+	language, fromScript, toScript string
+}
+
+func (p *fakeTransliterationProvider) Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error) {
+	p.language, p.fromScript, p.toScript = language, fromScript, toScript
+	return p.text, p.err
+}
+
+func TestTransliterateHandler(t *testing.T) {
+	valid := TransliterationRequest{Text: "こんにちは", Language: "ja", FromScript: "Jpan", ToScript: "Latn"}
+	tests := []struct {
+		name       string
+		request    TransliterationRequest
+		provider   TranslationProvider
+		wantStatus int
+		want       TransliterationResponse
+		wantError  string
+	}{
+		{
+			name:       "japanese to latin",
+			request:    valid,
+			provider:   &fakeTransliterationProvider{text: "konnichiwa"},
+			wantStatus: http.StatusOK,
+			want:       TransliterationResponse{OriginalText: "こんにちは", TransliteratedText: "konnichiwa", Language: "ja", Script: "Latn"},
+		},
+		{
+			name:       "invalid fromScript",
+			request:    TransliterationRequest{Text: "こんにちは", Language: "ja", FromScript: "japanese", ToScript: "Latn"},
+			provider:   &fakeTransliterationProvider{},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid fromScript",
+		},
+		{
+			name:       "invalid toScript",
+			request:    TransliterationRequest{Text: "こんにちは", Language: "ja", FromScript: "Jpan", ToScript: "LATN"},
+			provider:   &fakeTransliterationProvider{},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid toScript",
+		},
+		{
+			name:       "same scripts",
+			request:    TransliterationRequest{Text: "hello", Language: "en", FromScript: "Latn", ToScript: "Latn"},
+			provider:   &fakeTransliterationProvider{},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "must differ",
+		},
+		{
+			name:       "missing language",
+			request:    TransliterationRequest{Text: "こんにちは", FromScript: "Jpan", ToScript: "Latn"},
+			provider:   &fakeTransliterationProvider{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "provider without transliteration",
+			request:    valid,
+			provider:   &fakeTranslationProvider{},
+			wantStatus: http.StatusNotImplemented,
+			wantError:  errTransliterationNotSupported.Error(),
+		},
+		{
+			name:       "not configured",
+			request:    valid,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  errTranslationNotConfigured.Error(),
+		},
+		{
+			name:       "provider failure",
+			request:    valid,
+			provider:   &fakeTransliterationProvider{err: errors.New("provider unavailable")},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "Failed to execute transliteration: provider unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTranslationProvider(t, tt.provider)
+
+			recorder := performJSON(t, TransliterateHandler, http.MethodPost, tt.request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var body map[string]string
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				if !strings.Contains(body["error"], tt.wantError) {
+					t.Errorf("error = %q, want it to contain %q", body["error"], tt.wantError)
+				}
+				return
+			}
+
+			var got TransliterationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("response = %+v, want %+v", got, tt.want)
+			}
+			provider := tt.provider.(*fakeTransliterationProvider)
+			if provider.language != tt.request.Language || provider.fromScript != tt.request.FromScript || provider.toScript != tt.request.ToScript {
+				t.Errorf("provider received (%q, %q, %q), want the request fields", provider.language, provider.fromScript, provider.toScript)
+			}
+		})
+	}
+}
+
+func TestAzureTranslationProviderTransliterate(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     string
+		wantErr  error
+	}{
+		{name: "transliterated text", response: `[{"text":"konnichiwa","script":"Latn"}]`, want: "konnichiwa"},
+		{name: "empty result", response: `[]`, wantErr: errNoTranslationResult},
+		{name: "service error", status: http.StatusBadRequest, response: `{"error":{"code":400036,"message":"invalid script"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQuery string
+			var gotBody []struct{ Text string }
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				query := req.URL.Query()
+				gotQuery = query.Get("language") + " " + query.Get("fromScript") + " " + query.Get("toScript")
+				json.NewDecoder(req.Body).Decode(&gotBody)
+				w.Header().Set("Content-Type", "application/json")
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := translatortext.NewTranslatorClient(server.URL, staticTokenCredential{}, &azcore.ClientOptions{Transport: server.Client()})
+			if err != nil {
+				t.Fatalf("NewTranslatorClient: %v", err)
+			}
+			provider := &azureTranslationProvider{client: client}
+
+			got, err := provider.Transliterate(context.Background(), "こんにちは", "ja", "Jpan", "Latn")
+			if gotQuery != "ja Jpan Latn" || len(gotBody) != 1 || gotBody[0].Text != "こんにちは" {
+				t.Errorf("request = %q with %+v, want ja Jpan Latn with the text", gotQuery, gotBody)
+			}
+			if tt.status != 0 {
+				var responseErr *azcore.ResponseError
+				if !errors.As(err, &responseErr) || responseErr.StatusCode != tt.status {
+					t.Fatalf("error = %v, want an *azcore.ResponseError with status %d", err, tt.status)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Transliterate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
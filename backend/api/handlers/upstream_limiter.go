@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errUpstreamBusy は上流サービスの呼び出し枠を待機中にタイムアウトした場合のエラー
+var errUpstreamBusy = errors.New("上流サービスへの同時リクエスト数が上限に達しています")
+
+// upstreamSlots はプロセス全体で Translator / Speech の REST API を同時に呼び出せる数のセマフォ（nil は無制限）
+var upstreamSlots chan struct{}
+
+// upstreamWaitTimeout は呼び出し枠が空くまで待機する最大時間（0は無期限）
+var upstreamWaitTimeout time.Duration
+
+// SetUpstreamConcurrency は Translator / Speech の REST API をプロセス全体で同時に呼び出せる数と、
+// 上限に達した場合に待機する最大時間をセットします（limit が0の場合は無制限）
+func SetUpstreamConcurrency(limit int, timeout time.Duration) {
+	if limit <= 0 {
+		upstreamSlots = nil
+	} else {
+		upstreamSlots = make(chan struct{}, limit)
+	}
+	if timeout < 0 {
+		timeout = 0
+	}
+	upstreamWaitTimeout = timeout
+}
+
+// acquireUpstream は上流サービスの呼び出し枠を取得し、解放する関数を返します
+// 枠が空くまで待機し、待機時間が上限を超えた場合は errUpstreamBusy を返します
+func acquireUpstream(ctx context.Context) (release func(), err error) {
+	slots := upstreamSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	if upstreamWaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, upstreamWaitTimeout)
+		defer cancel()
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, errUpstreamBusy
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-realtime-translation-with-speech-service/backend/gospeech"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useUpstreamConcurrency はテストの間だけ上流サービスの同時呼び出し数を設定します
+func useUpstreamConcurrency(t *testing.T, limit int, timeout time.Duration) {
+	t.Helper()
+	previousSlots, previousTimeout := upstreamSlots, upstreamWaitTimeout
+	SetUpstreamConcurrency(limit, timeout)
+	t.Cleanup(func() { upstreamSlots, upstreamWaitTimeout = previousSlots, previousTimeout })
+}
+
+// concurrencyCounter は同時に実行中の処理数とその最大値を記録します
+type concurrencyCounter struct {
+	current, max atomic.Int32
+}
+
+func (c *concurrencyCounter) enter() {
+	n := c.current.Add(1)
+	for {
+		max := c.max.Load()
+		if n <= max || c.max.CompareAndSwap(max, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrencyCounter) leave() { c.current.Add(-1) }
+
+func TestAcquireUpstream(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		timeout  time.Duration
+		workers  int
+		hold     time.Duration
+		wantMax  int // 0 は上限なし
+		wantBusy bool
+	}{
+		{name: "unlimited", limit: 0, workers: 20, hold: 20 * time.Millisecond},
+		{name: "one at a time", limit: 1, workers: 10, hold: 2 * time.Millisecond, wantMax: 1},
+		{name: "queued callers wait for a slot", limit: 3, workers: 30, hold: 5 * time.Millisecond, wantMax: 3},
+		{name: "queue timeout", limit: 1, timeout: 10 * time.Millisecond, workers: 5, hold: 100 * time.Millisecond, wantMax: 1, wantBusy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useUpstreamConcurrency(t, tt.limit, tt.timeout)
+
+			var counter concurrencyCounter
+			var busy atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < tt.workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release, err := acquireUpstream(context.Background())
+					if errors.Is(err, errUpstreamBusy) {
+						busy.Add(1)
+						return
+					}
+					if err != nil {
+						t.Errorf("acquireUpstream: %v", err)
+						return
+					}
+					counter.enter()
+					time.Sleep(tt.hold)
+					counter.leave()
+					release()
+				}()
+			}
+			wg.Wait()
+
+			if got := int(counter.max.Load()); tt.wantMax > 0 && got > tt.wantMax {
+				t.Errorf("max concurrency = %d, want at most %d", got, tt.wantMax)
+			}
+			if got := busy.Load() > 0; got != tt.wantBusy {
+				t.Errorf("busy errors = %d, want some %v", busy.Load(), tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestAcquireUpstreamCanceled(t *testing.T) {
+	useUpstreamConcurrency(t, 1, 0)
+	release, err := acquireUpstream(context.Background())
+	if err != nil {
+		t.Fatalf("acquireUpstream: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := acquireUpstream(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquireUpstream error = %v, want context.Canceled", err)
+	}
+}
+
+// countingTranslationProvider は同時に実行中の翻訳数を記録するテスト用の翻訳プロバイダー
+type countingTranslationProvider struct {
+	counter concurrencyCounter
+	hold    time.Duration
+}
+
+func (p *countingTranslationProvider) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (*TranslationOutput, error) {
+	p.counter.enter()
+	defer p.counter.leave()
+	time.Sleep(p.hold)
+	return &TranslationOutput{TranslatedText: text}, nil
+}
+
+func (p *countingTranslationProvider) DetectLanguage(ctx context.Context, text string) (string, float64, error) {
+	return "ja", 1, nil
+}
+
+func TestTranslateHandlerUpstreamLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		requests int
+	}{
+		{name: "limit 1", limit: 1, requests: 10},
+		{name: "limit 4", limit: 4, requests: 40},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/translate", TranslateHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useUpstreamConcurrency(t, tt.limit, 0)
+			provider := &countingTranslationProvider{hold: 5 * time.Millisecond}
+			useTranslationProvider(t, provider)
+
+			var wg sync.WaitGroup
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodPost, "/translate", strings.NewReader(`{"text":"こんにちは","targetLanguage":"en","sourceLanguage":"ja"}`))
+					req.Header.Set("Content-Type", "application/json")
+					recorder := httptest.NewRecorder()
+					router.ServeHTTP(recorder, req)
+					if recorder.Code != http.StatusOK {
+						t.Errorf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := int(provider.counter.max.Load()); got > tt.limit {
+				t.Errorf("max concurrent translations = %d, want at most %d", got, tt.limit)
+			}
+		})
+	}
+}
+
+func TestTranscribeTranslateHandlerUpstreamLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		timeout  time.Duration
+		requests int
+		// occupied は全リクエストの前に他の処理が使用している呼び出し枠の数
+		occupied   int
+		wantStatus int
+	}{
+		{name: "limit 1", limit: 1, requests: 5, wantStatus: http.StatusOK},
+		{name: "limit 2", limit: 2, requests: 8, wantStatus: http.StatusOK},
+		{name: "busy until the timeout", limit: 1, timeout: 10 * time.Millisecond, requests: 1, occupied: 1, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	previousKey, previousRegion := speechSubscriptionKey, speechRegion
+	SetSpeechCredentials("test-key", "japaneast")
+	t.Cleanup(func() { SetSpeechCredentials(previousKey, previousRegion) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/transcribe-translate", TranscribeTranslateHandler)
+	audio := testWAV(make([]byte, 3200))
+	languages := map[string]string{"sourceLanguage": "ja-JP", "targetLanguage": "en"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useUpstreamConcurrency(t, tt.limit, tt.timeout)
+			for i := 0; i < tt.occupied; i++ {
+				release, err := acquireUpstream(context.Background())
+				if err != nil {
+					t.Fatalf("acquireUpstream: %v", err)
+				}
+				t.Cleanup(release)
+			}
+			var counter concurrencyCounter
+			var calls atomic.Int32
+			useRecognizeFile(t, func(ctx context.Context, reader *gospeech.AudioFileReader, sourceLanguage, targetLanguage string) ([]*gospeech.TranslationRecognitionResult, error) {
+				calls.Add(1)
+				counter.enter()
+				defer counter.leave()
+				time.Sleep(5 * time.Millisecond)
+				return nil, nil
+			})
+
+			var wg sync.WaitGroup
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					recorder := httptest.NewRecorder()
+					router.ServeHTTP(recorder, transcribeRequest(t, "", audio, languages))
+					if recorder.Code != tt.wantStatus {
+						t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := int(counter.max.Load()); got > tt.limit {
+				t.Errorf("max concurrent recognitions = %d, want at most %d", got, tt.limit)
+			}
+			if tt.wantStatus != http.StatusOK && calls.Load() != 0 {
+				t.Errorf("recognizer called %d times while the upstream was busy, want 0", calls.Load())
+			}
+		})
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // AudioStreamFormat represents the audio stream format
@@ -51,10 +54,131 @@ func (f *AudioStreamFormat) Channels() int {
 	return f.channels
 }
 
+// BytesPerSecond returns the number of bytes per second of audio in this format
+func (f *AudioStreamFormat) BytesPerSecond() int {
+	return f.samplesPerSecond * f.bitsPerSample / 8 * f.channels
+}
+
+// Duration returns the playback duration of the given number of bytes of audio in this format
+func (f *AudioStreamFormat) Duration(bytes int) time.Duration {
+	bytesPerSecond := f.BytesPerSecond()
+	if bytesPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(int64(bytes) * int64(time.Second) / int64(bytesPerSecond))
+}
+
+// BlockAlign returns the size in bytes of one sample frame (one sample for every channel)
+func (f *AudioStreamFormat) BlockAlign() int {
+	return (f.bitsPerSample + 7) / 8 * f.channels
+}
+
+// ErrMisalignedAudio is returned when PCM audio does not end on a sample frame boundary
+var ErrMisalignedAudio = errors.New("audio length is not a multiple of the block alignment")
+
+// AlignPCM checks that the length of data is a whole number of sample frames of format. A partial
+// trailing frame, which would shift every following sample and turn the audio into noise, is
+// handled according to mode. data itself is never modified.
+func AlignPCM(data []byte, format *AudioStreamFormat, mode PCMAlignment) ([]byte, error) {
+	blockAlign := format.BlockAlign()
+	if mode == PCMAlignmentNone || blockAlign <= 1 {
+		return data, nil
+	}
+	extra := len(data) % blockAlign
+	if extra == 0 {
+		return data, nil
+	}
+
+	switch mode {
+	case PCMAlignmentPad:
+		padded := make([]byte, len(data)+blockAlign-extra)
+		copy(padded, data)
+		return padded, nil
+	case PCMAlignmentTruncate:
+		return data[:len(data)-extra], nil
+	default:
+		return nil, fmt.Errorf("%w: %d bytes, block alignment %d", ErrMisalignedAudio, len(data), blockAlign)
+	}
+}
+
+// contentType returns the content type of PCM audio in this format, as sent to the service
+func (f *AudioStreamFormat) contentType() string {
+	return fmt.Sprintf("audio/x-wav; codec=audio/pcm; samplerate=%d; bitspersample=%d; channels=%d",
+		f.samplesPerSecond, f.bitsPerSample, f.channels)
+}
+
+// keepAliveFrameDuration is the length of the silence frame sent to keep a connection alive
+const keepAliveFrameDuration = 100 * time.Millisecond
+
+// silenceFrame returns a buffer of silence of the given duration in the given format
+func silenceFrame(format *AudioStreamFormat, duration time.Duration) []byte {
+	blockAlign := format.bitsPerSample / 8 * format.channels
+	size := int(int64(format.BytesPerSecond()) * int64(duration) / int64(time.Second))
+	if blockAlign > 0 {
+		size -= size % blockAlign
+	}
+	return make([]byte, size)
+}
+
+// sampleRateCheckWindow is how long audio is observed before comparing the apparent sample rate
+const sampleRateCheckWindow = 2 * time.Second
+
+// sampleRateTolerance is the relative difference at which the apparent rate counts as a mismatch
+const sampleRateTolerance = 0.25
+
+// sampleRateMonitor estimates the sample rate of a real-time source from its byte rate,
+// to catch audio whose declared format does not match what is actually captured
+type sampleRateMonitor struct {
+	declared *AudioStreamFormat
+	start    time.Time
+	bytes    int64
+	done     bool
+}
+
+// newSampleRateMonitor creates a monitor for audio declared in the given format
+func newSampleRateMonitor(declared *AudioStreamFormat) *sampleRateMonitor {
+	return &sampleRateMonitor{declared: declared}
+}
+
+// observe records n bytes arriving at now. Once the observation window has elapsed it
+// reports the apparent sample rate and whether it diverges from the declared one; it
+// reports at most once.
+func (m *sampleRateMonitor) observe(n int, now time.Time) (apparentRate int, mismatch bool, ready bool) {
+	if m.done || n <= 0 {
+		return 0, false, false
+	}
+
+	// The first chunk only marks the start; its bytes were captured before it
+	if m.start.IsZero() {
+		m.start = now
+		return 0, false, false
+	}
+
+	m.bytes += int64(n)
+	elapsed := now.Sub(m.start)
+	if elapsed < sampleRateCheckWindow {
+		return 0, false, false
+	}
+	m.done = true
+
+	bytesPerFrame := m.declared.bitsPerSample / 8 * m.declared.channels
+	if bytesPerFrame <= 0 || m.declared.samplesPerSecond <= 0 {
+		return 0, false, true
+	}
+
+	apparentRate = int(float64(m.bytes) / elapsed.Seconds() / float64(bytesPerFrame))
+	declaredRate := float64(m.declared.samplesPerSecond)
+	diff := float64(apparentRate) - declaredRate
+	if diff < 0 {
+		diff = -diff
+	}
+	return apparentRate, diff/declaredRate > sampleRateTolerance, true
+}
+
 // AudioConfig represents audio input configuration
 type AudioConfig struct {
 	format     *AudioStreamFormat
-	sourceType string // "Microphone", "File", "Stream", "PushStream"
+	sourceType string // "Microphone", "File", "Stream", "PushStream", "URL"
 	source     interface{}
 }
 
@@ -79,14 +203,14 @@ func NewAudioConfig(source io.Reader) (*AudioConfig, error) {
 	return config, nil
 }
 
-// NewAudioConfigFromDefaultMicrophone creates an audio config from the default microphone
+// ErrMicrophoneNotSupported is returned because capturing from a local microphone is not implemented.
+// Use a PushAudioInputStream fed by the application instead.
+var ErrMicrophoneNotSupported = errors.New("microphone capture is not implemented; use a push stream instead")
+
+// NewAudioConfigFromDefaultMicrophone creates an audio config from the default microphone.
+// Microphone capture is not implemented, so it always returns ErrMicrophoneNotSupported.
 func NewAudioConfigFromDefaultMicrophone() (*AudioConfig, error) {
-	// In a real implementation, we would initialize microphone here
-	return &AudioConfig{
-		format:     GetDefaultInputFormat(),
-		sourceType: "Microphone",
-		source:     nil,
-	}, nil
+	return nil, ErrMicrophoneNotSupported
 }
 
 // NewAudioConfigFromWavFile creates an audio config from a WAV file
@@ -138,7 +262,7 @@ func (c *AudioConfig) Source() interface{} {
 
 // Close closes the audio source if applicable
 func (c *AudioConfig) Close() error {
-	if c.sourceType == "File" || c.sourceType == "Stream" {
+	if c.sourceType == "File" || c.sourceType == "Stream" || c.sourceType == "URL" {
 		if closer, ok := c.source.(io.Closer); ok {
 			return closer.Close()
 		}
@@ -150,9 +274,25 @@ func (c *AudioConfig) Close() error {
 type PushAudioInputStream struct {
 	format *AudioStreamFormat
 	buffer chan []byte
-	closed bool
+
+	// done is closed by Close; buffer itself is never closed so a racing Write cannot panic
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// pending holds the unread remainder of a chunk larger than the reader's buffer
+	readMu  sync.Mutex
+	pending []byte
+
+	// buffered is the number of bytes written but not yet read
+	buffered int64
+
+	// alignment is the PCMAlignment applied to each Write, accessed atomically
+	alignment int32
 }
 
+// ErrStreamClosed is returned by Write after the push stream has been closed
+var ErrStreamClosed = errors.New("stream is closed")
+
 // NewPushAudioInputStream creates a new push audio input stream
 func NewPushAudioInputStream(format *AudioStreamFormat) *PushAudioInputStream {
 	if format == nil {
@@ -162,47 +302,103 @@ func NewPushAudioInputStream(format *AudioStreamFormat) *PushAudioInputStream {
 	return &PushAudioInputStream{
 		format: format,
 		buffer: make(chan []byte, 100), // Buffer 100 chunks
-		closed: false,
+		done:   make(chan struct{}),
 	}
 }
 
-// Write writes audio data to the stream
+// SetAlignment sets how Write handles data that does not end on a sample frame boundary
+func (s *PushAudioInputStream) SetAlignment(mode PCMAlignment) {
+	atomic.StoreInt32(&s.alignment, int32(mode))
+}
+
+// GetAlignment returns how Write handles data that does not end on a sample frame boundary
+func (s *PushAudioInputStream) GetAlignment() PCMAlignment {
+	return PCMAlignment(atomic.LoadInt32(&s.alignment))
+}
+
+// Write writes audio data to the stream. It blocks while the buffer is full, and returns
+// ErrStreamClosed if the stream is or becomes closed. Data that does not end on a sample frame
+// boundary is handled according to the alignment set with SetAlignment.
 func (s *PushAudioInputStream) Write(data []byte) (int, error) {
-	if s.closed {
-		return 0, errors.New("stream is closed")
+	select {
+	case <-s.done:
+		return 0, ErrStreamClosed
+	default:
+	}
+
+	aligned, err := AlignPCM(data, s.format, s.GetAlignment())
+	if err != nil {
+		return 0, err
+	}
+	if len(aligned) == 0 {
+		return len(data), nil
 	}
 
 	// Make a copy of the data to avoid external mutations
-	dataCopy := make([]byte, len(data))
-	copy(dataCopy, data)
+	dataCopy := make([]byte, len(aligned))
+	copy(dataCopy, aligned)
 
-	s.buffer <- dataCopy
-	return len(data), nil
+	select {
+	case s.buffer <- dataCopy:
+		atomic.AddInt64(&s.buffered, int64(len(dataCopy)))
+		return len(data), nil
+	case <-s.done:
+		return 0, ErrStreamClosed
+	}
 }
 
-// Read reads audio data from the stream
+// Read reads audio data from the stream, blocking until data is available or the stream is
+// closed. Data written before Close is still returned; after that Read returns io.EOF.
+// When p is smaller than the buffered chunk, the remainder is returned by the next Read.
 func (s *PushAudioInputStream) Read(p []byte) (int, error) {
-	if s.closed {
-		return 0, io.EOF
+	return s.readUntil(p, nil)
+}
+
+// readUntil is Read that also returns (0, nil) once cancel is closed, so a stopped
+// recognition does not stay blocked on (or consume audio from) the stream
+func (s *PushAudioInputStream) readUntil(p []byte, cancel <-chan struct{}) (int, error) {
+	s.readMu.Lock()
+	defer s.readMu.Unlock()
+
+	if len(s.pending) > 0 {
+		return s.consume(p, s.pending), nil
 	}
 
 	select {
 	case data := <-s.buffer:
-		n := copy(p, data)
-		return n, nil
-	default:
-		// No data available
+		return s.consume(p, data), nil
+	case <-cancel:
 		return 0, nil
+	case <-s.done:
+		// 閉じる前に書き込まれたデータを先に返す
+		select {
+		case data := <-s.buffer:
+			return s.consume(p, data), nil
+		default:
+			return 0, io.EOF
+		}
 	}
 }
 
-// Close closes the stream
+// consume copies data into p and keeps the remainder as pending
+func (s *PushAudioInputStream) consume(p, data []byte) int {
+	n := copy(p, data)
+	s.pending = data[n:]
+	atomic.AddInt64(&s.buffered, -int64(n))
+	return n
+}
+
+// Close closes the stream, unblocking any pending Read. It is safe to call more than once.
 func (s *PushAudioInputStream) Close() error {
-	s.closed = true
-	close(s.buffer)
+	s.closeOnce.Do(func() { close(s.done) })
 	return nil
 }
 
+// BufferedBytes returns the number of bytes written to the stream but not yet read
+func (s *PushAudioInputStream) BufferedBytes() int {
+	return int(atomic.LoadInt64(&s.buffered))
+}
+
 // Format returns the audio format
 func (s *PushAudioInputStream) Format() *AudioStreamFormat {
 	return s.format
@@ -0,0 +1,427 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAlignPCM(t *testing.T) {
+	mono16 := GetWaveFormatPCM(16000, 16, 1)
+	stereo16 := GetWaveFormatPCM(16000, 16, 2)
+	mono8 := GetWaveFormatPCM(8000, 8, 1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		format  *AudioStreamFormat
+		mode    PCMAlignment
+		want    []byte
+		wantErr error
+	}{
+		{name: "aligned data is unchanged", data: []byte{1, 2, 3, 4}, format: mono16, mode: PCMAlignmentReject, want: []byte{1, 2, 3, 4}},
+		{name: "none passes misaligned data", data: []byte{1, 2, 3}, format: mono16, mode: PCMAlignmentNone, want: []byte{1, 2, 3}},
+		{name: "pad completes the frame", data: []byte{1, 2, 3}, format: mono16, mode: PCMAlignmentPad, want: []byte{1, 2, 3, 0}},
+		{name: "pad stereo frame", data: []byte{1, 2, 3, 4, 5}, format: stereo16, mode: PCMAlignmentPad, want: []byte{1, 2, 3, 4, 5, 0, 0, 0}},
+		{name: "truncate drops the partial frame", data: []byte{1, 2, 3}, format: mono16, mode: PCMAlignmentTruncate, want: []byte{1, 2}},
+		{name: "truncate stereo frame", data: []byte{1, 2, 3, 4, 5, 6}, format: stereo16, mode: PCMAlignmentTruncate, want: []byte{1, 2, 3, 4}},
+		{name: "truncate a single byte to nothing", data: []byte{1}, format: mono16, mode: PCMAlignmentTruncate, want: []byte{}},
+		{name: "reject misaligned data", data: []byte{1, 2, 3}, format: mono16, mode: PCMAlignmentReject, wantErr: ErrMisalignedAudio},
+		{name: "8-bit mono is always aligned", data: []byte{1, 2, 3}, format: mono8, mode: PCMAlignmentReject, want: []byte{1, 2, 3}},
+		{name: "empty data", data: []byte{}, format: mono16, mode: PCMAlignmentReject, want: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]byte(nil), tt.data...)
+			got, err := AlignPCM(tt.data, tt.format, tt.mode)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AlignPCM error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AlignPCM: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("AlignPCM = %v, want %v", got, tt.want)
+			}
+			if !bytes.Equal(tt.data, original) {
+				t.Errorf("AlignPCM modified its input: %v, want %v", tt.data, original)
+			}
+		})
+	}
+}
+
+func TestPushAudioInputStreamAlignment(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      PCMAlignment
+		data      []byte
+		wantN     int
+		wantErr   error
+		wantAudio []byte
+	}{
+		{name: "default passes misaligned data", mode: PCMAlignmentNone, data: []byte{1, 2, 3}, wantN: 3, wantAudio: []byte{1, 2, 3}},
+		{name: "pad", mode: PCMAlignmentPad, data: []byte{1, 2, 3}, wantN: 3, wantAudio: []byte{1, 2, 3, 0}},
+		{name: "truncate", mode: PCMAlignmentTruncate, data: []byte{1, 2, 3}, wantN: 3, wantAudio: []byte{1, 2}},
+		{name: "truncate a partial frame only", mode: PCMAlignmentTruncate, data: []byte{1}, wantN: 1},
+		{name: "reject", mode: PCMAlignmentReject, data: []byte{1, 2, 3}, wantErr: ErrMisalignedAudio},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewPushAudioInputStream(GetWaveFormatPCM(16000, 16, 1))
+			stream.SetAlignment(tt.mode)
+			if got := stream.GetAlignment(); got != tt.mode {
+				t.Errorf("GetAlignment() = %v, want %v", got, tt.mode)
+			}
+			n, err := stream.Write(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Write error = %v, want %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("Write = %d, want %d", n, tt.wantN)
+			}
+			stream.Close()
+
+			got, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(got, tt.wantAudio) {
+				t.Errorf("stream audio = %v, want %v", got, tt.wantAudio)
+			}
+		})
+	}
+}
+
+func TestPushAudioInputStreamPartialReads(t *testing.T) {
+	tests := []struct {
+		name      string
+		writes    [][]byte
+		readSize  int
+		wantReads [][]byte
+	}{
+		{
+			name:      "chunk larger than the read buffer",
+			writes:    [][]byte{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+			readSize:  4,
+			wantReads: [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10}},
+		},
+		{
+			name:      "remainder is returned before the next chunk",
+			writes:    [][]byte{{1, 2, 3}, {4, 5}},
+			readSize:  2,
+			wantReads: [][]byte{{1, 2}, {3}, {4, 5}},
+		},
+		{
+			name:      "read buffer larger than the chunk",
+			writes:    [][]byte{{1, 2}, {3, 4}},
+			readSize:  8,
+			wantReads: [][]byte{{1, 2}, {3, 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewPushAudioInputStream(nil)
+			for _, data := range tt.writes {
+				if _, err := stream.Write(data); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			// Data written before Close is still returned, then Read reports io.EOF
+			stream.Close()
+
+			buf := make([]byte, tt.readSize)
+			for i, want := range tt.wantReads {
+				n, err := stream.Read(buf)
+				if err != nil {
+					t.Fatalf("read %d: %v", i, err)
+				}
+				if !bytes.Equal(buf[:n], want) {
+					t.Errorf("read %d = %v, want %v", i, buf[:n], want)
+				}
+			}
+			if n, err := stream.Read(buf); err != io.EOF {
+				t.Errorf("final Read = (%d, %v), want io.EOF", n, err)
+			}
+		})
+	}
+}
+
+func TestPushAudioInputStreamLargeChunk(t *testing.T) {
+	// One write of a full second of audio read back through a small buffer
+	data := make([]byte, 32000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	stream := NewPushAudioInputStream(nil)
+	if _, err := stream.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	stream.Close()
+
+	var got []byte
+	buf := make([]byte, 3000)
+	for {
+		n, err := stream.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("recovered %d bytes that differ from the %d written", len(got), len(data))
+	}
+}
+
+func TestPushAudioInputStreamBlockingRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes [][]byte
+		delay  time.Duration // pause before each write while the consumer is blocked
+	}{
+		{name: "no data before close", delay: 20 * time.Millisecond},
+		{name: "slow producer", writes: [][]byte{{1, 2}, {3}, {4, 5, 6}}, delay: 20 * time.Millisecond},
+		{name: "fast producer", writes: [][]byte{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewPushAudioInputStream(nil)
+
+			var want []byte
+			for _, data := range tt.writes {
+				want = append(want, data...)
+			}
+			go func() {
+				for _, data := range tt.writes {
+					time.Sleep(tt.delay)
+					if _, err := stream.Write(data); err != nil {
+						t.Errorf("Write: %v", err)
+					}
+				}
+				time.Sleep(tt.delay)
+				stream.Close()
+			}()
+
+			// Every Read either returns data or blocks until the stream is closed
+			var got []byte
+			buf := make([]byte, 16)
+			for {
+				n, err := stream.Read(buf)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				if n == 0 {
+					t.Fatal("Read returned no data without blocking")
+				}
+				got = append(got, buf[:n]...)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("read %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPushAudioInputStreamClose(t *testing.T) {
+	tests := []struct {
+		name       string
+		closeTwice bool
+	}{
+		{name: "close"},
+		{name: "close twice", closeTwice: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewPushAudioInputStream(nil)
+			readErr := make(chan error, 1)
+			go func() {
+				_, err := stream.Read(make([]byte, 16))
+				readErr <- err
+			}()
+
+			time.Sleep(20 * time.Millisecond)
+			stream.Close()
+			if tt.closeTwice {
+				stream.Close()
+			}
+			select {
+			case err := <-readErr:
+				if err != io.EOF {
+					t.Errorf("blocked Read error = %v, want io.EOF", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Close did not unblock the pending Read")
+			}
+
+			if _, err := stream.Write([]byte{1, 2}); !errors.Is(err, ErrStreamClosed) {
+				t.Errorf("Write after Close error = %v, want %v", err, ErrStreamClosed)
+			}
+		})
+	}
+}
+
+func TestPushAudioInputStreamConcurrentWriteAndClose(t *testing.T) {
+	// Writers racing with Close must get ErrStreamClosed rather than panic on a closed channel
+	stream := NewPushAudioInputStream(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := stream.Write([]byte{byte(j)}); err != nil {
+					if !errors.Is(err, ErrStreamClosed) {
+						t.Errorf("Write error = %v, want %v", err, ErrStreamClosed)
+					}
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	stream.Close()
+	wg.Wait()
+}
+
+func TestSampleRateMonitor(t *testing.T) {
+	declared := GetWaveFormatPCM(16000, 16, 1)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		bytesPerTick int // bytes observed every 100ms
+		ticks        int
+		wantReady    bool
+		wantMismatch bool
+		wantRate     int
+	}{
+		{name: "48kHz data declared as 16kHz", bytesPerTick: 9600, ticks: 21, wantReady: true, wantMismatch: true, wantRate: 48000},
+		{name: "matching 16kHz data", bytesPerTick: 3200, ticks: 21, wantReady: true, wantMismatch: false, wantRate: 16000},
+		{name: "within tolerance", bytesPerTick: 3600, ticks: 21, wantReady: true, wantMismatch: false, wantRate: 18000},
+		{name: "8kHz data declared as 16kHz", bytesPerTick: 1600, ticks: 21, wantReady: true, wantMismatch: true, wantRate: 8000},
+		{name: "not enough audio observed yet", bytesPerTick: 9600, ticks: 10, wantReady: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := newSampleRateMonitor(declared)
+			var rate int
+			var mismatch, ready bool
+			for i := 0; i < tt.ticks; i++ {
+				r, m, ok := monitor.observe(tt.bytesPerTick, start.Add(time.Duration(i)*100*time.Millisecond))
+				if ok {
+					if ready {
+						t.Fatal("the monitor reported more than once")
+					}
+					rate, mismatch, ready = r, m, ok
+				}
+			}
+			if ready != tt.wantReady {
+				t.Fatalf("ready = %v, want %v", ready, tt.wantReady)
+			}
+			if mismatch != tt.wantMismatch {
+				t.Errorf("mismatch = %v, want %v", mismatch, tt.wantMismatch)
+			}
+			if rate != tt.wantRate {
+				t.Errorf("apparent rate = %d, want %d", rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestMicrophoneSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func() error
+	}{
+		{name: "default microphone audio config", create: func() error {
+			_, err := NewAudioConfigFromDefaultMicrophone()
+			return err
+		}},
+		{name: "recognizer without an audio config", create: func() error {
+			config, err := SpeechTranslationConfigFromSubscription("test-key", "japaneast")
+			if err != nil {
+				return err
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			_, err = NewTranslationRecognizer(config, nil)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.create(); !errors.Is(err, ErrMicrophoneNotSupported) {
+				t.Errorf("error = %v, want ErrMicrophoneNotSupported", err)
+			}
+		})
+	}
+}
+
+func TestUnreadableAudioSourceIsCanceled(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, _ := newTestRecognizer(t, service)
+	recognizer.audioConfig = &AudioConfig{format: GetDefaultInputFormat(), sourceType: "Microphone"}
+
+	canceled := make(chan *CancellationDetails, 1)
+	recognizer.Canceled().Connect(func(eventArgs interface{}) {
+		canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+	})
+	if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+		t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+	}
+	defer recognizer.StopContinuousRecognition()
+
+	select {
+	case details := <-canceled:
+		if details.Reason != CancellationReasonError || details.ErrorCode != CancellationErrorRuntimeError {
+			t.Errorf("cancellation = %+v, want a runtime error", details)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the recognizer to cancel")
+	}
+}
+
+func TestAudioStreamFormatDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		format *AudioStreamFormat
+		bytes  int
+		want   time.Duration
+	}{
+		{name: "16kHz 16-bit mono", format: GetWaveFormatPCM(16000, 16, 1), bytes: 32000, want: time.Second},
+		{name: "partial second", format: GetWaveFormatPCM(16000, 16, 1), bytes: 3200, want: 100 * time.Millisecond},
+		{name: "48kHz 16-bit stereo", format: GetWaveFormatPCM(48000, 16, 2), bytes: 19200, want: 100 * time.Millisecond},
+		{name: "no audio", format: GetWaveFormatPCM(16000, 16, 1), bytes: 0, want: 0},
+		{name: "unknown rate", format: GetWaveFormatPCM(0, 16, 1), bytes: 3200, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Duration(tt.bytes); got != tt.want {
+				t.Errorf("Duration(%d) = %v, want %v", tt.bytes, got, tt.want)
+			}
+		})
+	}
+}
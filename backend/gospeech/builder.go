@@ -0,0 +1,113 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"errors"
+)
+
+// RecognizerOptions describes everything needed to build a ready-to-use TranslationRecognizer.
+// Exactly one connection mode is chosen from the populated fields, in this order of precedence:
+// AuthorizationToken, Endpoint, Host, then SubscriptionKey with Region.
+type RecognizerOptions struct {
+	SubscriptionKey    string
+	Region             string
+	Endpoint           string
+	Host               string
+	AuthorizationToken string
+
+	SourceLanguage  string
+	TargetLanguages []string
+
+	// AudioConfig is the audio input and is required.
+	// Microphone input is not supported, so there is no default audio source.
+	AudioConfig *AudioConfig
+
+	// ChunkSize is the number of bytes read from the audio source per send.
+	// DefaultAudioChunkSize is used when zero.
+	ChunkSize int
+}
+
+// BuildRecognizer validates the options, applies defaults and returns a ready recognizer
+func BuildRecognizer(options RecognizerOptions) (*TranslationRecognizer, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	config, err := options.translationConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config.SetSpeechRecognitionLanguage(options.SourceLanguage)
+	for _, lang := range options.TargetLanguages {
+		config.AddTargetLanguage(lang)
+	}
+
+	recognizer, err := NewTranslationRecognizer(config, options.AudioConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize := options.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultAudioChunkSize
+	}
+	if err := recognizer.SetChunkSize(chunkSize); err != nil {
+		return nil, err
+	}
+
+	return recognizer, nil
+}
+
+// validate checks that the options describe a usable connection and language setup
+func (o RecognizerOptions) validate() error {
+	var errs []error
+
+	switch {
+	case o.AuthorizationToken != "":
+		if o.Region == "" {
+			errs = append(errs, errors.New("region is required when using an authorization token"))
+		}
+	case o.Endpoint != "", o.Host != "":
+		if o.SubscriptionKey == "" {
+			errs = append(errs, errors.New("subscription key is required when using an endpoint or host"))
+		}
+	case o.SubscriptionKey != "":
+		if o.Region == "" {
+			errs = append(errs, errors.New("region is required when using a subscription key"))
+		}
+	default:
+		errs = append(errs, errors.New("one of subscription key or authorization token must be set"))
+	}
+
+	if o.SourceLanguage == "" {
+		errs = append(errs, errors.New("source language must be set"))
+	}
+	if len(o.TargetLanguages) == 0 {
+		errs = append(errs, errors.New("at least one target language must be set"))
+	}
+	if o.AudioConfig == nil {
+		errs = append(errs, errors.New("audio config must be set: microphone input is not supported, use a push stream, file or URL source"))
+	}
+	if o.ChunkSize < 0 {
+		errs = append(errs, errors.New("chunk size cannot be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// translationConfig creates the translation config for the chosen connection mode
+func (o RecognizerOptions) translationConfig() (*SpeechTranslationConfig, error) {
+	switch {
+	case o.AuthorizationToken != "":
+		return SpeechTranslationConfigFromAuthToken(o.AuthorizationToken, o.Region)
+	case o.Endpoint != "":
+		return SpeechTranslationConfigFromEndpoint(o.Endpoint, o.SubscriptionKey)
+	case o.Host != "":
+		return SpeechTranslationConfigFromHost(o.Host, o.SubscriptionKey)
+	default:
+		return SpeechTranslationConfigFromSubscription(o.SubscriptionKey, o.Region)
+	}
+}
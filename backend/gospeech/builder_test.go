@@ -0,0 +1,207 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestAudioConfig(t *testing.T) *AudioConfig {
+	t.Helper()
+	audioConfig, err := NewAudioConfigFromPushStream(NewPushAudioInputStream(nil))
+	if err != nil {
+		t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+	}
+	return audioConfig
+}
+
+func TestBuildRecognizerValidation(t *testing.T) {
+	audioConfig := newTestAudioConfig(t)
+	valid := RecognizerOptions{
+		SubscriptionKey: "key",
+		Region:          "japaneast",
+		SourceLanguage:  "ja-JP",
+		TargetLanguages: []string{"en"},
+		AudioConfig:     audioConfig,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(o *RecognizerOptions)
+		wantErr []string // substrings expected in the error; nil means success
+	}{
+		{name: "subscription key and region", modify: func(o *RecognizerOptions) {}},
+		{name: "authorization token", modify: func(o *RecognizerOptions) {
+			o.SubscriptionKey = ""
+			o.AuthorizationToken = "token"
+		}},
+		{name: "endpoint with key", modify: func(o *RecognizerOptions) {
+			o.Region = ""
+			o.Endpoint = "wss://example.com/speech/universal/v2"
+		}},
+		{name: "host with key", modify: func(o *RecognizerOptions) {
+			o.Region = ""
+			o.Host = "example.com"
+		}},
+		{name: "no credentials", modify: func(o *RecognizerOptions) {
+			o.SubscriptionKey = ""
+		}, wantErr: []string{"one of subscription key or authorization token must be set"}},
+		{name: "key without region", modify: func(o *RecognizerOptions) {
+			o.Region = ""
+		}, wantErr: []string{"region is required when using a subscription key"}},
+		{name: "token without region", modify: func(o *RecognizerOptions) {
+			o.SubscriptionKey = ""
+			o.Region = ""
+			o.AuthorizationToken = "token"
+		}, wantErr: []string{"region is required when using an authorization token"}},
+		{name: "endpoint without key", modify: func(o *RecognizerOptions) {
+			o.SubscriptionKey = ""
+			o.Endpoint = "wss://example.com"
+		}, wantErr: []string{"subscription key is required when using an endpoint or host"}},
+		{name: "no source language", modify: func(o *RecognizerOptions) {
+			o.SourceLanguage = ""
+		}, wantErr: []string{"source language must be set"}},
+		{name: "no target languages", modify: func(o *RecognizerOptions) {
+			o.TargetLanguages = nil
+		}, wantErr: []string{"at least one target language must be set"}},
+		{name: "unsupported target language", modify: func(o *RecognizerOptions) {
+			o.TargetLanguages = []string{"xx-invalid"}
+		}, wantErr: []string{"unsupported target languages: xx-invalid"}},
+		{name: "no audio config", modify: func(o *RecognizerOptions) {
+			o.AudioConfig = nil
+		}, wantErr: []string{"audio config must be set"}},
+		{name: "negative chunk size", modify: func(o *RecognizerOptions) {
+			o.ChunkSize = -1
+		}, wantErr: []string{"chunk size cannot be negative"}},
+		{name: "all problems are reported together", modify: func(o *RecognizerOptions) {
+			o.SourceLanguage = ""
+			o.ChunkSize = -1
+			o.AudioConfig = nil
+		}, wantErr: []string{"source language must be set", "chunk size cannot be negative", "audio config must be set"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := valid
+			tt.modify(&options)
+
+			recognizer, err := BuildRecognizer(options)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("BuildRecognizer: %v", err)
+				}
+				if recognizer == nil {
+					t.Fatal("BuildRecognizer returned a nil recognizer")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("BuildRecognizer succeeded, want an error containing %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRecognizerConnectionMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      RecognizerOptions
+		wantToken    string
+		wantEndpoint string
+		wantHost     string
+		wantRegion   string
+	}{
+		{
+			name:       "subscription key and region",
+			options:    RecognizerOptions{SubscriptionKey: "key", Region: "japaneast"},
+			wantRegion: "japaneast",
+		},
+		{
+			name:       "token takes precedence over everything else",
+			options:    RecognizerOptions{AuthorizationToken: "token", Region: "japaneast", Endpoint: "wss://example.com", SubscriptionKey: "key"},
+			wantToken:  "token",
+			wantRegion: "japaneast",
+		},
+		{
+			name:         "endpoint takes precedence over host",
+			options:      RecognizerOptions{Endpoint: "wss://example.com", Host: "example.org", SubscriptionKey: "key"},
+			wantEndpoint: "wss://example.com",
+		},
+		{
+			name:     "host",
+			options:  RecognizerOptions{Host: "example.org", SubscriptionKey: "key"},
+			wantHost: "example.org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.SourceLanguage = "ja-JP"
+			options.TargetLanguages = []string{"en", "de"}
+			options.AudioConfig = newTestAudioConfig(t)
+
+			recognizer, err := BuildRecognizer(options)
+			if err != nil {
+				t.Fatalf("BuildRecognizer: %v", err)
+			}
+			config := recognizer.config
+			if got := config.GetAuthorizationToken(); got != tt.wantToken {
+				t.Errorf("authorization token = %q, want %q", got, tt.wantToken)
+			}
+			if got := config.GetProperty(SpeechServiceConnectionEndpoint); got != tt.wantEndpoint {
+				t.Errorf("endpoint = %q, want %q", got, tt.wantEndpoint)
+			}
+			if got := config.GetProperty(SpeechServiceConnectionHost); got != tt.wantHost {
+				t.Errorf("host = %q, want %q", got, tt.wantHost)
+			}
+			if got := config.GetRegion(); got != tt.wantRegion {
+				t.Errorf("region = %q, want %q", got, tt.wantRegion)
+			}
+			if got := config.GetSpeechRecognitionLanguage(); got != "ja-JP" {
+				t.Errorf("source language = %q, want %q", got, "ja-JP")
+			}
+			if got := config.GetTargetLanguages(); !reflect.DeepEqual(got, []string{"en", "de"}) {
+				t.Errorf("target languages = %v, want %v", got, []string{"en", "de"})
+			}
+		})
+	}
+}
+
+func TestBuildRecognizerChunkSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+		want      int
+	}{
+		{name: "default", chunkSize: 0, want: DefaultAudioChunkSize},
+		{name: "custom", chunkSize: 3200, want: 3200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recognizer, err := BuildRecognizer(RecognizerOptions{
+				SubscriptionKey: "key",
+				Region:          "japaneast",
+				SourceLanguage:  "ja-JP",
+				TargetLanguages: []string{"en"},
+				AudioConfig:     newTestAudioConfig(t),
+				ChunkSize:       tt.chunkSize,
+			})
+			if err != nil {
+				t.Fatalf("BuildRecognizer: %v", err)
+			}
+			if got := recognizer.GetChunkSize(); got != tt.want {
+				t.Errorf("GetChunkSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
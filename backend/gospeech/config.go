@@ -6,7 +6,12 @@ package gospeech
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // SpeechConfig contains configuration for speech recognition services
@@ -204,7 +209,142 @@ func (c *SpeechConfig) SetProxy(hostname string, port int, username, password st
 	return nil
 }
 
+// SetProxyHostBypass sets the hosts that are connected to directly instead of through the proxy.
+// A host matches itself and its subdomains; a leading "*." or "." is accepted, e.g. "*.internal".
+func (c *SpeechConfig) SetProxyHostBypass(hosts ...string) {
+	c.SetProperty(SpeechServiceConnectionProxyHostBypass, strings.Join(hosts, ","))
+}
+
+// proxyFunc returns the proxy selection for the WebSocket dialer, or nil if no proxy is configured.
+// Credentials are carried in the proxy URL, from which the dialer sends Proxy-Authorization.
+func (c *SpeechConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	host := c.GetProperty(SpeechServiceConnectionProxyHostName)
+	if host == "" {
+		return nil
+	}
+	proxyURL := &url.URL{Scheme: "http", Host: host}
+	if port := c.GetProperty(SpeechServiceConnectionProxyPort); port != "" {
+		proxyURL.Host = net.JoinHostPort(host, port)
+	}
+	if user := c.GetProperty(SpeechServiceConnectionProxyUserName); user != "" {
+		if password := c.GetProperty(SpeechServiceConnectionProxyPassword); password != "" {
+			proxyURL.User = url.UserPassword(user, password)
+		} else {
+			proxyURL.User = url.User(user)
+		}
+	}
+	bypass := splitProxyBypass(c.GetProperty(SpeechServiceConnectionProxyHostBypass))
+
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyBypassed(req.URL.Hostname(), bypass) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// splitProxyBypass splits a comma or semicolon separated bypass list into lower-case host patterns
+func splitProxyBypass(value string) []string {
+	var hosts []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		host := strings.ToLower(strings.TrimSpace(field))
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "*"), ".")
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// proxyBypassed reports whether host is one of the bypass hosts or a subdomain of one
+func proxyBypassed(host string, bypass []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range bypass {
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconnect policy defaults, used when the properties are not set
+const (
+	defaultReconnectMaxRetries = 3
+	defaultReconnectBaseDelay  = 500 * time.Millisecond
+	defaultReconnectMaxDelay   = 10 * time.Second
+)
+
+// SetReconnectPolicy sets how continuous recognition reconnects after a transient connection failure.
+// The delay before each attempt doubles from baseDelay up to maxDelay; zero maxRetries disables reconnection.
+func (c *SpeechConfig) SetReconnectPolicy(maxRetries int, baseDelay, maxDelay time.Duration) error {
+	if maxRetries < 0 {
+		return errors.New("max retries cannot be negative")
+	}
+	if baseDelay < 0 || maxDelay < 0 {
+		return errors.New("reconnect delay cannot be negative")
+	}
+	if maxDelay < baseDelay {
+		return errors.New("max reconnect delay cannot be less than the base delay")
+	}
+
+	c.SetProperty(SpeechServiceConnectionReconnectMaxRetries, strconv.Itoa(maxRetries))
+	c.SetProperty(SpeechServiceConnectionReconnectBaseDelayMs, strconv.FormatInt(baseDelay.Milliseconds(), 10))
+	c.SetProperty(SpeechServiceConnectionReconnectMaxDelayMs, strconv.FormatInt(maxDelay.Milliseconds(), 10))
+	return nil
+}
+
+// GetReconnectPolicy returns the reconnect retry count and backoff delays
+func (c *SpeechConfig) GetReconnectPolicy() (maxRetries int, baseDelay, maxDelay time.Duration) {
+	maxRetries = defaultReconnectMaxRetries
+	if n, err := strconv.Atoi(c.GetProperty(SpeechServiceConnectionReconnectMaxRetries)); err == nil && n >= 0 {
+		maxRetries = n
+	}
+	baseDelay = defaultReconnectBaseDelay
+	if ms, err := strconv.ParseInt(c.GetProperty(SpeechServiceConnectionReconnectBaseDelayMs), 10, 64); err == nil && ms >= 0 {
+		baseDelay = time.Duration(ms) * time.Millisecond
+	}
+	maxDelay = defaultReconnectMaxDelay
+	if ms, err := strconv.ParseInt(c.GetProperty(SpeechServiceConnectionReconnectMaxDelayMs), 10, 64); err == nil && ms >= 0 {
+		maxDelay = time.Duration(ms) * time.Millisecond
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	return maxRetries, baseDelay, maxDelay
+}
+
 // SetServiceProperty sets a property that will be passed to the service
 func (c *SpeechConfig) SetServiceProperty(name, value string, channel ServicePropertyChannel) {
 	c.SetPropertyByName(fmt.Sprintf("ServiceProperty:%s:%d", name, channel), value)
 }
+
+// redactedValue replaces secret property values in diagnostic output
+const redactedValue = "***REDACTED***"
+
+// secretProperties lists the properties whose values must never appear in diagnostics
+var secretProperties = map[PropertyID]bool{
+	SpeechServiceConnectionKey:           true,
+	SpeechServiceAuthorizationToken:      true,
+	SpeechServiceConnectionProxyPassword: true,
+}
+
+// DebugSnapshot returns all configured properties for diagnostics, keyed by property name,
+// with the subscription key, authorization token and proxy password redacted
+func (c *SpeechConfig) DebugSnapshot() map[string]string {
+	props, propsByName := c.properties.snapshot()
+
+	snapshot := make(map[string]string, len(props)+len(propsByName))
+	for name, val := range propsByName {
+		if secretProperties[PropertyID(name)] && val != "" {
+			val = redactedValue
+		}
+		snapshot[name] = val
+	}
+	for id, val := range props {
+		if secretProperties[id] && val != "" {
+			val = redactedValue
+		}
+		snapshot[string(id)] = val
+	}
+	return snapshot
+}
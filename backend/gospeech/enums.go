@@ -4,7 +4,10 @@
 // Package enums defines the enumeration types used in the Speech SDK
 package gospeech
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // PropertyID represents speech property identifiers
 type PropertyID string
@@ -33,6 +36,13 @@ const (
 	SpeechServiceConnectionRecoLanguage           PropertyID = "SpeechServiceConnection_RecoLanguage"
 	SpeechSessionID                               PropertyID = "Speech_SessionId"
 	SpeechServiceConnectionUserDefinedQueryParams PropertyID = "SpeechServiceConnection_UserDefinedQueryParameters"
+	SpeechServiceConnectionReconnectMaxRetries    PropertyID = "SpeechServiceConnection_ReconnectMaxRetries"
+	SpeechServiceConnectionReconnectBaseDelayMs   PropertyID = "SpeechServiceConnection_ReconnectBaseDelayMs"
+	SpeechServiceConnectionReconnectMaxDelayMs    PropertyID = "SpeechServiceConnection_ReconnectMaxDelayMs"
+
+	// SpeechServiceResponse properties
+	SpeechServiceResponseTranslationIncludeSource PropertyID = "SpeechServiceResponse_TranslationIncludeSource"
+	SpeechServiceResponseProfanityOption          PropertyID = "SpeechServiceResponse_ProfanityOption"
 )
 
 // ResultReason defines the reason a result was generated
@@ -44,6 +54,7 @@ const (
 	ResultReasonNoMatch
 	ResultReasonCanceled
 	ResultReasonTranslatedSpeech
+	ResultReasonTranslatingSpeech
 )
 
 // String returns the string representation of ResultReason
@@ -57,6 +68,8 @@ func (r ResultReason) String() string {
 		return "Canceled"
 	case ResultReasonTranslatedSpeech:
 		return "TranslatedSpeech"
+	case ResultReasonTranslatingSpeech:
+		return "TranslatingSpeech"
 	default:
 		return fmt.Sprintf("Unknown ResultReason (%d)", r)
 	}
@@ -83,6 +96,11 @@ func (r CancellationReason) String() string {
 	}
 }
 
+// MarshalJSON encodes CancellationReason as its string representation
+func (r CancellationReason) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
 // CancellationErrorCode defines specific error codes for cancellation
 type CancellationErrorCode int
 
@@ -128,6 +146,11 @@ func (e CancellationErrorCode) String() string {
 	}
 }
 
+// MarshalJSON encodes CancellationErrorCode as its string representation
+func (e CancellationErrorCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
 // OutputFormat defines different output formats for recognition results
 type OutputFormat int
 
@@ -196,3 +219,156 @@ func (c ServicePropertyChannel) String() string {
 		return fmt.Sprintf("Unknown ServicePropertyChannel (%d)", c)
 	}
 }
+
+// WarningCode identifies the kind of warning raised during recognition
+type WarningCode int
+
+// WarningCode constants
+const (
+	WarningSampleRateMismatch WarningCode = iota
+	WarningPossibleDeadSource
+)
+
+// String returns the string representation of WarningCode
+func (c WarningCode) String() string {
+	switch c {
+	case WarningSampleRateMismatch:
+		return "SampleRateMismatch"
+	case WarningPossibleDeadSource:
+		return "PossibleDeadSource"
+	default:
+		return fmt.Sprintf("Unknown WarningCode (%d)", c)
+	}
+}
+
+// EmptyAudioBehavior defines how recognition completes when the audio source has no data
+type EmptyAudioBehavior int
+
+// EmptyAudioBehavior constants
+const (
+	// EmptyAudioError fails with ErrNoAudio (RecognizeOnce) or a Canceled event (continuous)
+	EmptyAudioError EmptyAudioBehavior = iota
+	// EmptyAudioEmptyResult completes with an empty NoMatch result
+	EmptyAudioEmptyResult
+)
+
+// String returns the string representation of EmptyAudioBehavior
+func (b EmptyAudioBehavior) String() string {
+	switch b {
+	case EmptyAudioError:
+		return "Error"
+	case EmptyAudioEmptyResult:
+		return "EmptyResult"
+	default:
+		return fmt.Sprintf("Unknown EmptyAudioBehavior (%d)", b)
+	}
+}
+
+// PCMAlignment defines how a push stream handles writes that do not end on a sample frame boundary
+type PCMAlignment int
+
+// PCMAlignment constants
+const (
+	// PCMAlignmentNone passes the audio through unchanged (the default)
+	PCMAlignmentNone PCMAlignment = iota
+	// PCMAlignmentPad completes the partial trailing frame with silence
+	PCMAlignmentPad
+	// PCMAlignmentTruncate drops the partial trailing frame
+	PCMAlignmentTruncate
+	// PCMAlignmentReject fails the write with ErrMisalignedAudio
+	PCMAlignmentReject
+)
+
+// String returns the string representation of PCMAlignment
+func (a PCMAlignment) String() string {
+	switch a {
+	case PCMAlignmentNone:
+		return "None"
+	case PCMAlignmentPad:
+		return "Pad"
+	case PCMAlignmentTruncate:
+		return "Truncate"
+	case PCMAlignmentReject:
+		return "Reject"
+	default:
+		return fmt.Sprintf("Unknown PCMAlignment (%d)", a)
+	}
+}
+
+// PunctuationMode defines how the service punctuates recognized and translated text
+type PunctuationMode int
+
+// PunctuationMode constants
+const (
+	// PunctuationExplicit inserts punctuation (the default)
+	PunctuationExplicit PunctuationMode = iota
+	// PunctuationImplicit keeps only punctuation that was dictated
+	PunctuationImplicit
+	// PunctuationNone removes punctuation
+	PunctuationNone
+)
+
+// String returns the string representation of PunctuationMode
+func (m PunctuationMode) String() string {
+	switch m {
+	case PunctuationExplicit:
+		return "Explicit"
+	case PunctuationImplicit:
+		return "Implicit"
+	case PunctuationNone:
+		return "None"
+	default:
+		return fmt.Sprintf("Unknown PunctuationMode (%d)", m)
+	}
+}
+
+// serviceValue returns the value used in the speech.config message
+func (m PunctuationMode) serviceValue() string {
+	switch m {
+	case PunctuationImplicit:
+		return "implicit"
+	case PunctuationNone:
+		return "none"
+	default:
+		return "explicit"
+	}
+}
+
+// ProfanityOption defines how profanity is handled in recognized and translated text
+type ProfanityOption int
+
+// ProfanityOption constants
+const (
+	// ProfanityMasked replaces profane words with asterisks (the default)
+	ProfanityMasked ProfanityOption = iota
+	// ProfanityRemoved removes profane words
+	ProfanityRemoved
+	// ProfanityRaw keeps profane words as spoken
+	ProfanityRaw
+)
+
+// String returns the string representation of ProfanityOption
+func (o ProfanityOption) String() string {
+	switch o {
+	case ProfanityMasked:
+		return "Masked"
+	case ProfanityRemoved:
+		return "Removed"
+	case ProfanityRaw:
+		return "Raw"
+	default:
+		return fmt.Sprintf("Unknown ProfanityOption (%d)", o)
+	}
+}
+
+// serviceValue returns the value used in the speech.config message
+func (o ProfanityOption) serviceValue() string {
+	switch o {
+	case ProfanityRemoved:
+		return "removed"
+	case ProfanityRaw:
+		return "raw"
+	default:
+		return "masked"
+	}
+}
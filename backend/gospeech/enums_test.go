@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCancellationDetailsJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		details CancellationDetails
+		want    string
+	}{
+		{
+			name:    "authentication failure",
+			details: CancellationDetails{Reason: CancellationReasonError, ErrorCode: CancellationErrorAuthenticationFailure, ErrorDetails: "invalid key"},
+			want:    `{"reason":"Error","errorCode":"AuthenticationFailure","errorDetails":"invalid key"}`,
+		},
+		{
+			name:    "end of stream omits empty details",
+			details: CancellationDetails{Reason: CancellationReasonEndOfStream, ErrorCode: CancellationErrorNoError},
+			want:    `{"reason":"EndOfStream","errorCode":"NoError"}`,
+		},
+		{
+			name:    "unknown values keep their number",
+			details: CancellationDetails{Reason: CancellationReason(9), ErrorCode: CancellationErrorCode(42)},
+			want:    `{"reason":"Unknown CancellationReason (9)","errorCode":"Unknown CancellationErrorCode (42)"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.details)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
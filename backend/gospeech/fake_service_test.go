@@ -0,0 +1,219 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeSpeechService is a minimal Speech Service endpoint that records what the recognizer sends
+// and lets a test push service frames back
+type fakeSpeechService struct {
+	server      *httptest.Server
+	connections atomic.Int32
+	audioBytes  atomic.Int64
+	accepted    chan *fakeServiceConn
+	// rejectNext is the number of upcoming connection attempts to refuse with 503
+	rejectNext atomic.Int32
+	// holdTurnEnd stops the service from ending the turn when the end-of-audio marker arrives
+	holdTurnEnd atomic.Bool
+
+	// onMessage, when set, is called for every message the recognizer sends
+	onMessage func(conn *fakeServiceConn, messageType int, message []byte)
+}
+
+// fakeServiceConn is one recognizer connection accepted by the fake service
+type fakeServiceConn struct {
+	conn       *websocket.Conn
+	header     http.Header
+	requestURI string
+	closed     chan struct{}
+	// readErr is the error that ended the connection; valid once closed is closed
+	readErr error
+
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	messages []fakeMessage
+}
+
+type fakeMessage struct {
+	messageType int
+	data        []byte
+}
+
+func newFakeSpeechService(t *testing.T) *fakeSpeechService {
+	t.Helper()
+	service := &fakeSpeechService{accepted: make(chan *fakeServiceConn, 10)}
+	upgrader := websocket.Upgrader{}
+	service.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for n := service.rejectNext.Load(); n > 0; n = service.rejectNext.Load() {
+			if service.rejectNext.CompareAndSwap(n, n-1) {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fc := &fakeServiceConn{conn: conn, header: req.Header.Clone(), requestURI: req.RequestURI, closed: make(chan struct{})}
+		defer close(fc.closed)
+		service.connections.Add(1)
+		service.accepted <- fc
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				fc.readErr = err
+				return
+			}
+			fc.mu.Lock()
+			fc.messages = append(fc.messages, fakeMessage{messageType: messageType, data: message})
+			fc.mu.Unlock()
+			if messageType == websocket.BinaryMessage {
+				service.audioBytes.Add(int64(len(message)))
+			}
+			if service.onMessage != nil {
+				service.onMessage(fc, messageType, message)
+			}
+			// Like the real service, end the turn once no more audio follows
+			if messageType == websocket.BinaryMessage && len(message) == 0 && !service.holdTurnEnd.Load() {
+				fc.send("turn.end", "{}")
+			}
+		}
+	}))
+	t.Cleanup(service.server.Close)
+	return service
+}
+
+// url returns the WebSocket URL of the fake service
+func (s *fakeSpeechService) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/speech/universal/v2"
+}
+
+// waitForConn waits for the next recognizer connection
+func (s *fakeSpeechService) waitForConn(t *testing.T) *fakeServiceConn {
+	t.Helper()
+	select {
+	case fc := <-s.accepted:
+		return fc
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a connection")
+		return nil
+	}
+}
+
+// send writes a text frame with the given path and JSON body to the recognizer
+func (c *fakeServiceConn) send(path, body string) error {
+	return c.sendWithRequestID(path, "test", body)
+}
+
+// sendWithRequestID writes a text frame with the given path, X-RequestId and JSON body to the recognizer
+func (c *fakeServiceConn) sendWithRequestID(path, requestID, body string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	frame := fmt.Sprintf("Path: %s\r\nX-RequestId: %s\r\nContent-Type: application/json\r\n\r\n%s", path, requestID, body)
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(frame))
+}
+
+// sendFinalPhrase sends a final speech.phrase with the given text and translations
+func (c *fakeServiceConn) sendFinalPhrase(text string, translations map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":         "final",
+		"NBest":        []map[string]interface{}{{"Display": text}},
+		"Translations": translations,
+	})
+	if err != nil {
+		return err
+	}
+	return c.send("speech.phrase", string(body))
+}
+
+// received returns the messages received so far
+func (c *fakeServiceConn) received() []fakeMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]fakeMessage(nil), c.messages...)
+}
+
+// textPaths returns the Path header of every text message received so far
+func (c *fakeServiceConn) textPaths() []string {
+	var paths []string
+	for _, m := range c.received() {
+		if m.messageType != websocket.TextMessage {
+			continue
+		}
+		for _, line := range strings.Split(string(m.data), "\r\n") {
+			if strings.HasPrefix(line, "Path:") {
+				paths = append(paths, strings.TrimSpace(strings.TrimPrefix(line, "Path:")))
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// textBody returns the body of the first text message received with the given path
+func (c *fakeServiceConn) textBody(path string) (string, bool) {
+	for _, m := range c.received() {
+		if m.messageType != websocket.TextMessage {
+			continue
+		}
+		header, body, _ := strings.Cut(string(m.data), "\r\n\r\n")
+		if strings.HasPrefix(header, "Path: "+path+"\r\n") {
+			return body, true
+		}
+	}
+	return "", false
+}
+
+// newTestRecognizer returns a recognizer connected to the fake service and the push stream feeding it
+func newTestRecognizer(t *testing.T, service *fakeSpeechService) (*TranslationRecognizer, *PushAudioInputStream) {
+	t.Helper()
+	stream := NewPushAudioInputStream(GetDefaultInputFormat())
+	audioConfig, err := NewAudioConfigFromPushStream(stream)
+	if err != nil {
+		t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+	}
+	return newTestRecognizerWithAudio(t, service, audioConfig), stream
+}
+
+// newTestRecognizerWithAudio creates a ja-JP → en recognizer connected to the fake service that reads from audioConfig
+func newTestRecognizerWithAudio(t *testing.T, service *fakeSpeechService, audioConfig *AudioConfig) *TranslationRecognizer {
+	t.Helper()
+	config, err := SpeechTranslationConfigFromEndpoint(service.url(), "test-key")
+	if err != nil {
+		t.Fatalf("SpeechTranslationConfigFromEndpoint: %v", err)
+	}
+	config.SetSpeechRecognitionLanguage("ja-JP")
+	config.AddTargetLanguage("en")
+
+	recognizer, err := NewTranslationRecognizer(config, audioConfig)
+	if err != nil {
+		t.Fatalf("NewTranslationRecognizer: %v", err)
+	}
+	return recognizer
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// AudioFileReader streams the PCM data of a WAV file, optionally looping it
+// for demos and load tests
+type AudioFileReader struct {
+	// Loop restarts from the beginning of the data chunk when the end is reached
+	Loop bool
+
+	file       *os.File
+	format     *AudioStreamFormat
+	dataOffset int64
+	dataSize   int64
+	remaining  int64
+}
+
+// NewAudioFileReader opens a WAV file and positions the reader at the start of its audio data
+func NewAudioFileReader(filePath string) (*AudioFileReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &AudioFileReader{file: file}
+	if err := r.parseHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to parse WAV header: %v", err)
+	}
+	return r, nil
+}
+
+// parseHeader reads the RIFF chunks up to the data chunk
+func (r *AudioFileReader) parseHeader() error {
+	var riff [12]byte
+	if _, err := io.ReadFull(r.file, riff[:]); err != nil {
+		return err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return errors.New("not a RIFF/WAVE file")
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := int64(len(riff))
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r.file, header[:]); err != nil {
+			return fmt.Errorf("data chunk not found: %v", err)
+		}
+		offset += int64(len(header))
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < int64(len(fmtChunk)) {
+				return errors.New("fmt chunk too short")
+			}
+			if _, err := io.ReadFull(r.file, fmtChunk[:]); err != nil {
+				return err
+			}
+			channels := int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			samplesPerSecond := int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bitsPerSample := int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			r.format = NewAudioStreamFormat(samplesPerSecond, bitsPerSample, channels)
+			if _, err := r.file.Seek(size-int64(len(fmtChunk)), io.SeekCurrent); err != nil {
+				return err
+			}
+		case "data":
+			if r.format == nil {
+				return errors.New("data chunk precedes fmt chunk")
+			}
+			// Some recorders leave the size unset; use the rest of the file instead
+			if size > info.Size()-offset {
+				size = info.Size() - offset
+			}
+			r.dataOffset = offset
+			r.dataSize = size
+			r.remaining = size
+			return nil
+		default:
+			if _, err := r.file.Seek(size, io.SeekCurrent); err != nil {
+				return err
+			}
+		}
+
+		// Chunks are padded to an even size
+		offset += size
+		if size%2 == 1 {
+			if _, err := r.file.Seek(1, io.SeekCurrent); err != nil {
+				return err
+			}
+			offset++
+		}
+	}
+}
+
+// Read implements io.Reader, returning only audio data. With Loop set it never returns io.EOF
+// unless the file has no audio data.
+func (r *AudioFileReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		if !r.Loop || r.dataSize == 0 {
+			return 0, io.EOF
+		}
+		if _, err := r.file.Seek(r.dataOffset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.remaining = r.dataSize
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.file.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF {
+		// The file was shorter than its header declared
+		r.remaining = 0
+		if n > 0 || r.Loop {
+			err = nil
+		}
+	}
+	return n, err
+}
+
+// Format returns the audio format declared in the WAV header
+func (r *AudioFileReader) Format() *AudioStreamFormat {
+	return r.format
+}
+
+// Close closes the underlying file
+func (r *AudioFileReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,163 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// wavChunk is a RIFF chunk written by writeTestWAV
+type wavChunk struct {
+	id   string
+	data []byte
+	size int // declared size; -1 uses len(data)
+}
+
+// writeTestWAV writes a 16kHz 16-bit mono WAV file made of the given chunks after the fmt chunk
+func writeTestWAV(t *testing.T, chunks ...wavChunk) string {
+	t.Helper()
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:2], 1)
+	binary.LittleEndian.PutUint16(fmtChunk[2:4], 1)
+	binary.LittleEndian.PutUint32(fmtChunk[4:8], 16000)
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], 32000)
+	binary.LittleEndian.PutUint16(fmtChunk[12:14], 2)
+	binary.LittleEndian.PutUint16(fmtChunk[14:16], 16)
+	chunks = append([]wavChunk{{id: "fmt ", data: fmtChunk, size: -1}}, chunks...)
+	for _, c := range chunks {
+		size := c.size
+		if size < 0 {
+			size = len(c.data)
+		}
+		body.WriteString(c.id)
+		binary.Write(&body, binary.LittleEndian, uint32(size))
+		body.Write(c.data)
+		if len(c.data)%2 == 1 && c.id != "data" {
+			body.WriteByte(0)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	binary.Write(&file, binary.LittleEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := os.WriteFile(path, file.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAudioFileReader(t *testing.T) {
+	audio := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name     string
+		chunks   []wavChunk
+		loop     bool
+		readSize int
+		total    int // bytes to read; 0 reads until EOF
+		want     []byte
+	}{
+		{
+			name:     "reads the data chunk then EOF",
+			chunks:   []wavChunk{{id: "data", data: audio, size: -1}},
+			readSize: 4,
+			want:     audio,
+		},
+		{
+			name:     "loops past the end of the file",
+			chunks:   []wavChunk{{id: "data", data: audio, size: -1}},
+			loop:     true,
+			readSize: 4,
+			total:    25,
+			want:     append(append(append([]byte{}, audio...), audio...), audio[:5]...),
+		},
+		{
+			name:     "skips an odd-sized chunk before the data on every loop",
+			chunks:   []wavChunk{{id: "LIST", data: []byte{9, 9, 9}, size: -1}, {id: "data", data: audio, size: -1}},
+			loop:     true,
+			readSize: 3,
+			total:    20,
+			want:     append(append([]byte{}, audio...), audio...),
+		},
+		{
+			name:     "data size larger than the file",
+			chunks:   []wavChunk{{id: "data", data: audio, size: 1000}},
+			loop:     true,
+			readSize: 8,
+			total:    15,
+			want:     append(append([]byte{}, audio...), audio[:5]...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewAudioFileReader(writeTestWAV(t, tt.chunks...))
+			if err != nil {
+				t.Fatalf("NewAudioFileReader: %v", err)
+			}
+			defer reader.Close()
+			reader.Loop = tt.loop
+
+			if got := reader.Format(); *got != *GetWaveFormatPCM(16000, 16, 1) {
+				t.Errorf("Format = %+v, want 16kHz 16-bit mono", got)
+			}
+
+			var got []byte
+			buf := make([]byte, tt.readSize)
+			for tt.total == 0 || len(got) < tt.total {
+				if tt.total > 0 && tt.total-len(got) < len(buf) {
+					buf = buf[:tt.total-len(got)]
+				}
+				n, err := reader.Read(buf)
+				got = append(got, buf[:n]...)
+				if err == io.EOF {
+					if tt.loop {
+						t.Fatal("a looping reader returned EOF")
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAudioFileReaderRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "not a WAV file", content: []byte("this is not a wave file")},
+		{name: "truncated header", content: []byte("RIFF")},
+		{name: "no data chunk", content: append([]byte("RIFF\x04\x00\x00\x00WAVE"), []byte("LIST\x02\x00\x00\x00ab")...)},
+		{name: "data before fmt", content: []byte("RIFF\x0e\x00\x00\x00WAVEdata\x02\x00\x00\x00ab")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "invalid.wav")
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if reader, err := NewAudioFileReader(path); err == nil {
+				reader.Close()
+				t.Error("NewAudioFileReader succeeded, want an error")
+			}
+		})
+	}
+}
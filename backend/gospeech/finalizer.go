@@ -0,0 +1,100 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// silenceFinalizer promotes the latest partial result to a final one when no new
+// partial arrives within the timeout, so captions are finalized during long pauses.
+// A service final that arrives after a promotion, with no partial in between, is
+// reported as a duplicate.
+type silenceFinalizer struct {
+	mu        sync.Mutex
+	timeout   time.Duration
+	now       func() time.Time
+	emit      func(*TranslationRecognitionResult)
+	timer     *time.Timer
+	pending   *TranslationRecognitionResult
+	finalized bool
+	stopped   bool
+}
+
+// newSilenceFinalizer creates a finalizer that calls emit with synthesized finals.
+// A zero timeout disables it.
+func newSilenceFinalizer(timeout time.Duration, now func() time.Time, emit func(*TranslationRecognitionResult)) *silenceFinalizer {
+	return &silenceFinalizer{timeout: timeout, now: now, emit: emit}
+}
+
+// partial records a partial result and restarts the silence timer
+func (f *silenceFinalizer) partial(result *TranslationRecognitionResult) {
+	if f.timeout <= 0 || result == nil || result.Text == "" {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return
+	}
+	f.pending = result
+	f.finalized = false
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	f.timer = time.AfterFunc(f.timeout, f.fire)
+}
+
+// final records a final result from the service and reports whether it should be
+// delivered, i.e. whether it was not already synthesized from the preceding partial
+func (f *silenceFinalizer) final(result *TranslationRecognitionResult) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.pending = nil
+	if f.finalized {
+		f.finalized = false
+		return false
+	}
+	return true
+}
+
+// fire emits the pending partial as a final result
+func (f *silenceFinalizer) fire() {
+	f.mu.Lock()
+	if f.stopped || f.pending == nil {
+		f.mu.Unlock()
+		return
+	}
+	result := *f.pending
+	f.pending = nil
+	f.timer = nil
+	f.finalized = true
+	f.mu.Unlock()
+
+	result.ResultID = fmt.Sprintf("result_%d", f.now().UnixNano())
+	result.Reason = ResultReasonTranslatedSpeech
+	if len(result.Translations) == 0 {
+		result.Reason = ResultReasonRecognizedSpeech
+	}
+	f.emit(&result)
+}
+
+// stop cancels any pending promotion
+func (f *silenceFinalizer) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	f.pending = nil
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SupportedLanguage is a language that can be recognized and used as a translation target
+type SupportedLanguage struct {
+	Code   string `json:"code"`   // Language code used for translation targets, e.g. "en"
+	Locale string `json:"locale"` // Locale used for recognition when only the code is given, e.g. "en-US"
+}
+
+//go:embed languages.json
+var defaultLanguagesJSON []byte
+
+var (
+	languagesMu sync.RWMutex
+	// languageMap maps the supported language codes to the full locale used for recognition
+	languageMap map[string]string
+)
+
+func init() {
+	languages, err := parseLanguages(defaultLanguagesJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded language list: %v", err))
+	}
+	setLanguages(languages)
+}
+
+// LoadSupportedLanguages replaces the supported languages with a JSON array of
+// {"code": ..., "locale": ...} objects, e.g. one built from the Translator languages API
+func LoadSupportedLanguages(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	languages, err := parseLanguages(data)
+	if err != nil {
+		return err
+	}
+	setLanguages(languages)
+	return nil
+}
+
+// SetSupportedLanguages replaces the supported languages
+func SetSupportedLanguages(languages []SupportedLanguage) error {
+	if err := validateLanguages(languages); err != nil {
+		return err
+	}
+	setLanguages(languages)
+	return nil
+}
+
+// SupportedLanguages returns the supported languages sorted by code
+func SupportedLanguages() []SupportedLanguage {
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	languages := make([]SupportedLanguage, 0, len(languageMap))
+	for code, locale := range languageMap {
+		languages = append(languages, SupportedLanguage{Code: code, Locale: locale})
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Code < languages[j].Code })
+	return languages
+}
+
+// IsSupportedTargetLanguage reports whether lang can be used as a translation target
+func IsSupportedTargetLanguage(lang string) bool {
+	_, ok := lookupLocale(normalizeLanguageCode(lang, false))
+	return ok
+}
+
+// lookupLocale returns the recognition locale of a supported language code
+func lookupLocale(code string) (string, bool) {
+	languagesMu.RLock()
+	defer languagesMu.RUnlock()
+	locale, ok := languageMap[code]
+	return locale, ok
+}
+
+func parseLanguages(data []byte) ([]SupportedLanguage, error) {
+	var languages []SupportedLanguage
+	if err := json.Unmarshal(data, &languages); err != nil {
+		return nil, fmt.Errorf("failed to parse language list: %v", err)
+	}
+	if err := validateLanguages(languages); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+func validateLanguages(languages []SupportedLanguage) error {
+	if len(languages) == 0 {
+		return errors.New("language list is empty")
+	}
+	for _, language := range languages {
+		if language.Code == "" || language.Locale == "" {
+			return fmt.Errorf("language entry needs both code and locale: %+v", language)
+		}
+	}
+	return nil
+}
+
+func setLanguages(languages []SupportedLanguage) {
+	m := make(map[string]string, len(languages))
+	for _, language := range languages {
+		m[strings.ToLower(language.Code)] = language.Locale
+	}
+	languagesMu.Lock()
+	defer languagesMu.Unlock()
+	languageMap = m
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSupportedTargetLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		want bool
+	}{
+		{lang: "en", want: true},
+		{lang: "it", want: true},
+		{lang: "pt", want: true},
+		{lang: "ru", want: true},
+		{lang: "ar", want: true},
+		{lang: "hi", want: true},
+		{lang: "nl", want: true},
+		{lang: "fil", want: true},
+		{lang: "en-US", want: true},
+		{lang: "zh-Hans", want: true},
+		{lang: "xx", want: false},
+		{lang: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			if got := IsSupportedTargetLanguage(tt.lang); got != tt.want {
+				t.Errorf("IsSupportedTargetLanguage(%q) = %v, want %v", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSupportedLanguages(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		wantErr       string
+		wantSupported []string
+		wantRejected  []string
+	}{
+		{
+			name:          "replaces the list",
+			json:          `[{"code":"en","locale":"en-US"},{"code":"sw","locale":"sw-KE"}]`,
+			wantSupported: []string{"en", "sw"},
+			wantRejected:  []string{"ja", "it"},
+		},
+		{name: "invalid JSON", json: `{`, wantErr: "failed to parse language list", wantSupported: []string{"ja", "it"}},
+		{name: "empty list", json: `[]`, wantErr: "language list is empty", wantSupported: []string{"ja", "it"}},
+		{name: "missing locale", json: `[{"code":"sw"}]`, wantErr: "needs both code and locale", wantSupported: []string{"ja", "it"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := SupportedLanguages()
+			t.Cleanup(func() { SetSupportedLanguages(previous) })
+
+			err := LoadSupportedLanguages(strings.NewReader(tt.json))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("LoadSupportedLanguages: %v", err)
+			}
+
+			// A rejected list leaves the current languages in place
+			for _, lang := range tt.wantSupported {
+				if !IsSupportedTargetLanguage(lang) {
+					t.Errorf("%s is not supported, want supported", lang)
+				}
+			}
+			for _, lang := range tt.wantRejected {
+				if IsSupportedTargetLanguage(lang) {
+					t.Errorf("%s is supported, want rejected", lang)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import "sync"
+
+// MetricsSnapshot holds the counters of a recognizer at a point in time
+type MetricsSnapshot struct {
+	AudioBytesSent int64 // audio sent to the service
+	PartialResults int64 // Recognizing events raised
+	FinalResults   int64 // Recognized events raised
+	DroppedResults int64 // final results dropped for being shorter than the minimum duration
+	Reconnects     int64 // connections established after the first one
+	Errors         int64 // Canceled events raised for an error
+}
+
+// RecognizerMetrics accumulates the counters of a recognizer. It is safe for concurrent use.
+type RecognizerMetrics struct {
+	mu       sync.Mutex
+	counters MetricsSnapshot
+}
+
+// Snapshot returns the current counters
+func (m *RecognizerMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters
+}
+
+// SnapshotAndReset returns the current counters and sets them to zero in one step,
+// so no update made by the recognition worker is lost between the two
+func (m *RecognizerMetrics) SnapshotAndReset() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := m.counters
+	m.counters = MetricsSnapshot{}
+	return snapshot
+}
+
+// update applies a change to the counters under the lock
+func (m *RecognizerMetrics) update(apply func(counters *MetricsSnapshot)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	apply(&m.counters)
+}
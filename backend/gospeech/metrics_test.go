@@ -0,0 +1,125 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecognizerMetricsSnapshotAndReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []func(m *MetricsSnapshot)
+		want    MetricsSnapshot
+	}{
+		{name: "no updates"},
+		{
+			name: "every counter",
+			updates: []func(m *MetricsSnapshot){
+				func(m *MetricsSnapshot) { m.AudioBytesSent += 3200 },
+				func(m *MetricsSnapshot) { m.PartialResults++ },
+				func(m *MetricsSnapshot) { m.FinalResults++ },
+				func(m *MetricsSnapshot) { m.DroppedResults++ },
+				func(m *MetricsSnapshot) { m.Reconnects++ },
+				func(m *MetricsSnapshot) { m.Errors++ },
+			},
+			want: MetricsSnapshot{AudioBytesSent: 3200, PartialResults: 1, FinalResults: 1, DroppedResults: 1, Reconnects: 1, Errors: 1},
+		},
+		{
+			name: "repeated updates accumulate",
+			updates: []func(m *MetricsSnapshot){
+				func(m *MetricsSnapshot) { m.FinalResults++ },
+				func(m *MetricsSnapshot) { m.FinalResults++ },
+				func(m *MetricsSnapshot) { m.AudioBytesSent += 100 },
+				func(m *MetricsSnapshot) { m.AudioBytesSent += 200 },
+			},
+			want: MetricsSnapshot{AudioBytesSent: 300, FinalResults: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metrics RecognizerMetrics
+			for _, update := range tt.updates {
+				metrics.update(update)
+			}
+			if got := metrics.Snapshot(); got != tt.want {
+				t.Errorf("Snapshot() = %+v, want %+v", got, tt.want)
+			}
+			if got := metrics.SnapshotAndReset(); got != tt.want {
+				t.Errorf("SnapshotAndReset() = %+v, want %+v", got, tt.want)
+			}
+			if got := metrics.Snapshot(); got != (MetricsSnapshot{}) {
+				t.Errorf("Snapshot() after reset = %+v, want zero", got)
+			}
+		})
+	}
+}
+
+func TestRecognizerMetricsConcurrentReset(t *testing.T) {
+	const updates = 10000
+	var metrics RecognizerMetrics
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < updates/4; i++ {
+				metrics.update(func(m *MetricsSnapshot) { m.FinalResults++ })
+			}
+		}()
+	}
+
+	// Every update lands in exactly one interval
+	var total int64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		total += metrics.SnapshotAndReset().FinalResults
+	}
+	if total != updates {
+		t.Errorf("snapshots add up to %d results, want %d", total, updates)
+	}
+}
+
+func TestRecognizerMetricsFromSession(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, stream := newTestRecognizer(t, service)
+	recognized := make(chan struct{}, 10)
+	recognizer.Recognized().Connect(func(interface{}) { recognized <- struct{}{} })
+	if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+		t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+	}
+	defer recognizer.StopContinuousRecognition()
+	fc := service.waitForConn(t)
+
+	stream.Write(make([]byte, 3200))
+	waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+	fc.send("translation.hypothesis", `{"Text":"こん","Translations":{"en":"Hel"}}`)
+	fc.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"})
+	select {
+	case <-recognized:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the recognized result")
+	}
+
+	want := MetricsSnapshot{AudioBytesSent: 3200, PartialResults: 1, FinalResults: 1}
+	if got := recognizer.Metrics().SnapshotAndReset(); got != want {
+		t.Errorf("SnapshotAndReset() = %+v, want %+v", got, want)
+	}
+	if got := recognizer.Metrics().Snapshot(); got != (MetricsSnapshot{}) {
+		t.Errorf("Snapshot() after reset = %+v, want zero", got)
+	}
+}
@@ -85,3 +85,19 @@ func (pc *PropertyCollection) SetPropertiesByName(properties map[string]string)
 		pc.propsByName[name] = val
 	}
 }
+
+// snapshot returns copies of all properties set by ID and by name
+func (pc *PropertyCollection) snapshot() (map[PropertyID]string, map[string]string) {
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+
+	props := make(map[PropertyID]string, len(pc.props))
+	for id, val := range pc.props {
+		props[id] = val
+	}
+	propsByName := make(map[string]string, len(pc.propsByName))
+	for name, val := range pc.propsByName {
+		propsByName[name] = val
+	}
+	return props, propsByName
+}
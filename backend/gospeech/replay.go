@@ -0,0 +1,109 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"sync"
+	"time"
+)
+
+// replayChunk is a chunk of audio that was sent to the service at a given time
+type replayChunk struct {
+	data   []byte
+	sentAt time.Time
+}
+
+// audioReplayBuffer retains recently sent audio so it can be replayed after a reconnect.
+// Chunks older than the window are discarded; a zero window disables retention.
+type audioReplayBuffer struct {
+	mu     sync.Mutex
+	window time.Duration
+	chunks []replayChunk
+	size   int
+}
+
+// newAudioReplayBuffer creates a replay buffer retaining audio for the given window
+func newAudioReplayBuffer(window time.Duration) *audioReplayBuffer {
+	return &audioReplayBuffer{window: window}
+}
+
+// setWindow changes the retention window and trims chunks that fall outside it
+func (b *audioReplayBuffer) setWindow(window time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.window = window
+	b.trimLocked(now)
+}
+
+// getWindow returns the retention window
+func (b *audioReplayBuffer) getWindow() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.window
+}
+
+// add records a chunk of sent audio
+func (b *audioReplayBuffer) add(data []byte, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.window <= 0 || len(data) == 0 {
+		return
+	}
+
+	// Keep a private copy since callers reuse their read buffers
+	dataCopy := make([]byte, len(data))
+	copy(dataCopy, data)
+
+	b.chunks = append(b.chunks, replayChunk{data: dataCopy, sentAt: now})
+	b.size += len(dataCopy)
+	b.trimLocked(now)
+}
+
+// snapshot returns the retained chunks, oldest first
+func (b *audioReplayBuffer) snapshot(now time.Time) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trimLocked(now)
+	chunks := make([][]byte, 0, len(b.chunks))
+	for _, chunk := range b.chunks {
+		chunks = append(chunks, chunk.data)
+	}
+	return chunks
+}
+
+// bufferedBytes returns the number of retained bytes
+func (b *audioReplayBuffer) bufferedBytes() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// reset discards all retained audio
+func (b *audioReplayBuffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.chunks = nil
+	b.size = 0
+}
+
+// trimLocked drops chunks older than the window; b.mu must be held
+func (b *audioReplayBuffer) trimLocked(now time.Time) {
+	if b.window <= 0 {
+		b.chunks = nil
+		b.size = 0
+		return
+	}
+
+	cutoff := now.Add(-b.window)
+	drop := 0
+	for drop < len(b.chunks) && b.chunks[drop].sentAt.Before(cutoff) {
+		b.size -= len(b.chunks[drop].data)
+		drop++
+	}
+	if drop > 0 {
+		b.chunks = append([]replayChunk(nil), b.chunks[drop:]...)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAudioReplayBuffer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	type chunk struct {
+		data []byte
+		at   time.Duration // offset from start
+	}
+	tests := []struct {
+		name      string
+		window    time.Duration
+		chunks    []chunk
+		now       time.Duration
+		want      [][]byte
+		wantBytes int
+	}{
+		{
+			name:      "zero window retains nothing",
+			window:    0,
+			chunks:    []chunk{{data: []byte{1, 2}, at: 0}},
+			now:       0,
+			want:      [][]byte{},
+			wantBytes: 0,
+		},
+		{
+			name:      "chunks inside the window are kept oldest first",
+			window:    time.Second,
+			chunks:    []chunk{{data: []byte{1}, at: 0}, {data: []byte{2, 3}, at: 500 * time.Millisecond}},
+			now:       time.Second,
+			want:      [][]byte{{1}, {2, 3}},
+			wantBytes: 3,
+		},
+		{
+			name:      "audio older than the window is discarded",
+			window:    time.Second,
+			chunks:    []chunk{{data: []byte{1}, at: 0}, {data: []byte{2}, at: 800 * time.Millisecond}, {data: []byte{3}, at: 1500 * time.Millisecond}},
+			now:       2 * time.Second,
+			want:      [][]byte{{3}},
+			wantBytes: 1,
+		},
+		{
+			name:      "everything expires",
+			window:    time.Second,
+			chunks:    []chunk{{data: []byte{1}, at: 0}, {data: []byte{2}, at: 100 * time.Millisecond}},
+			now:       5 * time.Second,
+			want:      [][]byte{},
+			wantBytes: 0,
+		},
+		{
+			name:      "empty chunks are ignored",
+			window:    time.Second,
+			chunks:    []chunk{{data: []byte{}, at: 0}, {data: []byte{1}, at: 0}},
+			now:       0,
+			want:      [][]byte{{1}},
+			wantBytes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := newAudioReplayBuffer(tt.window)
+			for _, c := range tt.chunks {
+				buffer.add(c.data, start.Add(c.at))
+			}
+
+			got := buffer.snapshot(start.Add(tt.now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshot = %v, want %v", got, tt.want)
+			}
+			if got := buffer.bufferedBytes(); got != tt.wantBytes {
+				t.Errorf("bufferedBytes() = %d, want %d", got, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestAudioReplayBufferCopiesData(t *testing.T) {
+	buffer := newAudioReplayBuffer(time.Second)
+	now := time.Now()
+	data := []byte{1, 2, 3}
+	buffer.add(data, now)
+	// Callers reuse their read buffers, so the retained chunk must not change
+	data[0] = 9
+
+	if got, want := buffer.snapshot(now), [][]byte{{1, 2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot = %v, want %v", got, want)
+	}
+}
+
+func TestSetReplayWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "disabled", window: 0, want: 0},
+		{name: "positive", window: 3 * time.Second, want: 3 * time.Second},
+		{name: "negative", window: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			err = recognizer.SetReplayWindow(tt.window)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SetReplayWindow(%v) succeeded, want an error", tt.window)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetReplayWindow(%v): %v", tt.window, err)
+			}
+			if got := recognizer.GetReplayWindow(); got != tt.want {
+				t.Errorf("GetReplayWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
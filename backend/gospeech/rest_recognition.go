@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// shortAudioResponse is the response of the short audio REST recognition API
+type shortAudioResponse struct {
+	RecognitionStatus string
+	DisplayText       string
+	Offset            int64
+	Duration          int64
+}
+
+// RecognizeShortAudio transcribes a complete block of PCM audio (up to about 60 seconds) with the
+// Speech Service REST API. It does not need a streaming connection, so it can be used as a
+// degraded path when the WebSocket endpoint is unreachable. Audio without speech yields "".
+func RecognizeShortAudio(ctx context.Context, config *SpeechConfig, format *AudioStreamFormat, audio []byte) (string, error) {
+	if config.GetRegion() == "" {
+		return "", fmt.Errorf("region is required for REST recognition")
+	}
+	language := normalizeLanguageCode(config.GetSpeechRecognitionLanguage(), true)
+	if language == "" {
+		return "", fmt.Errorf("invalid source language code: %s", config.GetSpeechRecognitionLanguage())
+	}
+	if format == nil {
+		format = GetDefaultInputFormat()
+	}
+
+	reqURL := fmt.Sprintf("https://%s.stt.speech.microsoft.com/speech/recognition/conversation/cognitiveservices/v1?language=%s",
+		config.GetRegion(), url.QueryEscape(language))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(audio))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", fmt.Sprintf("audio/wav; codecs=audio/pcm; samplerate=%d", format.SamplesPerSecond()))
+	req.Header.Set("Accept", "application/json")
+	if token := config.GetAuthorizationToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if key := config.GetSubscriptionKey(); key != "" {
+		req.Header.Set("Ocp-Apim-Subscription-Key", key)
+	} else {
+		return "", fmt.Errorf("authentication information is not configured")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("REST recognition request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("REST recognition failed: status %d", resp.StatusCode)
+	}
+
+	var result shortAudioResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode REST recognition response: %v", err)
+	}
+
+	switch result.RecognitionStatus {
+	case "Success":
+		return result.DisplayText, nil
+	case "NoMatch", "InitialSilenceTimeout", "BabbleTimeout":
+		return "", nil
+	default:
+		return "", fmt.Errorf("REST recognition failed: %s", result.RecognitionStatus)
+	}
+}
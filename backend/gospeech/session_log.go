@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// sessionLogSummaryEvery is how many suppressed lines pass between interim summaries
+const sessionLogSummaryEvery = 1000
+
+// sessionLogLimiter bounds the debug log output of one recognition session. Once the line or
+// byte cap is reached, further lines are counted per format instead of written, and a summary
+// of what was suppressed is logged periodically and when the session ends.
+// A nil limiter, or one without caps, writes every line.
+type sessionLogLimiter struct {
+	mu         sync.Mutex
+	maxLines   int
+	maxBytes   int
+	lines      int
+	bytes      int
+	suppressed int
+	byFormat   map[string]int
+}
+
+// newSessionLogLimiter creates a limiter; zero caps mean unlimited
+func newSessionLogLimiter(maxLines, maxBytes int) *sessionLogLimiter {
+	if maxLines <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	return &sessionLogLimiter{maxLines: maxLines, maxBytes: maxBytes, byFormat: make(map[string]int)}
+}
+
+// printf writes the line unless the session cap has been reached
+func (l *sessionLogLimiter) printf(format string, args ...interface{}) {
+	if l == nil {
+		log.Printf(format, args...)
+		return
+	}
+
+	line := fmt.Sprintf(format, args...)
+
+	l.mu.Lock()
+	overLines := l.maxLines > 0 && l.lines >= l.maxLines
+	overBytes := l.maxBytes > 0 && l.bytes+len(line) > l.maxBytes
+	if overLines || overBytes {
+		if l.suppressed == 0 {
+			log.Printf("[LOG] Session log limit reached (lines=%d, bytes=%d); suppressing further debug output", l.lines, l.bytes)
+		}
+		l.suppressed++
+		l.byFormat[format]++
+		suppressed := l.suppressed
+		l.mu.Unlock()
+
+		if suppressed%sessionLogSummaryEvery == 0 {
+			log.Printf("[LOG] %d log lines suppressed so far", suppressed)
+		}
+		return
+	}
+	l.lines++
+	l.bytes += len(line)
+	l.mu.Unlock()
+
+	log.Print(line)
+}
+
+// summarize logs how many lines were suppressed, grouped by message format
+func (l *sessionLogLimiter) summarize() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.suppressed == 0 {
+		return
+	}
+
+	formats := make([]string, 0, len(l.byFormat))
+	for format := range l.byFormat {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return l.byFormat[formats[i]] > l.byFormat[formats[j]] })
+
+	log.Printf("[LOG] %d log lines suppressed in this session", l.suppressed)
+	for _, format := range formats {
+		log.Printf("[LOG]   %d similar lines suppressed: %q", l.byFormat[format], format)
+	}
+}
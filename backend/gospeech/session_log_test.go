@@ -0,0 +1,133 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionLogLimiter(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxLines       int
+		maxBytes       int
+		lines          int
+		wantWritten    int
+		wantSuppressed int
+	}{
+		{name: "unlimited", lines: 20, wantWritten: 20},
+		{name: "under the line cap", maxLines: 30, lines: 20, wantWritten: 20},
+		{name: "line cap", maxLines: 5, lines: 20, wantWritten: 5, wantSuppressed: 15},
+		{name: "byte cap", maxBytes: 3 * len("[DEBUG] line 00"), lines: 20, wantWritten: 3, wantSuppressed: 17},
+		{name: "tighter of both caps", maxLines: 2, maxBytes: 1000, lines: 20, wantWritten: 2, wantSuppressed: 18},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			logger := newSessionLogLimiter(tt.maxLines, tt.maxBytes)
+			for i := 0; i < tt.lines; i++ {
+				logger.printf("[DEBUG] line %02d", i)
+			}
+			logger.summarize()
+
+			// the summary quotes the format string, which must not count as a written line
+			if got := buf.count("[DEBUG] line") - buf.count("line %02d"); got != tt.wantWritten {
+				t.Errorf("wrote %d lines, want %d", got, tt.wantWritten)
+			}
+			if tt.wantSuppressed == 0 {
+				if buf.count("suppress") != 0 {
+					t.Error("unexpected suppression output")
+				}
+				return
+			}
+			for _, want := range []string{
+				"Session log limit reached",
+				fmt.Sprintf("%d log lines suppressed in this session", tt.wantSuppressed),
+				fmt.Sprintf("%d similar lines suppressed: %q", tt.wantSuppressed, "[DEBUG] line %02d"),
+			} {
+				if buf.count(want) != 1 {
+					t.Errorf("log output has %d lines with %q, want 1", buf.count(want), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetSessionLogLimitRejectsNegativeValues(t *testing.T) {
+	tests := []struct {
+		name               string
+		maxLines, maxBytes int
+		wantErr            bool
+	}{
+		{name: "unlimited", maxLines: 0, maxBytes: 0},
+		{name: "both caps", maxLines: 100, maxBytes: 4096},
+		{name: "negative lines", maxLines: -1, wantErr: true},
+		{name: "negative bytes", maxBytes: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recognizer, _ := newTestRecognizer(t, newFakeSpeechService(t))
+			err := recognizer.SetSessionLogLimit(tt.maxLines, tt.maxBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetSessionLogLimit(%d, %d) error = %v, want error %v", tt.maxLines, tt.maxBytes, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if lines, bytes := recognizer.GetSessionLogLimit(); lines != tt.maxLines || bytes != tt.maxBytes {
+				t.Errorf("GetSessionLogLimit() = (%d, %d), want (%d, %d)", lines, bytes, tt.maxLines, tt.maxBytes)
+			}
+		})
+	}
+}
+
+func TestSessionLogLimitCoversAudioLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLines  int
+		wantLevel bool
+	}{
+		{name: "unlimited", wantLevel: true},
+		// the startup lines use up the cap before the level is logged
+		{name: "capped", maxLines: 1, wantLevel: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.nowFunc = clock.Now
+			if err := recognizer.SetSessionLogLimit(tt.maxLines, 0); err != nil {
+				t.Fatalf("SetSessionLogLimit: %v", err)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			service.waitForConn(t)
+			for i := 1; i <= 3; i++ {
+				clock.Advance(500 * time.Millisecond)
+				stream.Write(pcmTone(3200, 16000))
+				want := int64(3200 * i)
+				waitFor(t, "the chunk to be sent", func() bool { return service.audioBytes.Load() >= want })
+			}
+			recognizer.StopContinuousRecognition()
+
+			// the summary quotes the format string, which must not count as a logged level
+			if got := buf.count("Microphone audio level")-buf.count("Microphone audio level: %d") > 0; got != tt.wantLevel {
+				t.Errorf("audio level logged = %v, want %v", got, tt.wantLevel)
+			}
+			// the level is computed without writing to the standard logger directly
+			if n := buf.count("Audio buffer head bytes"); n != 0 {
+				t.Errorf("log output has %d buffer dump lines, want none", n)
+			}
+		})
+	}
+}
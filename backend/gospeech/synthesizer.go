@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Synthesizer collects the translated speech that the service streams on the translation.synthesis
+// path when a voice is configured, and passes the audio of each utterance to a callback
+type Synthesizer struct {
+	voiceName string
+	onAudio   func(audio []byte)
+
+	mu    sync.Mutex
+	audio []byte
+}
+
+// NewSynthesizer creates a synthesizer for the given voice that calls onAudio with the audio of each utterance
+func NewSynthesizer(voiceName string, onAudio func(audio []byte)) *Synthesizer {
+	return &Synthesizer{
+		voiceName: voiceName,
+		onAudio:   onAudio,
+	}
+}
+
+// VoiceName returns the voice used for synthesized output
+func (s *Synthesizer) VoiceName() string {
+	return s.voiceName
+}
+
+// HandleFrame appends the audio of a binary translation.synthesis frame.
+// It reports false for binary frames on other paths.
+func (s *Synthesizer) HandleFrame(frame []byte) (bool, error) {
+	headers, body, err := splitBinaryFrame(frame)
+	if err != nil {
+		return false, err
+	}
+	if headerValue(headers, "Path") != "translation.synthesis" {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	s.audio = append(s.audio, body...)
+	s.mu.Unlock()
+	return true, nil
+}
+
+// End passes the audio collected since the previous utterance to the callback.
+// When the service reports a failed synthesis, the collected audio is discarded.
+func (s *Synthesizer) End(succeeded bool) {
+	s.mu.Lock()
+	audio := s.audio
+	s.audio = nil
+	s.mu.Unlock()
+
+	if !succeeded || len(audio) == 0 || s.onAudio == nil {
+		return
+	}
+	s.onAudio(audio)
+}
+
+// splitBinaryFrame splits a binary service frame into its headers and body.
+// The frame starts with the header length as a big-endian uint16.
+func splitBinaryFrame(frame []byte) (string, []byte, error) {
+	if len(frame) < 2 {
+		return "", nil, errors.New("binary frame too short")
+	}
+	headerLen := int(binary.BigEndian.Uint16(frame[:2]))
+	if len(frame) < 2+headerLen {
+		return "", nil, errors.New("binary frame header exceeds frame size")
+	}
+	return string(frame[2 : 2+headerLen]), frame[2+headerLen:], nil
+}
+
+// headerValue returns the value of the named header in a CRLF-separated header block
+func headerValue(headers, name string) string {
+	for _, line := range strings.Split(headers, "\r\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// synthesisFrame builds a binary service frame with the given path and audio body
+func synthesisFrame(path string, audio []byte) []byte {
+	headers := "Path: " + path + "\r\nX-RequestId: test\r\nContent-Type: audio/x-wav"
+	frame := make([]byte, 2, 2+len(headers)+len(audio))
+	binary.BigEndian.PutUint16(frame, uint16(len(headers)))
+	frame = append(frame, headers...)
+	return append(frame, audio...)
+}
+
+// sendBinary writes a binary frame to the recognizer
+func (c *fakeServiceConn) sendBinary(frame []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.BinaryMessage, frame)
+}
+
+func TestSynthesizingEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		voice     string
+		frames    [][]byte
+		endStatus string
+		wantAudio []byte // nil when no event is expected
+	}{
+		{
+			name: "audio of an utterance", voice: "en-US-JennyNeural",
+			frames:    [][]byte{synthesisFrame("translation.synthesis", []byte{1, 2}), synthesisFrame("translation.synthesis", []byte{3})},
+			endStatus: "Success", wantAudio: []byte{1, 2, 3},
+		},
+		{
+			name: "frames on other paths are ignored", voice: "en-US-JennyNeural",
+			frames:    [][]byte{synthesisFrame("other", []byte{9}), synthesisFrame("translation.synthesis", []byte{1})},
+			endStatus: "Success", wantAudio: []byte{1},
+		},
+		{
+			name: "failed synthesis", voice: "en-US-JennyNeural",
+			frames:    [][]byte{synthesisFrame("translation.synthesis", []byte{1, 2})},
+			endStatus: "Error",
+		},
+		{
+			name:      "no voice",
+			frames:    [][]byte{synthesisFrame("translation.synthesis", []byte{1, 2})},
+			endStatus: "Success",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizer.config.SetVoiceName(tt.voice)
+
+			synthesized := make(chan []byte, 10)
+			recognizer.Synthesizing().Connect(func(eventArgs interface{}) {
+				synthesized <- eventArgs.(*TranslationSynthesisEventArgs).Result.Audio
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			for _, frame := range tt.frames {
+				if err := fc.sendBinary(frame); err != nil {
+					t.Fatalf("sendBinary: %v", err)
+				}
+			}
+			if err := fc.send("translation.synthesis.end", `{"SynthesisStatus":"`+tt.endStatus+`"}`); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			select {
+			case audio := <-synthesized:
+				if tt.wantAudio == nil {
+					t.Fatalf("unexpected Synthesizing event with %d bytes", len(audio))
+				}
+				if !bytes.Equal(audio, tt.wantAudio) {
+					t.Errorf("audio = %v, want %v", audio, tt.wantAudio)
+				}
+			case <-time.After(300 * time.Millisecond):
+				if tt.wantAudio != nil {
+					t.Fatal("timed out waiting for the Synthesizing event")
+				}
+			}
+		})
+	}
+}
+
+func TestBuildConnectionRequestVoice(t *testing.T) {
+	tests := []struct {
+		name      string
+		voice     string
+		wantQuery []string
+	}{
+		{name: "no voice"},
+		{name: "voice requests speech output", voice: "en-US-JennyNeural", wantQuery: []string{"features=texttospeech", "voice=en-US-JennyNeural"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			config.SetVoiceName(tt.voice)
+
+			got, _, err := buildConnectionRequest(config.SpeechConfig)
+			if err != nil {
+				t.Fatalf("buildConnectionRequest: %v", err)
+			}
+			if len(tt.wantQuery) == 0 && strings.Contains(got, "?") {
+				t.Errorf("URL = %q, want no query", got)
+			}
+			for _, want := range tt.wantQuery {
+				if !strings.Contains(got, want) {
+					t.Errorf("URL = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitBinaryFrame(t *testing.T) {
+	tests := []struct {
+		name        string
+		frame       []byte
+		wantHeaders string
+		wantBody    []byte
+		wantErr     bool
+	}{
+		{name: "headers and body", frame: synthesisFrame("translation.synthesis", []byte{1, 2}), wantHeaders: "Path: translation.synthesis\r\nX-RequestId: test\r\nContent-Type: audio/x-wav", wantBody: []byte{1, 2}},
+		{name: "too short", frame: []byte{0}, wantErr: true},
+		{name: "header longer than the frame", frame: []byte{0, 10, 'P'}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers, body, err := splitBinaryFrame(tt.frame)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitBinaryFrame error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if headers != tt.wantHeaders || !bytes.Equal(body, tt.wantBody) {
+				t.Errorf("splitBinaryFrame = (%q, %v), want (%q, %v)", headers, body, tt.wantHeaders, tt.wantBody)
+			}
+		})
+	}
+}
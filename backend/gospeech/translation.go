@@ -10,10 +10,16 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,8 +31,21 @@ type SpeechTranslationConfig struct {
 	*SpeechConfig
 	targetLanguages []string
 	voiceName       string
+	voiceStyle      string
+	primaryTarget   string
+	tokenProvider   AuthorizationTokenProvider
 }
 
+// ErrUnknownTargetLanguage is returned when a language is not among the configured target languages
+var ErrUnknownTargetLanguage = errors.New("language is not a configured target language")
+
+// AuthorizationTokenProvider returns a fresh authorization token for the Speech Service
+type AuthorizationTokenProvider func(ctx context.Context) (string, error)
+
+// TokenRefreshInterval is how often a recognizer with a token provider fetches a new token and
+// reconnects during continuous recognition. Speech Service tokens are valid for 10 minutes.
+var TokenRefreshInterval = 9 * time.Minute
+
 // NewSpeechTranslationConfig creates a new speech translation configuration
 func NewSpeechTranslationConfig() *SpeechTranslationConfig {
 	return &SpeechTranslationConfig{
@@ -124,6 +143,9 @@ func (c *SpeechTranslationConfig) RemoveTargetLanguage(language string) {
 
 	c.targetLanguages = newTargetLanguages
 	c.SetProperty(SpeechServiceConnectionTranslationToLanguages, strings.Join(c.targetLanguages, ","))
+	if c.primaryTarget == language {
+		c.primaryTarget = ""
+	}
 }
 
 // GetTargetLanguages returns the list of target languages for translation
@@ -131,6 +153,34 @@ func (c *SpeechTranslationConfig) GetTargetLanguages() []string {
 	return c.targetLanguages
 }
 
+// SetPrimaryTargetLanguage sets the target language used where a single translation is needed.
+// The language must already be a target language; "" restores the default.
+func (c *SpeechTranslationConfig) SetPrimaryTargetLanguage(language string) error {
+	if language == "" {
+		c.primaryTarget = ""
+		return nil
+	}
+	for _, lang := range c.targetLanguages {
+		if lang == language {
+			c.primaryTarget = language
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownTargetLanguage, language)
+}
+
+// GetPrimaryTargetLanguage returns the primary target language: the one set with
+// SetPrimaryTargetLanguage, or else the first target language added. It is "" if there are none.
+func (c *SpeechTranslationConfig) GetPrimaryTargetLanguage() string {
+	if c.primaryTarget != "" {
+		return c.primaryTarget
+	}
+	if len(c.targetLanguages) == 0 {
+		return ""
+	}
+	return c.targetLanguages[0]
+}
+
 // SetVoiceName sets the voice to use for synthesized output
 func (c *SpeechTranslationConfig) SetVoiceName(voiceName string) {
 	c.voiceName = voiceName
@@ -142,23 +192,228 @@ func (c *SpeechTranslationConfig) GetVoiceName() string {
 	return c.voiceName
 }
 
+// SetAuthorizationTokenProvider sets a callback that supplies authorization tokens. The token is
+// fetched on every connection and refreshed every TokenRefreshInterval during continuous
+// recognition. nil uses the static authorization token or subscription key.
+func (c *SpeechTranslationConfig) SetAuthorizationTokenProvider(provider AuthorizationTokenProvider) {
+	c.tokenProvider = provider
+}
+
+// GetAuthorizationTokenProvider returns the authorization token provider, or nil if none is set
+func (c *SpeechTranslationConfig) GetAuthorizationTokenProvider() AuthorizationTokenProvider {
+	return c.tokenProvider
+}
+
+// SetVoice sets the voice to use for synthesized output after checking that the voice
+// exists for its locale and that the locale is one of the target languages.
+// Use SetVoiceName to set a raw voice name without validation.
+func (c *SpeechTranslationConfig) SetVoice(voice Voice) error {
+	resolved, err := resolveVoice(voice)
+	if err != nil {
+		return err
+	}
+
+	voiceLang := resolved.Language()
+	for _, lang := range c.targetLanguages {
+		if normalizeLanguageCode(lang, false) == voiceLang {
+			c.SetVoiceName(resolved.Name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("voice %s (%s) does not match any target language %v", resolved.Name, resolved.Locale, c.targetLanguages)
+}
+
+// SetVoiceGender selects the first known voice of the given gender ("Female" or "Male") that speaks
+// one of the target languages and supports the configured speaking style, if any
+func (c *SpeechTranslationConfig) SetVoiceGender(gender string) error {
+	if !strings.EqualFold(gender, "Female") && !strings.EqualFold(gender, "Male") {
+		return fmt.Errorf("invalid voice gender: %s (use Female or Male)", gender)
+	}
+
+	for _, lang := range c.targetLanguages {
+		target := normalizeLanguageCode(lang, false)
+		for _, voice := range knownVoices {
+			if voice.Language() != target || !strings.EqualFold(voice.Gender, gender) {
+				continue
+			}
+			if c.voiceStyle != "" && !voice.SupportsStyle(c.voiceStyle) {
+				continue
+			}
+			c.SetVoiceName(voice.Name)
+			return nil
+		}
+	}
+
+	if c.voiceStyle != "" {
+		return fmt.Errorf("no %s voice with style %s for target languages %v", gender, c.voiceStyle, c.targetLanguages)
+	}
+	return fmt.Errorf("no %s voice for target languages %v", gender, c.targetLanguages)
+}
+
+// SetVoiceStyle sets the speaking style (e.g. "cheerful", "calm") of synthesized output.
+// The voice must be set first and must support the style; an empty style clears it.
+func (c *SpeechTranslationConfig) SetVoiceStyle(style string) error {
+	if style == "" {
+		c.voiceStyle = ""
+		return nil
+	}
+	if c.voiceName == "" {
+		return errors.New("voice must be set before the speaking style")
+	}
+
+	voice, ok := LookupVoice(c.voiceName)
+	if !ok {
+		return fmt.Errorf("speaking styles of voice %s are unknown", c.voiceName)
+	}
+	if !voice.SupportsStyle(style) {
+		return fmt.Errorf("voice %s does not support style %s", voice.Name, style)
+	}
+	c.voiceStyle = strings.ToLower(style)
+	return nil
+}
+
+// GetVoiceStyle returns the speaking style of synthesized output, or "" for the default
+func (c *SpeechTranslationConfig) GetVoiceStyle() string {
+	return c.voiceStyle
+}
+
+// BuildSynthesisSSML returns the SSML used to synthesize text with the configured voice,
+// wrapping it in <mstts:express-as> when a speaking style is set
+func (c *SpeechTranslationConfig) BuildSynthesisSSML(text string) (string, error) {
+	if c.voiceName == "" {
+		return "", errors.New("voice is not set")
+	}
+	return buildVoiceSSML(c.voiceName, c.voiceStyle, text)
+}
+
 // SetCustomModelCategoryID sets a Category ID that will be passed to the service
 // Category ID is used to find the custom model
 func (c *SpeechTranslationConfig) SetCustomModelCategoryID(categoryID string) {
 	c.SetPropertyByName("CUSTOM_MODEL_CATEGORY_ID", categoryID)
 }
 
+// SetIncludeSourceInTranslations controls whether the recognized source text is added to the
+// Translations map under the source language, so clients can render every language uniformly
+func (c *SpeechTranslationConfig) SetIncludeSourceInTranslations(include bool) {
+	c.SetProperty(SpeechServiceResponseTranslationIncludeSource, strconv.FormatBool(include))
+}
+
+// GetIncludeSourceInTranslations returns whether the source text is added to the Translations map
+func (c *SpeechTranslationConfig) GetIncludeSourceInTranslations() bool {
+	include, _ := strconv.ParseBool(c.GetProperty(SpeechServiceResponseTranslationIncludeSource))
+	return include
+}
+
+// SetProfanity sets how profanity is handled in recognized and translated text
+func (c *SpeechTranslationConfig) SetProfanity(mode ProfanityOption) {
+	c.SetProperty(SpeechServiceResponseProfanityOption, mode.serviceValue())
+}
+
+// GetProfanity returns how profanity is handled, defaulting to ProfanityMasked
+func (c *SpeechTranslationConfig) GetProfanity() ProfanityOption {
+	switch c.GetProperty(SpeechServiceResponseProfanityOption) {
+	case "removed":
+		return ProfanityRemoved
+	case "raw":
+		return ProfanityRaw
+	default:
+		return ProfanityMasked
+	}
+}
+
 // TranslationRecognitionResult defines the translation result
 type TranslationRecognitionResult struct {
 	// Common recognition result properties
 	ResultID string
 	Text     string
-	Reason   ResultReason
-	Offset   int64
-	Duration time.Duration
+	Reason   ResultReason  // RecognizedSpeech when speech was recognized but no translations were returned
+	Offset   int64         // Offset from the start of the audio stream in nanoseconds (wall-clock UnixNano if the service sent none)
+	Duration time.Duration // Duration of the recognized speech (1 second if the service sent none)
+
+	// TurnID identifies the service turn (audio stream) the result belongs to
+	TurnID string
+
+	// Confidence is the recognition confidence (0-1) of the source text, when the service provides it
+	Confidence float64
 
 	// Translation-specific properties
 	Translations map[string]string // Maps target language to translated text
+
+	// TranslationConfidences maps target language to a per-translation confidence (0-1).
+	// Languages for which the service provides no score are absent.
+	TranslationConfidences map[string]float64
+
+	// TranslationDetails maps target language to the translation with its timing.
+	// It is only populated in detailed output mode when the service provides timing.
+	TranslationDetails map[string]*TranslationDetail
+}
+
+// IsFinal reports whether the result is final, as opposed to an interim hypothesis
+// (ResultReasonTranslatingSpeech) raised through Recognizing
+func (r *TranslationRecognitionResult) IsFinal() bool {
+	return r.Reason != ResultReasonTranslatingSpeech
+}
+
+// Merge combines another frame of the same utterance into r. Translation maps are combined, with
+// other winning for languages present in both, and the text, offset and duration of other are kept
+// as the latest. Empty or zero fields of other do not overwrite r.
+func (r *TranslationRecognitionResult) Merge(other *TranslationRecognitionResult) {
+	if other == nil {
+		return
+	}
+
+	if other.ResultID != "" {
+		r.ResultID = other.ResultID
+	}
+	if other.Text != "" {
+		r.Text = other.Text
+	}
+	// 翻訳済みの結果を翻訳なしの結果で格下げしない
+	if other.Reason != ResultReasonRecognizedSpeech || len(r.Translations) == 0 {
+		r.Reason = other.Reason
+	}
+	if other.Offset != 0 {
+		r.Offset = other.Offset
+	}
+	if other.Duration != 0 {
+		r.Duration = other.Duration
+	}
+	if other.TurnID != "" {
+		r.TurnID = other.TurnID
+	}
+	if other.Confidence != 0 {
+		r.Confidence = other.Confidence
+	}
+
+	for lang, text := range other.Translations {
+		if r.Translations == nil {
+			r.Translations = make(map[string]string)
+		}
+		r.Translations[lang] = text
+	}
+	if r.Reason == ResultReasonRecognizedSpeech && len(r.Translations) > 0 {
+		r.Reason = ResultReasonTranslatedSpeech
+	}
+	for lang, confidence := range other.TranslationConfidences {
+		if r.TranslationConfidences == nil {
+			r.TranslationConfidences = make(map[string]float64)
+		}
+		r.TranslationConfidences[lang] = confidence
+	}
+	for lang, detail := range other.TranslationDetails {
+		if r.TranslationDetails == nil {
+			r.TranslationDetails = make(map[string]*TranslationDetail)
+		}
+		r.TranslationDetails[lang] = detail
+	}
+}
+
+// TranslationDetail contains a single translation with its timing within the audio stream
+type TranslationDetail struct {
+	Text     string
+	Offset   int64         // Offset from the start of the audio stream in nanoseconds
+	Duration time.Duration // Duration of the translated segment
 }
 
 // TranslationSynthesisResult represents the voice output in the target language
@@ -175,6 +430,10 @@ type EventArgs interface {
 // SessionEventArgs is the base type for session events
 type SessionEventArgs struct {
 	SessionID string
+
+	// AudioDuration is the total audio sent to the service, computed from the bytes sent and
+	// the audio format. It is only set for SessionStopped events.
+	AudioDuration time.Duration
 }
 
 // GetSessionID returns the session ID
@@ -202,9 +461,16 @@ type TranslationSynthesisEventArgs struct {
 
 // CancellationDetails contains details about why a result was canceled
 type CancellationDetails struct {
-	Reason       CancellationReason
-	ErrorCode    CancellationErrorCode
-	ErrorDetails string
+	Reason       CancellationReason    `json:"reason"`
+	ErrorCode    CancellationErrorCode `json:"errorCode"`
+	ErrorDetails string                `json:"errorDetails,omitempty"`
+}
+
+// WarningEventArgs contains data for non-fatal warnings raised during recognition
+type WarningEventArgs struct {
+	SessionEventArgs
+	Code    WarningCode
+	Message string
 }
 
 // TranslationRecognitionCanceledEventArgs contains data for translation recognition canceled events
@@ -218,38 +484,92 @@ type EventCallback func(interface{})
 
 // EventSignal handles connections to events
 type EventSignal struct {
-	callbacks []EventCallback
+	callbacks []*EventSubscription
+	onPanic   func(recovered interface{})
 	mu        sync.RWMutex
 }
 
+// EventSubscription identifies a callback connected to an event signal
+type EventSubscription struct {
+	callback EventCallback
+}
+
 // NewEventSignal creates a new event signal
 func NewEventSignal() *EventSignal {
 	return &EventSignal{
-		callbacks: make([]EventCallback, 0),
+		callbacks: make([]*EventSubscription, 0),
 	}
 }
 
-// Connect connects a callback to the event signal
-func (s *EventSignal) Connect(callback EventCallback) {
+// Connect connects a callback to the event signal and returns a handle for DisconnectHandle
+func (s *EventSignal) Connect(callback EventCallback) *EventSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := &EventSubscription{callback: callback}
+	s.callbacks = append(s.callbacks, sub)
+	return sub
+}
+
+// DisconnectHandle disconnects the single callback identified by the handle
+func (s *EventSignal) DisconnectHandle(sub *EventSubscription) {
+	if sub == nil {
+		return
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.callbacks = append(s.callbacks, callback)
+	for i, connected := range s.callbacks {
+		if connected == sub {
+			s.callbacks = append(s.callbacks[:i], s.callbacks[i+1:]...)
+			return
+		}
+	}
 }
 
 // Disconnect disconnects all callbacks
 func (s *EventSignal) Disconnect() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.callbacks = make([]EventCallback, 0)
+	s.callbacks = make([]*EventSubscription, 0)
+}
+
+// SetPanicHandler sets a callback that receives the value of a panic recovered from a connected callback.
+// If no handler is set, the panic is logged.
+func (s *EventSignal) SetPanicHandler(handler func(recovered interface{})) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onPanic = handler
 }
 
 // Signal signals all connected callbacks
+// A callback that panics does not stop the remaining callbacks or the recognition goroutine.
+// Callbacks are called without holding the lock, so they may connect or disconnect callbacks on
+// the same signal; such changes take effect from the next signal.
 func (s *EventSignal) Signal(args interface{}) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for _, callback := range s.callbacks {
-		callback(args)
+	callbacks := make([]EventCallback, len(s.callbacks))
+	for i, sub := range s.callbacks {
+		callbacks[i] = sub.callback
 	}
+	onPanic := s.onPanic
+	s.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		invokeCallback(callback, args, onPanic)
+	}
+}
+
+// invokeCallback calls a single callback, recovering from a panic in it
+func invokeCallback(callback EventCallback, args interface{}, onPanic func(recovered interface{})) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if onPanic != nil {
+				onPanic(recovered)
+				return
+			}
+			log.Printf("[ERROR] Event callback panicked: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+	callback(args)
 }
 
 // TranslationRecognizer performs translation on speech input
@@ -265,12 +585,53 @@ type TranslationRecognizer struct {
 	sessionStopped      *EventSignal
 	speechStartDetected *EventSignal
 	speechEndDetected   *EventSignal
+	warning             *EventSignal
+	utterance           *EventSignal
+	reestablished       *EventSignal
+	metrics             RecognizerMetrics
 	isContinuous        bool
 	continuousRunning   bool
 	continuousMutex     sync.Mutex
-	stopCh              chan struct{}
+	run                 *continuousRun
+	chunkSize           int
+	replayBuffer        *audioReplayBuffer
+	keepAliveInterval   time.Duration
+	sampleRateCheck     bool
+	closeTimeout        time.Duration
+	silenceFinalize     time.Duration
+	sessionContext      map[string]string
+	utteranceGrace      time.Duration
+	emptyAudio          EmptyAudioBehavior
+	zeroReadThreshold   int
+	nowFunc             func() time.Time // clock used for timestamps and intervals; replaced in tests
+	punctuation         PunctuationMode
+	languagePunctuation map[string]PunctuationMode
+	silenceTrimLevel    int
+	silenceTrimMax      time.Duration
+	logMaxLines         int
+	logMaxBytes         int
+	resendConfig        bool
+	resultParser        ResultParser
+	minResultDuration   time.Duration
+	maxTargetLanguages  int
+	interimTranslations bool
+	readTimeout         time.Duration
+	sessionID           string          // ID of the current logical session, kept across reconnects
+	sessionCtx          context.Context // context the logical session was started with, reused by Reconnect
+	sessionBytesSent    atomic.Int64    // audio bytes sent in the current logical session, across reconnects
+
+	// diagnostics reported by State, guarded by continuousMutex
+	connected    bool
+	connections  int
+	lastResultAt time.Time
 }
 
+// DefaultCloseHandshakeTimeout is how long closing a connection waits for the end-of-audio handshake
+const DefaultCloseHandshakeTimeout = 1 * time.Second
+
+// DefaultAudioChunkSize is the number of bytes read from the audio source per send
+const DefaultAudioChunkSize = 8192
+
 // NewTranslationRecognizer creates a new translation recognizer
 func NewTranslationRecognizer(translationConfig *SpeechTranslationConfig, audioConfig *AudioConfig) (*TranslationRecognizer, error) {
 	if translationConfig == nil {
@@ -282,7 +643,7 @@ func NewTranslationRecognizer(translationConfig *SpeechTranslationConfig, audioC
 	if audioConfig == nil {
 		audioConfig, err = NewAudioConfigFromDefaultMicrophone()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create default audio config: %v", err)
+			return nil, fmt.Errorf("failed to create default audio config: %w", err)
 		}
 	}
 
@@ -296,6 +657,17 @@ func NewTranslationRecognizer(translationConfig *SpeechTranslationConfig, audioC
 		return nil, fmt.Errorf("audio source must implement io.Reader")
 	}
 
+	// Validate target languages up front rather than failing once audio is sent
+	var invalidTargets []string
+	for _, lang := range translationConfig.GetTargetLanguages() {
+		if !IsSupportedTargetLanguage(lang) {
+			invalidTargets = append(invalidTargets, lang)
+		}
+	}
+	if len(invalidTargets) > 0 {
+		return nil, fmt.Errorf("unsupported target languages: %s", strings.Join(invalidTargets, ", "))
+	}
+
 	recognizer := &TranslationRecognizer{
 		config:              translationConfig,
 		audioConfig:         audioConfig,
@@ -308,9 +680,16 @@ func NewTranslationRecognizer(translationConfig *SpeechTranslationConfig, audioC
 		sessionStopped:      NewEventSignal(),
 		speechStartDetected: NewEventSignal(),
 		speechEndDetected:   NewEventSignal(),
+		warning:             NewEventSignal(),
+		utterance:           NewEventSignal(),
+		reestablished:       NewEventSignal(),
 		isContinuous:        false,
 		continuousRunning:   false,
-		stopCh:              make(chan struct{}),
+		chunkSize:           DefaultAudioChunkSize,
+		replayBuffer:        newAudioReplayBuffer(0),
+		closeTimeout:        DefaultCloseHandshakeTimeout,
+		utteranceGrace:      DefaultUtteranceGracePeriod,
+		nowFunc:             time.Now,
 	}
 
 	// Copy properties from translation config
@@ -335,9 +714,10 @@ func NewTranslationRecognizer(translationConfig *SpeechTranslationConfig, audioC
 	return recognizer, nil
 }
 
-// RecognizeOnce performs a single recognition operation
+// RecognizeOnce performs a single recognition operation. Canceling ctx or reaching its deadline
+// interrupts connecting, reading from a push stream, sending and receiving, and returns ctx.Err().
 func (r *TranslationRecognizer) RecognizeOnce(ctx context.Context) (*TranslationRecognitionResult, error) {
-	if r.config.GetSubscriptionKey() == "" {
+	if subscriptionKey(r.config.SpeechConfig) == "" && r.config.GetAuthorizationTokenProvider() == nil {
 		return nil, errors.New("subscription key is not set")
 	}
 
@@ -345,8 +725,11 @@ func (r *TranslationRecognizer) RecognizeOnce(ctx context.Context) (*Translation
 	r.raiseSessionStarted()
 
 	// WebSocket接続を確立
-	conn, err := r.connectToSpeechService()
+	conn, err := r.connectToSpeechService(ctx)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, r.cancelOnce(ctxErr)
+		}
 		r.raiseCanceled(&CancellationDetails{
 			Reason:       CancellationReasonError,
 			ErrorCode:    CancellationErrorConnectionFailure,
@@ -356,12 +739,24 @@ func (r *TranslationRecognizer) RecognizeOnce(ctx context.Context) (*Translation
 	}
 	defer conn.close()
 
+	// ctx が終了したらソケットを閉じ、送信や受信でブロックしている処理を中断する
+	stopInterrupt := context.AfterFunc(ctx, func() { conn.conn.Close() })
+	defer stopInterrupt()
+
 	// Signal speech start detected
 	r.raiseSpeechStartDetected()
 
-	// オーディオデータの読み取り
-	buffer := make([]byte, 8192)
-	n, err := r.audioConfig.Source().(io.Reader).Read(buffer)
+	// オーディオデータの読み取り（プッシュストリームは ctx の終了で中断する）
+	buffer := make([]byte, r.GetChunkSize())
+	var n int
+	if pushStream, ok := r.audioConfig.Source().(*PushAudioInputStream); ok {
+		n, err = pushStream.readUntil(buffer, ctx.Done())
+	} else {
+		n, err = r.audioConfig.Source().(io.Reader).Read(buffer)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, r.cancelOnce(ctxErr)
+	}
 	if err != nil {
 		if err != io.EOF {
 			r.raiseCanceled(&CancellationDetails{
@@ -376,6 +771,9 @@ func (r *TranslationRecognizer) RecognizeOnce(ctx context.Context) (*Translation
 	if n > 0 {
 		// オーディオデータの送信
 		if err := conn.sendAudioData(buffer[:n]); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, r.cancelOnce(ctxErr)
+			}
 			r.raiseCanceled(&CancellationDetails{
 				Reason:       CancellationReasonError,
 				ErrorCode:    CancellationErrorConnectionFailure,
@@ -384,34 +782,118 @@ func (r *TranslationRecognizer) RecognizeOnce(ctx context.Context) (*Translation
 			return nil, err
 		}
 
-		// 結果の受信
-		result, err := conn.receiveResults()
-		if err != nil {
-			r.raiseCanceled(&CancellationDetails{
-				Reason:       CancellationReasonError,
-				ErrorCode:    CancellationErrorConnectionFailure,
-				ErrorDetails: fmt.Sprintf("Error receiving results: %v", err),
-			})
-			return nil, err
+		// 確定結果を受信するまで、途中結果は Recognizing として通知する
+		var result *TranslationRecognitionResult
+		for result == nil {
+			received, err := conn.receiveResults()
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, r.cancelOnce(ctxErr)
+				}
+				r.raiseCanceled(&CancellationDetails{
+					Reason:       CancellationReasonError,
+					ErrorCode:    CancellationErrorConnectionFailure,
+					ErrorDetails: fmt.Sprintf("Error receiving results: %v", err),
+				})
+				return nil, err
+			}
+			if received == nil {
+				continue
+			}
+			if received.Reason == ResultReasonTranslatingSpeech {
+				r.raiseRecognizing(received)
+				continue
+			}
+			result = received
 		}
 
 		// Signal speech end detected
 		r.raiseSpeechEndDetected()
 
-		// Signal the appropriate events
-		r.raiseRecognizing(result)
+		// Signal the final result
 		r.raiseRecognized(result)
 
 		// Signal session stop
-		r.raiseSessionStopped()
+		r.raiseSessionStopped(n)
+
+		return result, nil
+	}
+
+	return r.completeWithoutAudio()
+}
 
+// cancelOnce raises a Canceled event for a RecognizeOnce interrupted by its context and returns err
+func (r *TranslationRecognizer) cancelOnce(err error) error {
+	r.raiseCanceled(&CancellationDetails{
+		Reason:       CancellationReasonError,
+		ErrorCode:    CancellationErrorRuntimeError,
+		ErrorDetails: fmt.Sprintf("Recognition interrupted: %v", err),
+	})
+	return err
+}
+
+// ErrNoAudio is returned when the audio source ends without providing any data
+var ErrNoAudio = errors.New("no audio data available")
+
+// completeWithoutAudio ends a recognition whose audio source had no data, according to the
+// configured EmptyAudioBehavior. It returns the empty result, or ErrNoAudio.
+func (r *TranslationRecognizer) completeWithoutAudio() (*TranslationRecognitionResult, error) {
+	if r.GetEmptyAudioBehavior() == EmptyAudioEmptyResult {
+		result := &TranslationRecognitionResult{
+			ResultID:     fmt.Sprintf("result_%d", r.now().UnixNano()),
+			Reason:       ResultReasonNoMatch,
+			Offset:       r.now().UnixNano(),
+			Translations: make(map[string]string),
+		}
+		r.raiseRecognized(result)
+		r.raiseSessionStopped(0)
 		return result, nil
 	}
 
-	return nil, errors.New("no audio data available")
+	r.raiseCanceled(&CancellationDetails{
+		Reason:       CancellationReasonEndOfStream,
+		ErrorCode:    CancellationErrorNoError,
+		ErrorDetails: ErrNoAudio.Error(),
+	})
+	return nil, ErrNoAudio
+}
+
+// RecognizeOnceOutcome is the result of RecognizeOnceAsync
+type RecognizeOnceOutcome struct {
+	Result *TranslationRecognitionResult
+	Err    error
+}
+
+// RecognizeOnceAsync performs a single recognition on a goroutine. The returned channel receives
+// exactly one outcome and is then closed. If ctx is done first, the outcome carries ctx.Err().
+func (r *TranslationRecognizer) RecognizeOnceAsync(ctx context.Context) <-chan RecognizeOnceOutcome {
+	outcomeCh := make(chan RecognizeOnceOutcome, 1)
+	go func() {
+		defer close(outcomeCh)
+		if err := ctx.Err(); err != nil {
+			outcomeCh <- RecognizeOnceOutcome{Err: err}
+			return
+		}
+
+		done := make(chan RecognizeOnceOutcome, 1)
+		go func() {
+			result, err := r.RecognizeOnce(ctx)
+			done <- RecognizeOnceOutcome{Result: result, Err: err}
+		}()
+
+		select {
+		case outcome := <-done:
+			outcomeCh <- outcome
+		case <-ctx.Done():
+			outcomeCh <- RecognizeOnceOutcome{Err: ctx.Err()}
+		}
+	}()
+	return outcomeCh
 }
 
-// StartContinuousRecognitionAsync starts continuous recognition
+// StartContinuousRecognitionAsync starts continuous recognition.
+// Starting is idempotent: if recognition is already running, including when another goroutine
+// started it concurrently, the call does nothing and returns nil. Use IsRunning to check the state.
 func (r *TranslationRecognizer) StartContinuousRecognitionAsync(ctx context.Context) error {
 	r.continuousMutex.Lock()
 	defer r.continuousMutex.Unlock()
@@ -420,44 +902,292 @@ func (r *TranslationRecognizer) StartContinuousRecognitionAsync(ctx context.Cont
 
 	if r.continuousRunning {
 		log.Printf("[DEBUG] Continuous recognition is already running")
-		return errors.New("continuous recognition is already running")
+		return nil
 	}
 
 	r.continuousRunning = true
-	r.stopCh = make(chan struct{})
+	previous := r.run
+	run := newContinuousRun(false)
+	r.run = run
+	r.sessionCtx = ctx
 
+	// 直前の停止がまだ終わっていない場合は、その終了を待ってから開始する
+	// （2つのワーカーが同じ音声ソースを読み取ったり接続を残したりしないように）
 	log.Printf("[DEBUG] Launching continuousRecognitionWorker")
-	go r.continuousRecognitionWorker(ctx)
+	go func() {
+		if previous != nil {
+			<-previous.done
+		}
+		r.continuousRecognitionWorker(ctx, run)
+	}()
 
 	log.Printf("[DEBUG] StartContinuousRecognitionAsync completed successfully")
 	return nil
 }
 
-// StopContinuousRecognitionAsync stops continuous recognition
-func (r *TranslationRecognizer) StopContinuousRecognitionAsync() error {
+// now returns the current time from the recognizer clock
+func (r *TranslationRecognizer) now() time.Time {
+	return r.nowFunc()
+}
+
+// RecognizerState is a snapshot of the recognizer internals, for diagnosing stuck sessions
+type RecognizerState struct {
+	Running            bool      // continuous recognition has been started and not stopped
+	Connected          bool      // a connection to the service is currently established
+	LastResultAt       time.Time // when the last result was received; zero if none
+	Reconnects         int       // connections established after the first one
+	BufferedAudioBytes int       // audio written to a push stream source but not yet read
+}
+
+// State returns a snapshot of the recognizer state. It is safe to call from any goroutine.
+func (r *TranslationRecognizer) State() RecognizerState {
+	r.continuousMutex.Lock()
+	state := RecognizerState{
+		Running:      r.continuousRunning,
+		Connected:    r.connected,
+		LastResultAt: r.lastResultAt,
+	}
+	if r.connections > 1 {
+		state.Reconnects = r.connections - 1
+	}
+	r.continuousMutex.Unlock()
+
+	if stream, ok := r.audioConfig.Source().(*PushAudioInputStream); ok {
+		state.BufferedAudioBytes = stream.BufferedBytes()
+	}
+	return state
+}
+
+// Metrics returns the counters of the recognizer. Use SnapshotAndReset for per-interval statistics.
+func (r *TranslationRecognizer) Metrics() *RecognizerMetrics {
+	return &r.metrics
+}
+
+// setConnected records that a connection to the service was established and returns its number
+func (r *TranslationRecognizer) setConnected() int {
+	r.continuousMutex.Lock()
+	r.connected = true
+	r.connections++
+	n := r.connections
+	r.continuousMutex.Unlock()
+
+	if n > 1 {
+		r.metrics.update(func(m *MetricsSnapshot) { m.Reconnects++ })
+	}
+	return n
+}
+
+// setDisconnected records that connection number n was closed. It does nothing if a newer
+// connection has been established since, so a worker exiting late cannot clear its successor's state
+func (r *TranslationRecognizer) setDisconnected(n int) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if r.connections == n {
+		r.connected = false
+	}
+}
+
+// markResultReceived records the time a result was received from the service
+func (r *TranslationRecognizer) markResultReceived() {
+	now := r.now()
 	r.continuousMutex.Lock()
 	defer r.continuousMutex.Unlock()
+	r.lastResultAt = now
+}
 
-	if !r.continuousRunning {
-		return errors.New("continuous recognition is not running")
+// IsRunning reports whether continuous recognition has been started and has not been stopped or
+// ended on its own (end of the audio source, cancellation, or reconnecting failed)
+func (r *TranslationRecognizer) IsRunning() bool {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.continuousRunning
+}
+
+// StopContinuousRecognitionAsync stops sending audio and lets the service return the results for the
+// audio already sent (at most DrainTimeout) before the connection is closed, so the last utterance
+// is not lost. Those results are raised as Recognized events and SessionStopped is raised after them.
+// The returned channel receives nil once recognition has stopped, or an error if it was not running,
+// and is then closed.
+func (r *TranslationRecognizer) StopContinuousRecognitionAsync() <-chan error {
+	stopped := make(chan error, 1)
+	run, err := r.beginDrain()
+	if err != nil {
+		stopped <- err
+		close(stopped)
+		return stopped
 	}
+	go func() {
+		defer close(stopped)
+		<-run.done
+		stopped <- nil
+	}()
+	return stopped
+}
 
-	close(r.stopCh)
+// beginDrain marks continuous recognition as stopped and asks the worker to drain the remaining results
+func (r *TranslationRecognizer) beginDrain() (*continuousRun, error) {
+	r.continuousMutex.Lock()
+	if !r.continuousRunning {
+		r.continuousMutex.Unlock()
+		return nil, errors.New("continuous recognition is not running")
+	}
+	run := r.run
 	r.continuousRunning = false
+	r.continuousMutex.Unlock()
 
-	return nil
+	close(run.drainCh)
+	return run, nil
+}
+
+// drain sends the end-of-audio marker and waits until the service ends the turn, the connection
+// fails, recognition is stopped, or DrainTimeout passes
+func (r *TranslationRecognizer) drain(ctx context.Context, conn *speechServiceConnection, stopCh <-chan struct{}, errCh <-chan error) {
+	// 以前のターンの終了通知は破棄する
+	select {
+	case <-conn.turnEnded:
+	default:
+	}
+
+	if err := conn.sendEndOfAudio(); err != nil {
+		log.Printf("[WARNING] Failed to send end of audio while draining: %v", err)
+		return
+	}
+
+	timer := time.NewTimer(DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-conn.turnEnded:
+	case err := <-errCh:
+		log.Printf("[WARNING] Connection failed while draining: %v", err)
+	case <-timer.C:
+		log.Printf("[WARNING] Timed out after %v waiting for remaining results", DrainTimeout)
+	case <-stopCh:
+	case <-ctx.Done():
+	}
+}
+
+// TokenHandoverTimeout is how long a connection replaced by a token refresh is kept open to deliver
+// the results of the audio already sent to it
+var TokenHandoverTimeout = 5 * time.Second
+
+// handOver ends the turn of a connection replaced by a token refresh and closes it once the service
+// has returned the results for the audio already sent (turn.end), TokenHandoverTimeout passes, or
+// the worker exits
+func (r *TranslationRecognizer) handOver(ctx context.Context, conn *speechServiceConnection, closeConn func(), done <-chan struct{}) {
+	defer closeConn()
+
+	// 以前のターンの終了通知は破棄する
+	select {
+	case <-conn.turnEnded:
+	default:
+	}
+
+	if err := conn.sendEndOfAudio(); err != nil {
+		log.Printf("[WARNING] Failed to send end of audio to the replaced connection: %v", err)
+		return
+	}
+
+	timer := time.NewTimer(TokenHandoverTimeout)
+	defer timer.Stop()
+	select {
+	case <-conn.turnEnded:
+	case <-timer.C:
+		log.Printf("[WARNING] Timed out after %v waiting for the results of the replaced connection", TokenHandoverTimeout)
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// continuousRun is one connection of a logical recognition session
+type continuousRun struct {
+	stopCh   chan struct{}
+	drainCh  chan struct{} // closed to stop sending audio and wait for the remaining results
+	done     chan struct{} // closed when the worker has exited
+	resumed  bool          // the run continues a session started by an earlier run
+	detached atomic.Bool   // the session continues in a newer run; do not raise SessionStopped
+}
+
+func newContinuousRun(resumed bool) *continuousRun {
+	return &continuousRun{
+		stopCh:  make(chan struct{}),
+		drainCh: make(chan struct{}),
+		done:    make(chan struct{}),
+		resumed: resumed,
+	}
+}
+
+// Reconnect replaces the connection of the running session with a new one, for recovering from a
+// degraded connection without restarting the session. The new connection sends speech.config again
+// and resumes with the audio that has not been sent; the session ID, target languages, metrics and
+// the audio duration reported by SessionStopped are kept, and the new connection runs on the context
+// the session was started with. SessionStarted and SessionStopped are not raised again;
+// ConnectionReestablished is raised once the new connection is up. If recognition is not running,
+// a new session is started with ctx.
+//
+// ctx only bounds the wait for the old connection to close. If it ends first, Reconnect returns
+// ctx.Err(), but the new connection is still made once the old one has closed, so the session can
+// always be stopped.
+func (r *TranslationRecognizer) Reconnect(ctx context.Context) error {
+	r.continuousMutex.Lock()
+	old := r.run
+	if !r.continuousRunning || old == nil {
+		r.continuousMutex.Unlock()
+		return r.StartContinuousRecognitionAsync(ctx)
+	}
+	old.detached.Store(true)
+	close(old.stopCh)
+	run := newContinuousRun(true)
+	r.run = run
+	sessionCtx := r.sessionCtx
+	r.continuousMutex.Unlock()
+
+	// 古いワーカーの終了を待ってから接続し、音声の読み取りが重ならないようにする
+	// 新しいワーカーは ctx の終了にかかわらず起動し、停止時に待つ run.done が必ず閉じられるようにする
+	go func() {
+		<-old.done
+		log.Printf("[DEBUG] Reconnecting continuous recognition")
+		r.continuousRecognitionWorker(sessionCtx, run)
+	}()
+
+	select {
+	case <-old.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // continuousRecognitionWorker handles the continuous recognition process
-func (r *TranslationRecognizer) continuousRecognitionWorker(ctx context.Context) {
-	log.Printf("[DEBUG] continuousRecognitionWorker started")
+// run is captured at start so that a later restart cannot replace the channel this worker waits on
+func (r *TranslationRecognizer) continuousRecognitionWorker(ctx context.Context, run *continuousRun) {
+	defer close(run.done)
+	// 音声の終端や再接続の失敗などでワーカーが自ら終了した場合も、実行中の状態を解除する
+	// （再接続で新しい接続に引き継いだ場合は r.run が差し替わっているので何もしない）
+	defer func() {
+		r.continuousMutex.Lock()
+		defer r.continuousMutex.Unlock()
+		if r.run == run {
+			r.continuousRunning = false
+		}
+	}()
+	stopCh := run.stopCh
+	drainCh := run.drainCh
 
-	// Signal session start
-	r.raiseSessionStarted()
+	// セッションごとのデバッグログの上限（オプション）
+	logger := newSessionLogLimiter(r.GetSessionLogLimit())
+	defer logger.summarize()
+
+	logger.printf("[DEBUG] continuousRecognitionWorker started: resumed=%v", run.resumed)
+
+	// Signal session start (once per logical session, not per reconnect)
+	if !run.resumed {
+		r.sessionBytesSent.Store(0)
+		r.raiseSessionStarted()
+	}
 
 	// WebSocket接続を確立
-	log.Printf("[DEBUG] Attempting to connect to Speech Service")
-	conn, err := r.connectToSpeechService()
+	logger.printf("[DEBUG] Attempting to connect to Speech Service")
+	conn, err := r.connectToSpeechService(ctx)
 	if err != nil {
 		log.Printf("[ERROR] Failed to connect to Speech Service: %v", err)
 		r.raiseCanceled(&CancellationDetails{
@@ -467,108 +1197,340 @@ func (r *TranslationRecognizer) continuousRecognitionWorker(ctx context.Context)
 		})
 		return
 	}
-	defer conn.close()
-	log.Printf("[DEBUG] Connection to Speech Service established: sourceLanguage=%s, targetLanguages=%v",
+	conn.logger = logger
+	// 現在の接続を閉じる関数（再接続時に差し替える）
+	// 接続ごとに起動したゴルーチン（受信、読み取りの中断、接続維持）は connWG で待ち合わせ、
+	// ワーカーの終了時に接続を閉じてからすべて終了するのを待つ
+	closeConn := func() { conn.close() }
+	// retireConn は現在の接続の受信エラーを無視させる（トークンの更新で置き換えた接続を閉じるまで結果だけを受け取る）
+	var retireConn func()
+	var connWG sync.WaitGroup
+	defer func() {
+		closeConn()
+		connWG.Wait()
+	}()
+	connNum := r.setConnected()
+	defer func() { r.setDisconnected(connNum) }()
+	if run.resumed {
+		r.raiseConnectionReestablished()
+	}
+	logger.printf("[DEBUG] Connection to Speech Service established: sourceLanguage=%s, targetLanguages=%v",
 		r.config.GetSpeechRecognitionLanguage(), r.GetTargetLanguages())
 
 	// Audio source setup
-	log.Printf("[DEBUG] Audio source configuration: SourceType=%s", r.audioConfig.SourceType())
-	var audioSource io.Reader
-	switch r.audioConfig.SourceType() {
-	case "Microphone":
-		audioSource = r.audioConfig.Source().(io.Reader)
-		log.Printf("[DEBUG] Microphone input set as audio source")
-	case "Stream":
-		audioSource = r.audioConfig.Source().(io.Reader)
-		log.Printf("[DEBUG] Stream set as audio source")
-	case "File":
-		audioSource = r.audioConfig.Source().(io.Reader)
-		log.Printf("[DEBUG] File set as audio source")
-	case "PushStream":
-		audioSource = r.audioConfig.Source().(io.Reader)
-		log.Printf("[DEBUG] PushStream set as audio source: %T", r.audioConfig.Source())
-	default:
-		log.Printf("[ERROR] Unsupported audio source type: %s", r.audioConfig.SourceType())
+	logger.printf("[DEBUG] Audio source configuration: SourceType=%s", r.audioConfig.SourceType())
+	audioSource, ok := r.audioConfig.Source().(io.Reader)
+	if !ok {
+		log.Printf("[ERROR] Audio source is not readable: SourceType=%s, source=%T", r.audioConfig.SourceType(), r.audioConfig.Source())
 		r.raiseCanceled(&CancellationDetails{
 			Reason:       CancellationReasonError,
-			ErrorCode:    CancellationErrorConnectionFailure,
-			ErrorDetails: "Unsupported audio source type",
+			ErrorCode:    CancellationErrorRuntimeError,
+			ErrorDetails: fmt.Sprintf("Audio source of type %s is not readable", r.audioConfig.SourceType()),
 		})
 		return
 	}
+	logger.printf("[DEBUG] %s set as audio source: %T", r.audioConfig.SourceType(), audioSource)
 
 	// オーディオデータを読み込むバッファ
-	buffer := make([]byte, 8192) // 8KBのバッファ
-	log.Printf("[DEBUG] Created 8KB audio buffer")
+	buffer := make([]byte, r.GetChunkSize())
+	logger.printf("[DEBUG] Created %d byte audio buffer", len(buffer))
+
+	// 宣言されたサンプルレートと実際のデータレートの比較（オプション）
+	var rateMonitor *sampleRateMonitor
+	if r.isSampleRateCheckEnabled() {
+		rateMonitor = newSampleRateMonitor(r.audioFormat())
+	}
 
 	// 音声レベルのログ出力用の変数
-	lastLogTime := time.Now()
+	lastLogTime := r.now()
 	logInterval := 500 * time.Millisecond // 500ミリ秒ごとにログを出力
-	log.Printf("[DEBUG] Set voice level log interval to %v", logInterval)
+	logger.printf("[DEBUG] Set voice level log interval to %v", logInterval)
 
 	// データ読み取り統計情報
 	var totalBytesRead int
 	var readAttempts int
 	var successfulReads int
-	var logStats time.Time = time.Now()
+	var logStats time.Time = r.now()
+
+	// データのない読み取りが続いた回数（デバイス停止の検出用）
+	zeroReadThreshold := r.GetZeroReadThreshold()
+	var consecutiveZeroReads int
 	statsLogInterval := 5 * time.Second // 5秒ごとに統計情報をログ出力
 
+	// 発話開始前の無音のスキップ（オプション）
+	trimLevel, trimMax := r.GetLeadingSilenceTrim()
+	trimming := trimLevel > 0
+	trimMaxBytes := int(int64(r.audioFormat().BytesPerSecond()) * int64(trimMax) / int64(time.Second))
+	var trimmedBytes int
+
 	// エラー処理用のチャネル
 	errCh := make(chan error, 1)
-	log.Printf("[DEBUG] Created channel for error handling")
+	logger.printf("[DEBUG] Created channel for error handling")
+
+	// ワーカー終了を通知するチャネル
+	// 接続を閉じる closeConn より先に閉じられるよう、defer の登録順に注意
+	done := make(chan struct{})
+	defer close(done)
+
+	// プッシュストリームの読み取りはデータが届くまでブロックするため、停止時やエラー時に中断できるようにする
+	// readCancel は接続ごとに作り直す
+	var readCancel chan struct{}
+	readAudio := audioSource.Read
+	pushStream, isPushStream := audioSource.(*PushAudioInputStream)
+	if isPushStream {
+		readAudio = func(p []byte) (int, error) { return pushStream.readUntil(p, readCancel) }
+	}
+
+	// 翻訳先言語ごとに分かれて届く確定結果を1つの発話にまとめる
+	targets := make([]string, 0, len(r.GetTargetLanguages()))
+	for _, lang := range r.GetTargetLanguages() {
+		targets = append(targets, normalizeLanguageCode(lang, false))
+	}
+	aggregator := newUtteranceAggregator(targets, r.GetUtteranceGracePeriod(), r.raiseUtterance)
+	defer aggregator.flush()
+
+	// 短すぎる確定結果（ノイズによる誤認識など）は破棄する
+	// タイミングのない結果（無音による確定など）は対象外
+	minDuration := r.GetMinResultDuration()
+	deliverFinal := func(result *TranslationRecognitionResult) {
+		if minDuration > 0 && result.Duration > 0 && result.Duration < minDuration {
+			logger.printf("[DEBUG] Dropping final result shorter than %v: duration=%v, Text=%s", minDuration, result.Duration, result.Text)
+			r.metrics.update(func(m *MetricsSnapshot) { m.DroppedResults++ })
+			return
+		}
+		r.raiseRecognized(result)
+		aggregator.add(result)
+	}
 
-	// 結果受信用のゴルーチン
-	log.Printf("[DEBUG] Starting goroutine for receiving results")
-	go func() {
+	// 無音が続いた場合に途中結果を確定させる（オプション）
+	finalizer := newSilenceFinalizer(r.GetSilenceFinalizeTimeout(), r.now, deliverFinal)
+	defer finalizer.stop()
+
+	// 結果の受信（接続ごとに起動する）
+	// connDone はその接続が閉じられたこと、retired はその接続が置き換えられた（または閉じられた）こと、
+	// receiveFailed は受信がエラーで終了したことを表す
+	receive := func(c *speechServiceConnection, connDone, retired <-chan struct{}, receiveFailed chan<- struct{}) {
 		for {
-			log.Printf("[DEBUG] Waiting for results from WebSocket...")
-			result, err := conn.receiveResults()
+			logger.printf("[DEBUG] Waiting for results from WebSocket...")
+			result, err := c.receiveResults()
 			if err != nil {
-				log.Printf("[ERROR] Error occurred while receiving results: %v", err)
-				errCh <- err
+				select {
+				case <-retired:
+					// 停止要求や再接続、トークンの更新により置き換えられた接続のエラーは扱わない
+					logger.printf("[DEBUG] Result receiver exiting after connection close: %v", err)
+				default:
+					log.Printf("[ERROR] Error occurred while receiving results: %v", err)
+					select {
+					case errCh <- err:
+					case <-retired:
+					}
+					close(receiveFailed)
+				}
 				return
 			}
 
+			select {
+			case <-connDone:
+				logger.printf("[DEBUG] Result receiver exiting after connection close")
+				return
+			default:
+			}
+
 			if result != nil {
-				log.Printf("[DEBUG] Received recognition result: Text=%s", result.Text)
-				// イベントを発火
-				r.raiseRecognizing(result)
-				r.raiseRecognized(result)
+				logger.printf("[DEBUG] Received recognition result: Text=%s", result.Text)
+				r.markResultReceived()
+				// 途中結果は Recognizing、確定結果は Recognized として通知する
+				if result.Reason == ResultReasonTranslatingSpeech {
+					r.raiseRecognizing(result)
+					finalizer.partial(result)
+				} else if finalizer.final(result) {
+					deliverFinal(result)
+				} else {
+					logger.printf("[DEBUG] Final result already emitted after silence: Text=%s", result.Text)
+				}
 			}
 		}
-	}()
+	}
+
+	// 接続に受信ゴルーチンと接続維持を割り当て、closeConn をその接続用に差し替える
+	attach := func(c *speechServiceConnection) {
+		conn = c
+		c.logger = logger
+		connDone := make(chan struct{})
+		retired := make(chan struct{})
+		receiveFailed := make(chan struct{})
+		var closeOnce, retireOnce sync.Once
+		retire := func() { retireOnce.Do(func() { close(retired) }) }
+		retireConn = retire
+		closeConn = func() {
+			closeOnce.Do(func() {
+				retire()
+				close(connDone)
+				c.close()
+			})
+		}
+
+		if isPushStream {
+			cancel := make(chan struct{})
+			readCancel = cancel
+			connWG.Add(1)
+			go func() {
+				defer connWG.Done()
+				select {
+				case <-stopCh:
+				case <-drainCh:
+				case <-ctx.Done():
+				case <-receiveFailed:
+				case <-connDone:
+				}
+				close(cancel)
+			}()
+		}
+
+		logger.printf("[DEBUG] Starting goroutine for receiving results")
+		connWG.Add(1)
+		go func() {
+			defer connWG.Done()
+			receive(c, connDone, retired, receiveFailed)
+		}()
+
+		// 無音区間中の接続維持
+		if interval := r.GetKeepAliveInterval(); interval > 0 {
+			connWG.Add(1)
+			go func() {
+				defer connWG.Done()
+				r.keepAliveLoop(c, interval, connDone)
+			}()
+		}
+	}
+	attach(conn)
+
+	// 一時的な切断の場合は指数バックオフで再接続し、同じセッションのまま音声の送信を再開する
+	// 停止要求やコンテキストの終了で待機を中断した場合も true を返し、ループ側で終了処理を行う
+	maxRetries, baseDelay, maxDelay := r.config.GetReconnectPolicy()
+	reconnect := func(cause error) bool {
+		if maxRetries == 0 || !isRecoverableConnectionError(cause) {
+			return false
+		}
+		closeConn()
+		r.setDisconnected(connNum)
+
+		for attempt := 1; attempt <= maxRetries; attempt++ {
+			delay := reconnectDelay(baseDelay, maxDelay, attempt)
+			log.Printf("[WARNING] Connection to Speech Service lost (%v); reconnecting in %v (attempt %d/%d)", cause, delay, attempt, maxRetries)
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-stopCh:
+				timer.Stop()
+				return true
+			case <-drainCh:
+				// 停止が要求された - 再接続せずに終了処理に進む
+				timer.Stop()
+				return true
+			case <-ctx.Done():
+				timer.Stop()
+				return true
+			}
+
+			c, err := r.connectToSpeechService(ctx)
+			if err != nil {
+				cause = err
+				continue
+			}
+			attach(c)
+
+			// 切断直前に送信した音声を再送し、発話の途切れを埋める
+			if err := r.replayAudio(c); err != nil {
+				cause = err
+				closeConn()
+				continue
+			}
+
+			connNum = r.setConnected()
+			r.raiseConnectionReestablished()
+			return true
+		}
 
-	log.Printf("[DEBUG] Starting continuous recognition loop")
+		log.Printf("[ERROR] Giving up reconnecting to Speech Service after %d attempts", maxRetries)
+		return false
+	}
+
+	// トークンプロバイダーが設定されている場合は、トークンの有効期限が切れる前に新しいトークンで接続し直す
+	var tokenRefresh <-chan time.Time
+	if r.config.GetAuthorizationTokenProvider() != nil {
+		ticker := time.NewTicker(TokenRefreshInterval)
+		defer ticker.Stop()
+		tokenRefresh = ticker.C
+	}
+
+	logger.printf("[DEBUG] Starting continuous recognition loop")
 	// Continuous recognition loop
 	for {
 		select {
-		case <-r.stopCh:
+		case <-tokenRefresh:
+			logger.printf("[DEBUG] Reconnecting with a refreshed authorization token")
+			c, err := r.connectToSpeechService(ctx)
+			if err != nil {
+				// 現在の接続のまま続け、次の更新時に再試行する
+				log.Printf("[WARNING] Failed to reconnect with a refreshed authorization token: %v", err)
+				continue
+			}
+			// 古い接続は送信済みの音声の結果を受け取り終えるまで残し、以降の音声は新しい接続に送る
+			// 送信済みの音声は古い接続で認識されるため、新しい接続には再送しない
+			old, closeOld := conn, closeConn
+			retireConn()
+			attach(c)
+			connWG.Add(1)
+			go func() {
+				defer connWG.Done()
+				r.handOver(ctx, old, closeOld, done)
+			}()
+			connNum = r.setConnected()
+			r.raiseConnectionReestablished()
+		case <-stopCh:
 			// Stop requested
-			log.Printf("[DEBUG] Stop request received")
-			r.raiseSessionStopped()
+			logger.printf("[DEBUG] Stop request received")
+			if run.detached.Load() {
+				// 再接続によりセッションは新しい接続で継続する
+				logger.printf("[DEBUG] Connection detached for reconnect")
+				return
+			}
+			r.raiseSessionStopped(int(r.sessionBytesSent.Load()))
+			return
+		case <-drainCh:
+			// 音声の送信を止め、送信済みの音声の結果が届くまで待ってから終了する
+			logger.printf("[DEBUG] Drain requested; waiting for remaining results")
+			r.drain(ctx, conn, stopCh, errCh)
+			r.raiseSessionStopped(int(r.sessionBytesSent.Load()))
 			return
 		case <-ctx.Done():
 			// Context canceled or timed out
-			log.Printf("[DEBUG] Context was canceled or timed out")
-			r.raiseSessionStopped()
+			logger.printf("[DEBUG] Context was canceled or timed out")
+			r.raiseSessionStopped(int(r.sessionBytesSent.Load()))
 			return
 		case err := <-errCh:
 			// エラーが発生した場合
 			log.Printf("[ERROR] Error occurred during continuous recognition: %v", err)
-			r.raiseCanceled(&CancellationDetails{
-				Reason:       CancellationReasonError,
-				ErrorCode:    CancellationErrorConnectionFailure,
-				ErrorDetails: fmt.Sprintf("Error in continuous recognition: %v", err),
-			})
+			if reconnect(err) {
+				continue
+			}
+			r.raiseCanceled(cancellationForReceiveError(err))
 			return
 		default:
 			// オーディオデータの読み込み
-			n, err := audioSource.Read(buffer)
+			n, err := readAudio(buffer)
 			if err != nil {
 				if err == io.EOF {
 					// ファイル終端に達した場合
-					log.Printf("[DEBUG] Reached end of file")
-					r.raiseSessionStopped()
+					logger.printf("[DEBUG] Reached end of file")
+					if totalBytesRead == 0 {
+						// 音声データが一度も届かなかった場合は設定に従って終了する
+						logger.printf("[DEBUG] Audio source ended without any data")
+						r.completeWithoutAudio()
+						return
+					}
+					r.raiseSessionStopped(int(r.sessionBytesSent.Load()))
 					return
 				}
 				// その他のエラー
@@ -582,30 +1544,56 @@ func (r *TranslationRecognizer) continuousRecognitionWorker(ctx context.Context)
 			}
 
 			if n > 0 {
+				consecutiveZeroReads = 0
+
 				// データ読み取り統計情報を更新
 				readAttempts++
 				successfulReads++
 				totalBytesRead += n
 
 				// 定期的に統計情報をログ出力
-				if time.Since(logStats) >= statsLogInterval {
-					log.Printf("[STATS] Audio reading statistics: attempts=%d, successful=%d, totalBytes=%d, avgBytes=%.2f/read",
+				if r.now().Sub(logStats) >= statsLogInterval {
+					logger.printf("[STATS] Audio reading statistics: attempts=%d, successful=%d, totalBytes=%d, avgBytes=%.2f/read",
 						readAttempts, successfulReads, totalBytesRead, float64(totalBytesRead)/float64(successfulReads))
-					logStats = time.Now()
+					logStats = r.now()
 				}
 
-				log.Printf("[DEBUG] Read %d bytes of audio data", n)
+				logger.printf("[DEBUG] Read %d bytes of audio data", n)
+
+				if rateMonitor != nil {
+					if apparentRate, mismatch, _ := rateMonitor.observe(n, r.now()); mismatch {
+						declaredRate := r.audioFormat().SamplesPerSecond()
+						log.Printf("[WARNING] Apparent sample rate %d Hz does not match declared %d Hz", apparentRate, declaredRate)
+						r.raiseWarning(WarningSampleRateMismatch,
+							fmt.Sprintf("audio arrives at about %d Hz but the format declares %d Hz", apparentRate, declaredRate))
+					}
+				}
 
 				// 音声レベルの計算と定期的なログ出力
-				if time.Since(lastLogTime) >= logInterval {
-					level := calculateAudioLevel(buffer[:n], n)
-					log.Printf("Microphone audio level: %d/100", level)
-					lastLogTime = time.Now()
+				if r.now().Sub(lastLogTime) >= logInterval {
+					logger.printf("Microphone audio level: %d/100", audioLevel(buffer[:n]))
+					lastLogTime = r.now()
+				}
+
+				// 発話が始まるまで（または上限まで）無音をスキップ
+				if trimming {
+					if trimmedBytes+n <= trimMaxBytes && audioLevel(buffer[:n]) < trimLevel {
+						trimmedBytes += n
+						continue
+					}
+					trimming = false
+					logger.printf("[DEBUG] Trimmed %d bytes of leading silence", trimmedBytes)
 				}
 
-				// オーディオデータの送信
+				// オーディオデータの送信（再接続時に再送できるよう保持する）
+				r.replayBuffer.add(buffer[:n], r.now())
 				if err := conn.sendAudioData(buffer[:n]); err != nil {
 					log.Printf("[ERROR] Error while sending audio data: %v", err)
+					if reconnect(err) {
+						r.sessionBytesSent.Add(int64(n))
+						r.metrics.update(func(m *MetricsSnapshot) { m.AudioBytesSent += int64(n) })
+						continue
+					}
 					r.raiseCanceled(&CancellationDetails{
 						Reason:       CancellationReasonError,
 						ErrorCode:    CancellationErrorConnectionFailure,
@@ -613,31 +1601,568 @@ func (r *TranslationRecognizer) continuousRecognitionWorker(ctx context.Context)
 					})
 					return
 				}
-				log.Printf("[DEBUG] Audio data sent")
+				r.sessionBytesSent.Add(int64(n))
+				r.metrics.update(func(m *MetricsSnapshot) { m.AudioBytesSent += int64(n) })
+				logger.printf("[DEBUG] Audio data sent")
 			} else {
-				log.Printf("[DEBUG] No audio data read (n=0)")
+				logger.printf("[DEBUG] No audio data read (n=0)")
+				consecutiveZeroReads++
+				if zeroReadThreshold > 0 && consecutiveZeroReads == zeroReadThreshold {
+					// セッションは継続し、警告のみ通知する
+					log.Printf("[WARNING] No audio data for %d consecutive reads", consecutiveZeroReads)
+					r.raiseWarning(WarningPossibleDeadSource,
+						fmt.Sprintf("no audio data received for %d consecutive reads; the audio source may be dead", consecutiveZeroReads))
+				}
+				// データがない場合は短い遅延を入れて CPU 使用率を抑える
+				time.Sleep(10 * time.Millisecond)
 			}
+		}
+	}
+}
+
+// keepAliveLoop sends a short frame of silence whenever no audio has been sent for the
+// keepalive interval, so the service does not close the connection during long pauses
+func (r *TranslationRecognizer) keepAliveLoop(conn *speechServiceConnection, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
 
-			// 短い遅延を入れて CPU 使用率を抑える
-			time.Sleep(10 * time.Millisecond)
+	silence := silenceFrame(r.audioFormat(), keepAliveFrameDuration)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if conn.idleFor(r.now()) < interval {
+				continue
+			}
+			log.Printf("[DEBUG] No audio sent for %v, sending keepalive frame", interval)
+			if err := conn.sendKeepAlive(silence); err != nil {
+				log.Printf("[WARNING] Failed to send keepalive frame: %v", err)
+				return
+			}
 		}
 	}
 }
 
+// audioFormat returns the format of the audio input, falling back to the default input format
+func (r *TranslationRecognizer) audioFormat() *AudioStreamFormat {
+	if format := r.audioConfig.Format(); format != nil {
+		return format
+	}
+	return GetDefaultInputFormat()
+}
+
+// ErrFirstResultTimeout is returned by WaitForFirstResult when no result arrives in time
+var ErrFirstResultTimeout = errors.New("no recognition result received before timeout")
+
+// WaitForFirstResult waits for the next final recognition result, returning ErrFirstResultTimeout
+// if none arrives within the timeout. Call it right after starting recognition to fail fast
+// on a dead audio source. Other Recognized subscribers are not affected.
+func (r *TranslationRecognizer) WaitForFirstResult(ctx context.Context, timeout time.Duration) (*TranslationRecognitionResult, error) {
+	resultCh := make(chan *TranslationRecognitionResult, 1)
+	sub := r.recognized.Connect(func(eventArgs interface{}) {
+		args, ok := eventArgs.(*TranslationRecognitionEventArgs)
+		if !ok || args.Result == nil || args.Result.Reason == ResultReasonCanceled {
+			return
+		}
+		// Never block the event loop; only the first result is of interest
+		select {
+		case resultCh <- args.Result:
+		default:
+		}
+	})
+	defer r.recognized.DisconnectHandle(sub)
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case <-timeoutCh:
+		return nil, ErrFirstResultTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // StartContinuousRecognition starts continuous recognition synchronously
 func (r *TranslationRecognizer) StartContinuousRecognition(ctx context.Context) error {
 	log.Printf("[DEBUG] StartContinuousRecognition called")
 	return r.StartContinuousRecognitionAsync(ctx)
 }
 
-// StopContinuousRecognition stops continuous recognition synchronously
+// StopContinuousRecognition stops continuous recognition and waits until the remaining results
+// have been delivered, as described for StopContinuousRecognitionAsync
 func (r *TranslationRecognizer) StopContinuousRecognition() error {
-	return r.StopContinuousRecognitionAsync()
+	return <-r.StopContinuousRecognitionAsync()
+}
+
+// DrainTimeout is how long stopping continuous recognition waits for the service to finish the turn
+var DrainTimeout = 5 * time.Second
+
+// StopContinuousRecognitionAndDrain stops sending audio, waits until the service has returned the
+// results for the audio already sent (at most DrainTimeout), and then stops continuous recognition.
+// It returns the final results delivered while draining; they are also raised as Recognized events
+// before it returns. If ctx ends first, recognition is stopped without waiting further.
+func (r *TranslationRecognizer) StopContinuousRecognitionAndDrain(ctx context.Context) ([]*TranslationRecognitionResult, error) {
+	var mu sync.Mutex
+	var results []*TranslationRecognitionResult
+	sub := r.recognized.Connect(func(eventArgs interface{}) {
+		if args, ok := eventArgs.(*TranslationRecognitionEventArgs); ok && args.Result != nil {
+			mu.Lock()
+			results = append(results, args.Result)
+			mu.Unlock()
+		}
+	})
+	defer r.recognized.DisconnectHandle(sub)
+
+	run, err := r.beginDrain()
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		close(run.stopCh)
+		<-run.done
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return results, err
+}
+
+// SetChunkSize sets the number of bytes read from the audio source per send
+func (r *TranslationRecognizer) SetChunkSize(size int) error {
+	if size <= 0 {
+		return errors.New("chunk size must be greater than 0")
+	}
+
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.chunkSize = size
+	return nil
+}
+
+// GetChunkSize returns the number of bytes read from the audio source per send
+func (r *TranslationRecognizer) GetChunkSize() int {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.chunkSize
+}
+
+// SetReplayWindow sets how much recently sent audio is retained for replay after a reconnect.
+// Larger windows fill longer gaps at the cost of memory; zero disables retention.
+func (r *TranslationRecognizer) SetReplayWindow(window time.Duration) error {
+	if window < 0 {
+		return errors.New("replay window cannot be negative")
+	}
+	r.replayBuffer.setWindow(window, r.now())
+	return nil
+}
+
+// GetReplayWindow returns how much recently sent audio is retained for replay
+func (r *TranslationRecognizer) GetReplayWindow() time.Duration {
+	return r.replayBuffer.getWindow()
+}
+
+// replayAudio resends the retained audio over a new connection after a reconnect
+func (r *TranslationRecognizer) replayAudio(conn *speechServiceConnection) error {
+	chunks := r.replayBuffer.snapshot(r.now())
+	for _, chunk := range chunks {
+		if err := conn.sendAudioData(chunk); err != nil {
+			return err
+		}
+	}
+	if len(chunks) > 0 {
+		log.Printf("[DEBUG] Replayed %d audio chunks after reconnect", len(chunks))
+	}
+	return nil
+}
+
+// ErrReadTimeout is the cause reported when the service sends nothing for longer than the read timeout
+var ErrReadTimeout = errors.New("no message received from the Speech Service within the read timeout")
+
+// SetReadTimeout sets how long a connection may go without any message from the service before it is
+// treated as stalled and reconnected according to the reconnect policy, instead of waiting forever.
+// The service sends nothing while there is no speech, so it must be longer than the longest expected
+// pause. Zero disables it. It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetReadTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return errors.New("read timeout cannot be negative")
+	}
+
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.readTimeout = timeout
+	return nil
+}
+
+// GetReadTimeout returns the read timeout, or zero when it is disabled
+func (r *TranslationRecognizer) GetReadTimeout() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.readTimeout
+}
+
+// SetKeepAliveInterval enables keepalive frames of silence when no audio has been sent for the
+// given interval, keeping the connection warm between utterances. Zero disables keepalives.
+func (r *TranslationRecognizer) SetKeepAliveInterval(interval time.Duration) error {
+	if interval < 0 {
+		return errors.New("keepalive interval cannot be negative")
+	}
+
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.keepAliveInterval = interval
+	return nil
+}
+
+// GetKeepAliveInterval returns the keepalive interval, or zero when keepalives are disabled
+func (r *TranslationRecognizer) GetKeepAliveInterval() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.keepAliveInterval
+}
+
+// SetSampleRateCheck enables comparing the declared sample rate with the rate at which audio
+// actually arrives, raising a Warning event on mismatch. Only meaningful for real-time sources
+// such as push streams fed from live capture.
+func (r *TranslationRecognizer) SetSampleRateCheck(enabled bool) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.sampleRateCheck = enabled
+}
+
+// isSampleRateCheckEnabled returns whether the sample rate check is enabled
+func (r *TranslationRecognizer) isSampleRateCheckEnabled() bool {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.sampleRateCheck
+}
+
+// SetCloseHandshakeTimeout sets how long closing the service connection may spend sending the
+// end-of-audio marker and close frame. Zero closes the socket immediately without a handshake.
+func (r *TranslationRecognizer) SetCloseHandshakeTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return errors.New("close handshake timeout cannot be negative")
+	}
+
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.closeTimeout = timeout
+	return nil
+}
+
+// GetCloseHandshakeTimeout returns the close handshake timeout
+func (r *TranslationRecognizer) GetCloseHandshakeTimeout() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.closeTimeout
 }
 
 // Event properties
 
-// Recognizing returns the event signal for recognizing events
+// SetSilenceFinalizeTimeout sets how long a partial result may go without an update before
+// it is emitted as a final result. Zero disables silence finalization.
+func (r *TranslationRecognizer) SetSilenceFinalizeTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("silence finalize timeout cannot be negative: %v", timeout)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.silenceFinalize = timeout
+	return nil
+}
+
+// GetSilenceFinalizeTimeout returns the silence finalization timeout
+func (r *TranslationRecognizer) GetSilenceFinalizeTimeout() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.silenceFinalize
+}
+
+// SetSessionContext sets values that tag the service connection, e.g. a tenant or correlation id.
+// Each entry is sent as a connection header of the same name (so "X-ConnectionId" replaces the
+// generated connection id) and in the context block of the configuration message.
+// It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetSessionContext(values map[string]string) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.sessionContext = make(map[string]string, len(values))
+	for key, value := range values {
+		r.sessionContext[key] = value
+	}
+}
+
+// GetSessionContext returns a copy of the session context values
+func (r *TranslationRecognizer) GetSessionContext() map[string]string {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	values := make(map[string]string, len(r.sessionContext))
+	for key, value := range r.sessionContext {
+		values[key] = value
+	}
+	return values
+}
+
+// SetUtteranceGracePeriod sets how long the Utterance event waits for translations of all
+// target languages before firing with the ones received so far
+func (r *TranslationRecognizer) SetUtteranceGracePeriod(grace time.Duration) error {
+	if grace < 0 {
+		return fmt.Errorf("utterance grace period cannot be negative: %v", grace)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.utteranceGrace = grace
+	return nil
+}
+
+// GetUtteranceGracePeriod returns the utterance grace period
+func (r *TranslationRecognizer) GetUtteranceGracePeriod() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.utteranceGrace
+}
+
+// SetEmptyAudioBehavior sets how recognition completes when the audio source ends without data
+func (r *TranslationRecognizer) SetEmptyAudioBehavior(behavior EmptyAudioBehavior) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.emptyAudio = behavior
+}
+
+// GetEmptyAudioBehavior returns the empty audio behavior
+func (r *TranslationRecognizer) GetEmptyAudioBehavior() EmptyAudioBehavior {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.emptyAudio
+}
+
+// SetPunctuation sets the default punctuation mode for recognized and translated text
+func (r *TranslationRecognizer) SetPunctuation(mode PunctuationMode) error {
+	if mode < PunctuationExplicit || mode > PunctuationNone {
+		return fmt.Errorf("invalid punctuation mode: %v", mode)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.punctuation = mode
+	return nil
+}
+
+// GetPunctuation returns the default punctuation mode
+func (r *TranslationRecognizer) GetPunctuation() PunctuationMode {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.punctuation
+}
+
+// SetLanguagePunctuation overrides the punctuation mode for one target language
+func (r *TranslationRecognizer) SetLanguagePunctuation(language string, mode PunctuationMode) error {
+	if mode < PunctuationExplicit || mode > PunctuationNone {
+		return fmt.Errorf("invalid punctuation mode: %v", mode)
+	}
+	normalized := normalizeLanguageCode(language, false)
+	if normalized == "" {
+		return fmt.Errorf("invalid target language code: %s", language)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if r.languagePunctuation == nil {
+		r.languagePunctuation = make(map[string]PunctuationMode)
+	}
+	r.languagePunctuation[normalized] = mode
+	return nil
+}
+
+// GetLanguagePunctuation returns the punctuation mode used for a target language
+func (r *TranslationRecognizer) GetLanguagePunctuation(language string) PunctuationMode {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if mode, ok := r.languagePunctuation[normalizeLanguageCode(language, false)]; ok {
+		return mode
+	}
+	return r.punctuation
+}
+
+// SetZeroReadThreshold sets how many consecutive reads without audio data raise a
+// PossibleDeadSource warning. The warning is raised again only after data has resumed.
+// Zero disables the check.
+func (r *TranslationRecognizer) SetZeroReadThreshold(reads int) error {
+	if reads < 0 {
+		return fmt.Errorf("zero read threshold cannot be negative: %d", reads)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.zeroReadThreshold = reads
+	return nil
+}
+
+// GetZeroReadThreshold returns the zero read threshold
+func (r *TranslationRecognizer) GetZeroReadThreshold() int {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.zeroReadThreshold
+}
+
+// SetLeadingSilenceTrim skips audio below the given level (0-100, as computed for the audio level
+// log) at the start of recognition, until speech onset or until maxSkip of audio has been skipped,
+// so the first result is not delayed by leading silence. A zero level disables trimming.
+func (r *TranslationRecognizer) SetLeadingSilenceTrim(level int, maxSkip time.Duration) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("silence level must be between 0 and 100: %d", level)
+	}
+	if level > 0 && maxSkip <= 0 {
+		return fmt.Errorf("max skip must be positive when trimming is enabled: %v", maxSkip)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.silenceTrimLevel = level
+	r.silenceTrimMax = maxSkip
+	return nil
+}
+
+// GetLeadingSilenceTrim returns the leading silence level and the maximum audio skipped
+func (r *TranslationRecognizer) GetLeadingSilenceTrim() (level int, maxSkip time.Duration) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.silenceTrimLevel, r.silenceTrimMax
+}
+
+// SetSessionLogLimit caps the debug log output of each recognition session at maxLines lines
+// and maxBytes bytes. Once reached, further debug lines are suppressed and a summary of the
+// suppressed lines is logged. Zero leaves the corresponding cap unlimited.
+func (r *TranslationRecognizer) SetSessionLogLimit(maxLines, maxBytes int) error {
+	if maxLines < 0 || maxBytes < 0 {
+		return fmt.Errorf("session log limits cannot be negative: lines=%d, bytes=%d", maxLines, maxBytes)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.logMaxLines = maxLines
+	r.logMaxBytes = maxBytes
+	return nil
+}
+
+// GetSessionLogLimit returns the per-session debug log caps
+func (r *TranslationRecognizer) GetSessionLogLimit() (maxLines, maxBytes int) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.logMaxLines, r.logMaxBytes
+}
+
+// SetSpeechConfigResend sets whether the speech.config message is sent before every audio chunk.
+// By default it is sent once per connection; keepalive frames never resend it.
+// It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetSpeechConfigResend(resend bool) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.resendConfig = resend
+}
+
+// GetSpeechConfigResend returns whether the speech.config message is sent before every audio chunk
+func (r *TranslationRecognizer) GetSpeechConfigResend() bool {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.resendConfig
+}
+
+// ResultParser parses a text frame from the service into a result. path is the value of the Path
+// header. Returning a nil result with a nil error ignores the frame; returning ErrUseDefaultParser
+// hands the frame to the built-in parser.
+type ResultParser func(path string, headers, body []byte) (*TranslationRecognitionResult, error)
+
+// ErrUseDefaultParser is returned by a ResultParser to let the built-in parser handle a frame
+var ErrUseDefaultParser = errors.New("use the default result parser")
+
+// SetResultParser sets a parser that sees every text frame before the built-in parser, so frame
+// types the library does not know can be handled. nil restores the built-in parser only.
+// It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetResultParser(parser ResultParser) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.resultParser = parser
+}
+
+// GetResultParser returns the custom result parser, or nil if only the built-in parser is used
+func (r *TranslationRecognizer) GetResultParser() ResultParser {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.resultParser
+}
+
+// SetMinResultDuration drops final results of continuous recognition whose duration reported by
+// the service is shorter than d, such as single syllables recognized from noise. 0 disables it.
+// It applies to recognitions started after the call.
+func (r *TranslationRecognizer) SetMinResultDuration(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("minimum result duration must not be negative: %v", d)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.minResultDuration = d
+	return nil
+}
+
+// GetMinResultDuration returns the minimum duration of final results, or 0 if disabled
+func (r *TranslationRecognizer) GetMinResultDuration() time.Duration {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.minResultDuration
+}
+
+// DefaultMaxTargetLanguages is the number of target languages a recognizer accepts by default
+const DefaultMaxTargetLanguages = 20
+
+// ErrTooManyTargetLanguages is returned when more target languages are configured than the recognizer accepts
+var ErrTooManyTargetLanguages = errors.New("too many target languages")
+
+// SetMaxTargetLanguages sets how many target languages a connection may request. Target languages
+// are sent in the speech.config body, never in the URL or headers, so a long list cannot exceed
+// their size limits, but the service still rejects or slows down on very long lists.
+// 0 restores DefaultMaxTargetLanguages. It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetMaxTargetLanguages(n int) error {
+	if n < 0 {
+		return fmt.Errorf("maximum number of target languages must not be negative: %d", n)
+	}
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.maxTargetLanguages = n
+	return nil
+}
+
+// GetMaxTargetLanguages returns how many target languages a connection may request
+func (r *TranslationRecognizer) GetMaxTargetLanguages() int {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if r.maxTargetLanguages == 0 {
+		return DefaultMaxTargetLanguages
+	}
+	return r.maxTargetLanguages
+}
+
+// SetInterimTranslations sets whether the service is asked to translate interim hypotheses as well
+// as final results. The translations arrive in the Translations of Recognizing events and may change
+// until the final result. It applies to connections opened after the call.
+func (r *TranslationRecognizer) SetInterimTranslations(enabled bool) {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.interimTranslations = enabled
+}
+
+// GetInterimTranslations returns whether interim hypotheses are translated
+func (r *TranslationRecognizer) GetInterimTranslations() bool {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	return r.interimTranslations
+}
+
+// Recognizing returns the event signal for recognizing events (interim hypotheses only)
 func (r *TranslationRecognizer) Recognizing() *EventSignal {
 	return r.recognizing
 }
@@ -677,28 +2202,71 @@ func (r *TranslationRecognizer) SpeechEndDetected() *EventSignal {
 	return r.speechEndDetected
 }
 
+// Warning returns the event signal for non-fatal warning events
+func (r *TranslationRecognizer) Warning() *EventSignal {
+	return r.warning
+}
+
+// Utterance returns the event signal fired once per utterance with the translations of all target languages
+func (r *TranslationRecognizer) Utterance() *EventSignal {
+	return r.utterance
+}
+
+// ConnectionReestablished returns the event signal fired when a session's connection is replaced by Reconnect
+func (r *TranslationRecognizer) ConnectionReestablished() *EventSignal {
+	return r.reestablished
+}
+
 // Event raisers
 
+// newSessionID starts a new logical session and returns its ID
+func (r *TranslationRecognizer) newSessionID() string {
+	id := fmt.Sprintf("session_%d", r.now().UnixNano())
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	r.sessionID = id
+	return id
+}
+
+// SessionID returns the ID of the current logical session, which is reported in every event and
+// stays the same across reconnects. A new ID is assigned when a session starts.
+func (r *TranslationRecognizer) SessionID() string {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if r.sessionID == "" {
+		r.sessionID = fmt.Sprintf("session_%d", r.now().UnixNano())
+	}
+	return r.sessionID
+}
+
 func (r *TranslationRecognizer) raiseSessionStarted() {
 	args := &SessionEventArgs{
-		SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+		SessionID: r.newSessionID(),
 	}
 	r.sessionStarted.Signal(args)
 }
 
-func (r *TranslationRecognizer) raiseSessionStopped() {
+func (r *TranslationRecognizer) raiseSessionStopped(audioBytes int) {
 	args := &SessionEventArgs{
-		SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+		SessionID:     r.SessionID(),
+		AudioDuration: r.audioFormat().Duration(audioBytes),
 	}
 	r.sessionStopped.Signal(args)
 }
 
+func (r *TranslationRecognizer) raiseConnectionReestablished() {
+	args := &SessionEventArgs{
+		SessionID: r.SessionID(),
+	}
+	r.reestablished.Signal(args)
+}
+
 func (r *TranslationRecognizer) raiseSpeechStartDetected() {
 	args := &RecognitionEventArgs{
 		SessionEventArgs: SessionEventArgs{
-			SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+			SessionID: r.SessionID(),
 		},
-		Offset: time.Now().UnixNano(),
+		Offset: r.now().UnixNano(),
 	}
 	r.speechStartDetected.Signal(args)
 }
@@ -706,18 +2274,19 @@ func (r *TranslationRecognizer) raiseSpeechStartDetected() {
 func (r *TranslationRecognizer) raiseSpeechEndDetected() {
 	args := &RecognitionEventArgs{
 		SessionEventArgs: SessionEventArgs{
-			SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+			SessionID: r.SessionID(),
 		},
-		Offset: time.Now().UnixNano(),
+		Offset: r.now().UnixNano(),
 	}
 	r.speechEndDetected.Signal(args)
 }
 
 func (r *TranslationRecognizer) raiseRecognizing(result *TranslationRecognitionResult) {
+	r.metrics.update(func(m *MetricsSnapshot) { m.PartialResults++ })
 	args := &TranslationRecognitionEventArgs{
 		RecognitionEventArgs: RecognitionEventArgs{
 			SessionEventArgs: SessionEventArgs{
-				SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+				SessionID: r.SessionID(),
 			},
 			Offset: result.Offset,
 		},
@@ -727,10 +2296,11 @@ func (r *TranslationRecognizer) raiseRecognizing(result *TranslationRecognitionR
 }
 
 func (r *TranslationRecognizer) raiseRecognized(result *TranslationRecognitionResult) {
+	r.metrics.update(func(m *MetricsSnapshot) { m.FinalResults++ })
 	args := &TranslationRecognitionEventArgs{
 		RecognitionEventArgs: RecognitionEventArgs{
 			SessionEventArgs: SessionEventArgs{
-				SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+				SessionID: r.SessionID(),
 			},
 			Offset: result.Offset,
 		},
@@ -739,18 +2309,34 @@ func (r *TranslationRecognizer) raiseRecognized(result *TranslationRecognitionRe
 	r.recognized.Signal(args)
 }
 
+func (r *TranslationRecognizer) raiseUtterance(result *TranslationRecognitionResult) {
+	args := &TranslationRecognitionEventArgs{
+		RecognitionEventArgs: RecognitionEventArgs{
+			SessionEventArgs: SessionEventArgs{
+				SessionID: r.SessionID(),
+			},
+			Offset: result.Offset,
+		},
+		Result: result,
+	}
+	r.utterance.Signal(args)
+}
+
 func (r *TranslationRecognizer) raiseCanceled(details *CancellationDetails) {
+	if details != nil && details.Reason == CancellationReasonError {
+		r.metrics.update(func(m *MetricsSnapshot) { m.Errors++ })
+	}
 	result := &TranslationRecognitionResult{
-		ResultID: fmt.Sprintf("canceled_%d", time.Now().UnixNano()),
+		ResultID: fmt.Sprintf("canceled_%d", r.now().UnixNano()),
 		Reason:   ResultReasonCanceled,
-		Offset:   time.Now().UnixNano(),
+		Offset:   r.now().UnixNano(),
 	}
 
 	args := &TranslationRecognitionCanceledEventArgs{
 		TranslationRecognitionEventArgs: TranslationRecognitionEventArgs{
 			RecognitionEventArgs: RecognitionEventArgs{
 				SessionEventArgs: SessionEventArgs{
-					SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+					SessionID: r.SessionID(),
 				},
 				Offset: result.Offset,
 			},
@@ -761,6 +2347,17 @@ func (r *TranslationRecognizer) raiseCanceled(details *CancellationDetails) {
 	r.canceled.Signal(args)
 }
 
+func (r *TranslationRecognizer) raiseWarning(code WarningCode, message string) {
+	args := &WarningEventArgs{
+		SessionEventArgs: SessionEventArgs{
+			SessionID: r.SessionID(),
+		},
+		Code:    code,
+		Message: message,
+	}
+	r.warning.Signal(args)
+}
+
 func (r *TranslationRecognizer) raiseSynthesizing(audio []byte) {
 	result := &TranslationSynthesisResult{
 		Audio:  audio,
@@ -769,7 +2366,7 @@ func (r *TranslationRecognizer) raiseSynthesizing(audio []byte) {
 
 	args := &TranslationSynthesisEventArgs{
 		SessionEventArgs: SessionEventArgs{
-			SessionID: fmt.Sprintf("session_%d", time.Now().UnixNano()),
+			SessionID: r.SessionID(),
 		},
 		Result: result,
 	}
@@ -826,7 +2423,7 @@ func (r *TranslationRecognizer) RemoveTargetLanguage(language string) {
 // Close cleans up resources
 func (r *TranslationRecognizer) Close() error {
 	// Stop continuous recognition if running
-	if r.continuousRunning {
+	if r.IsRunning() {
 		r.StopContinuousRecognition()
 	}
 
@@ -839,6 +2436,9 @@ func (r *TranslationRecognizer) Close() error {
 	r.sessionStopped.Disconnect()
 	r.speechStartDetected.Disconnect()
 	r.speechEndDetected.Disconnect()
+	r.warning.Disconnect()
+	r.utterance.Disconnect()
+	r.reestablished.Disconnect()
 
 	// Close audio config
 	if r.audioConfig != nil {
@@ -855,38 +2455,72 @@ type speechServiceConnection struct {
 	region         string
 	languages      []string
 	sourceLanguage string
+	outputFormat   OutputFormat
+	includeSource  bool
+	closeTimeout   time.Duration
+	sessionContext map[string]string
+	profanity      ProfanityOption
+	punctuation    PunctuationMode
+	langPunct      map[string]PunctuationMode
+	logger         *sessionLogLimiter // caps debug output of the session; nil writes everything
+	nowFunc        func() time.Time
+	turnID         string // current turn, set by turn.start and cleared by turn.end
+	resultParser   ResultParser
+	audioFormat    *AudioStreamFormat // format of the audio sent on this connection
+	synthesizer    *Synthesizer       // nil unless a voice is configured
+	resendConfig   bool               // send speech.config before every audio chunk instead of once
+	maxLanguages   int                // maximum number of target languages in speech.config
+	interimTrans   bool               // request translations of interim hypotheses
+	readTimeout    time.Duration      // maximum wait for a message from the service; 0 waits forever
+	turnEnded      chan struct{}      // signaled when the service sends turn.end
+
+	// writeMu serializes writes since keepalive frames are sent from a separate goroutine
+	writeMu    sync.Mutex
+	lastSendAt time.Time
+	configSent bool
+	requestID  string // request ID of the audio stream once speech.config has been sent
+	endSent    bool   // the end-of-audio marker has been sent
 }
 
 // connectToSpeechService connects to the Azure Speech Service WebSocket API
-func (r *TranslationRecognizer) connectToSpeechService() (*speechServiceConnection, error) {
+func (r *TranslationRecognizer) connectToSpeechService(ctx context.Context) (*speechServiceConnection, error) {
 	log.Printf("[DEBUG] Speech Service connection start: region=%s", r.config.GetRegion())
 
+	// トークンプロバイダーが設定されている場合は接続ごとに新しいトークンを取得する
+	if provider := r.config.GetAuthorizationTokenProvider(); provider != nil {
+		token, err := provider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get authorization token: %w", err)
+		}
+		r.config.SetAuthorizationToken(token)
+	}
+
 	dialer := websocket.Dialer{
 		EnableCompression: true,
+		Proxy:             r.config.proxyFunc(),
 	}
 
-	// Prepare headers
-	header := http.Header{}
+	wsURL, header, err := buildConnectionRequest(r.config.SpeechConfig)
+	if err != nil {
+		return nil, err
+	}
+	outputFormat := r.config.GetOutputFormat()
 	authToken := r.config.GetAuthorizationToken()
 	if authToken == "" {
-		authToken = r.config.GetSubscriptionKey()
+		authToken = subscriptionKey(r.config.SpeechConfig)
 	}
 
-	if authToken == "" {
-		return nil, fmt.Errorf("authentication information is not configured")
+	// セッションコンテキスト（テナントIDや相関IDなど）をヘッダーに追加
+	sessionContext := r.GetSessionContext()
+	for name, value := range sessionContext {
+		header.Set(name, value)
 	}
 
-	header.Add("Authorization", "Bearer "+authToken)
-	header.Add("Ocp-Apim-Subscription-Key", os.Getenv("SPEECH_SERVICE_KEY"))
-	header.Add("X-ConnectionId", uuid.New().String())
-
-	// Construct WebSocket URL
-	wsURL := fmt.Sprintf("wss://%s.stt.speech.microsoft.com/speech/universal/v2", r.config.GetRegion())
 	log.Printf("[DEBUG] Speech Service WebSocket URL: %s", wsURL)
 
 	// Establish WebSocket connection
 	log.Printf("[DEBUG] Attempting WebSocket connection...")
-	conn, resp, err := dialer.Dial(wsURL, header)
+	conn, resp, err := dialer.DialContext(ctx, wsURL, header)
 	if err != nil {
 		if resp != nil {
 			log.Printf("Connection error - Status: %d, Headers: %v", resp.StatusCode, resp.Header)
@@ -895,38 +2529,118 @@ func (r *TranslationRecognizer) connectToSpeechService() (*speechServiceConnecti
 	}
 	log.Printf("WebSocket connection to Speech Service established")
 
+	sc := r.newConnectionSettings(sessionContext)
+	sc.conn = conn
+	sc.authToken = authToken
+	sc.outputFormat = outputFormat
+	return sc, nil
+}
+
+// newConnectionSettings returns a connection populated with the recognizer settings but no socket
+func (r *TranslationRecognizer) newConnectionSettings(sessionContext map[string]string) *speechServiceConnection {
 	return &speechServiceConnection{
-		conn:           conn,
-		authToken:      authToken,
 		region:         r.config.GetRegion(),
 		languages:      r.GetTargetLanguages(),
 		sourceLanguage: r.config.GetSpeechRecognitionLanguage(),
-	}, nil
+		includeSource:  r.config.GetIncludeSourceInTranslations(),
+		profanity:      r.config.GetProfanity(),
+		closeTimeout:   r.GetCloseHandshakeTimeout(),
+		sessionContext: sessionContext,
+		punctuation:    r.GetPunctuation(),
+		langPunct:      r.languagePunctuationSnapshot(),
+		nowFunc:        r.nowFunc,
+		lastSendAt:     r.now(),
+		resendConfig:   r.GetSpeechConfigResend(),
+		maxLanguages:   r.GetMaxTargetLanguages(),
+		interimTrans:   r.GetInterimTranslations(),
+		readTimeout:    r.GetReadTimeout(),
+		resultParser:   r.GetResultParser(),
+		audioFormat:    r.audioFormat(),
+		synthesizer:    r.newSynthesizer(),
+		turnEnded:      make(chan struct{}, 1),
+	}
 }
 
-// sendAudioData sends audio data via WebSocket
-func (sc *speechServiceConnection) sendAudioData(data []byte) error {
-	log.Printf("[DEBUG] Audio data to send to Speech Service: %d bytes", len(data))
+// newSynthesizer returns a synthesizer that raises Synthesizing events, or nil if no voice is configured
+func (r *TranslationRecognizer) newSynthesizer() *Synthesizer {
+	voiceName := r.config.GetVoiceName()
+	if voiceName == "" {
+		return nil
+	}
+	return NewSynthesizer(voiceName, r.raiseSynthesizing)
+}
+
+// format returns the audio format of the connection, falling back to the default input format
+func (sc *speechServiceConnection) format() *AudioStreamFormat {
+	if sc.audioFormat == nil {
+		return GetDefaultInputFormat()
+	}
+	return sc.audioFormat
+}
+
+// languagePunctuationSnapshot returns a copy of the per-language punctuation modes
+func (r *TranslationRecognizer) languagePunctuationSnapshot() map[string]PunctuationMode {
+	r.continuousMutex.Lock()
+	defer r.continuousMutex.Unlock()
+	if len(r.languagePunctuation) == 0 {
+		return nil
+	}
+	snapshot := make(map[string]PunctuationMode, len(r.languagePunctuation))
+	for language, mode := range r.languagePunctuation {
+		snapshot[language] = mode
+	}
+	return snapshot
+}
 
-	requestID := uuid.New().String()
+// BuildSpeechConfigMessage returns the JSON body of the speech.config message that is sent to the
+// service before audio, without connecting. Useful for debugging protocol issues
+func (r *TranslationRecognizer) BuildSpeechConfigMessage() ([]byte, error) {
+	return r.newConnectionSettings(r.GetSessionContext()).buildSpeechConfigMessage()
+}
 
+// buildSpeechConfigMessage builds the JSON body of the speech.config message
+func (sc *speechServiceConnection) buildSpeechConfigMessage() ([]byte, error) {
 	// Normalize and validate language codes
 	normalizedSourceLang := normalizeLanguageCode(sc.sourceLanguage, true)
 	if normalizedSourceLang == "" {
-		return fmt.Errorf("invalid source language code: %s", sc.sourceLanguage)
+		return nil, fmt.Errorf("invalid source language code: %s", sc.sourceLanguage)
+	}
+	sc.logger.printf("[DEBUG] Normalized source language: %s (original: %s)", normalizedSourceLang, sc.sourceLanguage)
+
+	// 翻訳先言語は URL やヘッダーではなく、この JSON 本文でのみ送信する
+	if sc.maxLanguages > 0 && len(sc.languages) > sc.maxLanguages {
+		return nil, fmt.Errorf("%w: %d requested, at most %d", ErrTooManyTargetLanguages, len(sc.languages), sc.maxLanguages)
 	}
-	log.Printf("[DEBUG] Normalized source language: %s (original: %s)", normalizedSourceLang, sc.sourceLanguage)
 
 	// Normalize and validate target languages
 	normalizedTargetLangs := make([]string, 0, len(sc.languages))
 	for _, lang := range sc.languages {
 		normalized := normalizeLanguageCode(lang, false)
 		if normalized == "" {
-			return fmt.Errorf("invalid target language code: %s", lang)
+			return nil, fmt.Errorf("invalid target language code: %s", lang)
 		}
 		normalizedTargetLangs = append(normalizedTargetLangs, normalized)
 	}
-	log.Printf("[DEBUG] Normalized target languages: %v", normalizedTargetLangs)
+	sc.logger.printf("[DEBUG] Normalized target languages: %v", normalizedTargetLangs)
+
+	features := map[string]interface{}{
+		"enableTranslation":   true,
+		"wordLevelTimestamps": true,
+		"punctuation":         sc.punctuation.serviceValue(),
+	}
+
+	// 言語ごとの句読点設定（既定値と異なるもののみ）
+	if len(sc.langPunct) > 0 {
+		languagePunctuation := make(map[string]string)
+		for _, lang := range normalizedTargetLangs {
+			if mode, ok := sc.langPunct[lang]; ok && mode != sc.punctuation {
+				languagePunctuation[lang] = mode.serviceValue()
+			}
+		}
+		if len(languagePunctuation) > 0 {
+			features["translationPunctuation"] = languagePunctuation
+		}
+	}
 
 	// Construct WebSocket configuration message
 	configMsg := map[string]interface{}{
@@ -942,49 +2656,84 @@ func (sc *speechServiceConnection) sendAudioData(data []byte) error {
 				"speechRecognitionLanguage":    normalizedSourceLang,
 				"translationLanguages":         normalizedTargetLangs,
 				"sourceLanguageForTranslation": normalizedSourceLang,
-				"features": map[string]interface{}{
-					"enableTranslation":   true,
-					"wordLevelTimestamps": true,
-					"punctuation":         "explicit",
-				},
-				"profanity":               "masked",
-				"timeToDetectEndOfSpeech": "1500",
-				"scenarios":               []string{"conversation"},
+				"features":                     features,
+				"profanity":                    sc.profanity.serviceValue(),
+				"timeToDetectEndOfSpeech":      "1500",
+				"scenarios":                    []string{"conversation"},
 			},
 			"input": map[string]interface{}{
-				"format": "audio/x-wav",
+				"format": sc.format().contentType(),
 				"audioParameters": map[string]interface{}{
-					"sampleRate": 16000,
+					"sampleRate":    sc.format().SamplesPerSecond(),
+					"bitsPerSample": sc.format().BitsPerSample(),
+					"channels":      sc.format().Channels(),
 				},
 			},
 		},
 	}
 
+	// 途中結果の翻訳を要求する（translation.hypothesis に翻訳結果が含まれるようになる）
+	if sc.interimTrans {
+		speechConfig := configMsg["config"].(map[string]interface{})["speechConfig"].(map[string]interface{})
+		speechConfig["translation"] = map[string]interface{}{
+			"output": map[string]interface{}{
+				"interimResults": map[string]interface{}{"mode": "Always"},
+			},
+		}
+	}
+
+	// セッションコンテキストを context ブロックに追加（system は上書きしない）
+	messageContext := configMsg["context"].(map[string]interface{})
+	for key, value := range sc.sessionContext {
+		if _, exists := messageContext[key]; !exists {
+			messageContext[key] = value
+		}
+	}
+
 	// Convert configuration message to JSON
 	configBytes, err := json.Marshal(configMsg)
 	if err != nil {
 		log.Printf("[ERROR] Failed to JSON encode configuration message: %v", err)
-		return err
+		return nil, err
 	}
+	return configBytes, nil
+}
 
-	log.Printf("[DEBUG] Speech Service configuration: %s", string(configBytes))
+// sendAudioData sends audio data via WebSocket
+func (sc *speechServiceConnection) sendAudioData(data []byte) error {
+	sc.logger.printf("[DEBUG] Audio data to send to Speech Service: %d bytes", len(data))
 
-	// Construct message in Speech Service header format
-	configHeader := fmt.Sprintf("Path: speech.config\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: application/json\r\n\r\n%s",
-		requestID,
-		time.Now().UTC().Format(time.RFC3339),
-		configBytes)
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	return sc.sendAudioLocked(data, sc.resendConfig)
+}
 
-	// Send configuration message
-	if err := sc.conn.WriteMessage(websocket.TextMessage, []byte(configHeader)); err != nil {
-		log.Printf("[ERROR] Failed to send configuration message: %v", err)
-		return err
+// sendKeepAlive sends a frame of silence as audio only; speech.config is sent only if no audio
+// has been sent on the connection yet
+func (sc *speechServiceConnection) sendKeepAlive(silence []byte) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	return sc.sendAudioLocked(silence, false)
+}
+
+// sendAudioLocked sends an audio message, preceded by speech.config when it has not been sent
+// yet or resendConfig is set. sc.writeMu must be held.
+func (sc *speechServiceConnection) sendAudioLocked(data []byte, resendConfig bool) error {
+	if !sc.configSent || resendConfig {
+		requestID := uuid.New().String()
+		if err := sc.sendSpeechConfigLocked(requestID); err != nil {
+			return err
+		}
+		sc.requestID = requestID
+		sc.configSent = true
 	}
+	requestID := sc.requestID
 
 	// Construct audio message header
-	audioHeader := fmt.Sprintf("Path: audio\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: audio/x-wav\r\n\r\n",
+	audioHeader := fmt.Sprintf("Path: audio\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: %s\r\n\r\n",
 		requestID,
-		time.Now().UTC().Format(time.RFC3339))
+		sc.now().UTC().Format(time.RFC3339),
+		sc.format().contentType())
 
 	// Send audio header
 	if err := sc.conn.WriteMessage(websocket.TextMessage, []byte(audioHeader)); err != nil {
@@ -998,23 +2747,72 @@ func (sc *speechServiceConnection) sendAudioData(data []byte) error {
 		return err
 	}
 
-	log.Printf("[DEBUG] Message sent successfully - RequestID: %s, DataSize: %d bytes", requestID, len(data))
+	sc.lastSendAt = sc.now()
+	sc.logger.printf("[DEBUG] Message sent successfully - RequestID: %s, DataSize: %d bytes", requestID, len(data))
+	return nil
+}
+
+// sendSpeechConfigLocked sends the speech.config message. sc.writeMu must be held.
+func (sc *speechServiceConnection) sendSpeechConfigLocked(requestID string) error {
+	configBytes, err := sc.buildSpeechConfigMessage()
+	if err != nil {
+		return err
+	}
+
+	sc.logger.printf("[DEBUG] Speech Service configuration: %s", string(configBytes))
+
+	// Construct message in Speech Service header format
+	configHeader := fmt.Sprintf("Path: speech.config\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: application/json\r\n\r\n%s",
+		requestID,
+		sc.now().UTC().Format(time.RFC3339),
+		configBytes)
+
+	// Send configuration message
+	if err := sc.conn.WriteMessage(websocket.TextMessage, []byte(configHeader)); err != nil {
+		log.Printf("[ERROR] Failed to send configuration message: %v", err)
+		return err
+	}
 	return nil
 }
 
+// now returns the current time from the connection clock
+func (sc *speechServiceConnection) now() time.Time {
+	if sc.nowFunc == nil {
+		return time.Now()
+	}
+	return sc.nowFunc()
+}
+
+// idleFor returns how long it has been since audio was last sent
+func (sc *speechServiceConnection) idleFor(now time.Time) time.Duration {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	return now.Sub(sc.lastSendAt)
+}
+
 // receiveResults は認識結果を受信します
 func (sc *speechServiceConnection) receiveResults() (*TranslationRecognitionResult, error) {
+	// 読み取り期限は実時間で設定する（ソケットの期限は nowFunc ではなく実時間で判定されるため）
+	if sc.readTimeout > 0 {
+		if err := sc.conn.SetReadDeadline(time.Now().Add(sc.readTimeout)); err != nil {
+			return nil, err
+		}
+	}
 	messageType, message, err := sc.conn.ReadMessage()
 	if err != nil {
+		var netErr net.Error
+		if sc.readTimeout > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, fmt.Errorf("%w (%v)", ErrReadTimeout, sc.readTimeout)
+		}
 		return nil, err
 	}
 
-	log.Printf("[DEBUG] Message received from client: type=%d, dataSize=%d bytes", messageType, len(message))
+	sc.logger.printf("[DEBUG] Message received from client: type=%d, dataSize=%d bytes", messageType, len(message))
 
 	// テキストメッセージの場合（ヘッダーとJSONボディ）
 	if messageType == websocket.TextMessage {
 		// メッセージをヘッダーとボディに分割
-		parts := strings.Split(string(message), "\r\n\r\n")
+		parts := strings.SplitN(string(message), "\r\n\r\n", 2)
 		if len(parts) != 2 {
 			return nil, fmt.Errorf("invalid message format: expected header and body, got %d parts", len(parts))
 		}
@@ -1022,60 +2820,143 @@ func (sc *speechServiceConnection) receiveResults() (*TranslationRecognitionResu
 		headers := parts[0]
 		body := parts[1]
 
-		log.Printf("[DEBUG] Received headers:\n%s", headers)
-		log.Printf("[DEBUG] Received body:\n%s", body)
-
-		// JSONをパース
-		var response map[string]interface{}
-		if err := json.Unmarshal([]byte(body), &response); err != nil {
-			return nil, fmt.Errorf("JSON parse error: %v", err)
-		}
+		sc.logger.printf("[DEBUG] Received headers:\n%s", headers)
+		sc.logger.printf("[DEBUG] Received body:\n%s", body)
 
-		// レスポンスタイプをチェック - Pathヘッダーを確認
-		var messagePath string
+		// レスポンスタイプをチェック - Path と X-RequestId ヘッダーを確認
+		var messagePath, requestID string
 		headerLines := strings.Split(headers, "\r\n")
 		for _, line := range headerLines {
 			if strings.HasPrefix(line, "Path:") {
-				messagePath = strings.TrimPrefix(line, "Path:")
-				messagePath = strings.TrimSpace(messagePath)
-				break
+				messagePath = strings.TrimSpace(strings.TrimPrefix(line, "Path:"))
+			} else if strings.HasPrefix(line, "X-RequestId:") {
+				requestID = strings.TrimSpace(strings.TrimPrefix(line, "X-RequestId:"))
+			}
+		}
+
+		sc.logger.printf("[DEBUG] Message path: %s", messagePath)
+
+		// カスタムパーサーが設定されている場合は先に処理させる
+		if sc.resultParser != nil {
+			result, err := sc.resultParser(messagePath, []byte(headers), []byte(body))
+			if !errors.Is(err, ErrUseDefaultParser) {
+				if result != nil && result.TurnID == "" {
+					result.TurnID = sc.turnID
+				}
+				return result, err
 			}
 		}
 
-		log.Printf("[DEBUG] Message path: %s", messagePath)
+		// JSONをパース
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &response); err != nil {
+			return nil, fmt.Errorf("JSON parse error: %v", err)
+		}
 
 		// 異なるメッセージタイプを処理
 		switch messagePath {
 		case "turn.start":
-			// ターンスタートの処理 - 必要に応じてログを出力
-			log.Printf("[DEBUG] Turn started with context: %s", body)
+			// ターンの開始 - 以降の結果をこのターンに関連付ける
+			// context.serviceTag があればそれを、なければ X-RequestId をターンIDとして使用
+			sc.turnID = requestID
+			if turnContext, ok := response["context"].(map[string]interface{}); ok {
+				if serviceTag, ok := turnContext["serviceTag"].(string); ok && serviceTag != "" {
+					sc.turnID = serviceTag
+				}
+			}
+			sc.logger.printf("[DEBUG] Turn started: turnID=%s, context=%s", sc.turnID, body)
 			return nil, nil
+		case "translation.synthesis.end":
+			// 合成音声の終了 - 1発話分の音声を Synthesizing イベントで通知する
+			if sc.synthesizer != nil {
+				status, _ := response["SynthesisStatus"].(string)
+				if status != "" && status != "Success" {
+					log.Printf("[WARNING] Speech synthesis failed: status=%s, reason=%v", status, response["FailureReason"])
+				}
+				sc.synthesizer.End(status == "" || status == "Success")
+			}
+			return nil, nil
+		case "turn.end":
+			sc.logger.printf("[DEBUG] Turn ended: turnID=%s", sc.turnID)
+			sc.turnID = ""
+			select {
+			case sc.turnEnded <- struct{}{}:
+			default:
+			}
+			return nil, nil
+		case "speech.hypothesis", "translation.hypothesis":
+			// 途中結果の処理
+			result := &TranslationRecognitionResult{
+				ResultID:     fmt.Sprintf("result_%d", sc.now().UnixNano()),
+				Reason:       ResultReasonTranslatingSpeech,
+				Offset:       sc.now().UnixNano(),
+				Translations: make(map[string]string),
+				TurnID:       sc.turnID,
+			}
+			if text, ok := response["Text"].(string); ok {
+				result.Text = text
+			}
+			if offset, ok := jsonInt64(response["Offset"]); ok {
+				result.Offset = int64(ticksToDuration(offset))
+			}
+			if duration, ok := jsonInt64(response["Duration"]); ok {
+				result.Duration = ticksToDuration(duration)
+			}
+			sc.parseTranslations(response, result)
+			return result, nil
 		case "speech.phrase":
 			// 音声認識結果の処理
 			if response["type"] == "final" {
 				result := &TranslationRecognitionResult{
-					ResultID:     fmt.Sprintf("result_%d", time.Now().UnixNano()),
+					ResultID:     fmt.Sprintf("result_%d", sc.now().UnixNano()),
 					Reason:       ResultReasonTranslatedSpeech,
-					Offset:       time.Now().UnixNano(),
+					Offset:       sc.now().UnixNano(),
 					Duration:     1 * time.Second,
 					Translations: make(map[string]string),
+					TurnID:       sc.turnID,
+				}
+
+				// サービスが返すタイミング（100ナノ秒単位）を使用し、ない場合のみ現在時刻と1秒を使用する
+				if offset, ok := jsonInt64(response["Offset"]); ok {
+					result.Offset = int64(ticksToDuration(offset))
+				}
+				if duration, ok := jsonInt64(response["Duration"]); ok {
+					result.Duration = ticksToDuration(duration)
 				}
 
 				// 認識テキストの取得
 				if nbest, ok := response["NBest"].([]interface{}); ok && len(nbest) > 0 {
 					if firstResult, ok := nbest[0].(map[string]interface{}); ok {
-						if display, ok := firstResult["Display"].(string); ok {
-							result.Text = display
+						// Display がない設定では ITN、Lexical の順に使用する
+						for _, form := range []string{"Display", "ITN", "Lexical"} {
+							if text, ok := firstResult[form].(string); ok && text != "" {
+								result.Text = text
+								if form != "Display" {
+									sc.logger.printf("[DEBUG] NBest has no Display form; using %s: %s", form, text)
+								}
+								break
+							}
+						}
+						if confidence, ok := firstResult["Confidence"].(float64); ok {
+							result.Confidence = confidence
 						}
 					}
 				}
 
 				// 翻訳結果の取得
-				if translations, ok := response["Translations"].(map[string]interface{}); ok {
-					for lang, text := range translations {
-						if textStr, ok := text.(string); ok {
-							result.Translations[lang] = textStr
-						}
+				sc.parseTranslations(response, result)
+
+				// 認識はできたが翻訳結果がない場合は RecognizedSpeech として区別する
+				if len(result.Translations) == 0 && result.Text != "" {
+					log.Printf("[WARNING] Speech recognized but no translations returned: text=%s", result.Text)
+					result.Reason = ResultReasonRecognizedSpeech
+				}
+
+				// 認識元テキストを翻訳結果と同じ形式で含める（オプション）
+				if sc.includeSource && result.Text != "" {
+					sourceKey := normalizeLanguageCode(sc.sourceLanguage, false)
+					if _, exists := result.Translations[sourceKey]; !exists {
+						result.Translations[sourceKey] = result.Text
 					}
 				}
 
@@ -1084,53 +2965,351 @@ func (sc *speechServiceConnection) receiveResults() (*TranslationRecognitionResu
 		}
 	}
 
+	// バイナリメッセージの場合（合成音声）
+	if messageType == websocket.BinaryMessage && sc.synthesizer != nil {
+		if _, err := sc.synthesizer.HandleFrame(message); err != nil {
+			sc.logger.printf("[DEBUG] Ignoring malformed binary message: %v", err)
+		}
+	}
+
 	// 他のメッセージタイプやレスポンスタイプの場合はnilを返す
 	return nil, nil
 }
 
+// parseTranslations は翻訳結果をresultに設定します
+// 詳細出力モードの場合、サービスがタイミングを返していれば TranslationDetails も設定します
+func (sc *speechServiceConnection) parseTranslations(response map[string]interface{}, result *TranslationRecognitionResult) {
+	detailed := sc.outputFormat == OutputFormatDetailed
+
+	addTranslation := func(lang string, entry interface{}) {
+		switch v := entry.(type) {
+		case string:
+			result.Translations[lang] = v
+		case map[string]interface{}:
+			text, ok := v["Text"].(string)
+			if !ok {
+				return
+			}
+			result.Translations[lang] = text
+			if confidence, ok := v["Confidence"].(float64); ok {
+				if result.TranslationConfidences == nil {
+					result.TranslationConfidences = make(map[string]float64)
+				}
+				result.TranslationConfidences[lang] = confidence
+			}
+			if !detailed {
+				return
+			}
+			offset, hasOffset := jsonInt64(v["Offset"])
+			duration, hasDuration := jsonInt64(v["Duration"])
+			if !hasOffset && !hasDuration {
+				return
+			}
+			if result.TranslationDetails == nil {
+				result.TranslationDetails = make(map[string]*TranslationDetail)
+			}
+			result.TranslationDetails[lang] = &TranslationDetail{
+				Text:     text,
+				Offset:   int64(ticksToDuration(offset)),
+				Duration: ticksToDuration(duration),
+			}
+		}
+	}
+
+	// 簡易形式: {"Translations": {"de": "..."}}、詳細形式: {"Translations": {"de": {"Text": "...", "Offset": ..., "Duration": ..., "Confidence": ...}}}
+	if translations, ok := response["Translations"].(map[string]interface{}); ok {
+		for lang, entry := range translations {
+			addTranslation(lang, entry)
+		}
+	}
+
+	// translation.phrase 形式: {"Translation": {"Translations": [{"Language": "de", "Text": "..."}]}}
+	if translation, ok := response["Translation"].(map[string]interface{}); ok {
+		if items, ok := translation["Translations"].([]interface{}); ok {
+			for _, item := range items {
+				entry, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if lang, ok := entry["Language"].(string); ok {
+					addTranslation(lang, entry)
+				}
+			}
+		}
+	}
+}
+
+// jsonInt64 はJSONの数値をint64に変換します
+func jsonInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ticksToDuration はSpeech Serviceの100ナノ秒単位のティックをtime.Durationに変換します
+func ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks) * 100 * time.Nanosecond
+}
+
 // close はWebSocket接続を閉じます
+// 終了ハンドシェイクが有効な場合は、音声の終端マーカーとクローズフレームを送信してから閉じます
 func (sc *speechServiceConnection) close() error {
+	if sc.closeTimeout > 0 {
+		if err := sc.sendCloseHandshake(sc.closeTimeout); err != nil {
+			log.Printf("[DEBUG] Graceful close handshake with Speech Service failed: %v", err)
+		}
+	}
 	return sc.conn.Close()
 }
 
-// calculateAudioLevel は音声バッファから平均音声レベル（0-100の範囲）を計算します
-func calculateAudioLevel(buffer []byte, n int) int {
-	if n == 0 {
-		log.Printf("DEBUG: Audio buffer is empty (size=0)")
+// sendCloseHandshake は空のaudioメッセージ（音声の終端）とWebSocketのクローズフレームを送信します
+// サービス側でターンを正しく完了させ、課金を正確にするために必要です
+func (sc *speechServiceConnection) sendCloseHandshake(timeout time.Duration) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	if err := sc.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	if !sc.endSent {
+		if err := sc.sendEndOfAudioLocked(); err != nil {
+			return err
+		}
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := sc.conn.WriteControl(websocket.CloseMessage, closeMsg, deadline); err != nil {
+		return fmt.Errorf("failed to send close frame: %v", err)
+	}
+	return nil
+}
+
+// sendEndOfAudio tells the service that no more audio follows, so it finishes the current turn
+func (sc *speechServiceConnection) sendEndOfAudio() error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	return sc.sendEndOfAudioLocked()
+}
+
+// sendEndOfAudioLocked sends the end-of-audio marker; sc.writeMu must be held
+func (sc *speechServiceConnection) sendEndOfAudioLocked() error {
+	// 音声の終端マーカー: ボディが空のaudioメッセージ（送信中の音声と同じリクエストID）
+	requestID := sc.requestID
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	endHeader := fmt.Sprintf("Path: audio\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: %s\r\n\r\n",
+		requestID,
+		sc.now().UTC().Format(time.RFC3339),
+		sc.format().contentType())
+	if err := sc.conn.WriteMessage(websocket.TextMessage, []byte(endHeader)); err != nil {
+		return fmt.Errorf("failed to send end-of-audio header: %v", err)
+	}
+	if err := sc.conn.WriteMessage(websocket.BinaryMessage, []byte{}); err != nil {
+		return fmt.Errorf("failed to send end-of-audio marker: %v", err)
+	}
+	sc.endSent = true
+	return nil
+}
+
+// buildConnectionRequest returns the WebSocket URL and headers used to connect to the Speech Service.
+// The URL comes from the endpoint, the host or the region, in that order of precedence; an
+// authorization token takes precedence over the subscription key. When the config has neither, the
+// key is read from the SPEECH_SERVICE_KEY environment variable. Target languages are not part of
+// the request; they are sent in the speech.config message.
+func buildConnectionRequest(config *SpeechConfig) (string, http.Header, error) {
+	const path = "/speech/universal/v2"
+
+	var wsURL string
+	switch {
+	case config.GetProperty(SpeechServiceConnectionEndpoint) != "":
+		endpoint, err := normalizeWebSocketURL(config.GetProperty(SpeechServiceConnectionEndpoint))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid endpoint: %w", err)
+		}
+		wsURL = endpoint
+	case config.GetProperty(SpeechServiceConnectionHost) != "":
+		host, err := normalizeWebSocketURL(config.GetProperty(SpeechServiceConnectionHost))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid host: %w", err)
+		}
+		wsURL = strings.TrimSuffix(host, "/") + path
+	case config.GetRegion() != "":
+		wsURL = fmt.Sprintf("wss://%s.stt.speech.microsoft.com%s", config.GetRegion(), path)
+	default:
+		return "", nil, fmt.Errorf("no region, endpoint or host is configured")
+	}
+
+	query := url.Values{}
+	if config.GetOutputFormat() == OutputFormatDetailed {
+		query.Set("format", "detailed")
+	}
+	// 音声が設定されている場合は翻訳結果の合成音声を要求する
+	if voice := config.GetProperty(SpeechServiceConnectionTranslationVoice); voice != "" {
+		query.Set("features", "texttospeech")
+		query.Set("voice", voice)
+	}
+	if len(query) > 0 {
+		separator := "?"
+		if strings.Contains(wsURL, "?") {
+			separator = "&"
+		}
+		wsURL += separator + query.Encode()
+	}
+
+	header := http.Header{}
+	if token := config.GetAuthorizationToken(); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if key := subscriptionKey(config); key != "" {
+		header.Set("Ocp-Apim-Subscription-Key", key)
+	} else {
+		return "", nil, fmt.Errorf("authentication information is not configured")
+	}
+	header.Set("X-ConnectionId", uuid.New().String())
+
+	return wsURL, header, nil
+}
+
+// subscriptionKeyEnv is the environment variable read when no subscription key is configured
+const subscriptionKeyEnv = "SPEECH_SERVICE_KEY"
+
+// subscriptionKey returns the configured subscription key, falling back to subscriptionKeyEnv
+// only when the config has none
+func subscriptionKey(config *SpeechConfig) string {
+	if key := config.GetSubscriptionKey(); key != "" {
+		return key
+	}
+	return os.Getenv(subscriptionKeyEnv)
+}
+
+// normalizeWebSocketURL converts an endpoint or host to a WebSocket URL. https and a missing scheme
+// become wss; http becomes ws, for local containers that do not use TLS.
+func normalizeWebSocketURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "wss://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "wss", "https":
+		u.Scheme = "wss"
+	case "ws", "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("unsupported scheme %q: use wss, https, ws or http", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("no host in %q", raw)
+	}
+	return u.String(), nil
+}
+
+// isRecoverableConnectionError reports whether a connection error is transient and worth a reconnect
+func isRecoverableConnectionError(err error) bool {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		switch closeErr.Code {
+		case websocket.CloseAbnormalClosure, websocket.CloseGoingAway, websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, ErrReadTimeout) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// reconnectDelay returns the backoff before the given reconnect attempt (1-based),
+// doubling from base and capped at max
+func reconnectDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// cancellationForReceiveError builds the cancellation details for an error reading from the service.
+// When the service closed the WebSocket, the close code is mapped to an error code and included in the details.
+func cancellationForReceiveError(err error) *CancellationDetails {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return &CancellationDetails{
+			Reason:       CancellationReasonError,
+			ErrorCode:    CancellationErrorConnectionFailure,
+			ErrorDetails: fmt.Sprintf("Error in continuous recognition: %v", err),
+		}
+	}
+
+	var code CancellationErrorCode
+	switch closeErr.Code {
+	case websocket.CloseInternalServerErr:
+		code = CancellationErrorServiceError
+	case websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+		code = CancellationErrorServiceUnavailable
+	case websocket.CloseProtocolError, websocket.CloseUnsupportedData, websocket.CloseInvalidFramePayloadData, websocket.CloseMessageTooBig:
+		code = CancellationErrorBadRequest
+	case websocket.ClosePolicyViolation:
+		code = CancellationErrorForbidden
+	default:
+		// 1000, 1001, 1006 など
+		code = CancellationErrorConnectionFailure
+	}
+
+	return &CancellationDetails{
+		Reason:       CancellationReasonError,
+		ErrorCode:    code,
+		ErrorDetails: fmt.Sprintf("WebSocket closed by service: code=%d, reason=%q", closeErr.Code, closeErr.Text),
+	}
+}
+
+// audioLevel は16ビットPCMの音声バッファから平均音声レベル（0-100の範囲）を計算します。
+// ログは出力しないため、チャンクごとに呼び出しても問題ありません
+func audioLevel(buffer []byte) int {
+	samples := len(buffer) / 2
+	if samples == 0 {
 		return 0
 	}
 
-	// 音声データをint16に変換
 	var sum int64
-	for i := 0; i < n-1; i += 2 {
-		// リトルエンディアンでint16に変換
-		value := int16(buffer[i]) | (int16(buffer[i+1]) << 8)
+	for i := 0; i+1 < len(buffer); i += 2 {
+		// リトルエンディアンでint16に変換し、絶対値を取る
+		value := int64(int16(buffer[i]) | (int16(buffer[i+1]) << 8))
 		if value < 0 {
-			value = -value // 絶対値を取る
+			value = -value
 		}
-		sum += int64(value)
+		sum += value
 	}
 
-	// 平均振幅を計算し、0-100の範囲にスケーリング
-	avgAmplitude := sum / int64(n/2)
 	// int16の最大値は32767なので、その値で割って0-100のスケールに変換
-	level := int((avgAmplitude * 100) / 32767)
-
-	// バッファ内の最初の数バイトをデバッグのために表示
-	var bytesStr string
-	maxBytes := 16
-	if n < maxBytes {
-		maxBytes = n
+	level := int(sum / int64(samples) * 100 / 32767)
+	if level > 100 {
+		level = 100
 	}
-	for i := 0; i < maxBytes; i++ {
-		bytesStr += fmt.Sprintf("%02x ", buffer[i])
-	}
-	log.Printf("DEBUG: Audio buffer head bytes: %s, buffer size: %d, average amplitude: %d, level: %d/100",
-		bytesStr, n, avgAmplitude, level)
-
 	return level
 }
 
+// IsSupportedSourceLanguage reports whether lang can be used as the speech recognition language
+func IsSupportedSourceLanguage(lang string) bool {
+	return normalizeLanguageCode(lang, true) != ""
+}
+
 // normalizeLanguageCode normalizes language codes to BCP-47 format or simple language code
 func normalizeLanguageCode(lang string, isSourceLanguage bool) string {
 	// Remove spaces and convert to lowercase
@@ -1139,26 +3318,6 @@ func normalizeLanguageCode(lang string, isSourceLanguage bool) string {
 		return ""
 	}
 
-	// Language code mapping
-	langMap := map[string]string{
-		"ja": "ja-JP",
-		"en": "en-US",
-		"zh": "zh-CN",
-		"ko": "ko-KR",
-		"es": "es-ES",
-		"fr": "fr-FR",
-		"de": "de-DE",
-		"it": "it-IT",
-		"pt": "pt-BR",
-		"ru": "ru-RU",
-		"ar": "ar-SA",
-		"hi": "hi-IN",
-		"th": "th-TH",
-		"vi": "vi-VN",
-		"id": "id-ID",
-		"ms": "ms-MY",
-	}
-
 	if isSourceLanguage {
 		// Source language requires full BCP-47 format
 		if strings.Contains(lang, "-") {
@@ -1174,7 +3333,7 @@ func normalizeLanguageCode(lang string, isSourceLanguage bool) string {
 		}
 
 		// Use mapping to convert to full format
-		if normalized, ok := langMap[strings.ToLower(lang)]; ok {
+		if normalized, ok := lookupLocale(strings.ToLower(lang)); ok {
 			return normalized
 		}
 	} else {
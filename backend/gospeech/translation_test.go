@@ -0,0 +1,4024 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestBuildConnectionRequestAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		token    string
+		env      string
+		wantKey  string
+		wantAuth string
+		wantErr  bool
+	}{
+		{name: "subscription key", key: "config-key", wantKey: "config-key"},
+		{name: "config key wins over env", key: "config-key", env: "env-key", wantKey: "config-key"},
+		{name: "env fallback when config key is empty", env: "env-key", wantKey: "env-key"},
+		{name: "authorization token", token: "token", wantAuth: "Bearer token"},
+		{name: "token wins over key", key: "config-key", token: "token", env: "env-key", wantAuth: "Bearer token"},
+		{name: "neither", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(subscriptionKeyEnv, tt.env)
+
+			config := NewSpeechConfig()
+			config.SetProperty(SpeechServiceConnectionRegion, "japaneast")
+			config.SetProperty(SpeechServiceConnectionKey, tt.key)
+			config.SetAuthorizationToken(tt.token)
+
+			_, header, err := buildConnectionRequest(config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("buildConnectionRequest succeeded without credentials, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildConnectionRequest: %v", err)
+			}
+			if got := header.Get("Ocp-Apim-Subscription-Key"); got != tt.wantKey {
+				t.Errorf("Ocp-Apim-Subscription-Key = %q, want %q", got, tt.wantKey)
+			}
+			if got := header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+			}
+			if header.Get("X-ConnectionId") == "" {
+				t.Error("X-ConnectionId header is not set")
+			}
+		})
+	}
+}
+
+func TestBuildConnectionRequestFromSubscriptionUsesConfigKey(t *testing.T) {
+	t.Setenv(subscriptionKeyEnv, "env-key")
+
+	config, err := SpeechTranslationConfigFromSubscription("config-key", "japaneast")
+	if err != nil {
+		t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+	}
+	_, header, err := buildConnectionRequest(config.SpeechConfig)
+	if err != nil {
+		t.Fatalf("buildConnectionRequest: %v", err)
+	}
+	if got := header.Get("Ocp-Apim-Subscription-Key"); got != "config-key" {
+		t.Errorf("Ocp-Apim-Subscription-Key = %q, want %q", got, "config-key")
+	}
+}
+
+func TestBuildConnectionRequestURL(t *testing.T) {
+	const path = "/speech/universal/v2"
+	tests := []struct {
+		name     string
+		region   string
+		endpoint string
+		host     string
+		detailed bool
+		want     string
+		wantErr  string
+	}{
+		{name: "region", region: "japaneast", want: "wss://japaneast.stt.speech.microsoft.com" + path},
+		{name: "region with detailed output", region: "japaneast", detailed: true, want: "wss://japaneast.stt.speech.microsoft.com" + path + "?format=detailed"},
+		{name: "wss endpoint is used as is", endpoint: "wss://example.com/custom", want: "wss://example.com/custom"},
+		{name: "https endpoint becomes wss", endpoint: "https://example.com/custom", want: "wss://example.com/custom"},
+		{name: "http endpoint becomes ws", endpoint: "http://localhost:5000" + path, want: "ws://localhost:5000" + path},
+		{name: "endpoint without scheme becomes wss", endpoint: "example.com/custom", want: "wss://example.com/custom"},
+		{name: "endpoint keeps its query", endpoint: "wss://example.com/custom?cid=1", detailed: true, want: "wss://example.com/custom?cid=1&format=detailed"},
+		{name: "endpoint wins over host and region", region: "japaneast", endpoint: "wss://example.com/custom", host: "other.example.com", want: "wss://example.com/custom"},
+		{name: "host gets the path", host: "example.com", want: "wss://example.com" + path},
+		{name: "host with trailing slash", host: "https://example.com/", want: "wss://example.com" + path},
+		{name: "http host becomes ws", host: "http://localhost:5000", want: "ws://localhost:5000" + path},
+		{name: "host wins over region", region: "japaneast", host: "example.com", want: "wss://example.com" + path},
+		{name: "unsupported endpoint scheme", endpoint: "ftp://example.com", wantErr: "invalid endpoint"},
+		{name: "unsupported host scheme", host: "ftp://example.com", wantErr: "invalid host"},
+		{name: "nothing configured", wantErr: "no region, endpoint or host is configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSpeechConfig()
+			config.SetProperty(SpeechServiceConnectionKey, "key")
+			config.SetProperty(SpeechServiceConnectionRegion, tt.region)
+			config.SetProperty(SpeechServiceConnectionEndpoint, tt.endpoint)
+			config.SetProperty(SpeechServiceConnectionHost, tt.host)
+			if tt.detailed {
+				config.SetOutputFormat(OutputFormatDetailed)
+			}
+
+			got, header, err := buildConnectionRequest(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildConnectionRequest error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildConnectionRequest: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("URL = %q, want %q", got, tt.want)
+			}
+			if header.Get("X-ConnectionId") == "" {
+				t.Error("X-ConnectionId header is not set")
+			}
+		})
+	}
+}
+
+func TestConnectToConfiguredEndpointOrHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		newConfig func(serverURL string) (*SpeechTranslationConfig, error)
+		wantPath  string
+	}{
+		{
+			name: "endpoint with an http scheme",
+			newConfig: func(serverURL string) (*SpeechTranslationConfig, error) {
+				return SpeechTranslationConfigFromEndpoint(serverURL+"/custom/path", "test-key")
+			},
+			wantPath: "/custom/path",
+		},
+		{
+			name: "host gets the service path",
+			newConfig: func(serverURL string) (*SpeechTranslationConfig, error) {
+				return SpeechTranslationConfigFromHost(serverURL, "test-key")
+			},
+			wantPath: "/speech/universal/v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			config, err := tt.newConfig(service.server.URL)
+			if err != nil {
+				t.Fatalf("creating the config: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			audioConfig, err := NewAudioConfigFromPushStream(NewPushAudioInputStream(GetDefaultInputFormat()))
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, audioConfig)
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			defer recognizer.Close()
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+
+			fc := service.waitForConn(t)
+			if path, _, _ := strings.Cut(fc.requestURI, "?"); path != tt.wantPath {
+				t.Errorf("connected to %q, want path %q", fc.requestURI, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestNormalizeWebSocketURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "wss://example.com", want: "wss://example.com"},
+		{raw: "WSS://example.com/path", want: "wss://example.com/path"},
+		{raw: "https://example.com/path?x=1", want: "wss://example.com/path?x=1"},
+		{raw: "ws://localhost:5000", want: "ws://localhost:5000"},
+		{raw: "http://localhost:5000", want: "ws://localhost:5000"},
+		{raw: "  example.com  ", want: "wss://example.com"},
+		{raw: "ftp://example.com", wantErr: true},
+		{raw: "https://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := normalizeWebSocketURL(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("normalizeWebSocketURL(%q) = %q, want an error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeWebSocketURL(%q): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeWebSocketURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancellationForReceiveError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want CancellationErrorCode
+	}{
+		{name: "not a close error", err: errors.New("read failed"), want: CancellationErrorConnectionFailure},
+		{name: "normal closure", err: &websocket.CloseError{Code: websocket.CloseNormalClosure}, want: CancellationErrorConnectionFailure},
+		{name: "going away", err: &websocket.CloseError{Code: websocket.CloseGoingAway}, want: CancellationErrorConnectionFailure},
+		{name: "abnormal closure", err: &websocket.CloseError{Code: websocket.CloseAbnormalClosure}, want: CancellationErrorConnectionFailure},
+		{name: "internal server error", err: &websocket.CloseError{Code: websocket.CloseInternalServerErr}, want: CancellationErrorServiceError},
+		{name: "service restart", err: &websocket.CloseError{Code: websocket.CloseServiceRestart}, want: CancellationErrorServiceUnavailable},
+		{name: "try again later", err: &websocket.CloseError{Code: websocket.CloseTryAgainLater}, want: CancellationErrorServiceUnavailable},
+		{name: "protocol error", err: &websocket.CloseError{Code: websocket.CloseProtocolError}, want: CancellationErrorBadRequest},
+		{name: "unsupported data", err: &websocket.CloseError{Code: websocket.CloseUnsupportedData}, want: CancellationErrorBadRequest},
+		{name: "invalid payload", err: &websocket.CloseError{Code: websocket.CloseInvalidFramePayloadData}, want: CancellationErrorBadRequest},
+		{name: "message too big", err: &websocket.CloseError{Code: websocket.CloseMessageTooBig}, want: CancellationErrorBadRequest},
+		{name: "policy violation", err: &websocket.CloseError{Code: websocket.ClosePolicyViolation}, want: CancellationErrorForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := cancellationForReceiveError(tt.err)
+			if details.Reason != CancellationReasonError {
+				t.Errorf("Reason = %v, want %v", details.Reason, CancellationReasonError)
+			}
+			if details.ErrorCode != tt.want {
+				t.Errorf("ErrorCode = %v, want %v", details.ErrorCode, tt.want)
+			}
+			if details.ErrorDetails == "" {
+				t.Error("ErrorDetails is empty")
+			}
+		})
+	}
+}
+
+func TestServiceCloseCodeCancellation(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		want        CancellationErrorCode
+		wantDetails string
+	}{
+		{name: "internal server error", code: websocket.CloseInternalServerErr, want: CancellationErrorServiceError, wantDetails: "code=1011"},
+		{name: "try again later", code: websocket.CloseTryAgainLater, want: CancellationErrorServiceUnavailable, wantDetails: "code=1013"},
+		{name: "policy violation", code: websocket.ClosePolicyViolation, want: CancellationErrorForbidden, wantDetails: "code=1008"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			// Without reconnection, recoverable codes such as 1013 also cancel immediately
+			if err := recognizer.config.SetReconnectPolicy(0, 0, 0); err != nil {
+				t.Fatalf("SetReconnectPolicy: %v", err)
+			}
+			canceled := make(chan *CancellationDetails, 1)
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			fc := service.waitForConn(t)
+			fc.writeMu.Lock()
+			err := fc.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(tt.code, "closing"))
+			fc.writeMu.Unlock()
+			if err != nil {
+				t.Fatalf("failed to send the close frame: %v", err)
+			}
+
+			select {
+			case details := <-canceled:
+				if details.ErrorCode != tt.want {
+					t.Errorf("ErrorCode = %v, want %v", details.ErrorCode, tt.want)
+				}
+				if !strings.Contains(details.ErrorDetails, tt.wantDetails) {
+					t.Errorf("ErrorDetails = %q, want it to contain %q", details.ErrorDetails, tt.wantDetails)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the recognizer to cancel")
+			}
+		})
+	}
+}
+
+func TestStopEndsResultReceiver(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(recognizer *TranslationRecognizer, cancel context.CancelFunc)
+	}{
+		{name: "stop request", stop: func(recognizer *TranslationRecognizer, cancel context.CancelFunc) {
+			recognizer.StopContinuousRecognition()
+		}},
+		{name: "context canceled", stop: func(recognizer *TranslationRecognizer, cancel context.CancelFunc) {
+			cancel()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			var canceled atomic.Int32
+			recognizer.Canceled().Connect(func(interface{}) { canceled.Add(1) })
+			stopped := make(chan struct{}, 1)
+			recognizer.SessionStopped().Connect(func(interface{}) { stopped <- struct{}{} })
+
+			before := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := recognizer.StartContinuousRecognitionAsync(ctx); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			fc := service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+			waitFor(t, "audio to reach the service", func() bool { return service.audioBytes.Load() == 3200 })
+
+			tt.stop(recognizer, cancel)
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("SessionStopped was not raised")
+			}
+			select {
+			case <-fc.closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the service connection was not closed")
+			}
+			// The worker and the result receiver must both be gone
+			waitFor(t, "the recognition goroutines to exit", func() bool { return runtime.NumGoroutine() <= before })
+
+			if got := canceled.Load(); got != 0 {
+				t.Errorf("Canceled raised %d times on a clean stop, want 0", got)
+			}
+		})
+	}
+}
+
+func TestParseTranslations(t *testing.T) {
+	tests := []struct {
+		name             string
+		outputFormat     OutputFormat
+		body             string
+		wantTranslations map[string]string
+		wantDetails      map[string]*TranslationDetail
+	}{
+		{
+			name:             "simple format",
+			outputFormat:     OutputFormatSimple,
+			body:             `{"Translations": {"de": "Hallo", "en": "Hello"}}`,
+			wantTranslations: map[string]string{"de": "Hallo", "en": "Hello"},
+		},
+		{
+			name:             "detailed payload in simple mode keeps only the text",
+			outputFormat:     OutputFormatSimple,
+			body:             `{"Translations": {"de": {"Text": "Hallo", "Offset": 10000000, "Duration": 5000000}}}`,
+			wantTranslations: map[string]string{"de": "Hallo"},
+		},
+		{
+			name:             "detailed payload with timing",
+			outputFormat:     OutputFormatDetailed,
+			body:             `{"Translations": {"de": {"Text": "Hallo", "Offset": 10000000, "Duration": 5000000}, "en": "Hello"}}`,
+			wantTranslations: map[string]string{"de": "Hallo", "en": "Hello"},
+			wantDetails: map[string]*TranslationDetail{
+				"de": {Text: "Hallo", Offset: int64(time.Second), Duration: 500 * time.Millisecond},
+			},
+		},
+		{
+			name:             "detailed payload without timing",
+			outputFormat:     OutputFormatDetailed,
+			body:             `{"Translations": {"de": {"Text": "Hallo"}}}`,
+			wantTranslations: map[string]string{"de": "Hallo"},
+		},
+		{
+			name:             "translation.phrase payload",
+			outputFormat:     OutputFormatDetailed,
+			body:             `{"Translation": {"Translations": [{"Language": "de", "Text": "Hallo", "Offset": 20000000, "Duration": 10000000}, {"Language": "fr", "Text": "Bonjour"}]}}`,
+			wantTranslations: map[string]string{"de": "Hallo", "fr": "Bonjour"},
+			wantDetails: map[string]*TranslationDetail{
+				"de": {Text: "Hallo", Offset: int64(2 * time.Second), Duration: time.Second},
+			},
+		},
+		{
+			name:             "entries without text are ignored",
+			outputFormat:     OutputFormatDetailed,
+			body:             `{"Translations": {"de": {"Offset": 1}, "en": 42}}`,
+			wantTranslations: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.body), &response); err != nil {
+				t.Fatal(err)
+			}
+			sc := &speechServiceConnection{outputFormat: tt.outputFormat}
+			result := &TranslationRecognitionResult{Translations: make(map[string]string)}
+
+			sc.parseTranslations(response, result)
+
+			if !reflect.DeepEqual(result.Translations, tt.wantTranslations) {
+				t.Errorf("Translations = %v, want %v", result.Translations, tt.wantTranslations)
+			}
+			if !reflect.DeepEqual(result.TranslationDetails, tt.wantDetails) {
+				t.Errorf("TranslationDetails = %v, want %v", result.TranslationDetails, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestKeepAliveDuringAudioGap(t *testing.T) {
+	tests := []struct {
+		name           string
+		interval       time.Duration
+		wantKeepAlives bool
+	}{
+		{name: "disabled", interval: 0, wantKeepAlives: false},
+		{name: "enabled", interval: 100 * time.Millisecond, wantKeepAlives: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if err := recognizer.SetKeepAliveInterval(tt.interval); err != nil {
+				t.Fatalf("SetKeepAliveInterval: %v", err)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			// No audio is written, so anything binary the service receives is a keepalive
+			time.Sleep(500 * time.Millisecond)
+
+			var keepAlives int
+			for _, m := range fc.received() {
+				if m.messageType != websocket.BinaryMessage {
+					continue
+				}
+				keepAlives++
+				if len(m.data) != 3200 || !bytes.Equal(m.data, make([]byte, len(m.data))) {
+					t.Errorf("keepalive frame of %d bytes is not 100ms of silence", len(m.data))
+				}
+			}
+			if got := keepAlives > 0; got != tt.wantKeepAlives {
+				t.Errorf("received %d keepalive frames, want keepalives %v", keepAlives, tt.wantKeepAlives)
+			}
+		})
+	}
+}
+
+func TestSpeechConfigResend(t *testing.T) {
+	tests := []struct {
+		name        string
+		resend      bool
+		chunks      int
+		wantConfigs int
+	}{
+		{name: "sent once per connection", chunks: 3, wantConfigs: 1},
+		{name: "resent before every chunk", resend: true, chunks: 3, wantConfigs: 3},
+		{name: "keepalives alone send it once", chunks: 0, wantConfigs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.SetSpeechConfigResend(tt.resend)
+			if got := recognizer.GetSpeechConfigResend(); got != tt.resend {
+				t.Errorf("GetSpeechConfigResend() = %v, want %v", got, tt.resend)
+			}
+			if err := recognizer.SetKeepAliveInterval(100 * time.Millisecond); err != nil {
+				t.Fatalf("SetKeepAliveInterval: %v", err)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			for i := 0; i < tt.chunks; i++ {
+				stream.Write(bytes.Repeat([]byte{1}, 3200))
+				want := int64(3200 * (i + 1))
+				waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= want })
+			}
+			// The gap that follows is filled with keepalive frames only
+			audioBefore := service.audioBytes.Load()
+			time.Sleep(400 * time.Millisecond)
+			if service.audioBytes.Load() == audioBefore {
+				t.Error("no keepalive frames were sent during the gap")
+			}
+
+			configs := 0
+			for _, path := range fc.textPaths() {
+				if path == "speech.config" {
+					configs++
+				}
+			}
+			if configs != tt.wantConfigs {
+				t.Errorf("sent speech.config %d times, want %d", configs, tt.wantConfigs)
+			}
+		})
+	}
+}
+
+func TestSetKeepAliveIntervalRejectsNegative(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, _ := newTestRecognizer(t, service)
+	if err := recognizer.SetKeepAliveInterval(-time.Second); err == nil {
+		t.Error("SetKeepAliveInterval(-1s) succeeded, want an error")
+	}
+}
+
+func TestWaitForFirstResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		raise   []*TranslationRecognitionResult
+		cancel  bool
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "returns the first result",
+			timeout: 5 * time.Second,
+			raise:   []*TranslationRecognitionResult{{Text: "first", Reason: ResultReasonTranslatedSpeech}, {Text: "second", Reason: ResultReasonTranslatedSpeech}},
+			want:    "first",
+		},
+		{
+			name:    "times out without results",
+			timeout: 50 * time.Millisecond,
+			wantErr: ErrFirstResultTimeout,
+		},
+		{
+			name:    "canceled results do not count",
+			timeout: 50 * time.Millisecond,
+			raise:   []*TranslationRecognitionResult{{Reason: ResultReasonCanceled}},
+			wantErr: ErrFirstResultTimeout,
+		},
+		{
+			name:    "context cancellation",
+			timeout: 0,
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("key", "japaneast")
+			if err != nil {
+				t.Fatal(err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				for _, result := range tt.raise {
+					recognizer.raiseRecognized(result)
+				}
+				if tt.cancel {
+					cancel()
+				}
+			}()
+
+			start := time.Now()
+			result, err := recognizer.WaitForFirstResult(ctx, tt.timeout)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("WaitForFirstResult took %v", elapsed)
+			}
+			// The subscription made for the wait is removed whatever the outcome
+			recognizer.recognized.mu.RLock()
+			subscribers := len(recognizer.recognized.callbacks)
+			recognizer.recognized.mu.RUnlock()
+			if subscribers != 0 {
+				t.Errorf("%d Recognized subscribers left after WaitForFirstResult returned, want 0", subscribers)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WaitForFirstResult error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WaitForFirstResult: %v", err)
+			}
+			if result.Text != tt.want {
+				t.Errorf("result text = %q, want %q", result.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeSourceInTranslations(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeSource bool
+		translations  map[string]string
+		want          map[string]string
+	}{
+		{
+			name:         "disabled by default",
+			translations: map[string]string{"en": "Hello"},
+			want:         map[string]string{"en": "Hello"},
+		},
+		{
+			name:          "source text is added under the source language",
+			includeSource: true,
+			translations:  map[string]string{"en": "Hello"},
+			want:          map[string]string{"en": "Hello", "ja": "こんにちは"},
+		},
+		{
+			name:          "a service translation into the source language is kept",
+			includeSource: true,
+			translations:  map[string]string{"en": "Hello", "ja": "こんにちは！"},
+			want:          map[string]string{"en": "Hello", "ja": "こんにちは！"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if tt.includeSource {
+				recognizer.config.SetIncludeSourceInTranslations(true)
+			}
+			results := make(chan *TranslationRecognitionResult, 1)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				results <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			if err := service.waitForConn(t).sendFinalPhrase("こんにちは", tt.translations); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case result := <-results:
+				if !reflect.DeepEqual(result.Translations, tt.want) {
+					t.Errorf("Translations = %v, want %v", result.Translations, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no result was recognized")
+			}
+		})
+	}
+}
+
+func TestSampleRateMismatchWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		check       bool
+		wantWarning bool
+	}{
+		{name: "check disabled", check: false, wantWarning: false},
+		{name: "check enabled", check: true, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.SetSampleRateCheck(tt.check)
+			warnings := make(chan *WarningEventArgs, 1)
+			recognizer.Warning().Connect(func(eventArgs interface{}) {
+				warnings <- eventArgs.(*WarningEventArgs)
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			service.waitForConn(t)
+
+			// 48kHz 16-bit mono data fed in real time to a stream declared as 16kHz
+			chunk := make([]byte, 9600)
+			for i := 0; i < 24; i++ {
+				stream.Write(chunk)
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			select {
+			case warning := <-warnings:
+				if !tt.wantWarning {
+					t.Fatalf("unexpected warning: %s", warning.Message)
+				}
+				if warning.Code != WarningSampleRateMismatch {
+					t.Errorf("warning code = %v, want %v", warning.Code, WarningSampleRateMismatch)
+				}
+			case <-time.After(500 * time.Millisecond):
+				if tt.wantWarning {
+					t.Fatal("no sample rate warning was raised")
+				}
+			}
+		})
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeout       time.Duration
+		wantHandshake bool
+	}{
+		{name: "default timeout sends the close frame", timeout: DefaultCloseHandshakeTimeout, wantHandshake: true},
+		{name: "zero timeout closes immediately", timeout: 0, wantHandshake: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			if err := recognizer.SetCloseHandshakeTimeout(tt.timeout); err != nil {
+				t.Fatalf("SetCloseHandshakeTimeout: %v", err)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			fc := service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+			waitFor(t, "audio to reach the service", func() bool { return service.audioBytes.Load() == 3200 })
+
+			recognizer.StopContinuousRecognition()
+			select {
+			case <-fc.closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the service connection was not closed")
+			}
+
+			// Stopping drains the remaining results, so the end-of-audio marker is sent either way
+			messages := fc.received()
+			last := messages[len(messages)-1]
+			if last.messageType != websocket.BinaryMessage || len(last.data) != 0 {
+				t.Errorf("last message is not the end-of-audio marker: %q", last.data)
+			}
+			header := messages[len(messages)-2]
+			if header.messageType != websocket.TextMessage || !bytes.HasPrefix(header.data, []byte("Path: audio\r\n")) {
+				t.Errorf("end-of-audio marker is not preceded by an audio header: %q", header.data)
+			}
+			gotCloseFrame := websocket.IsCloseError(fc.readErr, websocket.CloseNormalClosure)
+			if gotCloseFrame != tt.wantHandshake {
+				t.Errorf("connection ended with %v, want a normal close frame: %v", fc.readErr, tt.wantHandshake)
+			}
+		})
+	}
+}
+
+func TestSilenceFinalization(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		finalBefore bool // the service sends its final before the silence timeout
+		finalAfter  bool // the service sends its final after the silence timeout
+		want        []string
+	}{
+		{name: "partial followed by silence is finalized once", timeout: 100 * time.Millisecond, want: []string{"こんにち"}},
+		{name: "late service final is not duplicated", timeout: 100 * time.Millisecond, finalAfter: true, want: []string{"こんにち"}},
+		{name: "service final before the timeout wins", timeout: 100 * time.Millisecond, finalBefore: true, want: []string{"こんにちは"}},
+		{name: "disabled by default", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if err := recognizer.SetSilenceFinalizeTimeout(tt.timeout); err != nil {
+				t.Fatalf("SetSilenceFinalizeTimeout: %v", err)
+			}
+			var mu sync.Mutex
+			var got []string
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, eventArgs.(*TranslationRecognitionEventArgs).Result.Text)
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			conn := service.waitForConn(t)
+			if err := conn.send("speech.hypothesis", `{"Text":"こんにち"}`); err != nil {
+				t.Fatal(err)
+			}
+			if tt.finalBefore {
+				if err := conn.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(300 * time.Millisecond)
+			if tt.finalAfter {
+				if err := conn.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(300 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recognized %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSilenceFinalizeTimeoutRejectsNegative(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, _ := newTestRecognizer(t, service)
+	if err := recognizer.SetSilenceFinalizeTimeout(-time.Second); err == nil {
+		t.Error("SetSilenceFinalizeTimeout(-1s) succeeded, want an error")
+	}
+}
+
+func TestNewTranslationRecognizerTargetLanguages(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		wantErr string // empty means success
+	}{
+		{name: "supported targets", targets: []string{"en", "de"}},
+		{name: "regional and mixed-case codes", targets: []string{"en-US", "ZH"}},
+		{name: "languages from the embedded list", targets: []string{"it", "pt", "ru", "ar", "hi", "nl"}},
+		{name: "one valid and one invalid target", targets: []string{"en", "xx"}, wantErr: "unsupported target languages: xx"},
+		{name: "all invalid targets are listed", targets: []string{"xx", "en", "klingon"}, wantErr: "unsupported target languages: xx, klingon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			for _, target := range tt.targets {
+				config.AddTargetLanguage(target)
+			}
+
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewTranslationRecognizer: %v", err)
+				}
+				recognizer.Close()
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("NewTranslationRecognizer error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSessionContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      map[string]string
+		wantHeaders map[string]string
+		wantContext map[string]string
+	}{
+		{
+			name:        "tenant and correlation ids",
+			values:      map[string]string{"X-Tenant-Id": "tenant-1", "X-Correlation-Id": "corr-1"},
+			wantHeaders: map[string]string{"X-Tenant-Id": "tenant-1", "X-Correlation-Id": "corr-1"},
+			wantContext: map[string]string{"X-Tenant-Id": "tenant-1", "X-Correlation-Id": "corr-1"},
+		},
+		{
+			name:        "connection id replaces the generated one",
+			values:      map[string]string{"X-ConnectionId": "session-42"},
+			wantHeaders: map[string]string{"X-ConnectionId": "session-42"},
+			wantContext: map[string]string{"X-ConnectionId": "session-42"},
+		},
+		{
+			name:        "system block is not overwritten",
+			values:      map[string]string{"system": "spoofed", "tenant": "tenant-1"},
+			wantContext: map[string]string{"tenant": "tenant-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.SetSessionContext(tt.values)
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			for name, want := range tt.wantHeaders {
+				if got := fc.header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+
+			// The config message is sent with the first audio
+			if _, err := stream.Write(make([]byte, 3200)); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			var body string
+			waitFor(t, "the speech.config message", func() bool {
+				var ok bool
+				body, ok = fc.textBody("speech.config")
+				return ok
+			})
+			var config struct {
+				Context map[string]interface{} `json:"context"`
+			}
+			if err := json.Unmarshal([]byte(body), &config); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			for key, want := range tt.wantContext {
+				if got := config.Context[key]; got != want {
+					t.Errorf("context[%q] = %v, want %q", key, got, want)
+				}
+			}
+			if _, ok := config.Context["system"].(map[string]interface{}); !ok {
+				t.Errorf("context.system = %v, want the SDK system block", config.Context["system"])
+			}
+		})
+	}
+}
+
+func TestUtteranceEvent(t *testing.T) {
+	type frame struct {
+		text         string
+		translations map[string]string
+	}
+	type utterance struct {
+		text         string
+		translations map[string]string
+	}
+	tests := []struct {
+		name   string
+		frames []frame
+		want   []utterance
+	}{
+		{
+			name: "translations in separate frames are aggregated",
+			frames: []frame{
+				{"こんにちは", map[string]string{"en": "Hello"}},
+				{"こんにちは", map[string]string{"de": "Hallo"}},
+			},
+			want: []utterance{{"こんにちは", map[string]string{"en": "Hello", "de": "Hallo"}}},
+		},
+		{
+			name:   "all translations in one frame",
+			frames: []frame{{"こんにちは", map[string]string{"en": "Hello", "de": "Hallo"}}},
+			want:   []utterance{{"こんにちは", map[string]string{"en": "Hello", "de": "Hallo"}}},
+		},
+		{
+			name:   "missing translation fires after the grace period",
+			frames: []frame{{"こんにちは", map[string]string{"en": "Hello"}}},
+			want:   []utterance{{"こんにちは", map[string]string{"en": "Hello"}}},
+		},
+		{
+			name: "a new utterance flushes the incomplete one",
+			frames: []frame{
+				{"こんにちは", map[string]string{"en": "Hello"}},
+				{"さようなら", map[string]string{"en": "Goodbye", "de": "Tschüss"}},
+			},
+			want: []utterance{
+				{"こんにちは", map[string]string{"en": "Hello"}},
+				{"さようなら", map[string]string{"en": "Goodbye", "de": "Tschüss"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizer.AddTargetLanguage("de")
+			if err := recognizer.SetUtteranceGracePeriod(200 * time.Millisecond); err != nil {
+				t.Fatalf("SetUtteranceGracePeriod: %v", err)
+			}
+			var mu sync.Mutex
+			var got []utterance
+			recognizer.Utterance().Connect(func(eventArgs interface{}) {
+				result := eventArgs.(*TranslationRecognitionEventArgs).Result
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, utterance{result.Text, result.Translations})
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			fc := service.waitForConn(t)
+			for _, f := range tt.frames {
+				if err := fc.sendFinalPhrase(f.text, f.translations); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Long enough for an incomplete utterance to expire
+			time.Sleep(500 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("utterances = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyAudioSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		behavior   EmptyAudioBehavior
+		continuous bool
+		wantEvents []string
+	}{
+		{name: "once with an error", behavior: EmptyAudioError, wantEvents: []string{"canceled"}},
+		{name: "once with an empty result", behavior: EmptyAudioEmptyResult, wantEvents: []string{"recognized", "stopped"}},
+		{name: "continuous with an error", behavior: EmptyAudioError, continuous: true, wantEvents: []string{"canceled"}},
+		{name: "continuous with an empty result", behavior: EmptyAudioEmptyResult, continuous: true, wantEvents: []string{"recognized", "stopped"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.SetEmptyAudioBehavior(tt.behavior)
+			stream.Close()
+
+			events := make(chan string, 10)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				if reason := eventArgs.(*TranslationRecognitionEventArgs).Result.Reason; reason != ResultReasonNoMatch {
+					t.Errorf("recognized reason = %v, want NoMatch", reason)
+				}
+				events <- "recognized"
+			})
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				details := eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+				if details.Reason != CancellationReasonEndOfStream || details.ErrorDetails != ErrNoAudio.Error() {
+					t.Errorf("cancellation = %+v, want end of stream with %q", details, ErrNoAudio)
+				}
+				events <- "canceled"
+			})
+			recognizer.SessionStopped().Connect(func(interface{}) { events <- "stopped" })
+
+			if tt.continuous {
+				if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+					t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+				}
+				defer recognizer.StopContinuousRecognition()
+			} else {
+				result, err := recognizer.RecognizeOnce(context.Background())
+				if tt.behavior == EmptyAudioError {
+					if !errors.Is(err, ErrNoAudio) {
+						t.Errorf("RecognizeOnce error = %v, want ErrNoAudio", err)
+					}
+				} else if err != nil || result == nil || result.Reason != ResultReasonNoMatch {
+					t.Errorf("RecognizeOnce = (%+v, %v), want an empty NoMatch result", result, err)
+				}
+			}
+
+			for _, want := range tt.wantEvents {
+				select {
+				case got := <-events:
+					if got != want {
+						t.Errorf("event = %s, want %s", got, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for the %s event", want)
+				}
+			}
+			select {
+			case got := <-events:
+				t.Errorf("unexpected %s event", got)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestRecognizeOnceAsync(t *testing.T) {
+	tests := []struct {
+		name         string
+		audio        bool // write audio before recognizing; otherwise the stream is closed empty
+		respond      bool
+		cancelBefore bool
+		cancelAfter  time.Duration
+		wantText     string
+		wantErr      error
+	}{
+		{name: "success", audio: true, respond: true, wantText: "Hello"},
+		{name: "error", wantErr: ErrNoAudio},
+		{name: "canceled before starting", audio: true, respond: true, cancelBefore: true, wantErr: context.Canceled},
+		{name: "canceled while waiting for the result", audio: true, cancelAfter: 100 * time.Millisecond, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			if tt.respond {
+				service.onMessage = func(conn *fakeServiceConn, messageType int, message []byte) {
+					if messageType == websocket.BinaryMessage && len(message) > 0 {
+						conn.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"})
+					}
+				}
+			}
+			recognizer, stream := newTestRecognizer(t, service)
+			if tt.audio {
+				stream.Write(make([]byte, 3200))
+			} else {
+				stream.Close()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelBefore {
+				cancel()
+			}
+			if tt.cancelAfter > 0 {
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+
+			outcomes := recognizer.RecognizeOnceAsync(ctx)
+			var got []RecognizeOnceOutcome
+			timeout := time.After(5 * time.Second)
+			for done := false; !done; {
+				select {
+				case outcome, ok := <-outcomes:
+					if ok {
+						got = append(got, outcome)
+					}
+					done = !ok
+				case <-timeout:
+					t.Fatal("timed out waiting for the channel to be closed")
+				}
+			}
+
+			if len(got) != 1 {
+				t.Fatalf("received %d outcomes, want exactly one", len(got))
+			}
+			outcome := got[0]
+			if tt.wantErr != nil {
+				if !errors.Is(outcome.Err, tt.wantErr) || outcome.Result != nil {
+					t.Errorf("outcome = (%+v, %v), want error %v", outcome.Result, outcome.Err, tt.wantErr)
+				}
+				return
+			}
+			if outcome.Err != nil {
+				t.Fatalf("outcome error = %v", outcome.Err)
+			}
+			if got := outcome.Result.Translations["en"]; got != tt.wantText {
+				t.Errorf("translation = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCustomResultParser(t *testing.T) {
+	// parser handles the synthetic custom.caption frame, whose body is plain text, and ignores custom.noise
+	parser := func(path string, headers, body []byte) (*TranslationRecognitionResult, error) {
+		switch path {
+		case "custom.caption":
+			return &TranslationRecognitionResult{Reason: ResultReasonTranslatedSpeech, Text: string(body), Translations: map[string]string{"en": "caption"}}, nil
+		case "custom.noise":
+			return nil, nil
+		}
+		return nil, ErrUseDefaultParser
+	}
+	tests := []struct {
+		name     string
+		parser   ResultParser
+		path     string
+		body     string
+		wantText string // empty when no result is expected
+	}{
+		{name: "custom frame", parser: parser, path: "custom.caption", body: "plain text caption", wantText: "plain text caption"},
+		{name: "standard frame falls back to the built-in parser", parser: parser, path: "speech.phrase", body: `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`, wantText: "こんにちは"},
+		{name: "ignored frame", parser: parser, path: "custom.noise", body: "ignored"},
+		{name: "built-in parser only", path: "speech.phrase", body: `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`, wantText: "こんにちは"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizer.SetResultParser(tt.parser)
+			if got := recognizer.GetResultParser() != nil; got != (tt.parser != nil) {
+				t.Errorf("GetResultParser() set = %v, want %v", got, tt.parser != nil)
+			}
+
+			recognized := make(chan string, 10)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result.Text
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			if err := fc.send(tt.path, tt.body); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			select {
+			case got := <-recognized:
+				if got != tt.wantText {
+					t.Errorf("recognized text = %q, want %q", got, tt.wantText)
+				}
+			case <-time.After(500 * time.Millisecond):
+				if tt.wantText != "" {
+					t.Fatal("timed out waiting for the recognized result")
+				}
+			}
+		})
+	}
+}
+
+func TestSpeechConfigAudioFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          *AudioStreamFormat
+		wantRate        float64
+		wantBits        float64
+		wantChannels    float64
+		wantContentType string
+	}{
+		{name: "default", format: GetDefaultInputFormat(), wantRate: 16000, wantBits: 16, wantChannels: 1,
+			wantContentType: "audio/x-wav; codec=audio/pcm; samplerate=16000; bitspersample=16; channels=1"},
+		{name: "8kHz telephony", format: GetWaveFormatPCM(8000, 16, 1), wantRate: 8000, wantBits: 16, wantChannels: 1,
+			wantContentType: "audio/x-wav; codec=audio/pcm; samplerate=8000; bitspersample=16; channels=1"},
+		{name: "48kHz stereo", format: GetWaveFormatPCM(48000, 16, 2), wantRate: 48000, wantBits: 16, wantChannels: 2,
+			wantContentType: "audio/x-wav; codec=audio/pcm; samplerate=48000; bitspersample=16; channels=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			stream := NewPushAudioInputStream(tt.format)
+			audioConfig, err := NewAudioConfigFromPushStream(stream)
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+			}
+			recognizer := newTestRecognizerWithAudio(t, service, audioConfig)
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+			waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+
+			body, ok := fc.textBody("speech.config")
+			if !ok {
+				t.Fatal("speech.config was not sent")
+			}
+			var config struct {
+				Config struct {
+					Input struct {
+						Format          string
+						AudioParameters map[string]float64
+					}
+				}
+			}
+			if err := json.Unmarshal([]byte(body), &config); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			input := config.Config.Input
+			if input.Format != tt.wantContentType {
+				t.Errorf("format = %q, want %q", input.Format, tt.wantContentType)
+			}
+			want := map[string]float64{"sampleRate": tt.wantRate, "bitsPerSample": tt.wantBits, "channels": tt.wantChannels}
+			if !reflect.DeepEqual(input.AudioParameters, want) {
+				t.Errorf("audioParameters = %v, want %v", input.AudioParameters, want)
+			}
+
+			// The audio header carries the same content type
+			var audioHeader string
+			for _, m := range fc.received() {
+				if m.messageType == websocket.TextMessage && strings.HasPrefix(string(m.data), "Path: audio\r\n") {
+					audioHeader = string(m.data)
+					break
+				}
+			}
+			if !strings.Contains(audioHeader, "Content-Type: "+tt.wantContentType+"\r\n") {
+				t.Errorf("audio header = %q, want Content-Type %q", audioHeader, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestEventSignalHandles(t *testing.T) {
+	tests := []struct {
+		name       string
+		disconnect []int // indexes of the handles to disconnect
+		want       []int // indexes of the callbacks expected to run, in order
+	}{
+		{name: "all connected", want: []int{0, 1, 2}},
+		{name: "disconnect the middle one", disconnect: []int{1}, want: []int{0, 2}},
+		{name: "disconnect the first and last", disconnect: []int{0, 2}, want: []int{1}},
+		{name: "disconnecting twice is harmless", disconnect: []int{1, 1}, want: []int{0, 2}},
+		{name: "disconnect all", disconnect: []int{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := NewEventSignal()
+			var called []int
+			var handles []*EventSubscription
+			for i := 0; i < 3; i++ {
+				i := i
+				handles = append(handles, signal.Connect(func(interface{}) { called = append(called, i) }))
+			}
+			for _, i := range tt.disconnect {
+				signal.DisconnectHandle(handles[i])
+			}
+			signal.DisconnectHandle(nil)
+
+			signal.Signal(nil)
+			if len(called) != len(tt.want) {
+				t.Fatalf("called %v, want %v", called, tt.want)
+			}
+			for i := range called {
+				if called[i] != tt.want[i] {
+					t.Fatalf("called %v, want %v", called, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEventSignalPanicIsolation(t *testing.T) {
+	tests := []struct {
+		name         string
+		panicHandler bool
+	}{
+		{name: "logged without a panic handler"},
+		{name: "reported to the panic handler", panicHandler: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := NewEventSignal()
+			var recovered []interface{}
+			if tt.panicHandler {
+				signal.SetPanicHandler(func(v interface{}) { recovered = append(recovered, v) })
+			}
+
+			var calls int
+			signal.Connect(func(interface{}) { calls++ })
+			signal.Connect(func(interface{}) { panic("boom") })
+			signal.Connect(func(interface{}) { calls++ })
+
+			signal.Signal(nil)
+			signal.Signal(nil)
+
+			if calls != 4 {
+				t.Errorf("other callbacks ran %d times, want 4", calls)
+			}
+			if tt.panicHandler && (len(recovered) != 2 || recovered[0] != "boom") {
+				t.Errorf("panic handler received %v, want two \"boom\" values", recovered)
+			}
+		})
+	}
+}
+
+func TestEventSignalCallbackCanChangeSubscriptions(t *testing.T) {
+	tests := []struct {
+		name string
+		// change runs inside the first callback; self is that callback's own handle
+		change func(signal *EventSignal, self *EventSubscription, record func(string))
+		want   []string // callbacks run by two signals, in order
+	}{
+		{
+			name:   "disconnect itself",
+			change: func(signal *EventSignal, self *EventSubscription, record func(string)) { signal.DisconnectHandle(self) },
+			want:   []string{"first", "second", "second"},
+		},
+		{
+			name: "connect another callback",
+			change: func(signal *EventSignal, self *EventSubscription, record func(string)) {
+				signal.Connect(func(interface{}) { record("added") })
+			},
+			want: []string{"first", "second", "first", "second", "added"},
+		},
+		{
+			name:   "disconnect all",
+			change: func(signal *EventSignal, self *EventSubscription, record func(string)) { signal.Disconnect() },
+			want:   []string{"first", "second"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := NewEventSignal()
+			var called []string
+			record := func(name string) { called = append(called, name) }
+			var self *EventSubscription
+			changed := false
+			self = signal.Connect(func(interface{}) {
+				record("first")
+				if !changed {
+					changed = true
+					tt.change(signal, self, record)
+				}
+			})
+			signal.Connect(func(interface{}) { record("second") })
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				signal.Signal(nil)
+				signal.Signal(nil)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Signal deadlocked when a callback changed the subscriptions")
+			}
+			if !reflect.DeepEqual(called, tt.want) {
+				t.Errorf("called %v, want %v", called, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinResultDuration(t *testing.T) {
+	short := `{"type":"final","Offset":0,"Duration":1000000,"NBest":[{"Display":"あ"}],"Translations":{"en":"Ah"}}`
+	valid := `{"type":"final","Offset":2000000,"Duration":8000000,"NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`
+	tests := []struct {
+		name    string
+		min     time.Duration
+		wantErr bool
+		want    []string
+	}{
+		{name: "disabled", want: []string{"あ", "こんにちは"}},
+		{name: "short result is dropped", min: 300 * time.Millisecond, want: []string{"こんにちは"}},
+		{name: "threshold equal to the duration keeps the result", min: 800 * time.Millisecond, want: []string{"こんにちは"}},
+		{name: "negative", min: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if err := recognizer.SetMinResultDuration(tt.min); (err != nil) != tt.wantErr {
+				t.Fatalf("SetMinResultDuration(%v) error = %v, wantErr %v", tt.min, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := recognizer.GetMinResultDuration(); got != tt.min {
+				t.Errorf("GetMinResultDuration() = %v, want %v", got, tt.min)
+			}
+
+			recognized := make(chan string, 10)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result.Text
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+			for _, phrase := range []string{short, valid} {
+				if err := fc.send("speech.phrase", phrase); err != nil {
+					t.Fatalf("send: %v", err)
+				}
+			}
+
+			// The valid result is always last, so everything before it has been delivered by then
+			var got []string
+			for len(got) == 0 || got[len(got)-1] != "こんにちは" {
+				select {
+				case text := <-recognized:
+					got = append(got, text)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for the valid result, got %v", got)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recognized %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationTokenRefresh(t *testing.T) {
+	tests := []struct {
+		name string
+		// tokens are returned by the provider in turn; an empty token fails the call. nil sets no provider.
+		tokens []string
+		// wantAuth is the credential of each connection the service accepts
+		wantAuth []string
+	}{
+		{name: "rotating tokens", tokens: []string{"token-1", "token-2", "token-3"}, wantAuth: []string{"Bearer token-1", "Bearer token-2", "Bearer token-3"}},
+		{name: "failed refresh keeps the connection", tokens: []string{"token-1", "", ""}, wantAuth: []string{"Bearer token-1"}},
+		{name: "no provider uses the subscription key", wantAuth: []string{"key:test-key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := TokenRefreshInterval
+			TokenRefreshInterval = 50 * time.Millisecond
+			t.Cleanup(func() { TokenRefreshInterval = previous })
+
+			service := newFakeSpeechService(t)
+			config, err := SpeechTranslationConfigFromEndpoint(service.url(), "test-key")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromEndpoint: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			var calls atomic.Int32
+			if tt.tokens != nil {
+				config.SetAuthorizationTokenProvider(func(ctx context.Context) (string, error) {
+					n := int(calls.Add(1)) - 1
+					if n >= len(tt.tokens) || tt.tokens[n] == "" {
+						return "", errors.New("token service unavailable")
+					}
+					return tt.tokens[n], nil
+				})
+			}
+			stream := NewPushAudioInputStream(GetDefaultInputFormat())
+			audioConfig, err := NewAudioConfigFromPushStream(stream)
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, audioConfig)
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			defer recognizer.Close()
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			// The refresh happens between audio reads, so keep audio flowing as a live session would
+			feeding := make(chan struct{})
+			defer close(feeding)
+			go func() {
+				ticker := time.NewTicker(10 * time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-feeding:
+						return
+					case <-ticker.C:
+						stream.Write(make([]byte, 320))
+					}
+				}
+			}()
+
+			var conns []*fakeServiceConn
+			for range tt.wantAuth {
+				conns = append(conns, service.waitForConn(t))
+			}
+			// Wait through several more refresh intervals to see that no further connections are made
+			if tt.tokens != nil {
+				waitFor(t, "the token provider to be exhausted", func() bool { return int(calls.Load()) > len(tt.tokens) })
+			} else {
+				time.Sleep(4 * TokenRefreshInterval)
+			}
+			select {
+			case fc := <-service.accepted:
+				t.Fatalf("unexpected connection with Authorization %q", fc.header.Get("Authorization"))
+			default:
+			}
+
+			for i, fc := range conns {
+				got := fc.header.Get("Authorization")
+				if key := fc.header.Get("Ocp-Apim-Subscription-Key"); key != "" {
+					got = "key:" + key
+				}
+				if got != tt.wantAuth[i] {
+					t.Errorf("connection %d credential = %q, want %q", i+1, got, tt.wantAuth[i])
+				}
+			}
+			// Every connection but the latest is replaced and closed
+			for i, fc := range conns[:len(conns)-1] {
+				select {
+				case <-fc.closed:
+				case <-time.After(5 * time.Second):
+					t.Errorf("connection %d was not closed after the token refresh", i+1)
+				}
+			}
+			select {
+			case <-conns[len(conns)-1].closed:
+				t.Error("the current connection was closed")
+			default:
+			}
+		})
+	}
+}
+
+func TestAuthorizationTokenRefreshHandover(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// endTurn makes the service end the turn of the replaced connection
+		endTurn bool
+	}{
+		{name: "replaced connection closes at turn.end", timeout: 5 * time.Second, endTurn: true},
+		{name: "replaced connection closes after the timeout", timeout: 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousInterval, previousTimeout := TokenRefreshInterval, TokenHandoverTimeout
+			TokenRefreshInterval, TokenHandoverTimeout = 50*time.Millisecond, tt.timeout
+			t.Cleanup(func() { TokenRefreshInterval, TokenHandoverTimeout = previousInterval, previousTimeout })
+
+			service := newFakeSpeechService(t)
+			service.holdTurnEnd.Store(true)
+			config, err := SpeechTranslationConfigFromEndpoint(service.url(), "test-key")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromEndpoint: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			// Only the first refresh succeeds, so there is exactly one replaced connection
+			var calls atomic.Int32
+			config.SetAuthorizationTokenProvider(func(ctx context.Context) (string, error) {
+				if n := calls.Add(1); n <= 2 {
+					return fmt.Sprintf("token-%d", n), nil
+				}
+				return "", errors.New("token service unavailable")
+			})
+			stream := NewPushAudioInputStream(GetDefaultInputFormat())
+			audioConfig, err := NewAudioConfigFromPushStream(stream)
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, audioConfig)
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			defer recognizer.Close()
+			recognized := make(chan string, 10)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result.Text
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			feeding := make(chan struct{})
+			defer close(feeding)
+			go func() {
+				ticker := time.NewTicker(10 * time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-feeding:
+						return
+					case <-ticker.C:
+						stream.Write(make([]byte, 320))
+					}
+				}
+			}()
+
+			old := service.waitForConn(t)
+			current := service.waitForConn(t)
+
+			// The replaced connection is asked to finish its turn but stays open for its results
+			waitFor(t, "the end of audio on the replaced connection", func() bool {
+				for _, m := range old.received() {
+					if m.messageType == websocket.BinaryMessage && len(m.data) == 0 {
+						return true
+					}
+				}
+				return false
+			})
+			ended := time.Now()
+			if err := old.sendFinalPhrase("古い接続", map[string]string{"en": "old connection"}); err != nil {
+				t.Fatalf("sendFinalPhrase: %v", err)
+			}
+			select {
+			case text := <-recognized:
+				if text != "古い接続" {
+					t.Errorf("recognized %q, want the result of the replaced connection", text)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the result of the replaced connection was not delivered")
+			}
+
+			if tt.endTurn {
+				old.send("turn.end", "{}")
+			}
+			select {
+			case <-old.closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the replaced connection was not closed")
+			}
+			elapsed := time.Since(ended)
+			if tt.endTurn && elapsed >= tt.timeout {
+				t.Errorf("replaced connection closed after %v, want it closed at turn.end", elapsed)
+			}
+			if !tt.endTurn && elapsed < tt.timeout/2 {
+				t.Errorf("replaced connection closed after %v, want it kept open for about %v", elapsed, tt.timeout)
+			}
+
+			select {
+			case <-current.closed:
+				t.Error("the current connection was closed")
+			default:
+			}
+			if !recognizer.IsRunning() {
+				t.Error("recognition stopped after the handover")
+			}
+			// Let the current connection end its turn when recognition stops
+			service.holdTurnEnd.Store(false)
+		})
+	}
+}
+
+func TestPrimaryTargetLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		set     string
+		remove  string
+		want    string
+		wantErr error
+	}{
+		{name: "defaults to the first target", targets: []string{"en", "fr"}, want: "en"},
+		{name: "explicit primary", targets: []string{"en", "fr"}, set: "fr", want: "fr"},
+		{name: "unknown language", targets: []string{"en", "fr"}, set: "de", want: "en", wantErr: ErrUnknownTargetLanguage},
+		{name: "removed primary falls back to the first target", targets: []string{"en", "fr", "de"}, set: "fr", remove: "fr", want: "en"},
+		{name: "no targets", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSpeechTranslationConfig()
+			for _, lang := range tt.targets {
+				config.AddTargetLanguage(lang)
+			}
+			if tt.set != "" {
+				if err := config.SetPrimaryTargetLanguage(tt.set); !errors.Is(err, tt.wantErr) {
+					t.Errorf("SetPrimaryTargetLanguage(%q) error = %v, want %v", tt.set, err, tt.wantErr)
+				}
+			}
+			if tt.remove != "" {
+				config.RemoveTargetLanguage(tt.remove)
+			}
+			if got := config.GetPrimaryTargetLanguage(); got != tt.want {
+				t.Errorf("GetPrimaryTargetLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopContinuousRecognitionAndDrain(t *testing.T) {
+	tests := []struct {
+		name string
+		// onEnd is what the service does when it receives the end-of-audio marker
+		onEnd       func(fc *fakeServiceConn)
+		cancel      bool
+		wantResults []string
+		wantErr     error
+	}{
+		{
+			name: "final result of the last utterance is returned",
+			onEnd: func(fc *fakeServiceConn) {
+				fc.sendFinalPhrase("さようなら", map[string]string{"en": "Goodbye"})
+				fc.send("turn.end", "{}")
+			},
+			wantResults: []string{"Goodbye"},
+		},
+		{
+			name:  "turn ends without a result",
+			onEnd: func(fc *fakeServiceConn) { fc.send("turn.end", "{}") },
+		},
+		{name: "service never ends the turn"},
+		{name: "canceled context", cancel: true, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := DrainTimeout
+			DrainTimeout = 200 * time.Millisecond
+			t.Cleanup(func() { DrainTimeout = previous })
+
+			service := newFakeSpeechService(t)
+			service.holdTurnEnd.Store(true)
+			service.onMessage = func(fc *fakeServiceConn, messageType int, message []byte) {
+				if messageType == websocket.BinaryMessage && len(message) == 0 && tt.onEnd != nil {
+					tt.onEnd(fc)
+				}
+			}
+			recognizer, stream := newTestRecognizer(t, service)
+			defer recognizer.Close()
+			var events atomic.Int32
+			recognizer.Recognized().Connect(func(interface{}) { events.Add(1) })
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+			waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			results, err := recognizer.StopContinuousRecognitionAndDrain(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Translations["en"])
+			}
+			if !reflect.DeepEqual(got, tt.wantResults) {
+				t.Errorf("results = %v, want %v", got, tt.wantResults)
+			}
+			// The drained results are also raised as events before it returns
+			if n := int(events.Load()); n != len(tt.wantResults) {
+				t.Errorf("%d Recognized events, want %d", n, len(tt.wantResults))
+			}
+			if _, err := recognizer.StopContinuousRecognitionAndDrain(context.Background()); err == nil {
+				t.Error("second drain succeeded, want an error because recognition is stopped")
+			}
+		})
+	}
+}
+
+func TestStopContinuousRecognitionAsync(t *testing.T) {
+	tests := []struct {
+		name string
+		// onEnd is what the service does when it receives the end-of-audio marker
+		onEnd      func(fc *fakeServiceConn)
+		notRunning bool
+		wantEvents []string
+		wantErr    bool
+	}{
+		{
+			name: "final sent as stop is called is delivered",
+			onEnd: func(fc *fakeServiceConn) {
+				fc.sendFinalPhrase("さようなら", map[string]string{"en": "Goodbye"})
+				fc.send("turn.end", "{}")
+			},
+			wantEvents: []string{"recognized:Goodbye", "stopped"},
+		},
+		{
+			name:       "service never ends the turn",
+			wantEvents: []string{"stopped"},
+		},
+		{name: "not running", notRunning: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := DrainTimeout
+			DrainTimeout = 200 * time.Millisecond
+			t.Cleanup(func() { DrainTimeout = previous })
+
+			service := newFakeSpeechService(t)
+			service.holdTurnEnd.Store(true)
+			service.onMessage = func(fc *fakeServiceConn, messageType int, message []byte) {
+				if messageType == websocket.BinaryMessage && len(message) == 0 && tt.onEnd != nil {
+					tt.onEnd(fc)
+				}
+			}
+			recognizer, stream := newTestRecognizer(t, service)
+			defer recognizer.Close()
+			var mu sync.Mutex
+			var events []string
+			record := func(event string) {
+				mu.Lock()
+				events = append(events, event)
+				mu.Unlock()
+			}
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				record("recognized:" + eventArgs.(*TranslationRecognitionEventArgs).Result.Translations["en"])
+			})
+			recognizer.SessionStopped().Connect(func(interface{}) { record("stopped") })
+
+			if !tt.notRunning {
+				if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+					t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+				}
+				service.waitForConn(t)
+				stream.Write(make([]byte, 3200))
+				waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+			}
+
+			stopped := recognizer.StopContinuousRecognitionAsync()
+			select {
+			case err := <-stopped:
+				if (err != nil) != tt.wantErr {
+					t.Errorf("error = %v, want error %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for recognition to stop")
+			}
+			if _, ok := <-stopped; ok {
+				t.Error("the stop channel was not closed after reporting the result")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
+
+// recognizerGoroutines returns the number of goroutines running recognizer code
+func recognizerGoroutines() int {
+	buf := make([]byte, 1<<20)
+	stacks := string(buf[:runtime.Stack(buf, true)])
+	n := 0
+	for _, stack := range strings.Split(stacks, "\n\n") {
+		if strings.Contains(stack, "gospeech.(*TranslationRecognizer)") || strings.Contains(stack, "gospeech.(*speechServiceConnection)") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStartStopDoesNotLeakGoroutines(t *testing.T) {
+	tests := []struct {
+		name string
+		// waitForStop waits for each stop to finish before starting again
+		waitForStop bool
+	}{
+		{name: "stop and wait", waitForStop: true},
+		{name: "restart before the previous stop finishes", waitForStop: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizer.SetKeepAliveInterval(time.Hour)
+			// Earlier tests may leave recognizers behind, so only growth is counted
+			before, beforeRecognizer := runtime.NumGoroutine(), recognizerGoroutines()
+
+			var stopped <-chan error
+			for i := 0; i < 100; i++ {
+				if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+					t.Fatalf("start %d: %v", i, err)
+				}
+				service.waitForConn(t)
+				stopped = recognizer.StopContinuousRecognitionAsync()
+				if tt.waitForStop {
+					if err := <-stopped; err != nil {
+						t.Fatalf("stop %d: %v", i, err)
+					}
+					if n := recognizerGoroutines() - beforeRecognizer; n > 0 {
+						t.Fatalf("%d recognizer goroutines still running after stop %d", n, i)
+					}
+				}
+			}
+			if err := <-stopped; err != nil {
+				t.Fatalf("last stop: %v", err)
+			}
+			if n := recognizerGoroutines() - beforeRecognizer; n > 0 {
+				t.Errorf("%d recognizer goroutines still running after the last stop", n)
+			}
+
+			// The service side of each connection exits shortly after the recognizer closes it
+			deadline := time.Now().Add(5 * time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if after := runtime.NumGoroutine(); after > before {
+				t.Errorf("%d goroutines after 100 starts and stops, want at most %d", after, before)
+			}
+			if got := service.connections.Load(); got != 100 {
+				t.Errorf("%d connections, want 100", got)
+			}
+		})
+	}
+}
+
+func TestNBestTextFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		nbest string
+		want  string
+	}{
+		{name: "display", nbest: `{"Display":"こんにちは。","ITN":"こんにちは","Lexical":"こんにちは"}`, want: "こんにちは。"},
+		{name: "ITN without display", nbest: `{"ITN":"3時","Lexical":"さんじ"}`, want: "3時"},
+		{name: "lexical only", nbest: `{"Lexical":"さんじ"}`, want: "さんじ"},
+		{name: "empty display falls back", nbest: `{"Display":"","Lexical":"さんじ"}`, want: "さんじ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			defer recognizer.Close()
+			recognized := make(chan string, 1)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result.Text
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			fc := service.waitForConn(t)
+			fc.send("speech.phrase", `{"type":"final","NBest":[`+tt.nbest+`],"Translations":{"en":"three o'clock"}}`)
+			select {
+			case got := <-recognized:
+				if got != tt.want {
+					t.Errorf("Text = %q, want %q", got, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the recognized result")
+			}
+		})
+	}
+}
+
+func TestMaxTargetLanguages(t *testing.T) {
+	var codes []string
+	for _, language := range SupportedLanguages() {
+		codes = append(codes, language.Code)
+	}
+	if len(codes) < 30 {
+		t.Fatalf("only %d supported languages, the test needs 30", len(codes))
+	}
+
+	tests := []struct {
+		name      string
+		languages int
+		max       int
+		wantErr   bool
+	}{
+		{name: "large list within a raised limit", languages: 30, max: 30},
+		{name: "default limit", languages: DefaultMaxTargetLanguages},
+		{name: "over the default limit", languages: DefaultMaxTargetLanguages + 1, wantErr: true},
+		{name: "over a lowered limit", languages: 3, max: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			config, err := SpeechTranslationConfigFromEndpoint(service.url(), "test-key")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromEndpoint: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			for _, code := range codes[:tt.languages] {
+				config.AddTargetLanguage(code)
+			}
+			stream := NewPushAudioInputStream(GetDefaultInputFormat())
+			audioConfig, err := NewAudioConfigFromPushStream(stream)
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromPushStream: %v", err)
+			}
+			recognizer, err := NewTranslationRecognizer(config, audioConfig)
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			defer recognizer.Close()
+			if err := recognizer.SetMaxTargetLanguages(tt.max); err != nil {
+				t.Fatalf("SetMaxTargetLanguages: %v", err)
+			}
+			canceled := make(chan *CancellationDetails, 1)
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+
+			if tt.wantErr {
+				select {
+				case details := <-canceled:
+					if !strings.Contains(details.ErrorDetails, ErrTooManyTargetLanguages.Error()) {
+						t.Errorf("ErrorDetails = %q, want it to mention %q", details.ErrorDetails, ErrTooManyTargetLanguages)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for the cancellation")
+				}
+				return
+			}
+
+			waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+			body, ok := fc.textBody("speech.config")
+			if !ok {
+				t.Fatal("speech.config was not sent")
+			}
+			var message struct {
+				Config struct {
+					SpeechConfig struct {
+						TranslationLanguages []string
+					}
+				}
+			}
+			if err := json.Unmarshal([]byte(body), &message); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			if got := len(message.Config.SpeechConfig.TranslationLanguages); got != tt.languages {
+				t.Errorf("speech.config carries %d target languages, want %d", got, tt.languages)
+			}
+			// The list is carried only in the body, not in the URL or the headers
+			list := strings.Join(codes[:2], ",")
+			if strings.Contains(fc.requestURI, list) {
+				t.Errorf("request URI %q contains the target languages", fc.requestURI)
+			}
+			for name, values := range fc.header {
+				if strings.Contains(strings.Join(values, " "), list) {
+					t.Errorf("header %s contains the target languages", name)
+				}
+			}
+		})
+	}
+}
+
+func TestInterimTranslations(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantMode string // interimResults mode in speech.config; empty when not requested
+	}{
+		{name: "requested", enabled: true, wantMode: "Always"},
+		{name: "not requested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			defer recognizer.Close()
+			recognizer.SetInterimTranslations(tt.enabled)
+			recognizing := make(chan *TranslationRecognitionResult, 10)
+			recognizer.Recognizing().Connect(func(eventArgs interface{}) {
+				recognizing <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+			stream.Write(make([]byte, 3200))
+			waitFor(t, "the audio chunk", func() bool { return service.audioBytes.Load() >= 3200 })
+
+			body, ok := fc.textBody("speech.config")
+			if !ok {
+				t.Fatal("speech.config was not sent")
+			}
+			var config struct {
+				Config struct {
+					SpeechConfig struct {
+						Translation struct {
+							Output struct {
+								InterimResults struct{ Mode string }
+							}
+						}
+					}
+				}
+			}
+			if err := json.Unmarshal([]byte(body), &config); err != nil {
+				t.Fatalf("speech.config is not JSON: %v", err)
+			}
+			if got := config.Config.SpeechConfig.Translation.Output.InterimResults.Mode; got != tt.wantMode {
+				t.Errorf("interimResults mode = %q, want %q", got, tt.wantMode)
+			}
+
+			// The interim translation is surfaced in the Recognizing event
+			fc.send("translation.hypothesis", `{"Text":"こんにち","Offset":0,"Duration":5000000,"Translation":{"TranslationStatus":"Success","Translations":[{"Language":"en","Text":"Hell"}]}}`)
+			select {
+			case result := <-recognizing:
+				if result.Text != "こんにち" || result.Translations["en"] != "Hell" {
+					t.Errorf("Recognizing result = %q %v, want こんにち with the en translation Hell", result.Text, result.Translations)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the Recognizing event")
+			}
+		})
+	}
+}
+
+// pollingSource is an audio source that returns (0, nil) instead of blocking while it has no data
+type pollingSource struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (s *pollingSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := copy(p, s.data)
+	s.data = s.data[n:]
+	return n, nil
+}
+
+func (s *pollingSource) Write(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = append(s.data, p...)
+}
+
+func (s *pollingSource) Close() error { return nil }
+
+func TestZeroReadWarning(t *testing.T) {
+	tests := []struct {
+		name         string
+		threshold    int
+		resume       bool // write audio after the first warning, then stop again
+		wantWarnings int
+	}{
+		{name: "disabled by default", threshold: 0, wantWarnings: 0},
+		{name: "persistent zero reads warn once", threshold: 5, wantWarnings: 1},
+		{name: "warns again after data resumes", threshold: 5, resume: true, wantWarnings: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Push streams block until data arrives, so only polling sources produce zero reads
+			service := newFakeSpeechService(t)
+			source := &pollingSource{}
+			audioConfig, err := NewAudioConfigFromStream(source, GetDefaultInputFormat())
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromStream: %v", err)
+			}
+			recognizer := newTestRecognizerWithAudio(t, service, audioConfig)
+			if err := recognizer.SetZeroReadThreshold(tt.threshold); err != nil {
+				t.Fatalf("SetZeroReadThreshold: %v", err)
+			}
+			var warnings atomic.Int32
+			recognizer.Warning().Connect(func(eventArgs interface{}) {
+				if code := eventArgs.(*WarningEventArgs).Code; code != WarningPossibleDeadSource {
+					t.Errorf("warning code = %v, want PossibleDeadSource", code)
+				}
+				warnings.Add(1)
+			})
+			stopped := make(chan struct{}, 1)
+			recognizer.SessionStopped().Connect(func(interface{}) { stopped <- struct{}{} })
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			service.waitForConn(t)
+
+			// The worker reads every 10ms, so this is well past the threshold
+			time.Sleep(300 * time.Millisecond)
+			if tt.resume {
+				source.Write(make([]byte, 3200))
+				time.Sleep(300 * time.Millisecond)
+			}
+
+			if got := int(warnings.Load()); got != tt.wantWarnings {
+				t.Errorf("received %d warnings, want %d", got, tt.wantWarnings)
+			}
+			// The session keeps running
+			select {
+			case <-stopped:
+				t.Error("the session stopped after zero reads")
+			default:
+			}
+		})
+	}
+}
+
+func TestSetZeroReadThresholdRejectsNegative(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, _ := newTestRecognizer(t, service)
+	if err := recognizer.SetZeroReadThreshold(-1); err == nil {
+		t.Error("SetZeroReadThreshold(-1) succeeded, want an error")
+	}
+}
+
+func TestTurnID(t *testing.T) {
+	tests := []struct {
+		name      string
+		turnStart string // body of turn.start; empty sends none
+		turnEnd   bool   // send turn.end before the results
+		want      string
+	}{
+		{name: "service tag from the turn context", turnStart: `{"context":{"serviceTag":"tag-1"}}`, want: "tag-1"},
+		{name: "request id without a service tag", turnStart: `{"context":{}}`, want: "request-1"},
+		{name: "no turn", want: ""},
+		{name: "cleared by turn.end", turnStart: `{"context":{"serviceTag":"tag-1"}}`, turnEnd: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			hypotheses := make(chan *TranslationRecognitionResult, 10)
+			recognizer.Recognizing().Connect(func(eventArgs interface{}) {
+				if result := eventArgs.(*TranslationRecognitionEventArgs).Result; result.Reason == ResultReasonTranslatingSpeech {
+					hypotheses <- result
+				}
+			})
+			finals := make(chan *TranslationRecognitionResult, 10)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				finals <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			fc := service.waitForConn(t)
+			if tt.turnStart != "" {
+				if err := fc.sendWithRequestID("turn.start", "request-1", tt.turnStart); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.turnEnd {
+				if err := fc.sendWithRequestID("turn.end", "request-1", `{}`); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := fc.send("speech.hypothesis", `{"Text":"こんにち"}`); err != nil {
+				t.Fatal(err)
+			}
+			if err := fc.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for kind, results := range map[string]chan *TranslationRecognitionResult{"hypothesis": hypotheses, "final": finals} {
+				select {
+				case result := <-results:
+					if result.TurnID != tt.want {
+						t.Errorf("%s TurnID = %q, want %q", kind, result.TurnID, tt.want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no %s result", kind)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSpeechConfigMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		targets     []string
+		context     map[string]string
+		wantSource  string
+		wantTargets []interface{}
+		wantContext map[string]interface{}
+		wantErr     string
+	}{
+		{name: "source and target", source: "ja-JP", targets: []string{"en"}, wantSource: "ja-JP", wantTargets: []interface{}{"en"}},
+		{name: "languages are normalized", source: "ja", targets: []string{"en-US", "DE"}, wantSource: "ja-JP", wantTargets: []interface{}{"en", "de"}},
+		{
+			name: "session context", source: "ja-JP", targets: []string{"en"},
+			context:     map[string]string{"tenant": "contoso", "system": "ignored"},
+			wantSource:  "ja-JP",
+			wantTargets: []interface{}{"en"},
+			wantContext: map[string]interface{}{"tenant": "contoso"},
+		},
+		{name: "invalid source language", source: "xx", targets: []string{"en"}, wantErr: "invalid source language code: xx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("test-key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			for _, lang := range tt.targets {
+				config.AddTargetLanguage(lang)
+			}
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage(tt.source)
+			if tt.context != nil {
+				recognizer.SetSessionContext(tt.context)
+			}
+
+			body, err := recognizer.BuildSpeechConfigMessage()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("BuildSpeechConfigMessage error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildSpeechConfigMessage: %v", err)
+			}
+
+			var message struct {
+				Context map[string]interface{}
+				Config  struct {
+					SpeechConfig struct {
+						SpeechRecognitionLanguage string
+						TranslationLanguages      []interface{}
+						Features                  map[string]interface{}
+					}
+					Input struct {
+						Format          string
+						AudioParameters map[string]interface{}
+					}
+				}
+			}
+			if err := json.Unmarshal(body, &message); err != nil {
+				t.Fatalf("message is not JSON: %v", err)
+			}
+			speechConfig := message.Config.SpeechConfig
+			if speechConfig.SpeechRecognitionLanguage != tt.wantSource {
+				t.Errorf("speechRecognitionLanguage = %q, want %q", speechConfig.SpeechRecognitionLanguage, tt.wantSource)
+			}
+			if !reflect.DeepEqual(speechConfig.TranslationLanguages, tt.wantTargets) {
+				t.Errorf("translationLanguages = %v, want %v", speechConfig.TranslationLanguages, tt.wantTargets)
+			}
+			if speechConfig.Features["enableTranslation"] != true {
+				t.Errorf("features = %v, want translation enabled", speechConfig.Features)
+			}
+			if !strings.HasPrefix(message.Config.Input.Format, "audio/x-wav") || message.Config.Input.AudioParameters["sampleRate"] != float64(16000) {
+				t.Errorf("input = %+v, want 16kHz audio/x-wav", message.Config.Input)
+			}
+			if _, ok := message.Context["system"].(map[string]interface{}); !ok {
+				t.Errorf("context.system = %v, want the SDK description", message.Context["system"])
+			}
+			for key, want := range tt.wantContext {
+				if got := message.Context[key]; got != want {
+					t.Errorf("context[%q] = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSpeechConfigMessageMatchesSentMessage(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, stream := newTestRecognizer(t, service)
+	preview, err := recognizer.BuildSpeechConfigMessage()
+	if err != nil {
+		t.Fatalf("BuildSpeechConfigMessage: %v", err)
+	}
+
+	if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+		t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+	}
+	defer recognizer.StopContinuousRecognition()
+	fc := service.waitForConn(t)
+	stream.Write(make([]byte, 3200))
+
+	var sent string
+	waitFor(t, "the speech.config message", func() bool {
+		var ok bool
+		sent, ok = fc.textBody("speech.config")
+		return ok
+	})
+	if sent != string(preview) {
+		t.Errorf("sent speech.config = %s, want the preview %s", sent, preview)
+	}
+}
+
+func TestPunctuationInSpeechConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultMode PunctuationMode
+		perLanguage map[string]PunctuationMode
+		wantDefault string
+		wantPerLang map[string]interface{}
+	}{
+		{name: "explicit by default", wantDefault: "explicit"},
+		{name: "default mode", defaultMode: PunctuationNone, wantDefault: "none"},
+		{
+			name:        "one target disables punctuation",
+			perLanguage: map[string]PunctuationMode{"de-DE": PunctuationNone},
+			wantDefault: "explicit",
+			wantPerLang: map[string]interface{}{"de": "none"},
+		},
+		{
+			name:        "overrides matching the default are omitted",
+			defaultMode: PunctuationImplicit,
+			perLanguage: map[string]PunctuationMode{"en": PunctuationImplicit, "de": PunctuationExplicit},
+			wantDefault: "implicit",
+			wantPerLang: map[string]interface{}{"de": "explicit"},
+		},
+		{
+			name:        "languages that are not targets are omitted",
+			perLanguage: map[string]PunctuationMode{"fr": PunctuationNone},
+			wantDefault: "explicit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("test-key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			config.AddTargetLanguage("de")
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+			if err := recognizer.SetPunctuation(tt.defaultMode); err != nil {
+				t.Fatalf("SetPunctuation: %v", err)
+			}
+			for language, mode := range tt.perLanguage {
+				if err := recognizer.SetLanguagePunctuation(language, mode); err != nil {
+					t.Fatalf("SetLanguagePunctuation: %v", err)
+				}
+				if got := recognizer.GetLanguagePunctuation(language); got != mode {
+					t.Errorf("GetLanguagePunctuation(%q) = %v, want %v", language, got, mode)
+				}
+			}
+
+			body, err := recognizer.BuildSpeechConfigMessage()
+			if err != nil {
+				t.Fatalf("BuildSpeechConfigMessage: %v", err)
+			}
+			var message struct {
+				Config struct {
+					SpeechConfig struct {
+						Features map[string]interface{}
+					}
+				}
+			}
+			if err := json.Unmarshal(body, &message); err != nil {
+				t.Fatalf("message is not JSON: %v", err)
+			}
+			features := message.Config.SpeechConfig.Features
+			if features["punctuation"] != tt.wantDefault {
+				t.Errorf("punctuation = %v, want %q", features["punctuation"], tt.wantDefault)
+			}
+			got, _ := features["translationPunctuation"].(map[string]interface{})
+			if !reflect.DeepEqual(got, tt.wantPerLang) {
+				t.Errorf("translationPunctuation = %v, want %v", features["translationPunctuation"], tt.wantPerLang)
+			}
+		})
+	}
+}
+
+func TestProfanityInSpeechConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      *ProfanityOption
+		wantMode  ProfanityOption
+		wantValue string
+	}{
+		{name: "masked by default", wantMode: ProfanityMasked, wantValue: "masked"},
+		{name: "masked", mode: profanityOption(ProfanityMasked), wantMode: ProfanityMasked, wantValue: "masked"},
+		{name: "removed", mode: profanityOption(ProfanityRemoved), wantMode: ProfanityRemoved, wantValue: "removed"},
+		{name: "raw", mode: profanityOption(ProfanityRaw), wantMode: ProfanityRaw, wantValue: "raw"},
+		{name: "unknown falls back to masked", mode: profanityOption(ProfanityOption(9)), wantMode: ProfanityMasked, wantValue: "masked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := SpeechTranslationConfigFromSubscription("test-key", "japaneast")
+			if err != nil {
+				t.Fatalf("SpeechTranslationConfigFromSubscription: %v", err)
+			}
+			config.SetSpeechRecognitionLanguage("ja-JP")
+			config.AddTargetLanguage("en")
+			if tt.mode != nil {
+				config.SetProfanity(*tt.mode)
+			}
+			if got := config.GetProfanity(); got != tt.wantMode {
+				t.Errorf("GetProfanity() = %v, want %v", got, tt.wantMode)
+			}
+			recognizer, err := NewTranslationRecognizer(config, newTestAudioConfig(t))
+			if err != nil {
+				t.Fatalf("NewTranslationRecognizer: %v", err)
+			}
+
+			body, err := recognizer.BuildSpeechConfigMessage()
+			if err != nil {
+				t.Fatalf("BuildSpeechConfigMessage: %v", err)
+			}
+			var message struct {
+				Config struct {
+					SpeechConfig struct {
+						Profanity string
+					}
+				}
+			}
+			if err := json.Unmarshal(body, &message); err != nil {
+				t.Fatalf("message is not JSON: %v", err)
+			}
+			if got := message.Config.SpeechConfig.Profanity; got != tt.wantValue {
+				t.Errorf("profanity = %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func profanityOption(o ProfanityOption) *ProfanityOption { return &o }
+
+func TestSetPunctuationRejectsInvalidModes(t *testing.T) {
+	service := newFakeSpeechService(t)
+	recognizer, _ := newTestRecognizer(t, service)
+	if err := recognizer.SetPunctuation(PunctuationMode(-1)); err == nil {
+		t.Error("SetPunctuation accepted an invalid mode")
+	}
+	if err := recognizer.SetLanguagePunctuation("en", PunctuationMode(3)); err == nil {
+		t.Error("SetLanguagePunctuation accepted an invalid mode")
+	}
+	if err := recognizer.SetLanguagePunctuation("", PunctuationNone); err == nil {
+		t.Error("SetLanguagePunctuation accepted an empty language")
+	}
+}
+
+func TestConcurrentStartIsIdempotent(t *testing.T) {
+	tests := []struct {
+		name     string
+		starters int
+	}{
+		{name: "double start", starters: 2},
+		{name: "many starters", starters: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if recognizer.IsRunning() {
+				t.Fatal("IsRunning = true before starting")
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.starters)
+			for i := 0; i < tt.starters; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- recognizer.StartContinuousRecognitionAsync(context.Background())
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Errorf("StartContinuousRecognitionAsync: %v", err)
+				}
+			}
+			if !recognizer.IsRunning() {
+				t.Error("IsRunning = false after starting")
+			}
+
+			service.waitForConn(t)
+			time.Sleep(50 * time.Millisecond)
+			if got := service.connections.Load(); got != 1 {
+				t.Errorf("connections = %d, want 1", got)
+			}
+
+			if err := recognizer.StopContinuousRecognition(); err != nil {
+				t.Fatalf("StopContinuousRecognition: %v", err)
+			}
+			if recognizer.IsRunning() {
+				t.Error("IsRunning = true after stopping")
+			}
+			if err := recognizer.StopContinuousRecognition(); err == nil {
+				t.Error("second StopContinuousRecognition succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestWorkerExitClearsRunning(t *testing.T) {
+	tests := []struct {
+		name        string
+		failedDials int32
+		// end makes the worker finish (or reconnect) without StopContinuousRecognition
+		end         func(fc *fakeServiceConn, stream *PushAudioInputStream)
+		wantRunning bool
+	}{
+		{name: "end of the audio source", end: func(fc *fakeServiceConn, stream *PushAudioInputStream) { stream.Close() }},
+		{name: "connection fails", failedDials: 100},
+		{name: "reconnect gives up", end: func(fc *fakeServiceConn, stream *PushAudioInputStream) {
+			fc.conn.UnderlyingConn().Close()
+		}, failedDials: -1},
+		{name: "reconnect succeeds", end: func(fc *fakeServiceConn, stream *PushAudioInputStream) {
+			fc.conn.UnderlyingConn().Close()
+		}, wantRunning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			if err := recognizer.config.SetReconnectPolicy(1, time.Millisecond, 5*time.Millisecond); err != nil {
+				t.Fatalf("SetReconnectPolicy: %v", err)
+			}
+			if tt.failedDials > 0 {
+				service.rejectNext.Store(tt.failedDials)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+
+			if tt.end != nil {
+				fc := service.waitForConn(t)
+				if tt.failedDials < 0 {
+					service.rejectNext.Store(100)
+				}
+				tt.end(fc, stream)
+			}
+
+			if tt.wantRunning {
+				service.waitForConn(t)
+				time.Sleep(50 * time.Millisecond)
+				if !recognizer.IsRunning() {
+					t.Error("IsRunning = false after reconnecting")
+				}
+				if err := recognizer.StopContinuousRecognition(); err != nil {
+					t.Errorf("StopContinuousRecognition: %v", err)
+				}
+				return
+			}
+			waitFor(t, "the worker to clear the running state", func() bool { return !recognizer.IsRunning() })
+
+			// The recognizer can be started again once the worker ended on its own
+			service.rejectNext.Store(0)
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync after the worker ended: %v", err)
+			}
+			if !recognizer.IsRunning() {
+				t.Error("IsRunning = false after restarting")
+			}
+			recognizer.StopContinuousRecognition()
+		})
+	}
+}
+
+func TestTranslationRecognitionResultMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  TranslationRecognitionResult
+		other *TranslationRecognitionResult
+		want  TranslationRecognitionResult
+	}{
+		{
+			name: "two partial results make one complete result",
+			base: TranslationRecognitionResult{
+				ResultID: "1", Text: "こんにちは", Reason: ResultReasonTranslatedSpeech, Offset: 100, Duration: time.Second, TurnID: "turn",
+				Translations:           map[string]string{"en": "Hello"},
+				TranslationConfidences: map[string]float64{"en": 0.9},
+			},
+			other: &TranslationRecognitionResult{
+				ResultID: "2", Text: "こんにちは。", Reason: ResultReasonTranslatedSpeech, Offset: 200, Duration: 2 * time.Second, TurnID: "turn",
+				Translations:       map[string]string{"de": "Hallo"},
+				TranslationDetails: map[string]*TranslationDetail{"de": {Text: "Hallo", Offset: 200}},
+			},
+			want: TranslationRecognitionResult{
+				ResultID: "2", Text: "こんにちは。", Reason: ResultReasonTranslatedSpeech, Offset: 200, Duration: 2 * time.Second, TurnID: "turn",
+				Translations:           map[string]string{"en": "Hello", "de": "Hallo"},
+				TranslationConfidences: map[string]float64{"en": 0.9},
+				TranslationDetails:     map[string]*TranslationDetail{"de": {Text: "Hallo", Offset: 200}},
+			},
+		},
+		{
+			name: "conflicting languages are last write wins",
+			base: TranslationRecognitionResult{
+				Text:                   "こんにちは",
+				Reason:                 ResultReasonTranslatedSpeech,
+				Translations:           map[string]string{"en": "Hi", "de": "Hallo"},
+				TranslationConfidences: map[string]float64{"en": 0.5},
+			},
+			other: &TranslationRecognitionResult{
+				Reason:                 ResultReasonTranslatedSpeech,
+				Translations:           map[string]string{"en": "Hello"},
+				TranslationConfidences: map[string]float64{"en": 0.9},
+			},
+			want: TranslationRecognitionResult{
+				Text:                   "こんにちは",
+				Reason:                 ResultReasonTranslatedSpeech,
+				Translations:           map[string]string{"en": "Hello", "de": "Hallo"},
+				TranslationConfidences: map[string]float64{"en": 0.9},
+			},
+		},
+		{
+			name:  "empty fields do not overwrite",
+			base:  TranslationRecognitionResult{ResultID: "1", Text: "こんにちは", Offset: 100, Duration: time.Second, TurnID: "turn", Confidence: 0.8},
+			other: &TranslationRecognitionResult{Reason: ResultReasonTranslatedSpeech},
+			want:  TranslationRecognitionResult{ResultID: "1", Text: "こんにちは", Reason: ResultReasonTranslatedSpeech, Offset: 100, Duration: time.Second, TurnID: "turn", Confidence: 0.8},
+		},
+		{
+			name:  "a translated result is not downgraded by an untranslated frame",
+			base:  TranslationRecognitionResult{Text: "こんにちは", Reason: ResultReasonTranslatedSpeech, Translations: map[string]string{"en": "Hello"}},
+			other: &TranslationRecognitionResult{Text: "こんにちは。", Reason: ResultReasonRecognizedSpeech},
+			want:  TranslationRecognitionResult{Text: "こんにちは。", Reason: ResultReasonTranslatedSpeech, Translations: map[string]string{"en": "Hello"}},
+		},
+		{
+			name:  "an untranslated result becomes translated when translations arrive",
+			base:  TranslationRecognitionResult{Text: "こんにちは", Reason: ResultReasonRecognizedSpeech},
+			other: &TranslationRecognitionResult{Reason: ResultReasonRecognizedSpeech, Translations: map[string]string{"en": "Hello"}},
+			want:  TranslationRecognitionResult{Text: "こんにちは", Reason: ResultReasonTranslatedSpeech, Translations: map[string]string{"en": "Hello"}},
+		},
+		{
+			name:  "nil is ignored",
+			base:  TranslationRecognitionResult{Text: "こんにちは", Reason: ResultReasonTranslatedSpeech},
+			other: nil,
+			want:  TranslationRecognitionResult{Text: "こんにちは", Reason: ResultReasonTranslatedSpeech},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.base
+			got.Merge(tt.other)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClock is a clock that only moves when the test advances it
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// lockedBuffer is a log destination that can be read while the recognizer is writing to it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) count(substr string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Count(b.buf.String(), substr)
+}
+
+// captureLog redirects the standard logger for the rest of the test
+func captureLog(t *testing.T) *lockedBuffer {
+	t.Helper()
+	buf := &lockedBuffer{}
+	log.SetOutput(buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return buf
+}
+
+func TestInjectableClock(t *testing.T) {
+	const chunk = 3200
+	tests := []struct {
+		name          string
+		advances      []time.Duration // time advanced before each chunk is written
+		wantLevelLogs int
+		wantStatsLogs int
+	}{
+		{name: "no time passes", advances: []time.Duration{0, 0, 0}},
+		{name: "every chunk after the level interval", advances: []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}, wantLevelLogs: 3},
+		{name: "half the level interval", advances: []time.Duration{250 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond}, wantLevelLogs: 2},
+		{name: "stats interval", advances: []time.Duration{5 * time.Second, time.Second, 4 * time.Second}, wantLevelLogs: 3, wantStatsLogs: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.nowFunc = clock.Now
+			if err := recognizer.SetChunkSize(chunk); err != nil {
+				t.Fatalf("SetChunkSize: %v", err)
+			}
+
+			sessionIDs := make(chan string, 1)
+			recognizer.SessionStarted().Connect(func(eventArgs interface{}) {
+				sessionIDs <- eventArgs.(*SessionEventArgs).SessionID
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+			waitFor(t, "the recognition loop", func() bool { return logs.count("Starting continuous recognition loop") == 1 })
+			start := clock.Now()
+			if sessionID, want := <-sessionIDs, fmt.Sprintf("session_%d", start.UnixNano()); sessionID != want {
+				t.Errorf("session id = %q, want %q from the fake clock", sessionID, want)
+			}
+
+			for i, d := range tt.advances {
+				clock.Advance(d)
+				stream.Write(make([]byte, chunk))
+				want := int64(chunk * (i + 1))
+				waitFor(t, "the chunk to be sent", func() bool { return service.audioBytes.Load() >= want })
+			}
+
+			if got := logs.count("Microphone audio level"); got != tt.wantLevelLogs {
+				t.Errorf("audio level logs = %d, want %d", got, tt.wantLevelLogs)
+			}
+			if got := logs.count("[STATS]"); got != tt.wantStatsLogs {
+				t.Errorf("stats logs = %d, want %d", got, tt.wantStatsLogs)
+			}
+
+			// speech.config の X-Timestamp も同じ時計を使う
+			wantTimestamp := "X-Timestamp: " + start.Add(tt.advances[0]).UTC().Format(time.RFC3339)
+			found := false
+			for _, m := range fc.received() {
+				if strings.HasPrefix(string(m.data), "Path: speech.config\r\n") {
+					found = strings.Contains(string(m.data), wantTimestamp)
+					break
+				}
+			}
+			if !found {
+				t.Errorf("speech.config does not carry %q", wantTimestamp)
+			}
+		})
+	}
+}
+
+func TestRecognizedButUntranslatedFinal(t *testing.T) {
+	tests := []struct {
+		name       string
+		phrase     string
+		wantReason ResultReason
+	}{
+		{name: "translated", phrase: `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`, wantReason: ResultReasonTranslatedSpeech},
+		{name: "no translations", phrase: `{"type":"final","NBest":[{"Display":"こんにちは"}]}`, wantReason: ResultReasonRecognizedSpeech},
+		{name: "empty translations", phrase: `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{}}`, wantReason: ResultReasonRecognizedSpeech},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			results := make(chan *TranslationRecognitionResult, 1)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				results <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			if err := service.waitForConn(t).send("speech.phrase", tt.phrase); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			select {
+			case result := <-results:
+				if result.Reason != tt.wantReason || result.Text != "こんにちは" {
+					t.Errorf("result = (%v, %q), want (%v, %q)", result.Reason, result.Text, tt.wantReason, "こんにちは")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the final result")
+			}
+		})
+	}
+}
+
+func TestRecognizerState(t *testing.T) {
+	tests := []struct {
+		name     string
+		buffered int  // audio written before starting
+		start    bool // start continuous recognition
+		results  int  // final results sent by the service
+		restart  bool // stop and start again immediately
+		want     RecognizerState
+	}{
+		{name: "idle with buffered audio", buffered: 6400, want: RecognizerState{BufferedAudioBytes: 6400}},
+		{name: "started without results", start: true, want: RecognizerState{Running: true, Connected: true}},
+		{name: "started with results", start: true, results: 2, want: RecognizerState{Running: true, Connected: true}},
+		{name: "restarted", start: true, restart: true, want: RecognizerState{Running: true, Connected: true, Reconnects: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			recognizer.nowFunc = clock.Now
+			if tt.buffered > 0 {
+				stream.Write(make([]byte, tt.buffered))
+			}
+
+			if tt.start {
+				if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+					t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+				}
+				defer recognizer.StopContinuousRecognition()
+				fc := service.waitForConn(t)
+
+				results := make(chan struct{}, tt.results)
+				recognizer.Recognized().Connect(func(interface{}) { results <- struct{}{} })
+				for i := 0; i < tt.results; i++ {
+					clock.Advance(time.Second)
+					if err := fc.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"}); err != nil {
+						t.Fatalf("sendFinalPhrase: %v", err)
+					}
+					select {
+					case <-results:
+					case <-time.After(5 * time.Second):
+						t.Fatal("timed out waiting for a result")
+					}
+				}
+				if tt.results > 0 {
+					tt.want.LastResultAt = clock.Now()
+				}
+
+				if tt.restart {
+					recognizer.StopContinuousRecognition()
+					if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+						t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+					}
+					service.waitForConn(t)
+					// 古い接続のワーカーが終了しても新しい接続の状態は変わらない
+					select {
+					case <-fc.closed:
+					case <-time.After(5 * time.Second):
+						t.Fatal("timed out waiting for the first connection to close")
+					}
+				}
+				waitFor(t, "the connection to be reported", func() bool {
+					state := recognizer.State()
+					return state.Connected && state.Reconnects == tt.want.Reconnects
+				})
+			}
+
+			if got := recognizer.State(); got != tt.want {
+				t.Errorf("State() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// pcmTone returns n bytes of 16-bit PCM with every sample at the given amplitude, alternating in sign
+func pcmTone(n int, amplitude int16) []byte {
+	data := make([]byte, n)
+	for i := 0; i+1 < n; i += 2 {
+		sample := amplitude
+		if (i/2)%2 == 1 {
+			sample = -amplitude
+		}
+		data[i] = byte(sample)
+		data[i+1] = byte(sample >> 8)
+	}
+	return data
+}
+
+func TestLeadingSilenceTrim(t *testing.T) {
+	const chunk = 3200 // 100ms
+	tests := []struct {
+		name     string
+		level    int
+		maxSkip  time.Duration
+		silence  int // leading silent chunks
+		speech   int // speech chunks after the silence
+		wantSent int64
+	}{
+		{name: "disabled", silence: 3, speech: 2, wantSent: 5 * chunk},
+		{name: "leading silence is trimmed", level: 5, maxSkip: time.Second, silence: 3, speech: 2, wantSent: 2 * chunk},
+		{name: "no leading silence", level: 5, maxSkip: time.Second, silence: 0, speech: 2, wantSent: 2 * chunk},
+		{name: "trimming stops at the cap", level: 5, maxSkip: 100 * time.Millisecond, silence: 3, speech: 2, wantSent: 4 * chunk},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			if err := recognizer.SetChunkSize(chunk); err != nil {
+				t.Fatalf("SetChunkSize: %v", err)
+			}
+			if err := recognizer.SetLeadingSilenceTrim(tt.level, tt.maxSkip); err != nil {
+				t.Fatalf("SetLeadingSilenceTrim: %v", err)
+			}
+			results := make(chan *TranslationRecognitionResult, 1)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				results <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+
+			for i := 0; i < tt.silence; i++ {
+				stream.Write(make([]byte, chunk))
+			}
+			for i := 0; i < tt.speech; i++ {
+				stream.Write(pcmTone(chunk, 16000))
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			waitFor(t, "the audio to be sent", func() bool { return service.audioBytes.Load() >= tt.wantSent })
+			waitFor(t, "the stream to be drained", func() bool { return stream.BufferedBytes() == 0 })
+			time.Sleep(50 * time.Millisecond)
+			if got := service.audioBytes.Load(); got != tt.wantSent {
+				t.Errorf("audio sent = %d bytes, want %d", got, tt.wantSent)
+			}
+
+			// 無音を除いた後の音声は通常どおり認識される
+			if err := fc.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"}); err != nil {
+				t.Fatalf("sendFinalPhrase: %v", err)
+			}
+			select {
+			case result := <-results:
+				if result.Translations["en"] != "Hello" {
+					t.Errorf("translation = %q, want %q", result.Translations["en"], "Hello")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the result")
+			}
+		})
+	}
+}
+
+func TestSetLeadingSilenceTrimRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   int
+		maxSkip time.Duration
+		wantErr bool
+	}{
+		{name: "disabled", level: 0, maxSkip: 0},
+		{name: "enabled", level: 5, maxSkip: time.Second},
+		{name: "negative level", level: -1, maxSkip: time.Second, wantErr: true},
+		{name: "level above 100", level: 101, maxSkip: time.Second, wantErr: true},
+		{name: "enabled without a cap", level: 5, maxSkip: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			if err := recognizer.SetLeadingSilenceTrim(tt.level, tt.maxSkip); (err != nil) != tt.wantErr {
+				t.Errorf("SetLeadingSilenceTrim(%d, %v) error = %v, want error %v", tt.level, tt.maxSkip, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAudioLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		buffer []byte
+		want   int
+	}{
+		{name: "empty", buffer: nil, want: 0},
+		{name: "single byte", buffer: []byte{0x7f}, want: 0},
+		{name: "silence", buffer: make([]byte, 3200), want: 0},
+		{name: "tone", buffer: pcmTone(3200, 16000), want: 48},
+		{name: "full scale", buffer: pcmTone(3200, 32767), want: 100},
+		{name: "most negative sample", buffer: []byte{0x00, 0x80}, want: 100},
+		{name: "trailing odd byte is ignored", buffer: append(pcmTone(4, 16000), 0x7f), want: 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			if got := audioLevel(tt.buffer); got != tt.want {
+				t.Errorf("audioLevel = %d, want %d", got, tt.want)
+			}
+			// チャンクごとに呼ばれるため、ログは出力しない
+			if n := logs.count("\n"); n != 0 {
+				t.Errorf("audioLevel wrote %d log lines, want none", n)
+			}
+		})
+	}
+}
+
+func TestSessionStoppedAudioDuration(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunks       int // 3200-byte chunks, 100ms each at 16kHz 16-bit mono
+		endOfStream  bool
+		wantDuration time.Duration
+	}{
+		{name: "stop after one chunk", chunks: 1, wantDuration: 100 * time.Millisecond},
+		{name: "stop after one second", chunks: 10, wantDuration: time.Second},
+		{name: "end of stream", chunks: 5, endOfStream: true, wantDuration: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			stopped := make(chan *SessionEventArgs, 1)
+			recognizer.SessionStopped().Connect(func(eventArgs interface{}) {
+				stopped <- eventArgs.(*SessionEventArgs)
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			for i := 0; i < tt.chunks; i++ {
+				if _, err := stream.Write(make([]byte, 3200)); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			waitFor(t, "all audio to be sent", func() bool {
+				sent := 0
+				for _, m := range fc.received() {
+					if m.messageType == websocket.BinaryMessage {
+						sent++
+					}
+				}
+				return sent == tt.chunks
+			})
+			if tt.endOfStream {
+				stream.Close()
+			} else if err := recognizer.StopContinuousRecognition(); err != nil {
+				t.Fatalf("StopContinuousRecognition: %v", err)
+			}
+
+			select {
+			case args := <-stopped:
+				if args.AudioDuration != tt.wantDuration {
+					t.Errorf("AudioDuration = %v, want %v", args.AudioDuration, tt.wantDuration)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for SessionStopped")
+			}
+		})
+	}
+}
+
+func TestFinalPhraseTiming(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name         string
+		phrase       string
+		wantOffset   int64
+		wantDuration time.Duration
+	}{
+		{
+			name:         "service timing in 100ns ticks",
+			phrase:       `{"type":"final","Offset":15000000,"Duration":23500000,"NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`,
+			wantOffset:   int64(1500 * time.Millisecond),
+			wantDuration: 2350 * time.Millisecond,
+		},
+		{
+			name:         "offset zero at the start of the stream",
+			phrase:       `{"type":"final","Offset":0,"Duration":5000000,"NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`,
+			wantOffset:   0,
+			wantDuration: 500 * time.Millisecond,
+		},
+		{
+			name:         "no timing falls back to the clock and one second",
+			phrase:       `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`,
+			wantOffset:   clock.Now().UnixNano(),
+			wantDuration: time.Second,
+		},
+		{
+			name:         "only the duration",
+			phrase:       `{"type":"final","Duration":20000000,"NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`,
+			wantOffset:   clock.Now().UnixNano(),
+			wantDuration: 2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizer.nowFunc = clock.Now
+			results := make(chan *TranslationRecognitionResult, 1)
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				results <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			if err := service.waitForConn(t).send("speech.phrase", tt.phrase); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			select {
+			case result := <-results:
+				if result.Offset != tt.wantOffset || result.Duration != tt.wantDuration {
+					t.Errorf("timing = (%d, %v), want (%d, %v)", result.Offset, result.Duration, tt.wantOffset, tt.wantDuration)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the final result")
+			}
+		})
+	}
+}
+
+func TestHypothesisAndFinalEvents(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		body            string
+		wantRecognizing bool
+		wantReason      ResultReason
+		wantText        string
+		wantTranslation string
+	}{
+		{
+			name:            "speech hypothesis",
+			path:            "speech.hypothesis",
+			body:            `{"Text":"こんに","Offset":1000000,"Duration":2000000}`,
+			wantRecognizing: true,
+			wantReason:      ResultReasonTranslatingSpeech,
+			wantText:        "こんに",
+		},
+		{
+			name:            "translation hypothesis",
+			path:            "translation.hypothesis",
+			body:            `{"Text":"こんにち","Translations":{"en":"Hell"}}`,
+			wantRecognizing: true,
+			wantReason:      ResultReasonTranslatingSpeech,
+			wantText:        "こんにち",
+			wantTranslation: "Hell",
+		},
+		{
+			name:            "final phrase",
+			path:            "speech.phrase",
+			body:            `{"type":"final","NBest":[{"Display":"こんにちは"}],"Translations":{"en":"Hello"}}`,
+			wantReason:      ResultReasonTranslatedSpeech,
+			wantText:        "こんにちは",
+			wantTranslation: "Hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			recognizing := make(chan *TranslationRecognitionResult, 1)
+			recognized := make(chan *TranslationRecognitionResult, 1)
+			recognizer.Recognizing().Connect(func(eventArgs interface{}) {
+				recognizing <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			if err := service.waitForConn(t).send(tt.path, tt.body); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			expected, unexpected := recognized, recognizing
+			if tt.wantRecognizing {
+				expected, unexpected = recognizing, recognized
+			}
+			select {
+			case result := <-expected:
+				if result.Reason != tt.wantReason || result.IsFinal() == tt.wantRecognizing {
+					t.Errorf("result reason = %v (final %v), want %v", result.Reason, result.IsFinal(), tt.wantReason)
+				}
+				if result.Text != tt.wantText || result.Translations["en"] != tt.wantTranslation {
+					t.Errorf("result = (%q, %q), want (%q, %q)", result.Text, result.Translations["en"], tt.wantText, tt.wantTranslation)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the result")
+			}
+			select {
+			case result := <-unexpected:
+				t.Errorf("the result was also raised through the other event: %+v", result)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestReconnectRaisesSessionEventsOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		startFirst bool
+		reconnects int
+	}{
+		{name: "no reconnect", startFirst: true},
+		{name: "one reconnect", startFirst: true, reconnects: 1},
+		{name: "several reconnects", startFirst: true, reconnects: 3},
+		{name: "reconnect without a running session starts one", reconnects: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			var started, stopped, reestablished atomic.Int32
+			recognizer.SessionStarted().Connect(func(interface{}) { started.Add(1) })
+			recognizer.SessionStopped().Connect(func(interface{}) { stopped.Add(1) })
+			recognizer.ConnectionReestablished().Connect(func(interface{}) { reestablished.Add(1) })
+
+			wantConnections, wantReestablished := int32(tt.reconnects), int32(tt.reconnects)
+			if tt.startFirst {
+				if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+					t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+				}
+				service.waitForConn(t)
+				wantConnections++
+			} else {
+				// Reconnect starts a new session when nothing is running
+				wantReestablished--
+			}
+			for i := 0; i < tt.reconnects; i++ {
+				if err := recognizer.Reconnect(context.Background()); err != nil {
+					t.Fatalf("Reconnect: %v", err)
+				}
+				service.waitForConn(t)
+			}
+			waitFor(t, "the last connection to be established", func() bool {
+				return reestablished.Load() == wantReestablished && recognizer.State().Connected
+			})
+			if err := recognizer.StopContinuousRecognition(); err != nil {
+				t.Fatalf("StopContinuousRecognition: %v", err)
+			}
+			waitFor(t, "SessionStopped", func() bool { return stopped.Load() > 0 })
+			time.Sleep(50 * time.Millisecond)
+
+			if got := service.connections.Load(); got != wantConnections {
+				t.Errorf("connections = %d, want %d", got, wantConnections)
+			}
+			if got := started.Load(); got != 1 {
+				t.Errorf("SessionStarted raised %d times, want 1", got)
+			}
+			if got := stopped.Load(); got != 1 {
+				t.Errorf("SessionStopped raised %d times, want 1", got)
+			}
+			if got := reestablished.Load(); got != wantReestablished {
+				t.Errorf("ConnectionReestablished raised %d times, want %d", got, wantReestablished)
+			}
+		})
+	}
+}
+
+func TestRecognizeOnceContext(t *testing.T) {
+	tests := []struct {
+		name string
+		// writeAudio sends audio so that RecognizeOnce waits for a result the service never sends
+		writeAudio bool
+		// interrupt ends the context passed to RecognizeOnce
+		interrupt func(cancel context.CancelFunc)
+		timeout   time.Duration
+		wantErr   error
+	}{
+		{
+			name:       "canceled during a slow receive",
+			writeAudio: true,
+			interrupt:  func(cancel context.CancelFunc) { time.AfterFunc(50*time.Millisecond, cancel) },
+			wantErr:    context.Canceled,
+		},
+		{name: "deadline during a slow receive", writeAudio: true, timeout: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{
+			name:      "canceled while waiting for audio",
+			interrupt: func(cancel context.CancelFunc) { time.AfterFunc(50*time.Millisecond, cancel) },
+			wantErr:   context.Canceled,
+		},
+		{name: "canceled before connecting", interrupt: func(cancel context.CancelFunc) { cancel() }, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			service.holdTurnEnd.Store(true)
+			recognizer, stream := newTestRecognizer(t, service)
+			canceled := make(chan *CancellationDetails, 1)
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+			})
+			if tt.writeAudio {
+				stream.Write(make([]byte, 3200))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			}
+			defer cancel()
+			if tt.interrupt != nil {
+				tt.interrupt(cancel)
+			}
+
+			start := time.Now()
+			result, err := recognizer.RecognizeOnce(ctx)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("RecognizeOnce returned after %v, want it to return promptly", elapsed)
+			}
+			if result != nil || !errors.Is(err, tt.wantErr) {
+				t.Errorf("RecognizeOnce = (%+v, %v), want error %v", result, err, tt.wantErr)
+			}
+			select {
+			case details := <-canceled:
+				if details.Reason != CancellationReasonError {
+					t.Errorf("cancellation reason = %v, want Error", details.Reason)
+				}
+			case <-time.After(time.Second):
+				t.Error("Canceled was not raised")
+			}
+		})
+	}
+}
+
+func TestConnectThroughProxy(t *testing.T) {
+	tests := []struct {
+		name      string
+		bypass    string
+		wantProxy bool
+	}{
+		{name: "connection is tunneled through the proxy", wantProxy: true},
+		{name: "bypassed host connects directly", bypass: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			var tunnels atomic.Int32
+			var proxyAuth atomic.Value
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodConnect {
+					http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+					return
+				}
+				tunnels.Add(1)
+				proxyAuth.Store(req.Header.Get("Proxy-Authorization"))
+				upstream, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+				client, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					upstream.Close()
+					return
+				}
+				client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+				go func() { io.Copy(upstream, client); upstream.Close() }()
+				io.Copy(client, upstream)
+				client.Close()
+			}))
+			t.Cleanup(proxy.Close)
+			proxyHost, proxyPort, _ := net.SplitHostPort(strings.TrimPrefix(proxy.URL, "http://"))
+			port, _ := strconv.Atoi(proxyPort)
+
+			recognizer, _ := newTestRecognizer(t, service)
+			if err := recognizer.config.SetProxy(proxyHost, port, "user", "secret"); err != nil {
+				t.Fatalf("SetProxy: %v", err)
+			}
+			if tt.bypass != "" {
+				recognizer.config.SetProxyHostBypass(tt.bypass)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			service.waitForConn(t)
+
+			if got := tunnels.Load() > 0; got != tt.wantProxy {
+				t.Errorf("connected through the proxy: %v, want %v", got, tt.wantProxy)
+			}
+			if tt.wantProxy {
+				want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+				if got, _ := proxyAuth.Load().(string); got != want {
+					t.Errorf("Proxy-Authorization = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		maxRetries  int
+		keepTalking bool // the service sends a hypothesis every 20ms
+		wantErr     bool
+		wantCancel  bool
+		wantReconn  bool
+	}{
+		{name: "silent service is reconnected", timeout: 100 * time.Millisecond, maxRetries: 3, wantReconn: true},
+		{name: "messages keep the connection", timeout: 100 * time.Millisecond, maxRetries: 3, keepTalking: true},
+		{name: "silent service without reconnects cancels", timeout: 100 * time.Millisecond, wantCancel: true},
+		{name: "disabled", maxRetries: 3},
+		{name: "negative timeout", timeout: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, _ := newTestRecognizer(t, service)
+			err := recognizer.SetReadTimeout(tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetReadTimeout(%v) error = %v, want error %v", tt.timeout, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if got := recognizer.GetReadTimeout(); got != 0 {
+					t.Errorf("GetReadTimeout() = %v after a rejected value, want 0", got)
+				}
+				return
+			}
+			if err := recognizer.config.SetReconnectPolicy(tt.maxRetries, time.Millisecond, 5*time.Millisecond); err != nil {
+				t.Fatalf("SetReconnectPolicy: %v", err)
+			}
+			var reestablished atomic.Int32
+			canceled := make(chan *CancellationDetails, 1)
+			recognizer.ConnectionReestablished().Connect(func(interface{}) { reestablished.Add(1) })
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				select {
+				case canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails:
+				default:
+				}
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+			fc := service.waitForConn(t)
+
+			if tt.keepTalking {
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					ticker := time.NewTicker(20 * time.Millisecond)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ticker.C:
+							fc.send("translation.hypothesis", `{"Text":"こん","Translations":{"en":"Hel"}}`)
+						case <-done:
+							return
+						}
+					}
+				}()
+			}
+
+			switch {
+			case tt.wantCancel:
+				select {
+				case details := <-canceled:
+					if !strings.Contains(details.ErrorDetails, ErrReadTimeout.Error()) {
+						t.Errorf("ErrorDetails = %q, want it to report the read timeout", details.ErrorDetails)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for the recognizer to cancel")
+				}
+			case tt.wantReconn:
+				service.waitForConn(t)
+				waitFor(t, "ConnectionReestablished", func() bool { return reestablished.Load() == 1 })
+			default:
+				// Several read timeouts pass without a reconnect
+				time.Sleep(500 * time.Millisecond)
+				if got := service.connections.Load(); got != 1 {
+					t.Errorf("connections = %d, want 1", got)
+				}
+				select {
+				case details := <-canceled:
+					t.Errorf("the session was canceled: %+v", details)
+				default:
+				}
+			}
+		})
+	}
+}
+
+func TestReconnectKeepsSessionState(t *testing.T) {
+	tests := []struct {
+		name       string
+		reconnects int
+	}{
+		{name: "one reconnect", reconnects: 1},
+		{name: "several reconnects", reconnects: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			defer recognizer.Close()
+			var mu sync.Mutex
+			sessionIDs := map[string]bool{}
+			var finals []string
+			record := func(id string) {
+				mu.Lock()
+				sessionIDs[id] = true
+				mu.Unlock()
+			}
+			recognizer.SessionStarted().Connect(func(eventArgs interface{}) { record(eventArgs.(*SessionEventArgs).SessionID) })
+			recognizer.ConnectionReestablished().Connect(func(eventArgs interface{}) { record(eventArgs.(*SessionEventArgs).SessionID) })
+			recognizer.Recognized().Connect(func(eventArgs interface{}) {
+				args := eventArgs.(*TranslationRecognitionEventArgs)
+				record(args.SessionID)
+				mu.Lock()
+				finals = append(finals, args.Result.Translations["en"])
+				mu.Unlock()
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+
+			fc := service.waitForConn(t)
+			want := []string{"before"}
+			fc.sendFinalPhrase("前", map[string]string{"en": "before"})
+			waitFor(t, "the first result", func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(finals) == 1
+			})
+			sessionID := recognizer.SessionID()
+
+			for i := 1; i <= tt.reconnects; i++ {
+				if err := recognizer.Reconnect(context.Background()); err != nil {
+					t.Fatalf("Reconnect %d: %v", i, err)
+				}
+				fc = service.waitForConn(t)
+
+				// The new connection sends speech.config again with the same target languages
+				stream.Write(make([]byte, 3200))
+				waitFor(t, "speech.config on the new connection", func() bool {
+					_, ok := fc.textBody("speech.config")
+					return ok
+				})
+				if config, _ := fc.textBody("speech.config"); !strings.Contains(config, `"en"`) {
+					t.Errorf("speech.config after reconnect %d = %s, want the target language en", i, config)
+				}
+
+				// Results on the new connection are delivered in the same session
+				text := fmt.Sprintf("after %d", i)
+				want = append(want, text)
+				fc.sendFinalPhrase("後", map[string]string{"en": text})
+				waitFor(t, "the result after reconnecting", func() bool {
+					mu.Lock()
+					defer mu.Unlock()
+					return len(finals) == len(want)
+				})
+			}
+
+			if got := recognizer.SessionID(); got != sessionID {
+				t.Errorf("SessionID() = %q after reconnecting, want %q", got, sessionID)
+			}
+			mu.Lock()
+			if len(sessionIDs) != 1 || !sessionIDs[sessionID] {
+				t.Errorf("events reported session IDs %v, want only %q", sessionIDs, sessionID)
+			}
+			if !reflect.DeepEqual(finals, want) {
+				t.Errorf("results = %v, want %v", finals, want)
+			}
+			mu.Unlock()
+			metrics := recognizer.Metrics().Snapshot()
+			if metrics.FinalResults != int64(len(want)) || metrics.AudioBytesSent != int64(3200*tt.reconnects) {
+				t.Errorf("metrics = %+v, want %d final results and %d audio bytes across the connections", metrics, len(want), 3200*tt.reconnects)
+			}
+
+			// A new session gets a new ID
+			if err := recognizer.StopContinuousRecognition(); err != nil {
+				t.Fatalf("StopContinuousRecognition: %v", err)
+			}
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			service.waitForConn(t)
+			if got := recognizer.SessionID(); got == sessionID {
+				t.Errorf("SessionID() = %q for a new session, want a new ID", got)
+			}
+		})
+	}
+}
+
+func TestReconnectUsesSessionContext(t *testing.T) {
+	tests := []struct {
+		name string
+		// reconnectCtx returns the context passed to Reconnect and a function that ends it afterwards
+		reconnectCtx func() (context.Context, context.CancelFunc)
+		cancelAfter  bool
+	}{
+		{name: "live context", reconnectCtx: func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}},
+		{name: "context ended before Reconnect", reconnectCtx: func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx, cancel
+		}},
+		{name: "context ended after Reconnect", reconnectCtx: func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}, cancelAfter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			var started atomic.Int32
+			recognizer.SessionStarted().Connect(func(interface{}) { started.Add(1) })
+			stopped := make(chan *SessionEventArgs, 2)
+			recognizer.SessionStopped().Connect(func(eventArgs interface{}) { stopped <- eventArgs.(*SessionEventArgs) })
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			first := service.waitForConn(t)
+
+			// 100ms of 16 kHz 16-bit mono audio on each connection
+			chunk := make([]byte, 3200)
+			stream.Write(chunk)
+			waitFor(t, "audio on the first connection", func() bool { return service.audioBytes.Load() >= 3200 })
+
+			ctx, cancel := tt.reconnectCtx()
+			if err := recognizer.Reconnect(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				t.Fatalf("Reconnect: %v", err)
+			}
+			if tt.cancelAfter {
+				cancel()
+			}
+			defer cancel()
+
+			// The new connection runs on the session context, whatever happens to ctx
+			second := service.waitForConn(t)
+			<-first.closed
+			sent := service.audioBytes.Load()
+			stream.Write(chunk)
+			waitFor(t, "audio on the second connection", func() bool { return service.audioBytes.Load() >= sent+3200 })
+			if !recognizer.IsRunning() {
+				t.Fatal("IsRunning = false after reconnecting")
+			}
+
+			stopErr := make(chan error, 1)
+			go func() { stopErr <- recognizer.StopContinuousRecognition() }()
+			select {
+			case err := <-stopErr:
+				if err != nil {
+					t.Fatalf("StopContinuousRecognition: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("StopContinuousRecognition did not return after Reconnect")
+			}
+			<-second.closed
+
+			if got := started.Load(); got != 1 {
+				t.Errorf("SessionStarted raised %d times, want 1", got)
+			}
+			select {
+			case args := <-stopped:
+				// SessionStopped covers the audio of both connections
+				if want := 200 * time.Millisecond; args.AudioDuration != want {
+					t.Errorf("SessionStopped.AudioDuration = %v, want %v", args.AudioDuration, want)
+				}
+			default:
+				t.Fatal("SessionStopped was not raised")
+			}
+			if len(stopped) != 0 {
+				t.Error("SessionStopped raised more than once")
+			}
+		})
+	}
+}
+
+func TestReconnectWithBackoff(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxRetries    int
+		failedDials   int32
+		closeCode     int // 0 drops the connection without a close frame
+		wantReconnect bool
+	}{
+		{name: "dropped connection reconnects", maxRetries: 3, wantReconnect: true},
+		{name: "dial fails twice before succeeding", maxRetries: 3, failedDials: 2, wantReconnect: true},
+		{name: "service restart reconnects", maxRetries: 3, closeCode: websocket.CloseServiceRestart, wantReconnect: true},
+		{name: "gives up after max retries", maxRetries: 3, failedDials: 3},
+		{name: "reconnection disabled", maxRetries: 0},
+		{name: "unrecoverable close code", maxRetries: 3, closeCode: websocket.ClosePolicyViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeSpeechService(t)
+			recognizer, stream := newTestRecognizer(t, service)
+			if err := recognizer.config.SetReconnectPolicy(tt.maxRetries, time.Millisecond, 5*time.Millisecond); err != nil {
+				t.Fatalf("SetReconnectPolicy: %v", err)
+			}
+			var started, stopped, reestablished atomic.Int32
+			canceled := make(chan *CancellationDetails, 1)
+			recognizer.SessionStarted().Connect(func(interface{}) { started.Add(1) })
+			recognizer.SessionStopped().Connect(func(interface{}) { stopped.Add(1) })
+			recognizer.ConnectionReestablished().Connect(func(interface{}) { reestablished.Add(1) })
+			recognizer.Canceled().Connect(func(eventArgs interface{}) {
+				canceled <- eventArgs.(*TranslationRecognitionCanceledEventArgs).CancellationDetails
+			})
+			if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+				t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+			}
+			defer recognizer.StopContinuousRecognition()
+
+			fc := service.waitForConn(t)
+			service.rejectNext.Store(tt.failedDials)
+			if tt.closeCode == 0 {
+				fc.conn.UnderlyingConn().Close()
+			} else {
+				fc.writeMu.Lock()
+				fc.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(tt.closeCode, "closing"))
+				fc.writeMu.Unlock()
+			}
+
+			if !tt.wantReconnect {
+				select {
+				case <-canceled:
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for the recognizer to cancel")
+				}
+				if got := service.connections.Load(); got != 1 {
+					t.Errorf("connections = %d, want 1", got)
+				}
+				if got := reestablished.Load(); got != 0 {
+					t.Errorf("ConnectionReestablished raised %d times, want 0", got)
+				}
+				return
+			}
+
+			// Audio written after the reconnect goes to the new connection of the same session
+			next := service.waitForConn(t)
+			waitFor(t, "ConnectionReestablished", func() bool { return reestablished.Load() == 1 })
+			if _, err := stream.Write(make([]byte, 3200)); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			waitFor(t, "audio on the new connection", func() bool {
+				for _, m := range next.received() {
+					if m.messageType == websocket.BinaryMessage {
+						return true
+					}
+				}
+				return false
+			})
+			select {
+			case details := <-canceled:
+				t.Errorf("the session was canceled: %+v", details)
+			default:
+			}
+			if got := service.connections.Load(); got != 2 {
+				t.Errorf("connections = %d, want 2", got)
+			}
+			if started.Load() != 1 || stopped.Load() != 0 {
+				t.Errorf("SessionStarted/Stopped raised %d/%d times, want 1/0", started.Load(), stopped.Load())
+			}
+		})
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 400 * time.Millisecond},
+		{attempt: 4, want: 500 * time.Millisecond},
+		{attempt: 10, want: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := reconnectDelay(100*time.Millisecond, 500*time.Millisecond, tt.attempt); got != tt.want {
+			t.Errorf("reconnectDelay(attempt %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// MaxURLAudioSize is the largest audio resource, in bytes, that NewAudioConfigFromURL streams
+var MaxURLAudioSize int64 = 512 << 20
+
+// maxURLResumes is how many times a dropped download is resumed with a range request
+const maxURLResumes = 3
+
+// Errors returned when streaming audio from a URL
+var (
+	ErrUnsupportedURLScheme = errors.New("audio URL must use http or https")
+	ErrAudioTooLarge        = errors.New("audio resource exceeds the maximum size")
+	ErrUnsupportedAudioType = errors.New("unsupported audio content type")
+)
+
+// urlAudioReader streams the audio data of an HTTP(S) resource. When the server supports
+// range requests, a download that drops midway is resumed from the last byte read.
+type urlAudioReader struct {
+	ctx          context.Context
+	client       *http.Client
+	url          string
+	body         io.ReadCloser
+	contentType  string
+	offset       int64 // bytes of the resource read so far
+	size         int64 // resource size, -1 if unknown
+	acceptRanges bool
+	resumes      int
+}
+
+// NewAudioConfigFromURL creates an audio config that streams a WAV or raw PCM resource over HTTP(S).
+// The format is taken from the WAV header, or from the rate and channels parameters of an
+// audio/pcm content type. Canceling ctx aborts the download.
+func NewAudioConfigFromURL(ctx context.Context, audioURL string) (*AudioConfig, error) {
+	u, err := url.Parse(audioURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audio URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedURLScheme
+	}
+	if u.Host == "" {
+		return nil, errors.New("audio URL has no host")
+	}
+
+	reader := &urlAudioReader{ctx: ctx, client: http.DefaultClient, url: u.String(), size: -1}
+	if err := reader.open(0); err != nil {
+		return nil, err
+	}
+
+	format, err := reader.detectFormat()
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &AudioConfig{
+		format:     format,
+		sourceType: "URL",
+		source:     reader,
+	}, nil
+}
+
+// open requests the resource from the given offset
+func (r *urlAudioReader) open(offset int64) error {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch audio: %w", err)
+	}
+
+	switch {
+	case offset == 0 && resp.StatusCode == http.StatusOK:
+		if resp.ContentLength > MaxURLAudioSize {
+			resp.Body.Close()
+			return ErrAudioTooLarge
+		}
+		r.size = resp.ContentLength
+		r.contentType = resp.Header.Get("Content-Type")
+		r.acceptRanges = resp.Header.Get("Accept-Ranges") == "bytes"
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("failed to fetch audio: %s", resp.Status)
+	}
+
+	r.body = resp.Body
+	return nil
+}
+
+// detectFormat determines the audio format from the content type, reading the WAV header if needed
+func (r *urlAudioReader) detectFormat() (*AudioStreamFormat, error) {
+	mediaType, params, err := mime.ParseMediaType(r.contentType)
+	if err != nil {
+		// Content-Type が無い（または不正な）場合はWAVとして扱う
+		mediaType = ""
+	}
+
+	switch mediaType {
+	case "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave", "", "application/octet-stream", "binary/octet-stream":
+		format, err := readWAVHeader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse WAV header: %v", err)
+		}
+		return format, nil
+	case "audio/pcm":
+		samplesPerSecond := 16000
+		if rate, err := strconv.Atoi(params["rate"]); err == nil && rate > 0 {
+			samplesPerSecond = rate
+		}
+		channels := 1
+		if n, err := strconv.Atoi(params["channels"]); err == nil && n > 0 {
+			channels = n
+		}
+		return NewAudioStreamFormat(samplesPerSecond, 16, channels), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAudioType, r.contentType)
+	}
+}
+
+// Read implements io.Reader, resuming the download with a range request if the connection drops
+func (r *urlAudioReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if r.offset > MaxURLAudioSize {
+		return n, ErrAudioTooLarge
+	}
+	if err == nil || (err == io.EOF && (r.size < 0 || r.offset >= r.size)) {
+		return n, err
+	}
+
+	// 途中で切断された場合は Range リクエストで続きから再開する
+	if ctxErr := r.ctx.Err(); ctxErr != nil {
+		return n, ctxErr
+	}
+	if !r.acceptRanges || r.resumes >= maxURLResumes {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	r.resumes++
+	r.body.Close()
+	if err := r.open(r.offset); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// Close closes the HTTP response body
+func (r *urlAudioReader) Close() error {
+	return r.body.Close()
+}
+
+// readWAVHeader reads the RIFF chunks of a WAV stream up to the start of its data chunk
+func readWAVHeader(r io.Reader) (*AudioStreamFormat, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE stream")
+	}
+
+	var format *AudioStreamFormat
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("data chunk not found: %v", err)
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		// Chunks are padded to an even size
+		skip := size + size%2
+
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < int64(len(fmtChunk)) {
+				return nil, errors.New("fmt chunk too short")
+			}
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return nil, err
+			}
+			// 1 is PCM; 0xFFFE (extensible) is also used by many recorders for PCM
+			if audioFormat := binary.LittleEndian.Uint16(fmtChunk[0:2]); audioFormat != 1 && audioFormat != 0xFFFE {
+				return nil, fmt.Errorf("only PCM WAV is supported (format tag %d)", audioFormat)
+			}
+			channels := int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			samplesPerSecond := int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bitsPerSample := int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			format = NewAudioStreamFormat(samplesPerSecond, bitsPerSample, channels)
+			skip -= int64(len(fmtChunk))
+		case "data":
+			if format == nil {
+				return nil, errors.New("data chunk precedes fmt chunk")
+			}
+			return format, nil
+		}
+
+		if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+			return nil, err
+		}
+	}
+}
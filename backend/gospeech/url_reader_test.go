@@ -0,0 +1,236 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testWAVBytes returns a 16kHz 16-bit mono WAV file containing pcm
+func testWAVBytes(pcm []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(pcm)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, uint16(1))
+	binary.Write(&b, binary.LittleEndian, uint16(1))
+	binary.Write(&b, binary.LittleEndian, uint32(16000))
+	binary.Write(&b, binary.LittleEndian, uint32(32000))
+	binary.Write(&b, binary.LittleEndian, uint16(2))
+	binary.Write(&b, binary.LittleEndian, uint16(16))
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+func TestNewAudioConfigFromURL(t *testing.T) {
+	pcm := make([]byte, 6400)
+	for i := range pcm {
+		pcm[i] = byte(i)
+	}
+	wav := testWAVBytes(pcm)
+
+	// server writes body with the content type; a dropAfter > 0 aborts the first response after
+	// that many bytes, ranges enables range requests and chunked omits the Content-Length
+	type server struct {
+		contentType string
+		body        []byte
+		status      int
+		dropAfter   int
+		ranges      bool
+		chunked     bool
+	}
+	tests := []struct {
+		name        string
+		server      *server
+		url         string
+		maxSize     int64
+		cancel      bool
+		wantFormat  [3]int // samples per second, bits per sample, channels
+		wantData    []byte
+		wantErr     error
+		wantErrText string
+		wantReadErr error
+	}{
+		{name: "wav", server: &server{contentType: "audio/wav", body: wav}, wantFormat: [3]int{16000, 16, 1}, wantData: pcm},
+		{name: "wav without a content type", server: &server{contentType: "application/octet-stream", body: wav}, wantFormat: [3]int{16000, 16, 1}, wantData: pcm},
+		{name: "raw pcm with parameters", server: &server{contentType: "audio/pcm; rate=8000; channels=2", body: pcm}, wantFormat: [3]int{8000, 16, 2}, wantData: pcm},
+		{name: "dropped download is resumed with a range request", server: &server{contentType: "audio/wav", body: wav, dropAfter: 3000, ranges: true}, wantFormat: [3]int{16000, 16, 1}, wantData: pcm},
+		{name: "dropped download without range support", server: &server{contentType: "audio/wav", body: wav, dropAfter: 3000}, wantFormat: [3]int{16000, 16, 1}, wantReadErr: io.ErrUnexpectedEOF},
+		{name: "unsupported content type", server: &server{contentType: "text/html", body: []byte("<html></html>")}, wantErr: ErrUnsupportedAudioType},
+		{name: "not found", server: &server{status: http.StatusNotFound}, wantErrText: "404"},
+		{name: "declared size too large", server: &server{contentType: "audio/wav", body: wav}, maxSize: 1024, wantErr: ErrAudioTooLarge},
+		{name: "streamed size too large", server: &server{contentType: "audio/wav", body: wav, chunked: true}, maxSize: 1024, wantFormat: [3]int{16000, 16, 1}, wantReadErr: ErrAudioTooLarge},
+		{name: "not a WAV file", server: &server{contentType: "audio/wav", body: []byte("not a wav file at all")}, wantErrText: "failed to parse WAV header"},
+		{name: "canceled", server: &server{contentType: "audio/wav", body: wav}, cancel: true, wantErr: context.Canceled},
+		{name: "unsupported scheme", url: "ftp://example.com/audio.wav", wantErr: ErrUnsupportedURLScheme},
+		{name: "no host", url: "http:///audio.wav", wantErrText: "no host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxSize > 0 {
+				previous := MaxURLAudioSize
+				MaxURLAudioSize = tt.maxSize
+				t.Cleanup(func() { MaxURLAudioSize = previous })
+			}
+
+			audioURL := tt.url
+			if tt.server != nil {
+				s := tt.server
+				dropped := false
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					if s.status != 0 {
+						http.Error(w, http.StatusText(s.status), s.status)
+						return
+					}
+					w.Header().Set("Content-Type", s.contentType)
+					if s.dropAfter > 0 && !dropped {
+						dropped = true
+						if s.ranges {
+							w.Header().Set("Accept-Ranges", "bytes")
+						}
+						w.Header().Set("Content-Length", strconv.Itoa(len(s.body)))
+						w.Write(s.body[:s.dropAfter])
+						panic(http.ErrAbortHandler)
+					}
+					if s.ranges {
+						http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(s.body))
+						return
+					}
+					if s.chunked {
+						w.(http.Flusher).Flush()
+					} else {
+						w.Header().Set("Content-Length", strconv.Itoa(len(s.body)))
+					}
+					w.Write(s.body)
+				}))
+				t.Cleanup(ts.Close)
+				audioURL = ts.URL + "/audio"
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			config, err := NewAudioConfigFromURL(ctx, audioURL)
+			if tt.wantErr != nil || tt.wantErrText != "" {
+				if err == nil {
+					config.Close()
+					t.Fatal("NewAudioConfigFromURL succeeded, want an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewAudioConfigFromURL: %v", err)
+			}
+			defer config.Close()
+
+			format := config.Format()
+			if got := [3]int{format.SamplesPerSecond(), format.BitsPerSample(), format.Channels()}; got != tt.wantFormat {
+				t.Errorf("format = %v, want %v", got, tt.wantFormat)
+			}
+			if got := config.SourceType(); got != "URL" {
+				t.Errorf("SourceType() = %q, want URL", got)
+			}
+
+			data, err := io.ReadAll(config.Source().(io.Reader))
+			if tt.wantReadErr != nil {
+				if !errors.Is(err, tt.wantReadErr) {
+					t.Errorf("read error = %v, want %v", err, tt.wantReadErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !bytes.Equal(data, tt.wantData) {
+				t.Errorf("read %d bytes that differ from the %d expected", len(data), len(tt.wantData))
+			}
+		})
+	}
+}
+
+func TestRecognitionFromURL(t *testing.T) {
+	pcm := make([]byte, 6400)
+	// The response stays open until the result arrives, so the session cannot end first
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write(testWAVBytes(pcm))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	service := newFakeSpeechService(t)
+	service.onMessage = func(conn *fakeServiceConn, messageType int, message []byte) {
+		if messageType == websocket.BinaryMessage && len(message) > 0 {
+			conn.sendFinalPhrase("こんにちは", map[string]string{"en": "Hello"})
+		}
+	}
+	audioConfig, err := NewAudioConfigFromURL(context.Background(), ts.URL+"/audio.wav")
+	if err != nil {
+		t.Fatalf("NewAudioConfigFromURL: %v", err)
+	}
+	recognizer := newTestRecognizerWithAudio(t, service, audioConfig)
+	defer recognizer.Close()
+
+	recognized := make(chan string, 10)
+	stopped := make(chan time.Duration, 1)
+	recognizer.Recognized().Connect(func(eventArgs interface{}) {
+		recognized <- eventArgs.(*TranslationRecognitionEventArgs).Result.Translations["en"]
+	})
+	recognizer.SessionStopped().Connect(func(eventArgs interface{}) {
+		stopped <- eventArgs.(*SessionEventArgs).AudioDuration
+	})
+	if err := recognizer.StartContinuousRecognitionAsync(context.Background()); err != nil {
+		t.Fatalf("StartContinuousRecognitionAsync: %v", err)
+	}
+	defer recognizer.StopContinuousRecognition()
+
+	select {
+	case got := <-recognized:
+		if got != "Hello" {
+			t.Errorf("translation = %q, want %q", got, "Hello")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the recognized result")
+	}
+	close(release)
+	// The whole resource is sent before the session ends at the end of the stream
+	select {
+	case got := <-stopped:
+		if want := 200 * time.Millisecond; got != want {
+			t.Errorf("AudioDuration = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the end of the stream")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultUtteranceGracePeriod is how long an utterance waits for missing target translations
+const DefaultUtteranceGracePeriod = 500 * time.Millisecond
+
+// utteranceAggregator merges final results for the same utterance that carry different target
+// translations, and emits the merged result once all targets are present or the grace period
+// elapses. Finals are considered the same utterance while their source text is unchanged.
+type utteranceAggregator struct {
+	mu      sync.Mutex
+	targets []string
+	grace   time.Duration
+	emit    func(*TranslationRecognitionResult)
+	timer   *time.Timer
+	pending *TranslationRecognitionResult
+}
+
+// newUtteranceAggregator creates an aggregator for the given (normalized) target languages
+func newUtteranceAggregator(targets []string, grace time.Duration, emit func(*TranslationRecognitionResult)) *utteranceAggregator {
+	return &utteranceAggregator{targets: targets, grace: grace, emit: emit}
+}
+
+// add merges a final result into the current utterance
+func (a *utteranceAggregator) add(result *TranslationRecognitionResult) {
+	a.mu.Lock()
+
+	var flushed *TranslationRecognitionResult
+	if a.pending != nil && a.pending.Text != result.Text {
+		flushed = a.takeLocked()
+	}
+
+	if a.pending == nil {
+		merged := &TranslationRecognitionResult{Translations: make(map[string]string)}
+		a.pending = merged
+		a.timer = time.AfterFunc(a.grace, func() { a.expire(merged) })
+	}
+	a.pending.Merge(result)
+
+	var complete *TranslationRecognitionResult
+	if a.completeLocked() {
+		complete = a.takeLocked()
+	}
+	a.mu.Unlock()
+
+	if flushed != nil {
+		a.emit(flushed)
+	}
+	if complete != nil {
+		a.emit(complete)
+	}
+}
+
+// completeLocked reports whether every target language has a translation
+func (a *utteranceAggregator) completeLocked() bool {
+	for _, lang := range a.targets {
+		if _, ok := a.pending.Translations[lang]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// takeLocked removes and returns the pending utterance
+func (a *utteranceAggregator) takeLocked() *TranslationRecognitionResult {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	result := a.pending
+	a.pending = nil
+	return result
+}
+
+// expire emits the utterance when its grace period elapses, unless it was already emitted
+func (a *utteranceAggregator) expire(utterance *TranslationRecognitionResult) {
+	a.mu.Lock()
+	if a.pending != utterance {
+		a.mu.Unlock()
+		return
+	}
+	result := a.takeLocked()
+	a.mu.Unlock()
+
+	a.emit(result)
+}
+
+// flush emits the pending utterance, if any, with the translations received so far
+func (a *utteranceAggregator) flush() {
+	a.mu.Lock()
+	var result *TranslationRecognitionResult
+	if a.pending != nil {
+		result = a.takeLocked()
+	}
+	a.mu.Unlock()
+
+	if result != nil {
+		a.emit(result)
+	}
+}
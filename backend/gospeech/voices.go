@@ -0,0 +1,147 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Voice describes a synthesis voice and the locale it speaks
+type Voice struct {
+	Name   string   // Full voice name, e.g. "en-US-JennyNeural"
+	Locale string   // BCP-47 locale of the voice, e.g. "en-US"
+	Gender string   // "Female" or "Male"
+	Styles []string // Speaking styles supported by the voice, if any
+}
+
+// knownVoices is the list of neural voices available for translation output
+var knownVoices = []Voice{
+	{Name: "ja-JP-NanamiNeural", Locale: "ja-JP", Gender: "Female", Styles: []string{"chat", "customerservice", "cheerful"}},
+	{Name: "ja-JP-KeitaNeural", Locale: "ja-JP", Gender: "Male"},
+	{Name: "en-US-JennyNeural", Locale: "en-US", Gender: "Female", Styles: []string{"assistant", "chat", "customerservice", "newscast", "angry", "cheerful", "sad", "excited", "friendly", "terrified", "shouting", "unfriendly", "whispering", "hopeful"}},
+	{Name: "en-US-GuyNeural", Locale: "en-US", Gender: "Male", Styles: []string{"newscast", "angry", "cheerful", "sad", "excited", "friendly", "terrified", "shouting", "unfriendly", "whispering", "hopeful"}},
+	{Name: "en-US-AriaNeural", Locale: "en-US", Gender: "Female", Styles: []string{"chat", "customerservice", "narration-professional", "newscast-casual", "newscast-formal", "cheerful", "empathetic", "angry", "sad", "excited", "friendly", "terrified", "shouting", "unfriendly", "whispering", "hopeful"}},
+	{Name: "en-GB-SoniaNeural", Locale: "en-GB", Gender: "Female", Styles: []string{"cheerful", "sad"}},
+	{Name: "en-GB-RyanNeural", Locale: "en-GB", Gender: "Male", Styles: []string{"cheerful", "chat", "whispering", "sad"}},
+	{Name: "zh-CN-XiaoxiaoNeural", Locale: "zh-CN", Gender: "Female", Styles: []string{"assistant", "chat", "customerservice", "newscast", "affectionate", "angry", "calm", "cheerful", "disgruntled", "fearful", "gentle", "lyrical", "sad", "serious", "poetry-reading"}},
+	{Name: "zh-CN-YunxiNeural", Locale: "zh-CN", Gender: "Male", Styles: []string{"narration-relaxed", "embarrassed", "fearful", "cheerful", "disgruntled", "serious", "angry", "sad", "depressed", "chat", "assistant", "newscast"}},
+	{Name: "ko-KR-SunHiNeural", Locale: "ko-KR", Gender: "Female"},
+	{Name: "ko-KR-InJoonNeural", Locale: "ko-KR", Gender: "Male"},
+	{Name: "es-ES-ElviraNeural", Locale: "es-ES", Gender: "Female"},
+	{Name: "es-ES-AlvaroNeural", Locale: "es-ES", Gender: "Male"},
+	{Name: "fr-FR-DeniseNeural", Locale: "fr-FR", Gender: "Female", Styles: []string{"cheerful", "sad"}},
+	{Name: "fr-FR-HenriNeural", Locale: "fr-FR", Gender: "Male", Styles: []string{"cheerful", "sad"}},
+	{Name: "de-DE-KatjaNeural", Locale: "de-DE", Gender: "Female"},
+	{Name: "de-DE-ConradNeural", Locale: "de-DE", Gender: "Male", Styles: []string{"cheerful"}},
+	{Name: "it-IT-ElsaNeural", Locale: "it-IT", Gender: "Female"},
+	{Name: "it-IT-DiegoNeural", Locale: "it-IT", Gender: "Male"},
+	{Name: "pt-BR-FranciscaNeural", Locale: "pt-BR", Gender: "Female", Styles: []string{"calm"}},
+	{Name: "pt-BR-AntonioNeural", Locale: "pt-BR", Gender: "Male"},
+	{Name: "ru-RU-SvetlanaNeural", Locale: "ru-RU", Gender: "Female"},
+	{Name: "ru-RU-DmitryNeural", Locale: "ru-RU", Gender: "Male"},
+	{Name: "ar-SA-ZariyahNeural", Locale: "ar-SA", Gender: "Female"},
+	{Name: "ar-SA-HamedNeural", Locale: "ar-SA", Gender: "Male"},
+	{Name: "hi-IN-SwaraNeural", Locale: "hi-IN", Gender: "Female"},
+	{Name: "hi-IN-MadhurNeural", Locale: "hi-IN", Gender: "Male"},
+	{Name: "th-TH-PremwadeeNeural", Locale: "th-TH", Gender: "Female"},
+	{Name: "th-TH-NiwatNeural", Locale: "th-TH", Gender: "Male"},
+	{Name: "vi-VN-HoaiMyNeural", Locale: "vi-VN", Gender: "Female"},
+	{Name: "vi-VN-NamMinhNeural", Locale: "vi-VN", Gender: "Male"},
+	{Name: "id-ID-GadisNeural", Locale: "id-ID", Gender: "Female"},
+	{Name: "id-ID-ArdiNeural", Locale: "id-ID", Gender: "Male"},
+	{Name: "ms-MY-YasminNeural", Locale: "ms-MY", Gender: "Female"},
+	{Name: "ms-MY-OsmanNeural", Locale: "ms-MY", Gender: "Male"},
+}
+
+// KnownVoices returns the list of voices known to this SDK
+func KnownVoices() []Voice {
+	voices := make([]Voice, len(knownVoices))
+	copy(voices, knownVoices)
+	return voices
+}
+
+// LookupVoice finds a known voice by name (case-insensitive)
+func LookupVoice(name string) (Voice, bool) {
+	for _, voice := range knownVoices {
+		if strings.EqualFold(voice.Name, name) {
+			return voice, true
+		}
+	}
+	return Voice{}, false
+}
+
+// Language returns the base language code of the voice locale, e.g. "en" for "en-US"
+func (v Voice) Language() string {
+	return normalizeLanguageCode(v.Locale, false)
+}
+
+// SupportsStyle reports whether the voice supports the speaking style (case-insensitive)
+func (v Voice) SupportsStyle(style string) bool {
+	for _, supported := range v.Styles {
+		if strings.EqualFold(supported, style) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildVoiceSSML builds an SSML document speaking text with the voice, in the style if not empty
+func buildVoiceSSML(voiceName, style, text string) (string, error) {
+	var escaped, escapedName bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(text)); err != nil {
+		return "", err
+	}
+	if err := xml.EscapeText(&escapedName, []byte(voiceName)); err != nil {
+		return "", err
+	}
+
+	// ロケールは音声名の先頭（例: en-US-JennyNeural → en-US）
+	locale := escapedName.String()
+	if parts := strings.SplitN(locale, "-", 3); len(parts) == 3 {
+		locale = parts[0] + "-" + parts[1]
+	}
+
+	content := escaped.String()
+	if style != "" {
+		content = fmt.Sprintf(`<mstts:express-as style="%s">%s</mstts:express-as>`, style, content)
+	}
+	return fmt.Sprintf(`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xmlns:mstts="https://www.w3.org/2001/mstts" xml:lang="%s"><voice name="%s">%s</voice></speak>`,
+		locale, escapedName.String(), content), nil
+}
+
+// resolveVoice fills in the locale of a voice from the known voices list and checks
+// that the name and locale agree
+func resolveVoice(voice Voice) (Voice, error) {
+	if voice.Name == "" {
+		return Voice{}, fmt.Errorf("voice name cannot be empty")
+	}
+
+	known, ok := LookupVoice(voice.Name)
+	if ok {
+		if voice.Locale != "" && !strings.EqualFold(voice.Locale, known.Locale) {
+			return Voice{}, fmt.Errorf("voice %s belongs to locale %s, not %s", known.Name, known.Locale, voice.Locale)
+		}
+		if voice.Gender == "" {
+			voice.Gender = known.Gender
+		}
+		if voice.Styles == nil {
+			voice.Styles = known.Styles
+		}
+		voice.Name = known.Name
+		voice.Locale = known.Locale
+		return voice, nil
+	}
+
+	// Unknown voices must state their locale, and follow the "<locale>-<Name>" naming convention
+	if voice.Locale == "" {
+		return Voice{}, fmt.Errorf("unknown voice %s: locale must be specified", voice.Name)
+	}
+	if !strings.HasPrefix(strings.ToLower(voice.Name), strings.ToLower(voice.Locale)+"-") {
+		return Voice{}, fmt.Errorf("voice %s does not match locale %s", voice.Name, voice.Locale)
+	}
+	return voice, nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) Microsoft. All rights reserved.
+// Licensed under the MIT license.
+
+package gospeech
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetVoice(t *testing.T) {
+	tests := []struct {
+		name      string
+		targets   []string
+		voice     Voice
+		wantVoice string
+		wantErr   string
+	}{
+		{name: "known voice for a target language", targets: []string{"en"}, voice: Voice{Name: "en-US-JennyNeural"}, wantVoice: "en-US-JennyNeural"},
+		{name: "name is matched case-insensitively", targets: []string{"ja", "en"}, voice: Voice{Name: "JA-JP-NANAMINEURAL"}, wantVoice: "ja-JP-NanamiNeural"},
+		{name: "other regional variant of a target", targets: []string{"en"}, voice: Voice{Name: "en-GB-SoniaNeural"}, wantVoice: "en-GB-SoniaNeural"},
+		{name: "known voice with its locale", targets: []string{"de"}, voice: Voice{Name: "de-DE-KatjaNeural", Locale: "de-DE"}, wantVoice: "de-DE-KatjaNeural"},
+		{name: "unknown voice with a matching locale", targets: []string{"fr"}, voice: Voice{Name: "fr-FR-NewNeural", Locale: "fr-FR"}, wantVoice: "fr-FR-NewNeural"},
+		{name: "empty name", targets: []string{"en"}, voice: Voice{}, wantErr: "voice name cannot be empty"},
+		{name: "voice not in the target languages", targets: []string{"en"}, voice: Voice{Name: "ja-JP-KeitaNeural"}, wantErr: "does not match any target language"},
+		{name: "known voice with the wrong locale", targets: []string{"en"}, voice: Voice{Name: "en-US-GuyNeural", Locale: "en-GB"}, wantErr: "belongs to locale en-US, not en-GB"},
+		{name: "unknown voice without a locale", targets: []string{"en"}, voice: Voice{Name: "en-US-NewNeural"}, wantErr: "locale must be specified"},
+		{name: "unknown voice named for another locale", targets: []string{"en"}, voice: Voice{Name: "ja-JP-NewNeural", Locale: "en-US"}, wantErr: "does not match locale en-US"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSpeechTranslationConfig()
+			for _, lang := range tt.targets {
+				config.AddTargetLanguage(lang)
+			}
+
+			err := config.SetVoice(tt.voice)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetVoice error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if got := config.GetVoiceName(); got != "" {
+					t.Errorf("voice name = %q after a rejected voice, want it unchanged", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetVoice: %v", err)
+			}
+			if got := config.GetVoiceName(); got != tt.wantVoice {
+				t.Errorf("voice name = %q, want %q", got, tt.wantVoice)
+			}
+		})
+	}
+}
+
+func TestVoiceStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		voice    string
+		gender   string
+		style    string
+		wantSSML string
+		wantErr  string
+	}{
+		{
+			name:     "style is applied in the SSML",
+			targets:  []string{"en"},
+			voice:    "en-US-JennyNeural",
+			style:    "Cheerful",
+			wantSSML: `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xmlns:mstts="https://www.w3.org/2001/mstts" xml:lang="en-US"><voice name="en-US-JennyNeural"><mstts:express-as style="cheerful">Hello &amp; goodbye</mstts:express-as></voice></speak>`,
+		},
+		{
+			name:     "no style",
+			targets:  []string{"ja"},
+			voice:    "ja-JP-KeitaNeural",
+			wantSSML: `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xmlns:mstts="https://www.w3.org/2001/mstts" xml:lang="ja-JP"><voice name="ja-JP-KeitaNeural">Hello &amp; goodbye</voice></speak>`,
+		},
+		{
+			name:     "gender selects a voice",
+			targets:  []string{"en"},
+			gender:   "male",
+			wantSSML: `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xmlns:mstts="https://www.w3.org/2001/mstts" xml:lang="en-US"><voice name="en-US-GuyNeural">Hello &amp; goodbye</voice></speak>`,
+		},
+		{name: "voice without styles", targets: []string{"ja"}, voice: "ja-JP-KeitaNeural", style: "cheerful", wantErr: "does not support style cheerful"},
+		{name: "style the voice does not support", targets: []string{"fr"}, voice: "fr-FR-DeniseNeural", style: "calm", wantErr: "does not support style calm"},
+		{name: "voice of unknown capabilities", targets: []string{"fr"}, voice: "fr-FR-NewNeural", style: "cheerful", wantErr: "speaking styles of voice fr-FR-NewNeural are unknown"},
+		{name: "style before voice", targets: []string{"en"}, style: "cheerful", wantErr: "voice must be set before the speaking style"},
+		{name: "invalid gender", targets: []string{"en"}, gender: "robot", wantErr: "invalid voice gender"},
+		{name: "no voice of the gender", targets: []string{"xx"}, gender: "Female", wantErr: "no Female voice for target languages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSpeechTranslationConfig()
+			for _, lang := range tt.targets {
+				config.AddTargetLanguage(lang)
+			}
+
+			var err error
+			switch {
+			case tt.voice != "":
+				locale := strings.Join(strings.SplitN(tt.voice, "-", 3)[:2], "-")
+				err = config.SetVoice(Voice{Name: tt.voice, Locale: locale})
+			case tt.gender != "":
+				err = config.SetVoiceGender(tt.gender)
+			}
+			if err == nil {
+				err = config.SetVoiceStyle(tt.style)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if got := config.GetVoiceStyle(); got != "" {
+					t.Errorf("style = %q after a rejected setting, want none", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ssml, err := config.BuildSynthesisSSML("Hello & goodbye")
+			if err != nil {
+				t.Fatalf("BuildSynthesisSSML: %v", err)
+			}
+			if ssml != tt.wantSSML {
+				t.Errorf("SSML = %s\nwant %s", ssml, tt.wantSSML)
+			}
+		})
+	}
+}
+
+func TestSetVoiceGenderWithStyle(t *testing.T) {
+	tests := []struct {
+		name      string
+		gender    string
+		style     string
+		wantVoice string
+		wantErr   string
+	}{
+		{name: "first voice supporting the style", gender: "Female", style: "calm", wantVoice: "zh-CN-XiaoxiaoNeural"},
+		{name: "no voice of the gender supports the style", gender: "Male", style: "calm", wantErr: "no Male voice with style calm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSpeechTranslationConfig()
+			config.AddTargetLanguage("zh-Hans")
+			if err := config.SetVoice(Voice{Name: "zh-CN-XiaoxiaoNeural"}); err != nil {
+				t.Fatalf("SetVoice: %v", err)
+			}
+			if err := config.SetVoiceStyle(tt.style); err != nil {
+				t.Fatalf("SetVoiceStyle: %v", err)
+			}
+
+			err := config.SetVoiceGender(tt.gender)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetVoiceGender error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetVoiceGender: %v", err)
+			}
+			if got := config.GetVoiceName(); got != tt.wantVoice {
+				t.Errorf("voice name = %q, want %q", got, tt.wantVoice)
+			}
+		})
+	}
+}
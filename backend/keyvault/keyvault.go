@@ -0,0 +1,127 @@
+// Package keyvault reads application secrets from Azure Key Vault
+package keyvault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// vaultScope is the token scope for the Key Vault data plane
+const vaultScope = "https://vault.azure.net/.default"
+
+// apiVersion is the Key Vault REST API version used to read secrets
+const apiVersion = "7.4"
+
+// ErrSecretNotFound is returned when the requested secret does not exist in the vault
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretGetter fetches the current value of a secret by name
+type SecretGetter interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// Client reads secrets from a single Key Vault using an Azure credential
+type Client struct {
+	vaultURL   string
+	credential azcore.TokenCredential
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the vault at vaultURL (e.g. https://myvault.vault.azure.net).
+// A nil httpClient uses http.DefaultClient.
+func NewClient(vaultURL string, credential azcore.TokenCredential, httpClient *http.Client) (*Client, error) {
+	if vaultURL == "" {
+		return nil, errors.New("vault URL cannot be empty")
+	}
+	if credential == nil {
+		return nil, errors.New("credential cannot be nil")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		vaultURL:   strings.TrimSuffix(vaultURL, "/"),
+		credential: credential,
+		httpClient: httpClient,
+	}, nil
+}
+
+// GetSecret returns the latest version of the named secret
+func (c *Client) GetSecret(ctx context.Context, name string) (string, error) {
+	token, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{vaultScope}})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Key Vault token: %v", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/secrets/%s?api-version=%s", c.vaultURL, url.PathEscape(name), apiVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read secret %s: status %d", name, resp.StatusCode)
+	}
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode secret %s: %v", name, err)
+	}
+	return body.Value, nil
+}
+
+// SpeechCredentials holds the Speech Service key and region
+type SpeechCredentials struct {
+	Key    string
+	Region string
+}
+
+// LoadSpeechCredentials reads the Speech Service key and region secrets from the vault.
+// Values missing from the vault are taken from fallback; the key must be found in one of them.
+func LoadSpeechCredentials(ctx context.Context, getter SecretGetter, keySecret, regionSecret string, fallback SpeechCredentials) (SpeechCredentials, error) {
+	creds := fallback
+
+	key, err := getter.GetSecret(ctx, keySecret)
+	switch {
+	case err == nil:
+		creds.Key = key
+	case !errors.Is(err, ErrSecretNotFound):
+		return SpeechCredentials{}, err
+	}
+
+	if regionSecret != "" {
+		region, err := getter.GetSecret(ctx, regionSecret)
+		switch {
+		case err == nil:
+			creds.Region = region
+		case !errors.Is(err, ErrSecretNotFound):
+			return SpeechCredentials{}, err
+		}
+	}
+
+	if creds.Key == "" {
+		return SpeechCredentials{}, fmt.Errorf("%w: %s", ErrSecretNotFound, keySecret)
+	}
+	return creds, nil
+}
@@ -0,0 +1,168 @@
+package keyvault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// fakeSecrets is a SecretGetter backed by a map; names in failing return a non-404 error
+type fakeSecrets struct {
+	values  map[string]string
+	failing map[string]bool
+}
+
+func (f fakeSecrets) GetSecret(ctx context.Context, name string) (string, error) {
+	if f.failing[name] {
+		return "", errors.New("vault unavailable")
+	}
+	if value, ok := f.values[name]; ok {
+		return value, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+func TestLoadSpeechCredentials(t *testing.T) {
+	fallback := SpeechCredentials{Key: "env-key", Region: "env-region"}
+	tests := []struct {
+		name         string
+		secrets      fakeSecrets
+		regionSecret string
+		fallback     SpeechCredentials
+		want         SpeechCredentials
+		wantErr      error
+	}{
+		{
+			name:         "key and region are loaded from the vault",
+			secrets:      fakeSecrets{values: map[string]string{"speech-key": "vault-key", "speech-region": "japaneast"}},
+			regionSecret: "speech-region",
+			fallback:     fallback,
+			want:         SpeechCredentials{Key: "vault-key", Region: "japaneast"},
+		},
+		{
+			name:         "missing region falls back to the environment",
+			secrets:      fakeSecrets{values: map[string]string{"speech-key": "vault-key"}},
+			regionSecret: "speech-region",
+			fallback:     fallback,
+			want:         SpeechCredentials{Key: "vault-key", Region: "env-region"},
+		},
+		{
+			name:         "missing key falls back to the environment",
+			secrets:      fakeSecrets{values: map[string]string{"speech-region": "japaneast"}},
+			regionSecret: "speech-region",
+			fallback:     fallback,
+			want:         SpeechCredentials{Key: "env-key", Region: "japaneast"},
+		},
+		{
+			name:     "empty region secret name is not read",
+			secrets:  fakeSecrets{values: map[string]string{"speech-key": "vault-key"}, failing: map[string]bool{"": true}},
+			fallback: fallback,
+			want:     SpeechCredentials{Key: "vault-key", Region: "env-region"},
+		},
+		{
+			name:         "key missing everywhere",
+			secrets:      fakeSecrets{},
+			regionSecret: "speech-region",
+			fallback:     SpeechCredentials{Region: "env-region"},
+			wantErr:      ErrSecretNotFound,
+		},
+		{
+			name:         "vault errors are not masked by the fallback",
+			secrets:      fakeSecrets{failing: map[string]bool{"speech-key": true}},
+			regionSecret: "speech-region",
+			fallback:     fallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadSpeechCredentials(context.Background(), tt.secrets, "speech-key", tt.regionSecret, tt.fallback)
+			if tt.want == (SpeechCredentials{}) {
+				if err == nil {
+					t.Fatalf("LoadSpeechCredentials = %+v, want an error", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSpeechCredentials: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadSpeechCredentials = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// staticCredential returns a fixed token and records the requested scopes
+type staticCredential struct {
+	scopes []string
+}
+
+func (c *staticCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.scopes = options.Scopes
+	return azcore.AccessToken{Token: "vault-token"}, nil
+}
+
+func TestClientGetSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr error
+	}{
+		{name: "secret value", status: http.StatusOK, body: `{"value":"vault-key","id":"x"}`, want: "vault-key"},
+		{name: "missing secret", status: http.StatusNotFound, body: `{}`, wantErr: ErrSecretNotFound},
+		{name: "forbidden", status: http.StatusForbidden, body: `{}`},
+		{name: "malformed body", status: http.StatusOK, body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/secrets/speech-key" || req.URL.Query().Get("api-version") != apiVersion {
+					t.Errorf("request = %s, want /secrets/speech-key?api-version=%s", req.URL, apiVersion)
+				}
+				if got := req.Header.Get("Authorization"); got != "Bearer vault-token" {
+					t.Errorf("Authorization = %q, want the vault token", got)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			credential := &staticCredential{}
+			client, err := NewClient(server.URL+"/", credential, server.Client())
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			got, err := client.GetSecret(context.Background(), "speech-key")
+			if len(credential.scopes) != 1 || credential.scopes[0] != vaultScope {
+				t.Errorf("token scopes = %v, want [%s]", credential.scopes, vaultScope)
+			}
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("GetSecret = %q, want an error", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetSecret: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetSecret = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
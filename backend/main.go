@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"go-realtime-translation-with-speech-service/backend/api/handlers"
+	"go-realtime-translation-with-speech-service/backend/keyvault"
 	translatortext "go-realtime-translation-with-speech-service/backend/translatortext"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
@@ -27,10 +31,35 @@ func main() {
 		log.Fatalf("TranslatorClientの作成に失敗しました: %v", err)
 	}
 
-	// 4. Speech Serviceの認証情報の取得（環境変数から）
+	// 4. Speech Serviceの認証情報の取得（環境変数またはKey Vaultから）
 	speechKey := os.Getenv("SPEECH_SERVICE_KEY")
 	speechRegion := os.Getenv("SPEECH_SERVICE_REGION")
 
+	// Key Vaultが設定されている場合はそちらから取得（見つからない値は環境変数を使用）
+	if vaultURL := os.Getenv("KEY_VAULT_URL"); vaultURL != "" {
+		vaultClient, err := keyvault.NewClient(vaultURL, cred, nil)
+		if err != nil {
+			log.Fatalf("Key Vaultクライアントの作成に失敗しました: %v", err)
+		}
+
+		keySecret := os.Getenv("KEY_VAULT_SPEECH_KEY_SECRET")
+		if keySecret == "" {
+			keySecret = "speech-service-key"
+		}
+		regionSecret := os.Getenv("KEY_VAULT_SPEECH_REGION_SECRET")
+		if regionSecret == "" {
+			regionSecret = "speech-service-region"
+		}
+
+		creds, err := keyvault.LoadSpeechCredentials(context.Background(), vaultClient, keySecret, regionSecret,
+			keyvault.SpeechCredentials{Key: speechKey, Region: speechRegion})
+		if err != nil {
+			log.Fatalf("Key VaultからSpeech Serviceの認証情報を取得できませんでした: %v", err)
+		}
+		speechKey, speechRegion = creds.Key, creds.Region
+		log.Printf("Speech Serviceの認証情報をKey Vaultから読み込みました: %s", vaultURL)
+	}
+
 	if speechKey == "" || speechRegion == "" {
 		log.Fatalf("エラー: Speech Serviceの認証情報が設定されていません。SPEECH_SERVICE_KEYとSPEECH_SERVICE_REGIONの環境変数を設定してください。")
 	}
@@ -43,6 +72,214 @@ func main() {
 	// ハンドラーにSpeech Service認証情報をセット
 	handlers.SetSpeechCredentials(speechKey, speechRegion)
 
+	// 途中経過の最小文字数（任意）
+	if v := os.Getenv("STREAMING_MIN_INTERIM_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_MIN_INTERIM_LENGTHの値が不正です: %v", err)
+		}
+		handlers.SetMinInterimLength(n)
+	}
+
+	// セッションごとの1秒あたりの最大送信メッセージ数（任意）
+	if v := os.Getenv("STREAMING_MAX_MESSAGE_RATE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_MAX_MESSAGE_RATEの値が不正です: %v", err)
+		}
+		handlers.SetMaxMessageRate(n)
+	}
+
+	// クライアントへの書き込み期限（任意、例: 10s）
+	if v := os.Getenv("STREAMING_WRITE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STREAMING_WRITE_TIMEOUTの値が不正です: %v", err)
+		}
+		handlers.SetWriteTimeout(d)
+	}
+
+	// ストリーミング接続が続けて失敗した場合にバッチ処理へ切り替えるまでの回数（任意）
+	if v := os.Getenv("STREAMING_FALLBACK_AFTER_FAILURES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_FALLBACK_AFTER_FAILURESの値が不正です: %v", err)
+		}
+		handlers.SetStreamingFallbackAfter(n)
+	}
+
+	// 途中から参加したクライアントに送信する直近の確定結果の数（任意）
+	if v := os.Getenv("STREAMING_HISTORY_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_HISTORY_SIZEの値が不正です: %v", err)
+		}
+		handlers.SetTranscriptHistorySize(n)
+	}
+
+	// 翻訳結果がない確定結果を元のテキストのみで送信するかどうか（任意、既定: true）
+	if v := os.Getenv("STREAMING_FORWARD_UNTRANSLATED"); v != "" {
+		forward, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("STREAMING_FORWARD_UNTRANSLATEDの値が不正です: %v", err)
+		}
+		handlers.SetForwardUntranslated(forward)
+	}
+
+	// 認識言語と翻訳先言語が同じ場合に認識テキストをそのまま返すかどうか（任意、既定: true）
+	if v := os.Getenv("STREAMING_PASS_THROUGH_SAME_LANGUAGE"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("STREAMING_PASS_THROUGH_SAME_LANGUAGEの値が不正です: %v", err)
+		}
+		handlers.SetPassThroughSameLanguage(enabled)
+	}
+
+	// セッションごとのデバッグログの行数・バイト数の上限（任意）
+	var logMaxLines, logMaxBytes int
+	if v := os.Getenv("STREAMING_SESSION_LOG_MAX_LINES"); v != "" {
+		logMaxLines, err = strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_SESSION_LOG_MAX_LINESの値が不正です: %v", err)
+		}
+	}
+	if v := os.Getenv("STREAMING_SESSION_LOG_MAX_BYTES"); v != "" {
+		logMaxBytes, err = strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_SESSION_LOG_MAX_BYTESの値が不正です: %v", err)
+		}
+	}
+	handlers.SetSessionLogLimit(logMaxLines, logMaxBytes)
+
+	// Translator / Speech の REST API の同時呼び出し数の上限と待機時間（任意、例: 8, 5s）
+	if v := os.Getenv("UPSTREAM_MAX_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("UPSTREAM_MAX_CONCURRENCYの値が不正です: %v", err)
+		}
+		var timeout time.Duration
+		if t := os.Getenv("UPSTREAM_QUEUE_TIMEOUT"); t != "" {
+			timeout, err = time.ParseDuration(t)
+			if err != nil {
+				log.Fatalf("UPSTREAM_QUEUE_TIMEOUTの値が不正です: %v", err)
+			}
+		}
+		handlers.SetUpstreamConcurrency(n, timeout)
+	}
+
+	// 文字起こし・翻訳エンドポイントにアップロードできる音声ファイルの最大サイズ（任意、バイト）
+	if v := os.Getenv("TRANSCRIBE_MAX_UPLOAD_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Fatalf("TRANSCRIBE_MAX_UPLOAD_BYTESの値が不正です: %v", err)
+		}
+		handlers.SetMaxUploadSize(n)
+	}
+
+	// 無音が続いた場合に Speech Service へ無音フレームを送る間隔（任意、例: 5s）
+	if v := os.Getenv("STREAMING_KEEPALIVE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STREAMING_KEEPALIVE_INTERVALの値が不正です: %v", err)
+		}
+		handlers.SetKeepAliveInterval(d)
+	}
+
+	// 字幕表示向けに途中経過をまとめて送信する間隔（任意、例: 300ms）
+	if v := os.Getenv("STREAMING_SUBTITLE_COALESCE_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STREAMING_SUBTITLE_COALESCE_WINDOWの値が不正です: %v", err)
+		}
+		handlers.SetSubtitleCoalesceWindow(d)
+	}
+
+	// セッションごとに送信待ちにできるメッセージ数の上限（任意、超えた場合は古い途中経過から破棄）
+	if v := os.Getenv("STREAMING_MAX_BUFFERED_FRAMES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_MAX_BUFFERED_FRAMESの値が不正です: %v", err)
+		}
+		handlers.SetMaxBufferedFrames(n)
+	}
+
+	// バッチ翻訳で同時に翻訳する項目数と1項目あたりの期限（任意、例: 4, 5s）
+	var batchWorkers int
+	var batchItemTimeout time.Duration
+	if v := os.Getenv("BATCH_TRANSLATION_WORKERS"); v != "" {
+		batchWorkers, err = strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("BATCH_TRANSLATION_WORKERSの値が不正です: %v", err)
+		}
+	}
+	if v := os.Getenv("BATCH_TRANSLATION_ITEM_TIMEOUT"); v != "" {
+		batchItemTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("BATCH_TRANSLATION_ITEM_TIMEOUTの値が不正です: %v", err)
+		}
+	}
+	handlers.SetBatchTranslation(batchWorkers, batchItemTimeout)
+
+	// これより短い確定結果を破棄する長さ（任意、例: 300ms）
+	if v := os.Getenv("STREAMING_MIN_RESULT_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STREAMING_MIN_RESULT_DURATIONの値が不正です: %v", err)
+		}
+		handlers.SetMinResultDuration(d)
+	}
+
+	// ストリーミングセッションで指定できる翻訳先言語の最大数（任意、既定値は20）
+	if v := os.Getenv("STREAMING_MAX_TARGET_LANGUAGES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("STREAMING_MAX_TARGET_LANGUAGESの値が不正です: %v", err)
+		}
+		handlers.SetMaxTargetLanguages(n)
+	}
+
+	// 確定前の途中経過も翻訳して送信するかどうか（任意、既定: false）
+	if v := os.Getenv("STREAMING_INTERIM_TRANSLATIONS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("STREAMING_INTERIM_TRANSLATIONSの値が不正です: %v", err)
+		}
+		handlers.SetInterimTranslations(enabled)
+	}
+
+	// サンプルフレームの境界で終わらない音声チャンクの扱い（任意、none, pad, truncate, reject。既定: none）
+	if v := os.Getenv("STREAMING_PCM_ALIGNMENT"); v != "" {
+		if err := handlers.SetPCMAlignment(v); err != nil {
+			log.Fatalf("STREAMING_PCM_ALIGNMENTの値が不正です: %v", err)
+		}
+	}
+
+	// Speech Service から何も届かない場合に再接続するまでの時間（任意、例: 2m）
+	if v := os.Getenv("STREAMING_READ_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STREAMING_READ_TIMEOUTの値が不正です: %v", err)
+		}
+		handlers.SetReadTimeout(d)
+	}
+
+	// 翻訳先言語ごとの確定結果の最小信頼度（任意、例: ja=0.6,en=0.5,*=0.3）
+	if err := handlers.SetMinFinalConfidence(os.Getenv("STREAMING_MIN_CONFIDENCE")); err != nil {
+		log.Fatalf("STREAMING_MIN_CONFIDENCEの値が不正です: %v", err)
+	}
+
+	// 最小信頼度に満たない確定結果を破棄せず lowConfidence を付けて送信するかどうか（任意、既定: false）
+	if v := os.Getenv("STREAMING_FLAG_LOW_CONFIDENCE"); v != "" {
+		flag, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("STREAMING_FLAG_LOW_CONFIDENCEの値が不正です: %v", err)
+		}
+		handlers.SetFlagLowConfidence(flag)
+	}
+
+	// 管理用エンドポイントの認証トークン（未設定の場合は管理用エンドポイントを無効化）
+	handlers.SetAdminToken(os.Getenv("ADMIN_API_TOKEN"))
+
 	// Ginルーターの設定
 	router := gin.Default()
 
@@ -50,7 +287,7 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Tenant-ID, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -70,6 +307,15 @@ func main() {
 		// 翻訳エンドポイント
 		api.POST("/translate", handlers.TranslateHandler)
 
+		// 複数テキストの翻訳エンドポイント
+		api.POST("/translate/batch", handlers.TranslateBatchHandler)
+
+		// 文字体系の変換エンドポイント
+		api.POST("/transliterate", handlers.TransliterateHandler)
+
+		// 音声ファイルの文字起こし・翻訳エンドポイント
+		api.POST("/transcribe-translate", handlers.TranscribeTranslateHandler)
+
 		// ストリーミング翻訳関連エンドポイント
 		streaming := api.Group("/streaming")
 		{
@@ -79,6 +325,15 @@ func main() {
 
 			// WebSocketエンドポイント - リアルタイム音声認識・翻訳用
 			streaming.GET("/ws/:sessionId", handlers.WebSocketHandler)
+
+			// Server-Sent Eventsエンドポイント - セッションの結果の一方向配信用
+			streaming.GET("/sse/:sessionId", handlers.SSEHandler)
+		}
+
+		// 管理用エンドポイント
+		admin := api.Group("/admin", handlers.AdminAuthMiddleware())
+		{
+			admin.POST("/tenants/:tenantId/sessions/close", handlers.CloseTenantSessionsHandler)
 		}
 	}
 
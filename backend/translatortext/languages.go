@@ -0,0 +1,78 @@
+package translatortext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// SupportedLanguage describes a language returned by the Languages endpoint.
+type SupportedLanguage struct {
+	// Code is the language code used in the to/from parameters, e.g. "ja" or "zh-Hans".
+	Code string
+	// Name is the display name of the language in the Accept-Language locale.
+	Name string
+	// NativeName is the display name of the language in the language itself.
+	NativeName string
+	// Dir is the directionality of the language, "ltr" or "rtl".
+	// Transliteration languages report the directionality of their first script.
+	Dir string
+}
+
+// languageEntry is a single language in the Languages response, keyed by language code.
+type languageEntry struct {
+	Name       string `json:"name"`
+	NativeName string `json:"nativeName"`
+	Dir        string `json:"dir"`
+	Scripts    []struct {
+		Dir string `json:"dir"`
+	} `json:"scripts"`
+}
+
+// SupportedLanguages returns the languages the service supports for the given scope
+// (translation, transliteration or dictionary), sorted by language code.
+//
+// The generated Languages method models each scope as a single fixed property, while the
+// service returns an object keyed by language code, so this method decodes the response itself.
+// If the operation fails it returns an *azcore.ResponseError type.
+func (client *TranslatorClient) SupportedLanguages(ctx context.Context, scope Get1ItemsItem) ([]SupportedLanguage, error) {
+	req, err := client.languagesCreateRequest(ctx, &TranslatorClientLanguagesOptions{Scope: []Get1ItemsItem{scope}})
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := client.internal.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(httpResp, http.StatusOK) {
+		return nil, runtime.NewResponseError(httpResp)
+	}
+
+	var result map[Get1ItemsItem]map[string]languageEntry
+	if err := runtime.UnmarshalAsJSON(httpResp, &result); err != nil {
+		return nil, err
+	}
+	entries, ok := result[scope]
+	if !ok {
+		return nil, fmt.Errorf("languages response has no %q scope", scope)
+	}
+
+	languages := make([]SupportedLanguage, 0, len(entries))
+	for code, entry := range entries {
+		dir := entry.Dir
+		if dir == "" && len(entry.Scripts) > 0 {
+			dir = entry.Scripts[0].Dir
+		}
+		languages = append(languages, SupportedLanguage{
+			Code:       code,
+			Name:       entry.Name,
+			NativeName: entry.NativeName,
+			Dir:        dir,
+		})
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Code < languages[j].Code })
+	return languages, nil
+}
@@ -0,0 +1,117 @@
+package translatortext
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// staticTokenCredential returns a fixed access token for tests.
+type staticTokenCredential struct{}
+
+func (staticTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "test-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestSupportedLanguages(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      Get1ItemsItem
+		status     int
+		response   string
+		want       []SupportedLanguage
+		wantErr    string
+		wantStatus int // status code of the *azcore.ResponseError
+	}{
+		{
+			name:  "translation",
+			scope: Get1ItemsItemTranslation,
+			response: `{"translation":{
+				"ja":{"name":"Japanese","nativeName":"日本語","dir":"ltr"},
+				"ar":{"name":"Arabic","nativeName":"العربية","dir":"rtl"},
+				"en":{"name":"English","nativeName":"English","dir":"ltr"}
+			}}`,
+			want: []SupportedLanguage{
+				{Code: "ar", Name: "Arabic", NativeName: "العربية", Dir: "rtl"},
+				{Code: "en", Name: "English", NativeName: "English", Dir: "ltr"},
+				{Code: "ja", Name: "Japanese", NativeName: "日本語", Dir: "ltr"},
+			},
+		},
+		{
+			name:  "transliteration takes the direction of the first script",
+			scope: Get1ItemsItemTransliteration,
+			response: `{"transliteration":{
+				"ar":{"name":"Arabic","nativeName":"العربية","scripts":[{"code":"Arab","dir":"rtl"},{"code":"Latn","dir":"ltr"}]}
+			}}`,
+			want: []SupportedLanguage{{Code: "ar", Name: "Arabic", NativeName: "العربية", Dir: "rtl"}},
+		},
+		{
+			name:     "missing scope",
+			scope:    Get1ItemsItemDictionary,
+			response: `{"translation":{}}`,
+			wantErr:  `languages response has no "dictionary" scope`,
+		},
+		{
+			name:       "service error",
+			scope:      Get1ItemsItemTranslation,
+			status:     http.StatusUnauthorized,
+			response:   `{"error":{"code":401000,"message":"unauthorized"}}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotScope string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotPath, gotScope = req.URL.Path, req.URL.Query().Get("scope")
+				w.Header().Set("Content-Type", "application/json")
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := NewTranslatorClient(server.URL, staticTokenCredential{}, &azcore.ClientOptions{
+				Transport: server.Client(),
+				Retry:     policy.RetryOptions{MaxRetries: -1},
+			})
+			if err != nil {
+				t.Fatalf("NewTranslatorClient: %v", err)
+			}
+
+			got, err := client.SupportedLanguages(context.Background(), tt.scope)
+			if gotPath != "/Languages" || gotScope != string(tt.scope) {
+				t.Errorf("request = %s?scope=%s, want /Languages?scope=%s", gotPath, gotScope, tt.scope)
+			}
+			if tt.wantStatus != 0 {
+				var responseErr *azcore.ResponseError
+				if !errors.As(err, &responseErr) || responseErr.StatusCode != tt.wantStatus {
+					t.Fatalf("error = %v, want an *azcore.ResponseError with status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SupportedLanguages: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("languages = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}